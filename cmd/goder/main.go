@@ -1,13 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/webgovernor/goder/internal/config"
 	"github.com/webgovernor/goder/internal/db"
+	"github.com/webgovernor/goder/internal/httpclient"
 	"github.com/webgovernor/goder/internal/llm/provider"
 	"github.com/webgovernor/goder/internal/permission"
 	"github.com/webgovernor/goder/internal/session"
@@ -16,6 +20,15 @@ import (
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "print version information and exit")
+	flag.BoolVar(showVersion, "v", false, "shorthand for -version")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(buildInfo())
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -23,6 +36,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Add configured models to the capabilities table
+	provider.SetCustomModels(cfg.Models)
+
+	if err := tui.SetMarkdownTheme(cfg.MarkdownTheme); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize database
 	database, err := db.New(cfg.DBPath())
 	if err != nil {
@@ -31,34 +52,117 @@ func main() {
 	}
 	defer database.Close()
 
+	// Shared HTTP client for all outbound requests (honors proxy env vars)
+	httpClient, err := httpclient.New(httpclient.Options{
+		CACertPath:         cfg.CACertPath,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error configuring HTTP client: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize services
 	sessionSvc := session.NewService(database)
-	registry := tools.DefaultRegistry(cfg.WorkDir)
+	newRegistry := func(workDir string) (*tools.Registry, error) {
+		ignore, err := tools.LoadIgnoreList(workDir, cfg.Ignore)
+		if err != nil {
+			return nil, fmt.Errorf("loading ignore patterns: %w", err)
+		}
+		return tools.DefaultRegistry(tools.Options{
+			WorkDir:             workDir,
+			HTTPClient:          httpClient,
+			Ignore:              ignore,
+			ConfineToWorkDir:    cfg.ConfineToWorkDir,
+			ExtraReadRoots:      cfg.ExtraReadRoots,
+			AtomicWrite:         cfg.AtomicWrite,
+			PreserveLineEndings: cfg.PreserveLineEndings,
+			MaxViewBytes:        cfg.MaxViewBytes,
+			BashRetryPatterns:   cfg.BashRetryPatterns,
+		}), nil
+	}
+	registry, err := newRegistry(cfg.WorkDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
 	permSvc := permission.NewService()
+	permSvc.SetAutoApprove(cfg.AutoApprove)
 
 	// Initialize LLM provider
-	var prov provider.Provider
-	switch cfg.Provider {
-	case "openai":
-		prov = provider.NewOpenAIProvider(cfg.APIKey, cfg.Model)
-	default:
-		fmt.Fprintf(os.Stderr, "error: unsupported provider %q (supported: openai)\n", cfg.Provider)
+	timeouts := provider.Timeouts{
+		Request:    time.Duration(cfg.RequestTimeout) * time.Second,
+		StreamIdle: time.Duration(cfg.StreamIdleTimeout) * time.Second,
+	}
+	newProvider := func(cfg config.Config) (provider.Provider, error) {
+		return buildProvider(cfg, httpClient, timeouts)
+	}
+	prov, err := newProvider(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Create the TUI model
 	model := tui.New(cfg, database, sessionSvc, registry, prov, permSvc)
+	model.SetProviderFactory(newProvider)
+	model.SetRegistryFactory(newRegistry)
+	model.SetVersion(buildInfo())
 
 	// Create the program
 	p := tea.NewProgram(
 		model,
+		tea.WithReportFocus(), // used to skip notifications while focused
 	)
 
 	// Give the model a reference to the program for async events
 	model.SetProgram(p)
 
+	// The model persists everything before it quits, so the database can
+	// be closed as soon as the program returns.
 	if _, err := p.Run(); err != nil {
+		database.Close()
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// buildProvider constructs the configured primary provider, wrapped with the
+// configured fallback providers if there are any and with the request rate
+// limit if one is set.
+func buildProvider(cfg config.Config, client *http.Client, timeouts provider.Timeouts) (provider.Provider, error) {
+	prov, err := buildFallbackProvider(cfg, client, timeouts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RequestsPerMinute > 0 {
+		prov = provider.NewRateLimitedProvider(prov, cfg.RequestsPerMinute)
+	}
+	return prov, nil
+}
+
+// buildFallbackProvider constructs the configured primary provider, wrapped
+// with the configured fallback providers if there are any.
+func buildFallbackProvider(cfg config.Config, client *http.Client, timeouts provider.Timeouts) (provider.Provider, error) {
+	prov, err := provider.New(cfg.Provider, cfg.APIKey, cfg.ModelID(), client, timeouts)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Providers) == 0 {
+		return prov, nil
+	}
+
+	var fallbacks []provider.Fallback
+	for _, pc := range cfg.Providers {
+		model := cfg.ResolveModel(pc.Model)
+		fb, err := provider.New(pc.Provider, pc.Key(), model, client, timeouts)
+		if err != nil {
+			return nil, fmt.Errorf("configuring fallback provider: %w", err)
+		}
+		fallbacks = append(fallbacks, provider.Fallback{
+			Provider: fb,
+			Label:    fmt.Sprintf("%s (%s)", pc.Provider, model),
+		})
+	}
+	return provider.NewFallbackProvider(prov, fallbacks...), nil
+}
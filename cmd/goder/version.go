@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// version and commit identify the build. They are set at build time:
+//
+//	go build -ldflags "-X main.version=v0.3.0 -X main.commit=$(git rev-parse --short HEAD)" ./cmd/goder
+//
+// When unset, they fall back to the module version and VCS revision that the
+// Go toolchain embeds in the binary.
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// buildInfo describes this build: version, commit, Go version, and platform,
+// one per line.
+func buildInfo() string {
+	v, c := version, commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		if c == "" {
+			c = vcsRevision(info)
+		}
+	}
+	if c == "" {
+		c = "unknown"
+	}
+
+	return fmt.Sprintf("goder %s\ncommit: %s\ngo: %s\nplatform: %s/%s",
+		v, c, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// vcsRevision returns the abbreviated VCS revision recorded in info, marked
+// "-dirty" if the working tree had uncommitted changes.
+func vcsRevision(info *debug.BuildInfo) string {
+	var rev string
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value[:min(12, len(s.Value))]
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if rev != "" && modified {
+		rev += "-dirty"
+	}
+	return rev
+}
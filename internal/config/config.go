@@ -1,28 +1,86 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
+	"strings"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
 )
 
+// ProviderConfig describes a fallback LLM provider.
+type ProviderConfig struct {
+	// Provider is the provider name. Defaults to the primary provider.
+	Provider string `json:"provider,omitempty"`
+
+	// Model is the model identifier to use with this provider.
+	Model string `json:"model"`
+
+	// APIKey is the provider API key. Loaded from environment if not set.
+	APIKey string `json:"apiKey,omitempty"`
+}
+
+// Key returns the configured API key, falling back to the provider's
+// environment variable. The result is not stored so that Save never writes
+// keys taken from the environment.
+func (p ProviderConfig) Key() string {
+	if p.APIKey != "" {
+		return p.APIKey
+	}
+	return apiKeyFromEnv(p.Provider)
+}
+
 // Config holds the application configuration.
 type Config struct {
 	// Provider is the LLM provider name (e.g. "openai", "anthropic").
 	Provider string `json:"provider"`
 
-	// Model is the model identifier (e.g. "gpt-4o", "claude-sonnet-4-20250514").
+	// Model is the model identifier (e.g. "gpt-4o", "claude-sonnet-4-20250514")
+	// or a key of ModelAliases.
 	Model string `json:"model"`
 
+	// ModelAliases maps short names to model identifiers (e.g. "fast" ->
+	// "gpt-4o-mini"), so Model and fallback models can refer to them.
+	ModelAliases map[string]string `json:"modelAliases,omitempty"`
+
 	// APIKey is the provider API key. Loaded from environment if not set in config.
 	APIKey string `json:"apiKey,omitempty"`
 
-	// MaxTokens is the maximum number of tokens in the LLM response.
+	// Providers lists fallback providers in priority order. When a request to
+	// the primary provider fails before producing output (other than with an
+	// authentication error), it is retried on each fallback in turn.
+	Providers []ProviderConfig `json:"providers,omitempty"`
+
+	// MaxTokens is the maximum number of tokens in the LLM response. 0 uses
+	// a default based on the model's output limit.
 	MaxTokens int `json:"maxTokens"`
 
+	// Models adds to the built-in table of model capabilities, keyed by
+	// model ID prefix (e.g. "gpt-6" or "my-finetune"). An entry replaces the
+	// built-in one for the models it matches, so it should list every
+	// capability the model has.
+	Models map[string]provider.ModelInfo `json:"models,omitempty"`
+
+	// ReasoningEffort sets how much reasoning models (o-series, gpt-5) think
+	// before answering: "minimal", "low", "medium", or "high". Empty uses
+	// the API default.
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
+
+	// ReasoningReserve is added to MaxTokens for reasoning models, whose
+	// hidden reasoning tokens count against the output limit.
+	ReasoningReserve int `json:"reasoningReserve"`
+
+	// Store keeps responses on the provider (visible in the OpenAI dashboard)
+	// and continues each request from the previous response instead of
+	// resending the whole conversation. Off by default for privacy.
+	Store bool `json:"store,omitempty"`
+
 	// DataDir is the directory for persistent storage (SQLite DB, etc.).
 	DataDir string `json:"dataDir,omitempty"`
 
@@ -32,11 +90,188 @@ type Config struct {
 	// MaxIterations is the maximum number of agent loop iterations before stopping.
 	MaxIterations int `json:"maxIterations"`
 
+	// MaxToolCallsPerTurn is the most tool calls run from a single model
+	// response. Further calls are answered with an error asking the model to
+	// reconsider. 0 disables the limit.
+	MaxToolCallsPerTurn int `json:"maxToolCallsPerTurn"`
+
+	// HistoryLimit is the maximum number of recent session messages sent to
+	// the provider each turn. The full history is still stored and shown.
+	// 0 sends the whole history.
+	HistoryLimit int `json:"historyLimit,omitempty"`
+
+	// RequestTimeout is the number of seconds to wait for an LLM provider to
+	// start responding (or to finish, for non-streaming calls). 0 disables it.
+	RequestTimeout int `json:"requestTimeout"`
+
+	// StreamIdleTimeout is the number of seconds a streaming response may go
+	// without receiving data before it is aborted. 0 disables it.
+	StreamIdleTimeout int `json:"streamIdleTimeout"`
+
+	// IterationDelay is the number of milliseconds the agent waits between
+	// loop iterations, after running tools and before the next request.
+	// 0 disables it.
+	IterationDelay int `json:"iterationDelay,omitempty"`
+
+	// RequestsPerMinute caps the rate of requests sent to the provider;
+	// requests over it wait their turn instead of being sent in a burst.
+	// 0 disables the limit.
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+
+	// ThrottleRateLimits makes the agent wait, up to a minute, for the
+	// provider's rate limits to reset when the rate limit headers of the last
+	// response show the next request would exceed them.
+	ThrottleRateLimits bool `json:"throttleRateLimits,omitempty"`
+
+	// CACertPath is an optional PEM file of extra CA certificates to trust for
+	// outbound HTTPS requests (e.g. behind a TLS-intercepting proxy).
+	CACertPath string `json:"caCertPath,omitempty"`
+
+	// InsecureSkipVerify disables TLS certificate verification for outbound
+	// requests. Use only as a last resort.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// DefaultMode is the operating mode goder starts in ("plan" or "build").
+	// Invalid values fall back to "plan".
+	DefaultMode string `json:"defaultMode,omitempty"`
+
+	// RememberMode saves the mode as DefaultMode whenever it is toggled, so
+	// the next launch starts in the last-used mode.
+	RememberMode bool `json:"rememberMode,omitempty"`
+
+	// Notify rings the terminal bell when the agent finishes, fails, or needs
+	// permission while the terminal window is not focused.
+	Notify bool `json:"notify,omitempty"`
+
+	// MarkdownTheme is the glamour style assistant messages are rendered
+	// with: "dark", "light", "notty", another standard style name, or the
+	// path of a JSON style file. Empty uses GLAMOUR_STYLE or picks dark or
+	// light to suit the terminal.
+	MarkdownTheme string `json:"markdownTheme,omitempty"`
+
+	// AssistantName renames the assistant, for deployments that rebrand it:
+	// it replaces "goder" in the header, window title, and the prompt's
+	// introduction, and labels the assistant's messages. Empty keeps goder.
+	AssistantName string `json:"assistantName,omitempty"`
+
+	// ToolOutputColors keeps the color codes in tool output, such as that of
+	// "ls --color", and shows the colors in tool result panels. By default
+	// they are stripped along with the other escape sequences, which are
+	// always removed. The model never sees them either way.
+	ToolOutputColors bool `json:"toolOutputColors,omitempty"`
+
+	// Verbosity sets how tool activity is shown in the transcript:
+	// "verbose" (the default) shows every tool call and result, and "quiet"
+	// collapses each turn's tool calls into a single line, which ctrl+o
+	// expands. /verbosity switches it for the current run.
+	Verbosity string `json:"verbosity,omitempty"`
+
+	// ModelCacheHours keeps the model lists shown in settings on disk, in
+	// the data directory, and reuses them for this many hours. 0 keeps them
+	// in memory for the current run only. Either way ctrl+r in the model
+	// list fetches it again.
+	ModelCacheHours int `json:"modelCacheHours,omitempty"`
+
+	// CompressToolResults condenses tool results over about 10,000
+	// characters before they are added to the history sent to the model,
+	// by asking CompressModel for the parts relevant to the task. The full
+	// output is still shown and stored.
+	CompressToolResults bool `json:"compressToolResults,omitempty"`
+
+	// CompressModel is the model, or alias, that condenses tool results for
+	// CompressToolResults; a small, cheap model is usually enough. Empty
+	// uses the main model.
+	CompressModel string `json:"compressModel,omitempty"`
+
+	// ShowStartupSummary greets a new session with a short description of
+	// the project, found without asking the model: its kind and test
+	// command, file count, git branch, and uncommitted changes.
+	ShowStartupSummary bool `json:"showStartupSummary,omitempty"`
+
+	// ReviewEdits shows every write and edit for review once permission is
+	// granted, so the change can be applied, skipped, or amended first.
+	ReviewEdits bool `json:"reviewEdits,omitempty"`
+
+	// AutoApprove runs every tool in build mode without asking for
+	// permission. Plan mode still blocks write tools.
+	AutoApprove bool `json:"autoApprove,omitempty"`
+
+	// AllowDangerous skips the extra confirmation of destructive commands,
+	// such as rm -rf or git reset --hard, which are otherwise confirmed every
+	// time even when bash is allowed for the session or AutoApprove is on.
+	AllowDangerous bool `json:"allowDangerous,omitempty"`
+
+	// PlanPrompt and BuildPrompt are extra instructions for plan and build
+	// mode, appended to the built-in mode section of the system prompt.
+	PlanPrompt  string `json:"planPrompt,omitempty"`
+	BuildPrompt string `json:"buildPrompt,omitempty"`
+
+	// ReplaceModePrompts makes a non-empty PlanPrompt or BuildPrompt replace
+	// the built-in instructions for its mode instead of extending them.
+	ReplaceModePrompts bool `json:"replaceModePrompts,omitempty"`
+
+	// DisabledTools lists tools, by name, that are neither offered to the
+	// model nor run, e.g. ["fetch", "bash"].
+	DisabledTools []string `json:"disabledTools,omitempty"`
+
+	// ToolRetries sets how many times a failed call to each tool, by name,
+	// is run again before its error goes back to the model, e.g.
+	// {"fetch": 2}. Only failures the tool deems transient are retried, with
+	// a short backoff between attempts. Tools not listed are not retried.
+	ToolRetries map[string]int `json:"toolRetries,omitempty"`
+
+	// BashRetryPatterns are regular expressions matched against the output
+	// of bash commands that exit with an error; a match makes the command
+	// transient for ToolRetries, e.g. "Could not resolve host".
+	BashRetryPatterns []string `json:"bashRetryPatterns,omitempty"`
+
+	// ToolResultFormat is how tool results are sent to the model: "text"
+	// (the default) sends the output as is, and "json" sends an object with
+	// "output" and "is_error" fields, which some models handle better.
+	ToolResultFormat string `json:"toolResultFormat,omitempty"`
+
+	// Ignore lists extra glob patterns skipped by the filesystem tools, in
+	// addition to the built-in defaults and the project's .goderignore.
+	Ignore []string `json:"ignore,omitempty"`
+
+	// ConfineToWorkDir rejects file tool paths that resolve outside the
+	// working directory. Defaults to true; disable only if the agent needs to
+	// read or write elsewhere on disk.
+	ConfineToWorkDir bool `json:"confineToWorkDir"`
+
+	// AtomicWrite makes the write and edit tools write to a temporary file
+	// and rename it into place, so a failed write never leaves a truncated
+	// file. Defaults to true.
+	AtomicWrite bool `json:"atomicWrite"`
+
+	// PreserveLineEndings makes the write and edit tools keep CRLF line
+	// endings in files that use them, converting the model's LF text.
+	// Defaults to true.
+	PreserveLineEndings bool `json:"preserveLineEndings"`
+
+	// ExtraReadRoots lists absolute directories that the read-only tools
+	// (view, grep, glob, ls) may access in addition to the working directory,
+	// e.g. a sibling library repo. Write tools stay confined to WorkDir.
+	ExtraReadRoots []string `json:"extraReadRoots,omitempty"`
+
+	// MaxViewBytes is the size in bytes above which the view tool refuses to
+	// read a whole file and asks for an offset and limit instead. 0 disables
+	// the check. Defaults to 10 MB.
+	MaxViewBytes int64 `json:"maxViewBytes"`
+
 	// Debug enables debug logging.
 	Debug bool `json:"debug"`
 
 	// WorkDir is the working directory. Defaults to cwd.
 	WorkDir string `json:"-"`
+
+	// Path is the config file Load read, or empty if none was found and the
+	// defaults are in use.
+	Path string `json:"-"`
+
+	// SavePath is the file Save writes to. Load sets it to Path, or to the
+	// user-level config file if no file was found.
+	SavePath string `json:"-"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -47,12 +282,20 @@ func DefaultConfig() Config {
 	}
 
 	return Config{
-		Provider:      "openai",
-		Model:         "gpt-4o",
-		MaxTokens:     4096,
-		MaxIterations: 25,
-		Shell:         shell,
-		Debug:         false,
+		Provider:            "openai",
+		DefaultMode:         "plan",
+		Model:               "gpt-4o",
+		ReasoningReserve:    16384,
+		MaxIterations:       25,
+		MaxToolCallsPerTurn: 20,
+		RequestTimeout:      60,
+		StreamIdleTimeout:   300,
+		ConfineToWorkDir:    true,
+		AtomicWrite:         true,
+		PreserveLineEndings: true,
+		MaxViewBytes:        10 << 20,
+		Shell:               shell,
+		Debug:               false,
 	}
 }
 
@@ -74,27 +317,25 @@ func Load() (Config, error) {
 		return cfg, fmt.Errorf("determining data directory: %w", err)
 	}
 
-	// Try to load config file (project-local first, then user-level)
-	configPaths := []string{
-		filepath.Join(cwd, ".goder.json"),
-	}
-
-	if configDir, err := os.UserConfigDir(); err == nil {
-		configPaths = append(configPaths, filepath.Join(configDir, "goder", "config.json"))
-	}
-
-	if homeDir, err := os.UserHomeDir(); err == nil {
-		configPaths = append(configPaths, filepath.Join(homeDir, ".goder.json"))
-	}
-
-	for _, path := range configPaths {
+	// Load the first config file found
+	for _, path := range SearchPaths(cwd) {
 		if data, err := os.ReadFile(path); err == nil {
 			if err := json.Unmarshal(data, &cfg); err != nil {
 				return cfg, fmt.Errorf("parsing config %s: %w", path, err)
 			}
+			cfg.Path = path
 			break
 		}
 	}
+	// The API key is kept out of the project file, which may be committed,
+	// and in the user config instead.
+	if cfg.Path == ProjectConfigPath(cwd) && cfg.APIKey == "" {
+		cfg.APIKey = userAPIKey()
+	}
+	cfg.SavePath = cfg.Path
+	if cfg.SavePath == "" {
+		cfg.SavePath, _ = UserConfigPath()
+	}
 
 	// Environment variable overrides
 	if v := os.Getenv("GODER_PROVIDER"); v != "" {
@@ -103,6 +344,9 @@ func Load() (Config, error) {
 	if v := os.Getenv("GODER_MODEL"); v != "" {
 		cfg.Model = v
 	}
+	if v := os.Getenv("GODER_CA_CERT_PATH"); v != "" {
+		cfg.CACertPath = v
+	}
 	if v := os.Getenv("GODER_SHELL"); v != "" {
 		cfg.Shell = v
 	}
@@ -112,11 +356,63 @@ func Load() (Config, error) {
 		}
 	}
 
+	if v := os.Getenv("GODER_CONFINE_TO_WORKDIR"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ConfineToWorkDir = b
+		}
+	}
+
+	if v := os.Getenv("GODER_DEFAULT_MODE"); v != "" {
+		cfg.DefaultMode = v
+	}
+	cfg.DefaultMode = normalizeMode(cfg.DefaultMode)
+
+	switch cfg.ReasoningEffort {
+	case "", "minimal", "low", "medium", "high":
+	default:
+		return cfg, fmt.Errorf("reasoningEffort must be minimal, low, medium, or high, got %q", cfg.ReasoningEffort)
+	}
+
+	switch cfg.Verbosity {
+	case "", "verbose", "quiet":
+	default:
+		return cfg, fmt.Errorf("verbosity must be verbose or quiet, got %q", cfg.Verbosity)
+	}
+
+	switch cfg.ToolResultFormat {
+	case "", "text", "json":
+	default:
+		return cfg, fmt.Errorf("toolResultFormat must be text or json, got %q", cfg.ToolResultFormat)
+	}
+
+	for _, pattern := range cfg.BashRetryPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return cfg, fmt.Errorf("bashRetryPatterns entry %q: %w", pattern, err)
+		}
+	}
+
+	for i, root := range cfg.ExtraReadRoots {
+		if !filepath.IsAbs(root) {
+			return cfg, fmt.Errorf("extraReadRoots entry %q must be an absolute path", root)
+		}
+		cfg.ExtraReadRoots[i] = filepath.Clean(root)
+	}
+
 	// Load API key from provider-specific env var
 	if cfg.APIKey == "" {
 		cfg.APIKey = apiKeyFromEnv(cfg.Provider)
 	}
 
+	for i := range cfg.Providers {
+		fb := &cfg.Providers[i]
+		if fb.Provider == "" {
+			fb.Provider = cfg.Provider
+		}
+		if fb.Model == "" {
+			return cfg, fmt.Errorf("providers[%d]: model is required", i)
+		}
+	}
+
 	// Ensure data directory exists
 	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
 		return cfg, fmt.Errorf("creating data directory: %w", err)
@@ -125,6 +421,16 @@ func Load() (Config, error) {
 	return cfg, nil
 }
 
+// normalizeMode validates an operating mode name, falling back to "plan".
+func normalizeMode(mode string) string {
+	switch m := strings.ToLower(strings.TrimSpace(mode)); m {
+	case "plan", "build":
+		return m
+	default:
+		return "plan"
+	}
+}
+
 // apiKeyFromEnv returns the API key for the given provider from environment variables.
 func apiKeyFromEnv(provider string) string {
 	switch provider {
@@ -155,26 +461,89 @@ func defaultDataDir() (string, error) {
 	return filepath.Join(home, ".local", "share", "goder"), nil
 }
 
-// Save persists the configuration to the user-level config file
+// SearchPaths returns the config files Load looks for, in order: the
+// project-local file in workDir, then the user-level files. Only the first
+// one found is read.
+func SearchPaths(workDir string) []string {
+	paths := []string{ProjectConfigPath(workDir)}
+	if path, err := UserConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(homeDir, ".goder.json"))
+	}
+	return paths
+}
+
+// ProjectConfigPath returns the project-local config file for workDir.
+func ProjectConfigPath(workDir string) string {
+	return filepath.Join(workDir, ".goder.json")
+}
+
+// IsProjectConfig reports whether path is a project-local config file rather
+// than one of the user-level files.
+func IsProjectConfig(path string) bool {
+	if filepath.Base(path) != ".goder.json" {
+		return false
+	}
+	home, err := os.UserHomeDir()
+	return err != nil || path != filepath.Join(home, ".goder.json")
+}
+
+// UserConfigPath returns the user-level config file
 // (~/.config/goder/config.json or $XDG_CONFIG_HOME/goder/config.json).
-// Only serializable fields are written; WorkDir is excluded (json:"-").
-func Save(cfg Config) error {
+func UserConfigPath() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return fmt.Errorf("determining config directory: %w", err)
+		return "", fmt.Errorf("determining config directory: %w", err)
+	}
+	return filepath.Join(configDir, "goder", "config.json"), nil
+}
+
+// Save persists the configuration to cfg.SavePath, or to the user-level
+// config file if it is empty. Only serializable fields are written; WorkDir
+// is excluded (json:"-"), and so is an API key taken from the environment.
+// The API key is never written to the project-local file, which may be
+// committed; when that is the target, the key is saved to the user-level
+// file instead, where Load finds it, and only the settings that differ from
+// the defaults are written (see projectFields).
+func Save(cfg Config) error {
+	path := cfg.SavePath
+	if path == "" {
+		var err error
+		if path, err = UserConfigPath(); err != nil {
+			return err
+		}
 	}
 
-	dir := filepath.Join(configDir, "goder")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	if cfg.APIKey == apiKeyFromEnv(cfg.Provider) {
+		cfg.APIKey = ""
+	}
+	if IsProjectConfig(path) && cfg.APIKey != "" {
+		if err := saveUserAPIKey(cfg.APIKey); err != nil {
+			return err
+		}
+		cfg.APIKey = ""
+	}
+	var data []byte
+	var err error
+	if IsProjectConfig(path) {
+		var fields map[string]json.RawMessage
+		if fields, err = projectFields(cfg, path); err != nil {
+			return err
+		}
+		data, err = json.MarshalIndent(fields, "", "  ")
+	} else {
+		data, err = json.MarshalIndent(cfg, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
 
-	path := filepath.Join(dir, "config.json")
 	if err := os.WriteFile(path, data, 0o600); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
@@ -182,6 +551,171 @@ func Save(cfg Config) error {
 	return nil
 }
 
+// envFields maps the config keys Load overrides from the environment to
+// their variables.
+var envFields = map[string]string{
+	"provider":         "GODER_PROVIDER",
+	"model":            "GODER_MODEL",
+	"caCertPath":       "GODER_CA_CERT_PATH",
+	"shell":            "GODER_SHELL",
+	"maxIterations":    "GODER_MAX_ITERATIONS",
+	"confineToWorkDir": "GODER_CONFINE_TO_WORKDIR",
+	"defaultMode":      "GODER_DEFAULT_MODE",
+	"dataDir":          "GODER_DATA_DIR",
+}
+
+// projectFields returns the settings to write to the project config at path:
+// those that differ from the defaults, so the file, which may be committed,
+// holds only what the project chose. A setting whose environment variable is
+// set keeps the value already in the file, or is left out, since cfg holds
+// the override rather than the project's choice.
+func projectFields(cfg Config, path string) (map[string]json.RawMessage, error) {
+	defaults := DefaultConfig()
+	defaults.DataDir, _ = defaultDataDir()
+	defaultFields, err := configFields(defaults)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := configFields(cfg)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return nil, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+
+	for key, value := range fields {
+		if bytes.Equal(value, defaultFields[key]) {
+			delete(fields, key)
+		}
+	}
+	for key, env := range envFields {
+		if os.Getenv(env) == "" {
+			continue
+		}
+		if old, ok := existing[key]; ok {
+			fields[key] = old
+		} else {
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+// configFields returns cfg as JSON object members.
+func configFields(cfg Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("marshaling config: %w", err)
+	}
+	return fields, nil
+}
+
+// userAPIKey returns the API key in the user-level config file, or "" if
+// there is none.
+func userAPIKey() string {
+	path, err := UserConfigPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var user struct {
+		APIKey string `json:"apiKey"`
+	}
+	if json.Unmarshal(data, &user) != nil {
+		return ""
+	}
+	return user.APIKey
+}
+
+// saveUserAPIKey sets the API key in the user-level config file, keeping the
+// rest of the file as it is.
+func saveUserAPIKey(key string) error {
+	path, err := UserConfigPath()
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+	fields["apiKey"], _ = json.Marshal(key)
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
+
+// APIKeyFor returns the API key to use for the named provider: the primary
+// key if it is the configured provider, a key from a matching fallback entry,
+// or the provider's environment variable.
+func (c Config) APIKeyFor(provider string) string {
+	if provider == c.Provider && c.APIKey != "" {
+		return c.APIKey
+	}
+	for _, pc := range c.Providers {
+		if pc.Provider == provider && pc.APIKey != "" {
+			return pc.APIKey
+		}
+	}
+	return apiKeyFromEnv(provider)
+}
+
+// ResolveModel returns the model identifier for name, expanding it if it is
+// an alias.
+func (c Config) ResolveModel(name string) string {
+	if id, ok := c.ModelAliases[name]; ok && id != "" {
+		return id
+	}
+	return name
+}
+
+// ModelID returns the resolved identifier of the configured model.
+func (c Config) ModelID() string {
+	return c.ResolveModel(c.Model)
+}
+
+// ModelLabel returns the configured model for display: the alias followed by
+// the resolved identifier in parentheses, or just the identifier.
+func (c Config) ModelLabel() string {
+	if id := c.ModelID(); id != c.Model {
+		return fmt.Sprintf("%s (%s)", c.Model, id)
+	}
+	return c.Model
+}
+
+// DefaultAssistantName is the name the assistant goes by when AssistantName
+// is not set.
+const DefaultAssistantName = "goder"
+
+// DisplayName returns the name the assistant goes by: AssistantName, or
+// DefaultAssistantName if it is not set.
+func (c Config) DisplayName() string {
+	if name := strings.TrimSpace(c.AssistantName); name != "" {
+		return name
+	}
+	return DefaultAssistantName
+}
+
 // DBPath returns the path to the SQLite database file.
 func (c Config) DBPath() string {
 	return filepath.Join(c.DataDir, "goder.db")
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveKeepsAPIKeyOutOfProjectFile(t *testing.T) {
+	workDir, userDir := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("HOME", userDir)
+	t.Setenv("GODER_DATA_DIR", filepath.Join(userDir, "data"))
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Chdir(workDir)
+
+	cfg := DefaultConfig()
+	cfg.WorkDir = workDir
+	cfg.SavePath = ProjectConfigPath(workDir)
+	cfg.APIKey = "sk-secret"
+	cfg.Model = "gpt-4.1"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := os.ReadFile(ProjectConfigPath(workDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(project), "sk-secret") {
+		t.Errorf("project config holds the API key:\n%s", project)
+	}
+	userPath, _ := UserConfigPath()
+	user, err := os.ReadFile(userPath)
+	if err != nil || !strings.Contains(string(user), "sk-secret") {
+		t.Fatalf("user config = %q (%v), want the API key", user, err)
+	}
+
+	// The project file is loaded, with the key from the user config.
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Path != ProjectConfigPath(workDir) || loaded.Model != "gpt-4.1" || loaded.APIKey != "sk-secret" {
+		t.Errorf("loaded path %q, model %q, key %q", loaded.Path, loaded.Model, loaded.APIKey)
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	cfg := Config{Model: "fast", ModelAliases: map[string]string{"fast": "gpt-4o-mini", "empty": ""}}
+
+	if got := cfg.ModelID(); got != "gpt-4o-mini" {
+		t.Errorf("ModelID() = %q, want the alias expanded", got)
+	}
+	if got := cfg.ModelLabel(); got != "fast (gpt-4o-mini)" {
+		t.Errorf("ModelLabel() = %q", got)
+	}
+	for _, name := range []string{"gpt-4.1", "empty"} {
+		if got := cfg.ResolveModel(name); got != name {
+			t.Errorf("ResolveModel(%q) = %q, want it unchanged", name, got)
+		}
+	}
+}
+
+func TestSaveProjectConfigWritesOnlyChanges(t *testing.T) {
+	workDir, userDir := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("HOME", userDir)
+	t.Setenv("GODER_DATA_DIR", "")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(userDir, "data"))
+	t.Setenv("GODER_MODEL", "o3")
+	t.Setenv("GODER_SHELL", "/bin/zsh")
+	path := ProjectConfigPath(workDir)
+	if err := os.WriteFile(path, []byte(`{"model": "gpt-4.1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.WorkDir = workDir
+	cfg.SavePath = path
+	cfg.DataDir, _ = defaultDataDir()
+	cfg.Model = "o3"       // from GODER_MODEL
+	cfg.Shell = "/bin/zsh" // from GODER_SHELL
+	cfg.MaxIterations = 40
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"model": "gpt-4.1", "maxIterations": 40.0}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("project config = %s, want only the model from the file and maxIterations", data)
+	}
+}
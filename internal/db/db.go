@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/webgovernor/goder/internal/message"
@@ -15,21 +16,35 @@ import (
 
 // DB wraps a SQLite database connection.
 type DB struct {
+	// mu guards conn and path, which MoveTo replaces. Every operation holds
+	// it for reading, so none runs on a connection being swapped out.
+	mu   sync.RWMutex
 	conn *sql.DB
+	path string
 }
 
 // Session represents a conversation session.
 type Session struct {
-	ID        string
-	Title     string
-	Summary   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           string
+	Title        string
+	Summary      string
+	Instructions string // set by the user with /system, sent with every request
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // New opens (or creates) a SQLite database at the given path and runs migrations.
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	conn, err := open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{conn: conn, path: dbPath}, nil
+}
+
+// open opens the database at path and runs migrations.
+func open(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
@@ -46,11 +61,20 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("running migrations: %w", err)
 	}
 
-	return db, nil
+	return conn, nil
+}
+
+// Path returns the path of the database file.
+func (db *DB) Path() string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.path
 }
 
 // Close closes the database connection.
 func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	return db.conn.Close()
 }
 
@@ -61,6 +85,7 @@ func (db *DB) migrate() error {
 		id         TEXT PRIMARY KEY,
 		title      TEXT NOT NULL DEFAULT '',
 		summary    TEXT NOT NULL DEFAULT '',
+		instructions TEXT NOT NULL DEFAULT '',
 		created_at DATETIME NOT NULL DEFAULT (datetime('now')),
 		updated_at DATETIME NOT NULL DEFAULT (datetime('now'))
 	);
@@ -75,6 +100,9 @@ func (db *DB) migrate() error {
 		input_tokens INTEGER NOT NULL DEFAULT 0,
 		output_tokens INTEGER NOT NULL DEFAULT 0,
 		total_tokens INTEGER NOT NULL DEFAULT 0,
+		response_id  TEXT NOT NULL DEFAULT '',
+		attachments  TEXT NOT NULL DEFAULT '[]',
+		hidden       INTEGER NOT NULL DEFAULT 0,
 		created_at   DATETIME NOT NULL DEFAULT (datetime('now')),
 		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
 	);
@@ -96,6 +124,24 @@ func (db *DB) migrate() error {
 	if _, err := db.conn.Exec("ALTER TABLE messages ADD COLUMN total_tokens INTEGER NOT NULL DEFAULT 0"); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
 		return err
 	}
+	if _, err := db.conn.Exec("ALTER TABLE messages ADD COLUMN response_id TEXT NOT NULL DEFAULT ''"); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	if _, err := db.conn.Exec("ALTER TABLE messages ADD COLUMN attachments TEXT NOT NULL DEFAULT '[]'"); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	if _, err := db.conn.Exec("ALTER TABLE sessions ADD COLUMN instructions TEXT NOT NULL DEFAULT ''"); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	if _, err := db.conn.Exec("ALTER TABLE messages ADD COLUMN hidden INTEGER NOT NULL DEFAULT 0"); err == nil {
+		// Until the column existed, the only system messages stored were
+		// notes to the model, which are hidden now.
+		if _, err := db.conn.Exec("UPDATE messages SET hidden = 1 WHERE role = 'system'"); err != nil {
+			return err
+		}
+	} else if !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 
 	return nil
 }
@@ -104,6 +150,8 @@ func (db *DB) migrate() error {
 
 // CreateSession creates a new session and returns it.
 func (db *DB) CreateSession(id, title string) (*Session, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	now := time.Now()
 	_, err := db.conn.Exec(
 		"INSERT INTO sessions (id, title, created_at, updated_at) VALUES (?, ?, ?, ?)",
@@ -122,10 +170,12 @@ func (db *DB) CreateSession(id, title string) (*Session, error) {
 
 // GetSession retrieves a session by ID.
 func (db *DB) GetSession(id string) (*Session, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	s := &Session{}
 	err := db.conn.QueryRow(
-		"SELECT id, title, summary, created_at, updated_at FROM sessions WHERE id = ?", id,
-	).Scan(&s.ID, &s.Title, &s.Summary, &s.CreatedAt, &s.UpdatedAt)
+		"SELECT id, title, summary, instructions, created_at, updated_at FROM sessions WHERE id = ?", id,
+	).Scan(&s.ID, &s.Title, &s.Summary, &s.Instructions, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +184,10 @@ func (db *DB) GetSession(id string) (*Session, error) {
 
 // ListSessions returns all sessions ordered by most recent first.
 func (db *DB) ListSessions() ([]*Session, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	rows, err := db.conn.Query(
-		"SELECT id, title, summary, created_at, updated_at FROM sessions ORDER BY updated_at DESC",
+		"SELECT id, title, summary, instructions, created_at, updated_at FROM sessions ORDER BY updated_at DESC",
 	)
 	if err != nil {
 		return nil, err
@@ -145,7 +197,7 @@ func (db *DB) ListSessions() ([]*Session, error) {
 	var sessions []*Session
 	for rows.Next() {
 		s := &Session{}
-		if err := rows.Scan(&s.ID, &s.Title, &s.Summary, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.Title, &s.Summary, &s.Instructions, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -155,6 +207,8 @@ func (db *DB) ListSessions() ([]*Session, error) {
 
 // UpdateSessionTitle updates a session's title.
 func (db *DB) UpdateSessionTitle(id, title string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	_, err := db.conn.Exec(
 		"UPDATE sessions SET title = ?, updated_at = datetime('now') WHERE id = ?",
 		title, id,
@@ -162,8 +216,50 @@ func (db *DB) UpdateSessionTitle(id, title string) error {
 	return err
 }
 
+// GetSessionSummary returns a session's stored summary.
+func (db *DB) GetSessionSummary(id string) (string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var summary string
+	err := db.conn.QueryRow("SELECT summary FROM sessions WHERE id = ?", id).Scan(&summary)
+	return summary, err
+}
+
+// UpdateSessionSummary replaces a session's stored summary.
+func (db *DB) UpdateSessionSummary(id, summary string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	_, err := db.conn.Exec(
+		"UPDATE sessions SET summary = ?, updated_at = datetime('now') WHERE id = ?",
+		summary, id,
+	)
+	return err
+}
+
+// GetSessionInstructions returns a session's instructions.
+func (db *DB) GetSessionInstructions(id string) (string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var instructions string
+	err := db.conn.QueryRow("SELECT instructions FROM sessions WHERE id = ?", id).Scan(&instructions)
+	return instructions, err
+}
+
+// UpdateSessionInstructions replaces a session's instructions.
+func (db *DB) UpdateSessionInstructions(id, instructions string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	_, err := db.conn.Exec(
+		"UPDATE sessions SET instructions = ?, updated_at = datetime('now') WHERE id = ?",
+		instructions, id,
+	)
+	return err
+}
+
 // DeleteSession deletes a session and its messages.
 func (db *DB) DeleteSession(id string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return err
@@ -183,6 +279,51 @@ func (db *DB) DeleteSession(id string) error {
 
 // AddMessage persists a message to the database.
 func (db *DB) AddMessage(msg message.Message) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if err := insertMessage(db.conn, msg); err != nil {
+		return err
+	}
+
+	// Touch the session's updated_at
+	_, _ = db.conn.Exec("UPDATE sessions SET updated_at = datetime('now') WHERE id = ?", msg.SessionID)
+
+	return nil
+}
+
+// ImportSession creates s with its messages in one transaction, so a failed
+// import leaves nothing behind. Unlike CreateSession, the session keeps the
+// summary, instructions, and timestamps it is given.
+func (db *DB) ImportSession(s *Session, messages []message.Message) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO sessions (id, title, summary, instructions, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		s.ID, s.Title, s.Summary, s.Instructions, s.CreatedAt, s.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := insertMessage(tx, msg); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertMessage inserts msg using ex.
+func insertMessage(ex execer, msg message.Message) error {
 	toolCallsJSON, err := json.Marshal(msg.ToolCalls)
 	if err != nil {
 		return fmt.Errorf("marshaling tool calls: %w", err)
@@ -191,27 +332,30 @@ func (db *DB) AddMessage(msg message.Message) error {
 	if err != nil {
 		return fmt.Errorf("marshaling tool results: %w", err)
 	}
+	attachmentsJSON, err := json.Marshal(msg.Attachments)
+	if err != nil {
+		return fmt.Errorf("marshaling attachments: %w", err)
+	}
 
-	_, err = db.conn.Exec(
-		`INSERT INTO messages (id, session_id, role, content, tool_calls, tool_results, input_tokens, output_tokens, total_tokens, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err = ex.Exec(
+		`INSERT INTO messages (id, session_id, role, content, tool_calls, tool_results, input_tokens, output_tokens, total_tokens, response_id, attachments, hidden, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		msg.ID, msg.SessionID, string(msg.Role), msg.Content,
-		string(toolCallsJSON), string(toolResultsJSON), msg.InputTokens, msg.OutputTokens, msg.TotalTokens, msg.CreatedAt,
+		string(toolCallsJSON), string(toolResultsJSON), msg.InputTokens, msg.OutputTokens, msg.TotalTokens, msg.ResponseID,
+		string(attachmentsJSON), msg.Hidden, msg.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("inserting message: %w", err)
 	}
-
-	// Touch the session's updated_at
-	_, _ = db.conn.Exec("UPDATE sessions SET updated_at = datetime('now') WHERE id = ?", msg.SessionID)
-
 	return nil
 }
 
 // GetMessages returns all messages for a session in chronological order.
 func (db *DB) GetMessages(sessionID string) ([]message.Message, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	rows, err := db.conn.Query(
-		`SELECT id, session_id, role, content, tool_calls, tool_results, input_tokens, output_tokens, total_tokens, created_at
+		`SELECT id, session_id, role, content, tool_calls, tool_results, input_tokens, output_tokens, total_tokens, response_id, attachments, hidden, created_at
 		 FROM messages WHERE session_id = ? ORDER BY created_at ASC`,
 		sessionID,
 	)
@@ -224,11 +368,12 @@ func (db *DB) GetMessages(sessionID string) ([]message.Message, error) {
 	for rows.Next() {
 		var msg message.Message
 		var role string
-		var toolCallsJSON, toolResultsJSON string
+		var toolCallsJSON, toolResultsJSON, attachmentsJSON string
 
 		if err := rows.Scan(
 			&msg.ID, &msg.SessionID, &role, &msg.Content,
-			&toolCallsJSON, &toolResultsJSON, &msg.InputTokens, &msg.OutputTokens, &msg.TotalTokens, &msg.CreatedAt,
+			&toolCallsJSON, &toolResultsJSON, &msg.InputTokens, &msg.OutputTokens, &msg.TotalTokens, &msg.ResponseID,
+			&attachmentsJSON, &msg.Hidden, &msg.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -241,6 +386,9 @@ func (db *DB) GetMessages(sessionID string) ([]message.Message, error) {
 		if err := json.Unmarshal([]byte(toolResultsJSON), &msg.ToolResults); err != nil {
 			return nil, fmt.Errorf("unmarshaling tool results: %w", err)
 		}
+		if err := json.Unmarshal([]byte(attachmentsJSON), &msg.Attachments); err != nil {
+			return nil, fmt.Errorf("unmarshaling attachments: %w", err)
+		}
 
 		messages = append(messages, msg)
 	}
@@ -250,6 +398,8 @@ func (db *DB) GetMessages(sessionID string) ([]message.Message, error) {
 
 // GetSessionTokenTotal returns the total tokens used in a session.
 func (db *DB) GetSessionTokenTotal(sessionID string) (int, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	var total int
 	err := db.conn.QueryRow(
 		"SELECT COALESCE(SUM(total_tokens), 0) FROM messages WHERE session_id = ?", sessionID,
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MoveTo copies the database to path, which must not exist yet, and
+// continues with the copy. The connection is closed while copying so that
+// every write is in the copied file. If the copy cannot be made or opened,
+// the original is reopened and an error is returned. The original file is
+// left in place either way. Other operations wait until the move is done.
+func (db *DB) MoveTo(path string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err := db.conn.Close(); err != nil {
+		return fmt.Errorf("closing database: %w", err)
+	}
+
+	moveErr := copyDatabase(db.path, path)
+	if moveErr == nil {
+		conn, err := open(path)
+		if err == nil {
+			db.conn, db.path = conn, path
+			return nil
+		}
+		moveErr = err
+		removeDatabase(path)
+	}
+
+	conn, err := open(db.path)
+	if err != nil {
+		return errors.Join(moveErr, fmt.Errorf("reopening %s: %w", db.path, err))
+	}
+	db.conn = conn
+	return moveErr
+}
+
+// copyDatabase copies the database file at src to dst, along with its
+// write-ahead log if one was left behind.
+func copyDatabase(src, dst string) error {
+	if err := copyFile(src, dst); err != nil {
+		removeDatabase(dst)
+		return err
+	}
+	if _, err := os.Stat(src + "-wal"); err == nil {
+		if err := copyFile(src+"-wal", dst+"-wal"); err != nil {
+			removeDatabase(dst)
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies src to dst, which must not exist.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("reading database: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copying database: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return fmt.Errorf("copying database: %w", err)
+	}
+	return out.Close()
+}
+
+// removeDatabase removes a database file and its WAL and shared-memory
+// files, ignoring errors.
+func removeDatabase(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(path + suffix)
+	}
+}
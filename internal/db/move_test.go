@@ -0,0 +1,102 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestDB opens a database in a temporary directory with one session.
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "goder.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.CreateSession("s1", "first"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMoveToCopiesWAL(t *testing.T) {
+	db := newTestDB(t)
+	oldPath := db.Path()
+
+	// A second connection that has read the database keeps SQLite from
+	// checkpointing and removing the log when MoveTo closes its own, so
+	// the session is still in the -wal file MoveTo has to copy.
+	other, err := sql.Open("sqlite3", oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	var n int
+	if err := other.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(t.TempDir(), "goder.db")
+	if err := db.MoveTo(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldPath + "-wal"); err != nil {
+		t.Fatalf("no WAL was left to copy: %v", err)
+	}
+	if db.Path() != newPath {
+		t.Errorf("Path() = %s, want %s", db.Path(), newPath)
+	}
+	if s, err := db.GetSession("s1"); err != nil || s.Title != "first" {
+		t.Fatalf("GetSession after the move = %+v, %v", s, err)
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Errorf("the original was not left in place: %v", err)
+	}
+}
+
+func TestMoveToRefusesExistingTarget(t *testing.T) {
+	db := newTestDB(t)
+	oldPath := db.Path()
+	target := filepath.Join(t.TempDir(), "goder.db")
+	if err := os.WriteFile(target, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.MoveTo(target); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("MoveTo onto an existing file: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "keep" {
+		t.Errorf("target was changed to %q", data)
+	}
+	if db.Path() != oldPath {
+		t.Errorf("Path() = %s, want %s", db.Path(), oldPath)
+	}
+	if _, err := db.GetSession("s1"); err != nil {
+		t.Errorf("GetSession after the refused move: %v", err)
+	}
+}
+
+func TestMoveToRecoversFromFailedCopy(t *testing.T) {
+	db := newTestDB(t)
+	oldPath := db.Path()
+	target := filepath.Join(t.TempDir(), "missing", "goder.db")
+
+	if err := db.MoveTo(target); err == nil {
+		t.Fatal("MoveTo into a missing directory succeeded")
+	}
+	if db.Path() != oldPath {
+		t.Errorf("Path() = %s, want %s", db.Path(), oldPath)
+	}
+
+	// The original is open again and still writable.
+	if _, err := db.CreateSession("s2", "second"); err != nil {
+		t.Fatalf("CreateSession after the failed move: %v", err)
+	}
+	sessions, err := db.ListSessions()
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("ListSessions after the failed move = %d sessions, %v", len(sessions), err)
+	}
+}
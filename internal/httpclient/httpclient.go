@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Options configures the outbound HTTP client.
+type Options struct {
+	// CACertPath is an optional PEM file of additional trusted CA certificates,
+	// appended to the system pool (e.g. for TLS-intercepting proxies).
+	CACertPath string
+
+	// InsecureSkipVerify disables TLS certificate verification. Only intended
+	// as an escape hatch for broken corporate proxies.
+	InsecureSkipVerify bool
+}
+
+// New returns an HTTP client for all outbound requests. Proxies are taken
+// from the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY environment variables.
+func New(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CACertPath != "" {
+		pem, err := os.ReadFile(opts.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in %s", opts.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -3,8 +3,10 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/webgovernor/goder/internal/llm/prompt"
 	"github.com/webgovernor/goder/internal/llm/provider"
@@ -28,13 +30,16 @@ const (
 	EventAgentError
 	EventPermissionRequest
 	EventPersistMessage // intermediate message that should be saved to DB
+	EventToolExecStart  // a tool call is about to be executed
+	EventNotice         // informational message for the user, e.g. a provider fallback
+	EventRateLimit      // the provider reported its rate limits with a response
 )
 
 // Event is sent from the agent loop to the TUI for rendering.
 type Event struct {
 	Type EventType
 
-	// For StreamText
+	// For StreamText and Notice
 	Text string
 
 	// For ToolCall events
@@ -50,32 +55,91 @@ type Event struct {
 	// For Done - the final complete message
 	FinalMessage *message.Message
 
+	// For Done - files changed by tools during the turn
+	Changes []FileChange
+
 	// For PermissionRequest
 	PermissionReq *permission.Request
+
+	// For RateLimit
+	RateLimit *provider.RateLimitStatus
 }
 
 // Agent orchestrates the LLM + tool execution loop.
 type Agent struct {
-	provider      provider.Provider
-	registry      *tools.Registry
-	permSvc       *permission.Service
-	workDir       string
-	mode          string
-	model         string
-	maxTokens     int
-	maxIterations int
+	provider       provider.Provider
+	registry       *tools.Registry
+	permSvc        *permission.Service
+	workDir        string
+	mode           string
+	model          string
+	assistantName  string
+	summary        string
+	instructions   string
+	pinned         []string
+	modePrompts    prompt.ModePrompts
+	maxTokens      int
+	maxIterations  int
+	maxToolCalls   int
+	historyLimit   int
+	delay          time.Duration
+	reviewEdits    bool
+	allowDangerous bool
+	store          bool
+	throttle       bool
+	toolColors     bool
+	metrics        *ToolMetrics
+	disabledTools  map[string]bool
+	toolRetries    map[string]int
+
+	compressToolResults bool
+	compressModel       string
+
+	reasoningEffort  string
+	reasoningReserve int
+	toolResultFormat string
 }
 
 // Config holds agent construction parameters.
 type Config struct {
-	Provider      provider.Provider
-	Registry      *tools.Registry
-	PermSvc       *permission.Service
-	WorkDir       string
-	Mode          string
-	Model         string
-	MaxTokens     int
-	MaxIterations int
+	Provider       provider.Provider
+	Registry       *tools.Registry
+	PermSvc        *permission.Service
+	WorkDir        string
+	Mode           string
+	Model          string
+	AssistantName  string             // the assistant's name in the system prompt; empty means goder
+	Summary        string             // stored session summary, injected into the system prompt
+	Instructions   string             // session instructions set with /system, sent with every request
+	Pinned         []string           // absolute paths of files whose contents are sent with every request
+	ModePrompts    prompt.ModePrompts // user instructions for each mode
+	MaxTokens      int
+	MaxIterations  int
+	MaxToolCalls   int            // max tool calls run per model response; 0 means no limit
+	HistoryLimit   int            // max recent messages sent per request; 0 means all
+	Delay          time.Duration  // wait between loop iterations; 0 means none
+	ReviewEdits    bool           // ask the user to review file writes before they happen
+	AllowDangerous bool           // skip the extra confirmation of calls flagged by tools.DangerChecker
+	Store          bool           // have the provider store responses and continue from the last one
+	Throttle       bool           // wait for rate limits to reset when the next request would exceed them
+	ToolColors     bool           // keep color codes in tool output; other escape sequences are always removed
+	Metrics        *ToolMetrics   // records tool calls if non-nil; shared across runs
+	DisabledTools  []string       // tools neither offered to the model nor run
+	ToolRetries    map[string]int // times a transient failure of each tool is retried
+
+	// ReasoningEffort and ReasoningReserve are passed to reasoning models;
+	// see provider.Request.
+	ReasoningEffort  string
+	ReasoningReserve int
+
+	// ToolResultFormat is how tool results are sent; see
+	// provider.Request.
+	ToolResultFormat string
+
+	// CompressToolResults condenses oversized tool results with
+	// CompressModel before they are sent to the model; empty means Model.
+	CompressToolResults bool
+	CompressModel       string
 }
 
 // New creates a new Agent.
@@ -84,15 +148,42 @@ func New(cfg Config) *Agent {
 	if maxIter <= 0 {
 		maxIter = DefaultMaxIterations
 	}
+	disabled := make(map[string]bool, len(cfg.DisabledTools))
+	for _, name := range cfg.DisabledTools {
+		disabled[name] = true
+	}
 	return &Agent{
-		provider:      cfg.Provider,
-		registry:      cfg.Registry,
-		permSvc:       cfg.PermSvc,
-		workDir:       cfg.WorkDir,
-		mode:          cfg.Mode,
-		model:         cfg.Model,
-		maxTokens:     cfg.MaxTokens,
-		maxIterations: maxIter,
+		provider:       cfg.Provider,
+		registry:       cfg.Registry,
+		permSvc:        cfg.PermSvc,
+		workDir:        cfg.WorkDir,
+		mode:           cfg.Mode,
+		model:          cfg.Model,
+		assistantName:  cfg.AssistantName,
+		summary:        cfg.Summary,
+		instructions:   cfg.Instructions,
+		pinned:         cfg.Pinned,
+		modePrompts:    cfg.ModePrompts,
+		maxTokens:      cfg.MaxTokens,
+		maxIterations:  maxIter,
+		maxToolCalls:   cfg.MaxToolCalls,
+		historyLimit:   cfg.HistoryLimit,
+		delay:          cfg.Delay,
+		reviewEdits:    cfg.ReviewEdits,
+		allowDangerous: cfg.AllowDangerous,
+		store:          cfg.Store,
+		throttle:       cfg.Throttle,
+		toolColors:     cfg.ToolColors,
+		metrics:        cfg.Metrics,
+		disabledTools:  disabled,
+		toolRetries:    cfg.ToolRetries,
+
+		reasoningEffort:  cfg.ReasoningEffort,
+		reasoningReserve: cfg.ReasoningReserve,
+		toolResultFormat: cfg.ToolResultFormat,
+
+		compressToolResults: cfg.CompressToolResults,
+		compressModel:       cfg.CompressModel,
 	}
 }
 
@@ -107,6 +198,14 @@ func (a *Agent) SetMode(mode string) {
 func (a *Agent) Run(ctx context.Context, history []message.Message, sessionID string) <-chan Event {
 	events := make(chan Event, 64)
 
+	// Work from a snapshot of the registry, so that the tools offered to the
+	// model and the tools it can run stay the same if the registry changes
+	// during the run. Disabled tools are left out of it entirely.
+	a.registry = a.registry.Clone()
+	for name := range a.disabledTools {
+		a.registry.Remove(name)
+	}
+
 	go func() {
 		defer close(events)
 		a.runLoop(ctx, history, sessionID, events)
@@ -116,7 +215,7 @@ func (a *Agent) Run(ctx context.Context, history []message.Message, sessionID st
 }
 
 func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionID string, events chan<- Event) {
-	systemPrompt := prompt.BuildSystemPrompt(a.mode, a.model, a.workDir, a.registry)
+	systemPrompt := prompt.BuildSystemPrompt(a.mode, a.model, a.assistantName, a.workDir, a.summary, a.modePrompts, a.registry)
 
 	// Build tool definitions, filtering by mode
 	toolDefs := a.buildToolDefs()
@@ -124,21 +223,61 @@ func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionI
 	currentHistory := make([]message.Message, len(history))
 	copy(currentHistory, history)
 
+	changes := newChangeTracker(a.workDir)
+
+	// The rate limits reported with the last response, and the input tokens
+	// it used, for throttling the next request.
+	var rateLimit *provider.RateLimitStatus
+	var lastInputTokens int
+
 	for iteration := 0; iteration < a.maxIterations; iteration++ {
+		if iteration > 0 && a.delay > 0 {
+			sleep(ctx, a.delay)
+		}
+		if iteration > 0 && a.throttle {
+			if d := throttleDelay(rateLimit, lastInputTokens); d > 0 {
+				events <- Event{Type: EventNotice, Text: throttleNotice(d)}
+				sleep(ctx, d)
+			}
+		}
 		if ctx.Err() != nil {
 			events <- Event{Type: EventAgentError, Error: ctx.Err()}
 			return
 		}
 
-		// Send to LLM
+		// Send to LLM. With stored responses, only the messages since the
+		// last one are sent; historyLimit takes precedence since the stored
+		// chain always carries the whole conversation.
+		messages, previousID := trimHistory(currentHistory, a.historyLimit), ""
+		if a.store && a.historyLimit <= 0 {
+			messages, previousID = chainHistory(currentHistory)
+		}
+		// Pinned files are re-read every iteration, so edits made by tools
+		// during the turn are reflected. Session instructions go in the
+		// system prompt too, which a stored response chain does not carry
+		// over, so they are sent once per request however long the chain.
 		req := provider.Request{
-			SystemPrompt: systemPrompt,
-			Messages:     currentHistory,
-			Tools:        toolDefs,
-			MaxTokens:    a.maxTokens,
+			SystemPrompt: systemPrompt + prompt.PinnedFilesSection(readPinnedFiles(a.workDir, a.pinned)) +
+				prompt.SessionInstructionsSection(a.instructions),
+			Messages:  messages,
+			Tools:     toolDefs,
+			MaxTokens: a.maxTokens,
+
+			ReasoningEffort:  a.reasoningEffort,
+			ReasoningReserve: a.reasoningReserve,
+			ToolResultFormat: a.toolResultFormat,
+
+			Store:              a.store,
+			PreviousResponseID: previousID,
 		}
 
 		streamCh, err := a.provider.SendMessage(ctx, req)
+		if err != nil && previousID != "" && errors.Is(err, provider.ErrPreviousResponse) {
+			// The stored response may have expired or been deleted, or
+			// belong to another account; send the whole conversation.
+			req.Messages, req.PreviousResponseID = currentHistory, ""
+			streamCh, err = a.provider.SendMessage(ctx, req)
+		}
 		if err != nil {
 			events <- Event{Type: EventAgentError, Error: fmt.Errorf("LLM request failed: %w", err)}
 			return
@@ -155,6 +294,10 @@ func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionI
 		pendingCalls := make(map[string]*pendingToolCall)
 
 		var usage provider.Usage
+		var responseID string
+		var incomplete string
+		var status string
+		var finished bool // the provider reported the end of the response
 
 		for event := range streamCh {
 			switch event.Type {
@@ -200,43 +343,117 @@ func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionI
 					delete(pendingCalls, event.ToolCallID)
 				}
 
+			case provider.EventFallback:
+				events <- Event{Type: EventNotice, Text: event.Text}
+
 			case provider.EventError:
 				events <- Event{Type: EventAgentError, Error: event.Error}
 				return
 
 			case provider.EventDone:
 				usage = event.Usage
+				responseID = event.ResponseID
+				incomplete = event.Incomplete
+				status = event.Status
+				finished = true
+				if event.RateLimit != nil {
+					rateLimit = event.RateLimit
+					events <- Event{Type: EventRateLimit, RateLimit: event.RateLimit}
+				}
 				// handled below
 			}
 		}
+		lastInputTokens = usage.InputTokens
+		if !finished {
+			// The stream closed without an outcome, as when the run is
+			// cancelled; a partial response is not worth continuing from.
+			err := ctx.Err()
+			if err == nil {
+				err = errors.New("the response stream ended without finishing")
+			}
+			events <- Event{Type: EventAgentError, Error: err}
+			return
+		}
 
 		// Create the assistant message
 		assistantMsg := message.NewAssistantMessage(sessionID, textContent.String(), toolCalls)
 		assistantMsg.InputTokens = usage.InputTokens
 		assistantMsg.OutputTokens = usage.OutputTokens
 		assistantMsg.TotalTokens = usage.TotalTokens
+		if a.store {
+			assistantMsg.ResponseID = responseID
+		}
 
 		// Add to history
 		currentHistory = append(currentHistory, assistantMsg)
 
-		// If no tool calls, we're done
+		switch {
+		case incomplete != "":
+			events <- Event{Type: EventNotice, Text: incompleteNotice(incomplete)}
+		case status == "" && len(toolCalls) == 0:
+			events <- Event{Type: EventNotice, Text: "The response ended without the provider marking it complete, so it may be cut short."}
+		}
+
+		// A response with no tool calls ends the turn, whatever its text
+		// and status: there is nothing to send back, so another request
+		// would only ask the model to repeat itself.
 		if len(toolCalls) == 0 {
-			events <- Event{Type: EventAgentDone, FinalMessage: &assistantMsg}
+			events <- Event{Type: EventAgentDone, FinalMessage: &assistantMsg, Changes: changes.Changes()}
 			return
 		}
 
 		// Persist the intermediate assistant message (with tool calls)
 		events <- Event{Type: EventPersistMessage, FinalMessage: &assistantMsg}
 
-		// Execute tool calls
+		// Execute tool calls, asking about those that need permission
+		// together if there are several.
+		batch := a.batchPermissions(ctx, toolCalls)
 		var toolResults []message.ToolResult
-		for _, tc := range toolCalls {
+		var compressUsage provider.Usage // of condensing the results
+		for i, tc := range toolCalls {
 			if ctx.Err() != nil {
 				events <- Event{Type: EventAgentError, Error: ctx.Err()}
 				return
 			}
 
-			result := a.executeTool(ctx, tc, events)
+			if a.maxToolCalls > 0 && i >= a.maxToolCalls {
+				result := message.ToolResult{
+					ToolCallID: tc.ID,
+					Name:       tc.Name,
+					Output: fmt.Sprintf("Error: not run. This response requested %d tool calls, over the limit of %d per response. "+
+						"Reconsider which calls are needed and make the rest in a later response.", len(toolCalls), a.maxToolCalls),
+					IsError: true,
+				}
+				toolResults = append(toolResults, result)
+				events <- Event{
+					Type:         EventToolResult,
+					ToolCallID:   tc.ID,
+					ToolCallName: tc.Name,
+					ToolOutput:   result.Output,
+					ToolIsError:  true,
+				}
+				continue
+			}
+
+			var changedPaths []string
+			if t, ok := a.registry.Get(tc.Name); ok {
+				if fc, ok := t.(tools.FileChanger); ok {
+					// A call whose paths are refused changes nothing.
+					changedPaths, _ = fc.ChangedPaths(tc.Input)
+				}
+			}
+
+			events <- Event{Type: EventToolExecStart, ToolCallID: tc.ID, ToolCallName: tc.Name}
+			changes.Before(changedPaths)
+			result := a.executeTool(ctx, tc, batch, events)
+			changes.After(changedPaths)
+			condensed, usage := a.compressToolResult(ctx, currentHistory, tc, &result)
+			if condensed {
+				events <- Event{Type: EventNotice, Text: compressNotice(result)}
+			}
+			compressUsage.InputTokens += usage.InputTokens
+			compressUsage.OutputTokens += usage.OutputTokens
+			compressUsage.TotalTokens += usage.TotalTokens
 			toolResults = append(toolResults, result)
 
 			events <- Event{
@@ -250,6 +467,9 @@ func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionI
 
 		// Create tool result message and add to history
 		toolResultMsg := message.NewToolResultMessage(sessionID, toolResults)
+		toolResultMsg.InputTokens = compressUsage.InputTokens
+		toolResultMsg.OutputTokens = compressUsage.OutputTokens
+		toolResultMsg.TotalTokens = compressUsage.TotalTokens
 		currentHistory = append(currentHistory, toolResultMsg)
 
 		// Persist the tool result message
@@ -264,8 +484,36 @@ func (a *Agent) runLoop(ctx context.Context, history []message.Message, sessionI
 	}
 }
 
-// executeTool runs a single tool call, handling permissions.
-func (a *Agent) executeTool(ctx context.Context, tc message.ToolCall, events chan<- Event) message.ToolResult {
+// incompleteNotice tells the user why a response was cut short.
+func incompleteNotice(reason string) string {
+	if reason == "max_output_tokens" {
+		return "The response was truncated by the output token limit. Raise maxTokens in the config to allow longer responses."
+	}
+	return fmt.Sprintf("The response was cut short by the provider (%s).", reason)
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// executeTool runs a single tool call, handling permissions. batch holds the
+// user's decisions on calls already asked about together, by call ID.
+func (a *Agent) executeTool(ctx context.Context, tc message.ToolCall, batch map[string]permission.Response, events chan<- Event) message.ToolResult {
+	if a.disabledTools[tc.Name] {
+		return message.ToolResult{
+			ToolCallID: tc.ID,
+			Name:       tc.Name,
+			Output:     fmt.Sprintf("Error: tool '%s' has been disabled by the user.", tc.Name),
+			IsError:    true,
+		}
+	}
+
 	tool, ok := a.registry.Get(tc.Name)
 	if !ok {
 		return message.ToolResult{
@@ -289,7 +537,18 @@ func (a *Agent) executeTool(ctx context.Context, tc message.ToolCall, events cha
 
 	// Check permissions for tools that require them
 	if tool.RequiresPermission() && a.permSvc != nil {
-		resp := a.permSvc.Check(ctx, tc.Name, string(tc.Input))
+		resp, decided := batch[tc.ID]
+		if !decided {
+			var err error
+			if resp, err = a.checkPermission(ctx, tc, tool); err != nil {
+				return message.ToolResult{
+					ToolCallID: tc.ID,
+					Name:       tc.Name,
+					Output:     fmt.Sprintf("Error: %s", err.Error()),
+					IsError:    true,
+				}
+			}
+		}
 		if resp == permission.Deny {
 			return message.ToolResult{
 				ToolCallID: tc.ID,
@@ -298,15 +557,28 @@ func (a *Agent) executeTool(ctx context.Context, tc message.ToolCall, events cha
 				IsError:    true,
 			}
 		}
+
+		if r, ok := tool.(tools.Reviewer); ok && a.reviewEdits {
+			if result, handled := a.reviewChange(ctx, tc, r); handled {
+				return result
+			}
+		}
 	}
 
 	// Execute the tool
-	output, err := tool.Execute(ctx, tc.Input)
+	output, err := a.runTool(ctx, tc, tool, events)
+	output = tools.CleanTerminalOutput(output, a.toolColors)
 	if err != nil {
+		// Keep any output produced before the failure, such as the partial
+		// output of a command that timed out.
+		errOutput := fmt.Sprintf("Error: %s", err.Error())
+		if output != "" {
+			errOutput = output + "\n\n" + errOutput
+		}
 		return message.ToolResult{
 			ToolCallID: tc.ID,
 			Name:       tc.Name,
-			Output:     fmt.Sprintf("Error: %s", err.Error()),
+			Output:     errOutput,
 			IsError:    true,
 		}
 	}
@@ -319,6 +591,28 @@ func (a *Agent) executeTool(ctx context.Context, tc message.ToolCall, events cha
 	}
 }
 
+// checkPermission asks the user whether tc may run, unless its tool is
+// allowed for the session. Dangerous calls are confirmed even if the tool is
+// allowed for the session. Calls the tool would refuse, such as those naming
+// a path outside the allowed roots, return the refusal without asking.
+func (a *Agent) checkPermission(ctx context.Context, tc message.ToolCall, tool tools.Tool) (permission.Response, error) {
+	var preview string
+	if p, ok := tool.(tools.Previewer); ok {
+		var err error
+		if preview, err = p.Preview(tc.Input); err != nil {
+			return permission.Deny, err
+		}
+	}
+	var warning string
+	if d, ok := tool.(tools.DangerChecker); ok && !a.allowDangerous {
+		warning = d.Danger(tc.Input)
+	}
+	if warning != "" {
+		return a.permSvc.CheckDangerous(ctx, tc.Name, string(tc.Input), preview, warning), nil
+	}
+	return a.permSvc.Check(ctx, tc.Name, string(tc.Input), preview), nil
+}
+
 // buildToolDefs creates tool definitions, filtering by mode.
 func (a *Agent) buildToolDefs() []provider.ToolDefinition {
 	var defs []provider.ToolDefinition
@@ -335,3 +629,56 @@ func (a *Agent) buildToolDefs() []provider.ToolDefinition {
 	}
 	return defs
 }
+
+// reviewChange shows the file change a tool call would make for review. It
+// reports false if the call should go on to run as proposed; otherwise the
+// change was skipped, or the user's amended content was written instead.
+func (a *Agent) reviewChange(ctx context.Context, tc message.ToolCall, r tools.Reviewer) (message.ToolResult, bool) {
+	proposal, err := r.Propose(tc.Input)
+	if err != nil {
+		// Let the tool itself report the problem.
+		return message.ToolResult{}, false
+	}
+
+	review := a.permSvc.Review(ctx, tc.Name, proposal.Diff, proposal.Content)
+	switch {
+	case review.Response == permission.Deny:
+		return message.ToolResult{
+			ToolCallID: tc.ID,
+			Name:       tc.Name,
+			Output:     "Change skipped by user during review.",
+			IsError:    true,
+		}, true
+	case review.Content == proposal.Content:
+		return message.ToolResult{}, false
+	}
+
+	// The user amended the change: write their version in its place.
+	write, ok := a.registry.Get("write")
+	if !ok {
+		return message.ToolResult{
+			ToolCallID: tc.ID,
+			Name:       tc.Name,
+			Output:     "Error: the reviewed change could not be applied because the write tool is unavailable.",
+			IsError:    true,
+		}, true
+	}
+	input, _ := json.Marshal(map[string]string{
+		"file_path": proposal.Path,
+		"content":   review.Content,
+	})
+	output, err := write.Execute(ctx, input)
+	if err != nil {
+		return message.ToolResult{
+			ToolCallID: tc.ID,
+			Name:       tc.Name,
+			Output:     fmt.Sprintf("Error: %s", err.Error()),
+			IsError:    true,
+		}, true
+	}
+	return message.ToolResult{
+		ToolCallID: tc.ID,
+		Name:       tc.Name,
+		Output:     output + "\n\nThe user edited your proposed change during review before it was applied. View the file if you need its final content.",
+	}, true
+}
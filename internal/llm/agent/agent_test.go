@@ -0,0 +1,820 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/permission"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+// fakeTool is a tool with a canned result.
+type fakeTool struct {
+	name       string
+	output     string
+	err        error
+	permission bool
+	calls      int
+}
+
+func (f *fakeTool) Name() string                { return f.name }
+func (f *fakeTool) Description() string         { return "fake " + f.name }
+func (f *fakeTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (f *fakeTool) RequiresPermission() bool    { return f.permission }
+func (f *fakeTool) Execute(context.Context, json.RawMessage) (string, error) {
+	f.calls++
+	return f.output, f.err
+}
+
+// runAgent runs an agent with cfg on a single prompt and returns its events.
+func runAgent(t *testing.T, cfg Config) []Event {
+	t.Helper()
+	if cfg.Registry == nil {
+		cfg.Registry = tools.NewRegistry()
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = "build"
+	}
+	cfg.WorkDir = t.TempDir()
+
+	history := []message.Message{message.NewUserMessage("s", "hello")}
+	var events []Event
+	for ev := range New(cfg).Run(context.Background(), history, "s") {
+		events = append(events, ev)
+	}
+	return events
+}
+
+// lastEvent returns the final event, failing the test if there is none.
+func lastEvent(t *testing.T, events []Event) Event {
+	t.Helper()
+	if len(events) == 0 {
+		t.Fatal("no events")
+	}
+	return events[len(events)-1]
+}
+
+func TestRunTextResponse(t *testing.T) {
+	mock := provider.NewMock(provider.TextResponse("hi there"))
+
+	events := runAgent(t, Config{Provider: mock})
+
+	done := lastEvent(t, events)
+	if done.Type != EventAgentDone || done.FinalMessage.Content != "hi there" {
+		t.Fatalf("final event = %+v, want done with the response text", done)
+	}
+	if n := len(mock.Requests()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestRunEndsTurnOnResponseOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		events     []provider.StreamEvent
+		wantType   EventType
+		wantNotice bool
+	}{
+		{"completed", []provider.StreamEvent{
+			{Type: provider.EventTextDelta, Text: "all done"},
+			{Type: provider.EventDone, Status: "completed"},
+		}, EventAgentDone, false},
+		{"no status", []provider.StreamEvent{
+			{Type: provider.EventTextDelta, Text: "all do"},
+			{Type: provider.EventDone},
+		}, EventAgentDone, true},
+		{"no outcome", []provider.StreamEvent{
+			{Type: provider.EventTextDelta, Text: "all do"},
+		}, EventAgentError, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := provider.NewMock(provider.MockResponse{Events: tc.events}, provider.TextResponse("again"))
+
+			events := runAgent(t, Config{Provider: mock})
+
+			if last := lastEvent(t, events); last.Type != tc.wantType {
+				t.Errorf("final event = %+v, want type %v", last, tc.wantType)
+			}
+			var notice bool
+			for _, ev := range events {
+				notice = notice || ev.Type == EventNotice
+			}
+			if notice != tc.wantNotice {
+				t.Errorf("notice = %v, want %v", notice, tc.wantNotice)
+			}
+			// The turn never goes on to another request.
+			if n := len(mock.Requests()); n != 1 {
+				t.Errorf("sent %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestRunToolCallThenContinue(t *testing.T) {
+	tool := &fakeTool{name: "lookup", output: "42"}
+	registry := tools.NewRegistry()
+	registry.Register(tool)
+
+	mock := provider.NewMock(
+		provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "lookup", Input: json.RawMessage(`{}`)}),
+		provider.TextResponse("the answer is 42"),
+	)
+
+	events := runAgent(t, Config{Provider: mock, Registry: registry})
+
+	if tool.calls != 1 {
+		t.Errorf("tool ran %d times, want 1", tool.calls)
+	}
+	if done := lastEvent(t, events); done.Type != EventAgentDone || done.FinalMessage.Content != "the answer is 42" {
+		t.Fatalf("final event = %+v, want done after the tool result", done)
+	}
+
+	// The second request carries the tool call and its result.
+	reqs := mock.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(reqs))
+	}
+	msgs := reqs[1].Messages
+	last := msgs[len(msgs)-1]
+	if len(last.ToolResults) != 1 || last.ToolResults[0].Output != "42" || last.ToolResults[0].IsError {
+		t.Errorf("last message = %+v, want the tool result", last)
+	}
+}
+
+func TestRunCleansToolOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		colors bool
+		want   string
+	}{
+		{"stripped by default", false, "main.go\nok"},
+		{"colors kept", true, "\x1b[1;34mmain.go\x1b[0m\nok"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := tools.NewRegistry()
+			registry.Register(&fakeTool{name: "ls", output: "\x1b[1;34mmain.go\x1b[0m\n\x1b]0;title\x07 50%\rok"})
+			mock := provider.NewMock(
+				provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "ls", Input: json.RawMessage(`{}`)}),
+				provider.TextResponse("done"),
+			)
+
+			events := runAgent(t, Config{Provider: mock, Registry: registry, ToolColors: tc.colors})
+
+			var got string
+			for _, ev := range events {
+				if ev.Type == EventToolResult {
+					got = ev.ToolOutput
+				}
+			}
+			if got != tc.want {
+				t.Errorf("tool result = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunSendsSessionInstructions(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&fakeTool{name: "ls", output: "main.go"})
+	call := provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "ls", Input: json.RawMessage(`{}`)})
+	call.Events[len(call.Events)-1].ResponseID = "resp_1"
+	mock := provider.NewMock(call, provider.TextResponse("done"))
+
+	runAgent(t, Config{Provider: mock, Registry: registry, Instructions: "We're debugging auth.", Store: true})
+
+	reqs := mock.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(reqs))
+	}
+	for i, req := range reqs {
+		if !strings.Contains(req.SystemPrompt, "We're debugging auth.") {
+			t.Errorf("request %d: system prompt lacks the session instructions", i)
+		}
+		// The chained request must not add the instructions to the stored
+		// conversation again.
+		for _, msg := range req.Messages {
+			if msg.Role == message.System {
+				t.Errorf("request %d sends the system message %q", i, msg.Content)
+			}
+		}
+	}
+	if reqs[1].PreviousResponseID != "resp_1" || len(reqs[1].Messages) != 1 {
+		t.Errorf("second request chains from %q with %d messages, want resp_1 and the tool result",
+			reqs[1].PreviousResponseID, len(reqs[1].Messages))
+	}
+}
+
+func TestRunCompressesToolResults(t *testing.T) {
+	long := strings.Repeat("noise line\n", 2000) + "main.go:12: undefined: foo\n"
+	for _, tc := range []struct {
+		name     string
+		output   string
+		compress bool
+	}{
+		{"oversized output", long, true},
+		{"short output", "main.go:12: undefined: foo", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := tools.NewRegistry()
+			registry.Register(&fakeTool{name: "build", output: tc.output})
+			responses := []provider.MockResponse{
+				provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "build", Input: json.RawMessage(`{}`)}),
+			}
+			if tc.compress {
+				responses = append(responses, provider.MockResponse{Events: []provider.StreamEvent{
+					{Type: provider.EventTextDelta, Text: "main.go:12: undefined: foo"},
+					{Type: provider.EventDone, Status: "completed", Usage: provider.Usage{InputTokens: 5000, OutputTokens: 10, TotalTokens: 5010}},
+				}})
+			}
+			mock := provider.NewMock(append(responses, provider.TextResponse("done"))...)
+
+			events := runAgent(t, Config{Provider: mock, Registry: registry, CompressToolResults: true, CompressModel: "small"})
+
+			for _, ev := range events {
+				if ev.Type == EventToolResult && ev.ToolOutput != tc.output {
+					t.Errorf("tool result event = %q, want the full output", ev.ToolOutput)
+				}
+			}
+			reqs := mock.Requests()
+			if len(reqs) != len(responses)+1 {
+				t.Fatalf("sent %d requests, want %d", len(reqs), len(responses)+1)
+			}
+			if !tc.compress {
+				return
+			}
+			if reqs[1].Model != "small" || !strings.Contains(reqs[1].Messages[0].Content, "hello") {
+				t.Errorf("compress request = %+v, want the compress model and the task", reqs[1])
+			}
+			msgs := reqs[2].Messages
+			tr := msgs[len(msgs)-1].ToolResults[0]
+			if tr.Output != tc.output || !strings.HasSuffix(tr.ForModel(), "\nmain.go:12: undefined: foo") {
+				t.Errorf("tool result sent = %+v, want the condensed output for the model", tr)
+			}
+			for _, ev := range events {
+				if ev.Type == EventPersistMessage && ev.FinalMessage.Role == message.Tool && ev.FinalMessage.TotalTokens != 5010 {
+					t.Errorf("tool result message records %d tokens, want the 5010 used condensing it", ev.FinalMessage.TotalTokens)
+				}
+			}
+		})
+	}
+}
+
+// answerPermissions answers every permission request on svc with resp until
+// the test ends.
+func answerPermissions(t *testing.T, svc *permission.Service, resp permission.Response) {
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		for {
+			select {
+			case req := <-svc.RequestCh():
+				req.ResponseCh <- resp
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+func TestRunToolResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		tool       *fakeTool
+		call       string // tool name the model calls
+		mode       string
+		permission permission.Response
+		wantOutput string
+		wantError  bool
+		wantRuns   int
+	}{
+		{
+			name:       "success",
+			tool:       &fakeTool{name: "ls", output: "main.go"},
+			call:       "ls",
+			wantOutput: "main.go",
+			wantRuns:   1,
+		},
+		{
+			name:       "tool error keeps output",
+			tool:       &fakeTool{name: "bash", output: "partial", err: errors.New("timed out")},
+			call:       "bash",
+			wantOutput: "partial\n\nError: timed out",
+			wantError:  true,
+			wantRuns:   1,
+		},
+		{
+			name:       "unknown tool",
+			tool:       &fakeTool{name: "ls"},
+			call:       "rm",
+			wantOutput: "Error: unknown tool 'rm'",
+			wantError:  true,
+		},
+		{
+			name:       "permission denied",
+			tool:       &fakeTool{name: "write", permission: true},
+			call:       "write",
+			permission: permission.Deny,
+			wantOutput: "Permission denied by user.",
+			wantError:  true,
+		},
+		{
+			name:       "permission allowed",
+			tool:       &fakeTool{name: "write", output: "wrote", permission: true},
+			call:       "write",
+			permission: permission.Allow,
+			wantOutput: "wrote",
+			wantRuns:   1,
+		},
+		{
+			name:       "write tool in plan mode",
+			tool:       &fakeTool{name: "write", permission: true},
+			call:       "write",
+			mode:       "plan",
+			wantOutput: "not available in PLAN mode",
+			wantError:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := tools.NewRegistry()
+			registry.Register(tt.tool)
+			permSvc := permission.NewService()
+			answerPermissions(t, permSvc, tt.permission)
+
+			mock := provider.NewMock(
+				provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: tt.call, Input: json.RawMessage(`{}`)}),
+				provider.TextResponse("done"),
+			)
+			events := runAgent(t, Config{Provider: mock, Registry: registry, PermSvc: permSvc, Mode: tt.mode})
+
+			var result *Event
+			for i := range events {
+				if events[i].Type == EventToolResult {
+					result = &events[i]
+				}
+			}
+			if result == nil {
+				t.Fatalf("no tool result in %+v", events)
+			}
+			if !strings.Contains(result.ToolOutput, tt.wantOutput) || result.ToolIsError != tt.wantError {
+				t.Errorf("result = %q (error %v), want %q (error %v)", result.ToolOutput, result.ToolIsError, tt.wantOutput, tt.wantError)
+			}
+			if tt.tool.calls != tt.wantRuns {
+				t.Errorf("tool ran %d times, want %d", tt.tool.calls, tt.wantRuns)
+			}
+			// The model sees the result and gets to respond to it.
+			if done := lastEvent(t, events); done.Type != EventAgentDone {
+				t.Errorf("final event = %+v, want done", done)
+			}
+		})
+	}
+}
+
+func TestRunMaxIterations(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&fakeTool{name: "ls", output: "main.go"})
+
+	call := provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "ls", Input: json.RawMessage(`{}`)})
+	mock := provider.NewMock(call, call, call)
+
+	events := runAgent(t, Config{Provider: mock, Registry: registry, MaxIterations: 2})
+
+	last := lastEvent(t, events)
+	if last.Type != EventAgentError || !strings.Contains(last.Error.Error(), "maximum iterations (2)") {
+		t.Fatalf("final event = %+v, want the iteration limit error", last)
+	}
+	if n := len(mock.Requests()); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestRunRecordsDeletions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"old.txt", "gen/a.go", "gen/b.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("line\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := tools.NewRegistry()
+	registry.Register(tools.NewDeleteTool(dir))
+
+	mock := provider.NewMock(
+		provider.ToolCallResponse(
+			message.ToolCall{ID: "c1", Name: "delete", Input: json.RawMessage(`{"path":"old.txt"}`)},
+			message.ToolCall{ID: "c2", Name: "delete", Input: json.RawMessage(`{"path":"gen","recursive":true}`)},
+		),
+		provider.TextResponse("cleaned up"),
+	)
+	history := []message.Message{message.NewUserMessage("s", "clean up")}
+	var events []Event
+	for ev := range New(Config{Provider: mock, Registry: registry, Mode: "build", WorkDir: dir}).Run(context.Background(), history, "s") {
+		events = append(events, ev)
+	}
+
+	done := lastEvent(t, events)
+	if done.Type != EventAgentDone {
+		t.Fatalf("final event = %+v, want done", done)
+	}
+	var deleted []string
+	for _, c := range done.Changes {
+		if !c.Deleted || c.Lines != -1 {
+			t.Errorf("change %+v, want a one-line deletion", c)
+		}
+		deleted = append(deleted, filepath.ToSlash(c.Path))
+	}
+	if want := []string{"old.txt", "gen/a.go", "gen/b.go"}; strings.Join(deleted, ",") != strings.Join(want, ",") {
+		t.Errorf("deleted %q, want %q", deleted, want)
+	}
+}
+
+func TestRunMaxToolCalls(t *testing.T) {
+	tool := &fakeTool{name: "ls", output: "main.go"}
+	registry := tools.NewRegistry()
+	registry.Register(tool)
+
+	var calls []message.ToolCall
+	for _, id := range []string{"c1", "c2", "c3"} {
+		calls = append(calls, message.ToolCall{ID: id, Name: "ls", Input: json.RawMessage(`{}`)})
+	}
+	mock := provider.NewMock(provider.ToolCallResponse(calls...), provider.TextResponse("done"))
+
+	events := runAgent(t, Config{Provider: mock, Registry: registry, MaxToolCalls: 2})
+
+	if tool.calls != 2 {
+		t.Errorf("tool ran %d times, want 2", tool.calls)
+	}
+	if done := lastEvent(t, events); done.Type != EventAgentDone {
+		t.Fatalf("final event = %+v, want the turn to continue after the cap", done)
+	}
+
+	// Every call gets a result; the one over the cap tells the model why it
+	// was not run.
+	reqs := mock.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(reqs))
+	}
+	msgs := reqs[1].Messages
+	results := msgs[len(msgs)-1].ToolResults
+	if len(results) != 3 {
+		t.Fatalf("got %d tool results, want 3", len(results))
+	}
+	for _, r := range results[:2] {
+		if r.IsError || r.Output != "main.go" {
+			t.Errorf("result %+v, want the tool output", r)
+		}
+	}
+	if r := results[2]; r.ToolCallID != "c3" || !r.IsError || !strings.Contains(r.Output, "requested 3 tool calls, over the limit of 2") {
+		t.Errorf("capped result = %+v, want the over-limit notice", r)
+	}
+}
+
+func TestRunProviderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		resp provider.MockResponse
+	}{
+		{"request error", provider.MockResponse{Err: errors.New("connection refused")}},
+		{"stream error", provider.StreamErrorResponse(errors.New("overloaded"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := runAgent(t, Config{Provider: provider.NewMock(tt.resp)})
+			if last := lastEvent(t, events); last.Type != EventAgentError {
+				t.Fatalf("final event = %+v, want an error", last)
+			}
+		})
+	}
+}
+
+func TestRunUsesRegistrySnapshot(t *testing.T) {
+	tool := &fakeTool{name: "ls", output: "main.go"}
+	registry := tools.NewRegistry()
+	registry.Register(tool)
+
+	call := provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "ls", Input: json.RawMessage(`{}`)})
+	mock := provider.NewMock(call, call, provider.TextResponse("done"))
+	a := New(Config{Provider: mock, Registry: registry, Mode: "build", WorkDir: t.TempDir()})
+	events := a.Run(context.Background(), []message.Message{message.NewUserMessage("s", "hello")}, "s")
+
+	// Toggle the tool while the run reads the registry.
+	stop := make(chan struct{})
+	toggled := make(chan struct{})
+	go func() {
+		defer close(toggled)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				registry.Remove("ls")
+				registry.Register(tool)
+			}
+		}
+	}()
+
+	for ev := range events {
+		if ev.Type == EventToolResult && ev.ToolIsError {
+			t.Errorf("tool result = %q, want the tool to stay available for the run", ev.ToolOutput)
+		}
+	}
+	close(stop)
+	<-toggled
+}
+
+func TestRunDisabledTools(t *testing.T) {
+	fetch := &fakeTool{name: "fetch", output: "page"}
+	registry := tools.NewRegistry()
+	registry.Register(&fakeTool{name: "ls"})
+	registry.Register(fetch)
+
+	mock := provider.NewMock(
+		provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "fetch", Input: json.RawMessage(`{}`)}),
+		provider.TextResponse("done"),
+	)
+	events := runAgent(t, Config{Provider: mock, Registry: registry, DisabledTools: []string{"fetch"}})
+
+	for _, def := range mock.Requests()[0].Tools {
+		if def.Name == "fetch" {
+			t.Error("disabled tool was offered to the model")
+		}
+	}
+	if fetch.calls != 0 {
+		t.Error("disabled tool ran")
+	}
+	for _, ev := range events {
+		if ev.Type == EventToolResult && !strings.Contains(ev.ToolOutput, "disabled") {
+			t.Errorf("tool result = %q, want it to say the tool is disabled", ev.ToolOutput)
+		}
+	}
+	if _, ok := registry.Get("fetch"); !ok {
+		t.Error("disabling a tool for a run removed it from the shared registry")
+	}
+}
+
+func TestRunDelayRespectsCancel(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(&fakeTool{name: "ls", output: "main.go"})
+	mock := provider.NewMock(
+		provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "ls", Input: json.RawMessage(`{}`)}),
+		provider.TextResponse("done"),
+	)
+	a := New(Config{Provider: mock, Registry: registry, Mode: "build", WorkDir: t.TempDir(), Delay: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var last Event
+	for ev := range a.Run(ctx, []message.Message{message.NewUserMessage("s", "hello")}, "s") {
+		if ev.Type == EventToolResult {
+			cancel() // during the delay before the next request
+		}
+		last = ev
+	}
+
+	if last.Type != EventAgentError || !errors.Is(last.Error, context.Canceled) {
+		t.Errorf("final event = %+v, want the cancellation", last)
+	}
+	if n := len(mock.Requests()); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+// rewriteTool overwrites a file when run.
+type rewriteTool struct {
+	fakeTool
+	path, content string
+}
+
+func (r *rewriteTool) Execute(context.Context, json.RawMessage) (string, error) {
+	return "ok", os.WriteFile(r.path, []byte(r.content), 0o644)
+}
+
+func TestRunSendsPinnedFiles(t *testing.T) {
+	pinned := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(pinned, []byte("first version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := tools.NewRegistry()
+	registry.Register(&rewriteTool{fakeTool: fakeTool{name: "rewrite"}, path: pinned, content: "second version\n"})
+
+	mock := provider.NewMock(
+		provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "rewrite", Input: json.RawMessage(`{}`)}),
+		provider.TextResponse("done"),
+	)
+	runAgent(t, Config{Provider: mock, Registry: registry, Pinned: []string{pinned}})
+
+	reqs := mock.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(reqs))
+	}
+	if !strings.Contains(reqs[0].SystemPrompt, "first version") {
+		t.Error("first request is missing the pinned file")
+	}
+	if p := reqs[1].SystemPrompt; !strings.Contains(p, "second version") || strings.Contains(p, "first version") {
+		t.Error("second request does not have the rewritten pinned file")
+	}
+}
+
+// dangerTool is a tool that flags every call as dangerous.
+type dangerTool struct{ fakeTool }
+
+func (d *dangerTool) Danger(json.RawMessage) string { return "wipes everything" }
+
+func TestRunBatchPermissions(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		answer    permission.Response // to the batch request
+		wantAsked int                 // requests, including the batch request
+		wantRuns  int                 // of each tool needing permission
+	}{
+		{"allow all", permission.Allow, 1, 1},
+		{"deny all", permission.Deny, 1, 0},
+		{"step through", permission.AskEach, 3, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			write := &fakeTool{name: "write", output: "wrote", permission: true}
+			edit := &fakeTool{name: "edit", output: "edited", permission: true}
+			ls := &fakeTool{name: "ls", output: "main.go"}
+			registry := tools.NewRegistry()
+			for _, tool := range []*fakeTool{write, edit, ls} {
+				registry.Register(tool)
+			}
+
+			permSvc := permission.NewService()
+			var asked []permission.Request
+			done := make(chan struct{})
+			t.Cleanup(func() { close(done) })
+			go func() {
+				for {
+					select {
+					case req := <-permSvc.RequestCh():
+						asked = append(asked, req)
+						if req.IsBatch() {
+							req.ResponseCh <- tc.answer
+						} else {
+							req.ResponseCh <- permission.Allow
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+
+			mock := provider.NewMock(
+				provider.ToolCallResponse(
+					message.ToolCall{ID: "c1", Name: "write", Input: json.RawMessage(`{}`)},
+					message.ToolCall{ID: "c2", Name: "ls", Input: json.RawMessage(`{}`)},
+					message.ToolCall{ID: "c3", Name: "edit", Input: json.RawMessage(`{}`)},
+				),
+				provider.TextResponse("done"),
+			)
+			runAgent(t, Config{Provider: mock, Registry: registry, PermSvc: permSvc})
+
+			if len(asked) != tc.wantAsked {
+				t.Fatalf("asked %d times, want %d", len(asked), tc.wantAsked)
+			}
+			if batch := asked[0].Batch; len(batch) != 2 || batch[0].ToolName != "write" || batch[1].ToolName != "edit" {
+				t.Errorf("batch request = %+v, want the write and edit calls", batch)
+			}
+			if write.calls != tc.wantRuns || edit.calls != tc.wantRuns || ls.calls != 1 {
+				t.Errorf("runs: write %d, edit %d, ls %d; want %d, %d, 1", write.calls, edit.calls, ls.calls, tc.wantRuns, tc.wantRuns)
+			}
+		})
+	}
+}
+
+// flakyTool fails with a transient error until it has run failures times.
+type flakyTool struct {
+	fakeTool
+	failures int
+}
+
+func (f *flakyTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	if f.calls++; f.calls <= f.failures {
+		return "", errors.New("connection reset")
+	}
+	return f.output, nil
+}
+
+func (f *flakyTool) Retryable(_ json.RawMessage, _ string, err error) bool { return err != nil }
+
+func TestRunRetriesTransientToolFailures(t *testing.T) {
+	defer func(d time.Duration) { toolRetryBackoff = d }(toolRetryBackoff)
+	toolRetryBackoff = time.Millisecond
+
+	for _, tc := range []struct {
+		name       string
+		retries    int
+		wantRuns   int
+		wantOutput string
+	}{
+		{"recovers", 2, 3, "page"},
+		{"gives up", 1, 2, "Error: connection reset"},
+		{"not configured", 0, 1, "Error: connection reset"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := &flakyTool{fakeTool: fakeTool{name: "fetch", output: "page"}, failures: 2}
+			registry := tools.NewRegistry()
+			registry.Register(tool)
+			mock := provider.NewMock(
+				provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "fetch", Input: json.RawMessage(`{}`)}),
+				provider.TextResponse("done"),
+			)
+
+			events := runAgent(t, Config{Provider: mock, Registry: registry, ToolRetries: map[string]int{"fetch": tc.retries}})
+
+			if tool.calls != tc.wantRuns {
+				t.Errorf("tool ran %d times, want %d", tool.calls, tc.wantRuns)
+			}
+			var notices int
+			for _, ev := range events {
+				switch ev.Type {
+				case EventNotice:
+					notices++
+				case EventToolResult:
+					if ev.ToolOutput != tc.wantOutput {
+						t.Errorf("tool result = %q, want %q", ev.ToolOutput, tc.wantOutput)
+					}
+				}
+			}
+			if want := tc.wantRuns - 1; notices != want {
+				t.Errorf("got %d retry notices, want %d", notices, want)
+			}
+			if n := len(mock.Requests()); n != 2 {
+				t.Errorf("sent %d requests, want 2", n)
+			}
+		})
+	}
+}
+
+func TestRunConfirmsDangerousCalls(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		tool := &dangerTool{fakeTool{name: "bash", output: "ok", permission: true}}
+		registry := tools.NewRegistry()
+		registry.Register(tool)
+
+		// Auto-approval covers ordinary calls, but not dangerous ones.
+		permSvc := permission.NewService()
+		permSvc.SetAutoApprove(true)
+		var warnings []string
+		done := make(chan struct{})
+		t.Cleanup(func() { close(done) })
+		go func() {
+			for {
+				select {
+				case req := <-permSvc.RequestCh():
+					warnings = append(warnings, req.Warning)
+					req.ResponseCh <- permission.Deny
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		mock := provider.NewMock(
+			provider.ToolCallResponse(message.ToolCall{ID: "c1", Name: "bash", Input: json.RawMessage(`{}`)}),
+			provider.TextResponse("done"),
+		)
+		runAgent(t, Config{Provider: mock, Registry: registry, PermSvc: permSvc, AllowDangerous: allow})
+
+		switch {
+		case !allow && (len(warnings) != 1 || warnings[0] != "wipes everything" || tool.calls != 0):
+			t.Errorf("dangerous call: warnings %q, ran %d times; want one prompt and no run", warnings, tool.calls)
+		case allow && (len(warnings) != 0 || tool.calls != 1):
+			t.Errorf("with AllowDangerous: warnings %q, ran %d times; want no prompt and one run", warnings, tool.calls)
+		}
+	}
+}
+
+func TestThrottleDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     *provider.RateLimitStatus
+		nextTokens int
+		want       time.Duration
+	}{
+		{"no status", nil, 100, 0},
+		{"plenty left", &provider.RateLimitStatus{RemainingRequests: 5, RemainingTokens: 5000, ResetRequests: time.Second, ResetTokens: time.Second}, 100, 0},
+		{"out of requests", &provider.RateLimitStatus{RemainingRequests: 0, RemainingTokens: 5000, ResetRequests: 2 * time.Second}, 100, 2 * time.Second},
+		{"too few tokens", &provider.RateLimitStatus{RemainingRequests: 5, RemainingTokens: 50, ResetTokens: 3 * time.Second}, 100, 3 * time.Second},
+		{"tokens unknown", &provider.RateLimitStatus{RemainingRequests: 5, RemainingTokens: -1, ResetTokens: 3 * time.Second}, 100, 0},
+		{"both, longest wins", &provider.RateLimitStatus{RemainingRequests: 0, RemainingTokens: 50, ResetRequests: 2 * time.Second, ResetTokens: 3 * time.Second}, 100, 3 * time.Second},
+		{"capped", &provider.RateLimitStatus{RemainingRequests: 0, ResetRequests: time.Hour}, 0, maxThrottleDelay},
+	}
+	for _, tt := range tests {
+		if got := throttleDelay(tt.status, tt.nextTokens); got != tt.want {
+			t.Errorf("%s: throttleDelay = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/permission"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+// batchPermissions asks the user once about the calls in a model response
+// that need permission, when there are several, and returns the decision
+// for each call it covers by ID. Calls left out, including those flagged as
+// dangerous, which are always confirmed one at a time, are checked as they
+// run. An empty map means every call is checked on its own.
+//
+// Previews are computed before any of the calls run, so the preview of a
+// call that depends on an earlier one in the batch may be out of date.
+func (a *Agent) batchPermissions(ctx context.Context, toolCalls []message.ToolCall) map[string]permission.Response {
+	if a.permSvc == nil || a.mode == "plan" {
+		return nil
+	}
+	if a.maxToolCalls > 0 && len(toolCalls) > a.maxToolCalls {
+		toolCalls = toolCalls[:a.maxToolCalls]
+	}
+
+	var items []permission.BatchItem
+	var ids []string
+	for _, tc := range toolCalls {
+		tool, ok := a.registry.Get(tc.Name)
+		if !ok || a.disabledTools[tc.Name] || !tool.RequiresPermission() || a.permSvc.IsAllowed(tc.Name) {
+			continue
+		}
+		if d, ok := tool.(tools.DangerChecker); ok && !a.allowDangerous && d.Danger(tc.Input) != "" {
+			continue
+		}
+		var preview string
+		if p, ok := tool.(tools.Previewer); ok {
+			var err error
+			if preview, err = p.Preview(tc.Input); err != nil {
+				// Refused calls are reported when they run, not asked about.
+				continue
+			}
+		}
+		items = append(items, permission.BatchItem{ToolName: tc.Name, Input: string(tc.Input), Preview: preview})
+		ids = append(ids, tc.ID)
+	}
+	if len(items) < 2 {
+		return nil
+	}
+
+	resp := a.permSvc.CheckBatch(ctx, items)
+	if resp == permission.AskEach {
+		return nil
+	}
+	decisions := make(map[string]permission.Response, len(ids))
+	for _, id := range ids {
+		decisions[id] = resp
+	}
+	return decisions
+}
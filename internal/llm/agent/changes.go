@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+// changeDiffMaxBytes is the largest file whose content is kept to diff the
+// turn's changes.
+const changeDiffMaxBytes = 1 << 20
+
+// FileChange summarizes how a file changed over the course of a turn.
+type FileChange struct {
+	Path    string // relative to the working directory when possible
+	Created bool   // the file did not exist before the turn
+	Deleted bool   // the file no longer exists after the turn
+	Lines   int    // net change in line count
+	Diff    string // unified diff over the turn; empty for binary or very large files
+}
+
+// changeTracker records the state of files before and after tool calls that
+// modify them, aggregating per path across a turn.
+type changeTracker struct {
+	workDir string
+	order   []string
+	before  map[string]fileSnapshot
+	after   map[string]fileSnapshot
+}
+
+type fileSnapshot struct {
+	exists   bool
+	lines    int
+	sum      [sha256.Size]byte
+	text     string // content, if diffable
+	diffable bool   // text holds the full content of a text file
+}
+
+func newChangeTracker(workDir string) *changeTracker {
+	return &changeTracker{
+		workDir: workDir,
+		before:  make(map[string]fileSnapshot),
+		after:   make(map[string]fileSnapshot),
+	}
+}
+
+// Before snapshots paths ahead of a tool call. Only the first snapshot of a
+// path in a turn is kept, so the summary spans the whole turn.
+func (c *changeTracker) Before(paths []string) {
+	for _, p := range paths {
+		if _, ok := c.before[p]; ok {
+			continue
+		}
+		c.before[p] = snapshotFile(p)
+		c.order = append(c.order, p)
+	}
+}
+
+// After snapshots paths once a tool call has completed.
+func (c *changeTracker) After(paths []string) {
+	for _, p := range paths {
+		c.after[p] = snapshotFile(p)
+	}
+}
+
+// Changes returns the files that actually changed, in first-touched order.
+func (c *changeTracker) Changes() []FileChange {
+	var changes []FileChange
+	for _, p := range c.order {
+		before, after := c.before[p], c.after[p]
+		if before == after {
+			continue // failed or no-op calls
+		}
+
+		rel, err := filepath.Rel(c.workDir, p)
+		if err != nil {
+			rel = p
+		}
+		change := FileChange{
+			Path:    rel,
+			Created: !before.exists && after.exists,
+			Deleted: before.exists && !after.exists,
+			Lines:   after.lines - before.lines,
+		}
+		if before.diffable && after.diffable {
+			change.Diff = tools.UnifiedDiff(filepath.ToSlash(rel), before.text, after.text)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// snapshotFile returns whether path exists, how many lines it has, and for
+// text files up to changeDiffMaxBytes, its content.
+func snapshotFile(path string) fileSnapshot {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileSnapshot{diffable: true}
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	snap := fileSnapshot{exists: true, lines: lines, sum: sha256.Sum256(data)}
+	if len(data) <= changeDiffMaxBytes && bytes.IndexByte(data, 0) < 0 {
+		snap.text, snap.diffable = string(data), true
+	}
+	return snap
+}
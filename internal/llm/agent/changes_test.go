@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangeTracker(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(path(name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("edited.go", "a\nb\n")
+	write("removed.txt", "x\ny\nz\n")
+	write("same.txt", "same\n")
+
+	c := newChangeTracker(dir)
+	call := func(names []string, change func()) {
+		var paths []string
+		for _, n := range names {
+			paths = append(paths, path(n))
+		}
+		c.Before(paths)
+		change()
+		c.After(paths)
+	}
+
+	call([]string{"edited.go"}, func() { write("edited.go", "a\nB\nc\n") })
+	call([]string{"new.txt"}, func() { write("new.txt", "hello\n") })
+	call([]string{"removed.txt"}, func() { os.Remove(path("removed.txt")) })
+	call([]string{"same.txt"}, func() { write("same.txt", "same\n") })
+	// A second edit in the same turn is diffed against the first snapshot.
+	call([]string{"edited.go"}, func() { write("edited.go", "a\nB\nc\nd\n") })
+
+	changes := c.Changes()
+	if len(changes) != 3 {
+		t.Fatalf("got %d changes, want 3 (the no-op write skipped): %+v", len(changes), changes)
+	}
+
+	edited := changes[0]
+	if edited.Path != "edited.go" || edited.Created || edited.Deleted || edited.Lines != 2 {
+		t.Errorf("edited = %+v", edited)
+	}
+	if !strings.Contains(edited.Diff, "\n-b\n") || !strings.HasSuffix(edited.Diff, "\n+d") {
+		t.Errorf("edited diff spans only part of the turn:\n%s", edited.Diff)
+	}
+	if created := changes[1]; created.Path != "new.txt" || !created.Created || created.Lines != 1 {
+		t.Errorf("created = %+v", created)
+	}
+	if deleted := changes[2]; deleted.Path != "removed.txt" || !deleted.Deleted || deleted.Lines != -3 {
+		t.Errorf("deleted = %+v", deleted)
+	}
+}
+
+func TestSnapshotFileSkipsBinaryDiffs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.bin")
+	if err := os.WriteFile(path, []byte("PNG\x00\x01\x02"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if snap := snapshotFile(path); !snap.exists || snap.diffable || snap.text != "" {
+		t.Errorf("snapshot = %+v, want an existing file without diffable text", snap)
+	}
+}
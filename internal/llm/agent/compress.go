@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/webgovernor/goder/internal/llm/prompt"
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+const (
+	// compressThreshold is the tool output length, in bytes, above which
+	// the output is condensed before it is sent to the model.
+	compressThreshold = 10000
+
+	// compressMaxTokens bounds the output of the condense request.
+	compressMaxTokens = 1024
+
+	// compressTimeout bounds how long the condense request may take. It is
+	// short because the raw output is sent instead if it runs out.
+	compressTimeout = 20 * time.Second
+
+	// compressInputLimit caps the tool output included in the condense
+	// request, so that it fits the context of a small model.
+	compressInputLimit = 200000
+)
+
+// compressToolResult asks the compress model to condense an oversized tool
+// result, and sets the condensed text as the result's ModelOutput; the
+// user still sees the full Output. Errors, short outputs, and condensed
+// outputs that are no shorter are left as they are, as is the result when
+// the request fails or times out. It reports whether the result was
+// condensed, and the tokens the request used.
+func (a *Agent) compressToolResult(ctx context.Context, history []message.Message, tc message.ToolCall, result *message.ToolResult) (bool, provider.Usage) {
+	if !a.compressToolResults || result.IsError || len(result.Output) <= compressThreshold {
+		return false, provider.Usage{}
+	}
+
+	output := tools.StripANSI(result.Output)
+	if len(output) > compressInputLimit {
+		// Cut at a rune boundary so the request stays valid UTF-8.
+		cut := compressInputLimit
+		for cut > 0 && !utf8.RuneStart(output[cut]) {
+			cut--
+		}
+		output = output[:cut] + "\n[... output truncated ...]"
+	}
+	var input strings.Builder
+	if task := lastUserText(history); task != "" {
+		fmt.Fprintf(&input, "The assistant's task:\n\n%s\n\n", task)
+	}
+	fmt.Fprintf(&input, "Tool call: %s %s\n\nOutput:\n\n%s", tc.Name, tc.Input, output)
+
+	ctx, cancel := context.WithTimeout(ctx, compressTimeout)
+	defer cancel()
+	condensed, usage, err := a.completeWithUsage(ctx, provider.Request{
+		SystemPrompt: prompt.CompressToolResultPrompt(),
+		Messages:     []message.Message{message.NewUserMessage("", input.String())},
+		MaxTokens:    compressMaxTokens,
+		Model:        a.compressModel,
+	})
+	condensed = strings.TrimSpace(condensed)
+	if err != nil || condensed == "" || len(condensed) >= len(result.Output) {
+		return false, usage
+	}
+
+	result.ModelOutput = fmt.Sprintf("[Condensed from %d characters of tool output; the user sees it in full. "+
+		"Call the tool again with narrower arguments if you need the exact text.]\n%s", len(result.Output), condensed)
+	return true, usage
+}
+
+// completeWithUsage streams the response to req and returns its text and
+// token usage. Unlike Provider.Complete, it reports the usage, so that
+// auxiliary requests made during a turn count towards it.
+func (a *Agent) completeWithUsage(ctx context.Context, req provider.Request) (string, provider.Usage, error) {
+	ch, err := a.provider.SendMessage(ctx, req)
+	if err != nil {
+		return "", provider.Usage{}, err
+	}
+
+	var text strings.Builder
+	var usage provider.Usage
+	finished := false
+	for event := range ch {
+		switch event.Type {
+		case provider.EventTextDelta:
+			text.WriteString(event.Text)
+		case provider.EventError:
+			err = event.Error
+		case provider.EventDone:
+			usage = event.Usage
+			finished = true
+		}
+	}
+	if err == nil && !finished {
+		err = ctx.Err()
+		if err == nil {
+			err = errors.New("the response stream ended without finishing")
+		}
+	}
+	return text.String(), usage, err
+}
+
+// compressNotice tells the user that a tool result was condensed for the
+// model.
+func compressNotice(result message.ToolResult) string {
+	return fmt.Sprintf("The %s output (%d characters) was condensed to %d characters for the model.",
+		result.Name, len(result.Output), len(result.ModelOutput))
+}
+
+// lastUserText returns the content of the last user message in history, or
+// "" if there is none.
+func lastUserText(history []message.Message) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == message.User && !history[i].Hidden && history[i].Content != "" {
+			return history[i].Content
+		}
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// trimHistory returns the most recent limit messages of history, or all of
+// them if limit is not positive. The cut never separates a tool call from its
+// results: results whose call falls outside the window are dropped too, unless
+// that would leave nothing, in which case the window is widened to include
+// the call. The window is also widened to keep the latest user message, so
+// the model always sees the request it is working on.
+func trimHistory(history []message.Message, limit int) []message.Message {
+	if limit <= 0 || len(history) <= limit {
+		return history
+	}
+
+	start := len(history) - limit
+	for start < len(history) && history[start].IsToolResult() {
+		start++
+	}
+	if start == len(history) {
+		start = len(history) - limit
+		for start > 0 && history[start].IsToolResult() {
+			start--
+		}
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == message.User && !history[i].Hidden {
+			start = min(start, i)
+			break
+		}
+	}
+	return history[start:]
+}
+
+// chainHistory splits history for continuing a stored response: it returns
+// the messages after the latest assistant message, and the ID of the
+// response that produced it. If that response was not stored, or nothing
+// follows it, history is returned whole with an empty ID.
+func chainHistory(history []message.Message) ([]message.Message, string) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != message.Assistant {
+			continue
+		}
+		if history[i].ResponseID == "" || i == len(history)-1 {
+			break
+		}
+		return history[i+1:], history[i].ResponseID
+	}
+	return history, ""
+}
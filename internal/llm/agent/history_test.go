@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+func TestTrimHistory(t *testing.T) {
+	call := message.NewAssistantMessage("s", "", []message.ToolCall{{ID: "c1", Name: "ls"}})
+	result := message.NewToolResultMessage("s", []message.ToolResult{{ToolCallID: "c1", Name: "ls"}})
+	history := []message.Message{
+		message.NewUserMessage("s", "one"),
+		message.NewAssistantMessage("s", "reply", nil),
+		message.NewUserMessage("s", "two"),
+		call,
+		result,
+	}
+
+	tests := []struct {
+		limit     int
+		wantFirst string // ID of the first kept message
+		wantLen   int
+	}{
+		{0, history[0].ID, 5},
+		{10, history[0].ID, 5},
+		{3, history[2].ID, 3},
+		{2, history[2].ID, 3}, // the latest user message is kept
+		{1, history[2].ID, 3},
+	}
+	for _, tt := range tests {
+		got := trimHistory(history, tt.limit)
+		if len(got) != tt.wantLen || got[0].ID != tt.wantFirst {
+			t.Errorf("trimHistory(limit=%d) kept %d messages starting at %s, want %d starting at %s",
+				tt.limit, len(got), got[0].ID, tt.wantLen, tt.wantFirst)
+		}
+	}
+
+	// A window starting on tool results drops them along with their call.
+	history = append(history, message.NewUserMessage("s", "three"))
+	if got := trimHistory(history, 2); len(got) != 1 || got[0].Role != message.User {
+		t.Errorf("expected only the last user message, got %d messages", len(got))
+	}
+
+	// Without a user message, a lone result keeps its call.
+	history = []message.Message{call, result}
+	if got := trimHistory(history, 1); len(got) != 2 || got[0].ID != call.ID {
+		t.Errorf("expected the call and its result, got %d messages", len(got))
+	}
+}
+
+func TestChainHistory(t *testing.T) {
+	first := message.NewAssistantMessage("s", "reply", nil)
+	first.ResponseID = "resp_1"
+	call := message.NewAssistantMessage("s", "", []message.ToolCall{{ID: "c1", Name: "ls"}})
+	call.ResponseID = "resp_2"
+	result := message.NewToolResultMessage("s", []message.ToolResult{{ToolCallID: "c1", Name: "ls"}})
+	history := []message.Message{message.NewUserMessage("s", "one"), first, message.NewUserMessage("s", "two")}
+
+	msgs, id := chainHistory(history)
+	if id != "resp_1" || len(msgs) != 1 || msgs[0].ID != history[2].ID {
+		t.Errorf("after a stored reply: id %q, %d messages", id, len(msgs))
+	}
+
+	msgs, id = chainHistory(append(history, call, result))
+	if id != "resp_2" || len(msgs) != 1 || msgs[0].ID != result.ID {
+		t.Errorf("after a stored tool call: id %q, %d messages", id, len(msgs))
+	}
+
+	// The latest reply was not stored, so the earlier one cannot be used.
+	unstored := message.NewAssistantMessage("s", "reply", nil)
+	msgs, id = chainHistory(append(history, unstored, message.NewUserMessage("s", "three")))
+	if id != "" || len(msgs) != 5 {
+		t.Errorf("after an unstored reply: id %q, %d messages", id, len(msgs))
+	}
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ToolStat aggregates the calls to one tool.
+type ToolStat struct {
+	Name   string
+	Calls  int
+	Errors int
+	Total  time.Duration // time spent executing, excluding permission prompts
+	Max    time.Duration
+}
+
+// Average returns the mean execution time per call.
+func (s ToolStat) Average() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// ToolMetrics records tool call counts and durations across agent runs. It
+// is safe for concurrent use.
+type ToolMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*ToolStat
+}
+
+// NewToolMetrics creates an empty ToolMetrics.
+func NewToolMetrics() *ToolMetrics {
+	return &ToolMetrics{stats: make(map[string]*ToolStat)}
+}
+
+// Record adds one call to the named tool.
+func (m *ToolMetrics) Record(name string, elapsed time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[name]
+	if !ok {
+		s = &ToolStat{Name: name}
+		m.stats[name] = s
+	}
+	s.Calls++
+	if failed {
+		s.Errors++
+	}
+	s.Total += elapsed
+	s.Max = max(s.Max, elapsed)
+}
+
+// Snapshot returns the stats for each tool used, by total time descending.
+func (m *ToolMetrics) Snapshot() []ToolStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]ToolStat, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
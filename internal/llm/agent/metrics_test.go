@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToolMetrics(t *testing.T) {
+	m := NewToolMetrics()
+	m.Record("view", 10*time.Millisecond, false)
+	m.Record("grep", 300*time.Millisecond, false)
+	m.Record("grep", 100*time.Millisecond, true)
+
+	stats := m.Snapshot()
+	if len(stats) != 2 || stats[0].Name != "grep" || stats[1].Name != "view" {
+		t.Fatalf("snapshot = %+v, want grep then view", stats)
+	}
+	grep := stats[0]
+	if grep.Calls != 2 || grep.Errors != 1 || grep.Max != 300*time.Millisecond || grep.Average() != 200*time.Millisecond {
+		t.Errorf("grep stats = %+v, average %s", grep, grep.Average())
+	}
+}
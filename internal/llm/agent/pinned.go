@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/webgovernor/goder/internal/llm/prompt"
+)
+
+// MaxPinnedBytes is the most of a pinned file included in each request.
+const MaxPinnedBytes = 64 << 10
+
+// readPinnedFiles reads the current contents of the pinned files at paths.
+// Files that have gone missing or turned binary are listed with a note
+// instead, so the model knows the pin is stale.
+func readPinnedFiles(workDir string, paths []string) []prompt.PinnedFile {
+	files := make([]prompt.PinnedFile, 0, len(paths))
+	for _, path := range paths {
+		f := prompt.PinnedFile{Path: path}
+		if rel, err := filepath.Rel(workDir, path); err == nil && filepath.IsLocal(rel) {
+			f.Path = rel
+		}
+
+		data, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			f.Note = fmt.Sprintf("(could not be read: %s)", err)
+		case bytes.IndexByte(data, 0) >= 0:
+			f.Note = "(binary file; contents not shown)"
+		case len(data) > MaxPinnedBytes:
+			f.Content = string(data[:MaxPinnedBytes])
+			f.Note = fmt.Sprintf("(only the first %d KB of %d KB is shown; view the file for the rest)", MaxPinnedBytes>>10, (len(data)+1023)>>10)
+		default:
+			f.Content = string(data)
+		}
+		files = append(files, f)
+	}
+	return files
+}
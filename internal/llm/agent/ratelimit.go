@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
+)
+
+// maxThrottleDelay caps how long the agent waits for a rate limit to reset,
+// so a provider reporting a long reset cannot stall a turn indefinitely.
+const maxThrottleDelay = time.Minute
+
+// throttleDelay returns how long to wait before the next request so that it
+// is not refused for exceeding the rate limits in status. nextTokens is
+// roughly how many tokens the request will use; the last request's input
+// is a fair lower bound, since the conversation only grows during a turn.
+func throttleDelay(status *provider.RateLimitStatus, nextTokens int) time.Duration {
+	if status == nil {
+		return 0
+	}
+	var d time.Duration
+	if status.RemainingRequests == 0 {
+		d = max(d, status.ResetRequests)
+	}
+	if status.RemainingTokens >= 0 && status.RemainingTokens < nextTokens {
+		d = max(d, status.ResetTokens)
+	}
+	return min(d, maxThrottleDelay)
+}
+
+// throttleNotice tells the user the agent is waiting out a rate limit.
+func throttleNotice(d time.Duration) string {
+	return fmt.Sprintf("Nearly at the provider's rate limit; waiting %s before the next request.", d.Round(time.Second))
+}
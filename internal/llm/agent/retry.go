@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+// toolRetryBackoff is the wait before the first retry of a failed tool
+// call; it doubles with each further retry.
+var toolRetryBackoff = time.Second
+
+// runTool executes tc, running it again up to the tool's configured number
+// of retries while it fails in a way its tool deems transient (see
+// tools.Retrier). Each attempt is recorded in the metrics, and each retry
+// is announced with a notice. It returns the last attempt's output and
+// error.
+func (a *Agent) runTool(ctx context.Context, tc message.ToolCall, tool tools.Tool, events chan<- Event) (string, error) {
+	retrier, _ := tool.(tools.Retrier)
+	retries := a.toolRetries[tc.Name]
+	backoff := toolRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		output, err := tool.Execute(ctx, tc.Input)
+		if a.metrics != nil {
+			a.metrics.Record(tc.Name, time.Since(start), err != nil)
+		}
+		if attempt >= retries || retrier == nil || ctx.Err() != nil || !retrier.Retryable(tc.Input, output, err) {
+			return output, err
+		}
+
+		events <- Event{Type: EventNotice, Text: retryNotice(tc.Name, err, backoff, attempt+1, retries)}
+		sleep(ctx, backoff)
+		if ctx.Err() != nil {
+			return output, err
+		}
+		backoff *= 2
+	}
+}
+
+// retryNotice tells the user that a tool call failed and is being retried.
+func retryNotice(name string, err error, wait time.Duration, retry, retries int) string {
+	reason := "failed"
+	if err != nil {
+		reason = fmt.Sprintf("failed (%s)", err)
+	}
+	return fmt.Sprintf("%s %s; retrying in %s (retry %d of %d).", name, reason, wait, retry, retries)
+}
@@ -14,28 +14,129 @@ import (
 var promptFS embed.FS
 
 // corePrompt returns the prompt text for the given model, falling back to default.md
-// if no model-specific prompt file exists.
-func corePrompt(model string) string {
+// if no model-specific prompt file exists. A non-empty name replaces the
+// assistant's name in the prompt's introduction.
+func corePrompt(model, name string) string {
+	text := ""
 	if model != "" {
-		data, err := promptFS.ReadFile("prompts/" + model + ".md")
-		if err == nil {
-			return string(data)
+		if data, err := promptFS.ReadFile("prompts/" + model + ".md"); err == nil {
+			text = string(data)
 		}
 	}
+	if text == "" {
+		data, err := promptFS.ReadFile("prompts/default.md")
+		if err != nil {
+			// This should never happen since default.md is embedded at compile time.
+			panic("prompt: embedded default.md not found: " + err.Error())
+		}
+		text = string(data)
+	}
+	if name != "" {
+		text = strings.Replace(text, "You are goder,", "You are "+name+",", 1)
+	}
+	return text
+}
+
+// TitlePrompt returns the instructions used to generate a short session title
+// from the opening exchange of a conversation.
+func TitlePrompt() string {
+	return "You generate titles for coding assistant conversations. " +
+		"Reply with a single title of 3 to 6 words that summarizes the conversation. " +
+		"Do not use quotes, trailing punctuation, or any other text."
+}
+
+// SummaryPrompt returns the instructions used to produce a running summary
+// of a session, which is later injected into the system prompt.
+func SummaryPrompt() string {
+	return "You maintain the long-term memory of a coding assistant conversation. " +
+		"Write a concise summary of the conversation so far, merging in any previous summary provided. " +
+		"Capture the user's goals, decisions made, files and functions involved, changes already applied, " +
+		"and any open questions or next steps. Use short bullet points and omit pleasantries."
+}
+
+// CompressToolResultPrompt returns the instructions used to condense an
+// oversized tool result before it is sent to the model.
+func CompressToolResultPrompt() string {
+	return "You condense the output of a tool called by a coding assistant, which only sees your condensed version. " +
+		"Keep everything relevant to the assistant's task verbatim: file paths, line numbers, code, error messages, and counts. " +
+		"Drop repetitive, boilerplate, or unrelated content, noting briefly what was left out. " +
+		"Reply with the condensed output only, without commentary."
+}
+
+// ModePrompts holds user-configured instructions for each mode.
+type ModePrompts struct {
+	Plan  string
+	Build string
+
+	// Replace makes a non-empty mode prompt replace the built-in
+	// instructions for that mode rather than being appended to them.
+	Replace bool
+}
+
+// PinnedFile is a file the user pinned to the context, with its contents as
+// of the current request.
+type PinnedFile struct {
+	Path    string // relative to the working directory where possible
+	Content string
+	Note    string // why the content is missing or incomplete, if it is
+}
+
+// PinnedFilesSection renders pinned files for the end of the system prompt,
+// or returns "" if there are none.
+func PinnedFilesSection(files []PinnedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n# Pinned Files\n\n")
+	sb.WriteString("The user pinned these files to the context. Their current contents are shown below and refreshed before every request, ")
+	sb.WriteString("so there is no need to view them again unless you need line numbers.\n")
+	for _, f := range files {
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n", f.Path))
+		if f.Note != "" {
+			sb.WriteString(f.Note + "\n\n")
+		}
+		if f.Content == "" {
+			continue
+		}
+		fence := codeFence(f.Content)
+		sb.WriteString(fence + "\n" + strings.TrimSuffix(f.Content, "\n") + "\n" + fence + "\n")
+	}
+	return sb.String()
+}
+
+// SessionInstructionsSection renders the instructions the user set for a
+// session with /system for the end of the system prompt, or returns "" if
+// there are none.
+func SessionInstructionsSection(instructions string) string {
+	if instructions == "" {
+		return ""
+	}
+	return "\n# Session Instructions\n\nThe user set these instructions for this session. Follow them in addition to your other instructions:\n\n" + instructions + "\n"
+}
 
-	data, err := promptFS.ReadFile("prompts/default.md")
-	if err != nil {
-		// This should never happen since default.md is embedded at compile time.
-		panic("prompt: embedded default.md not found: " + err.Error())
+// codeFence returns a backtick fence longer than any run of backticks in
+// content.
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
 	}
-	return string(data)
+	return strings.Repeat("`", max(3, longest+1))
 }
 
 // BuildSystemPrompt assembles the full system prompt for the coding agent.
-func BuildSystemPrompt(mode string, model string, workDir string, registry *tools.Registry) string {
+// name is the assistant's name, empty for the default; summary is the
+// stored session summary, if any.
+func BuildSystemPrompt(mode string, model string, name string, workDir string, summary string, modePrompts ModePrompts, registry *tools.Registry) string {
 	var sb strings.Builder
 
-	sb.WriteString(corePrompt(model))
+	sb.WriteString(corePrompt(model, name))
 	sb.WriteString("\n\n")
 
 	// Environment info
@@ -50,24 +151,49 @@ func BuildSystemPrompt(mode string, model string, workDir string, registry *tool
 	sb.WriteString("When using tools, default to operating within this directory. ")
 	sb.WriteString("Use relative paths when referring to files in the project.\n\n")
 
+	// Long-term session memory
+	if summary != "" {
+		sb.WriteString("# Session Summary\n\n")
+		sb.WriteString("The following summarizes earlier parts of this conversation. ")
+		sb.WriteString("Treat it as context the user and you already share.\n\n")
+		sb.WriteString(strings.TrimSpace(summary))
+		sb.WriteString("\n\n")
+	}
+
 	// Mode-specific instructions
+	custom := strings.TrimSpace(modePrompts.Build)
 	if mode == "plan" {
+		custom = strings.TrimSpace(modePrompts.Plan)
+	}
+	switch {
+	case custom != "" && modePrompts.Replace:
+		sb.WriteString(fmt.Sprintf("# Mode: %s\n\n", strings.ToUpper(mode)))
+		// Write tools are filtered out in plan mode regardless, so keep
+		// telling the model not to expect them.
+		if mode == "plan" {
+			sb.WriteString("You are in PLAN mode. Tools that modify files (write, edit, move, delete) are not available.\n\n")
+		}
+	case mode == "plan":
 		sb.WriteString("# Mode: PLAN\n\n")
 		sb.WriteString("You are in PLAN mode. You should analyze and reason about the codebase but NOT make any modifications.\n")
-		sb.WriteString("- Do NOT use tools that modify files (write, edit). These tools are not available in this mode.\n")
+		sb.WriteString("- Do NOT use tools that modify files (write, edit, move, delete). These tools are not available in this mode.\n")
 		sb.WriteString("- You MUST use the read-only tools (glob, grep, view, ls) to explore the codebase BEFORE answering any question about it. Do not rely on general knowledge alone.\n")
 		sb.WriteString("- Your responses MUST reference specific files, functions, types, and patterns found in this codebase. Never give generic advice when project-specific guidance is possible.\n")
 		sb.WriteString("- When the user asks how to do something, find existing examples in the codebase first, then base your plan on those concrete patterns.\n")
 		sb.WriteString("- Good planning requires investigation. Before forming a plan, search for relevant files, read their contents, and understand the existing code structure.\n")
 		sb.WriteString("- When the user asks about changes, explore the codebase first, then explain what changes you would make and where, referencing specific file paths and line numbers.\n")
-		sb.WriteString("- If the user wants to execute changes, remind them to switch to BUILD mode (ctrl+t).\n\n")
-	} else {
+		sb.WriteString("- If the user wants to execute changes, remind them to switch to BUILD mode (ctrl+t), or to run /execute to have you implement your latest plan.\n\n")
+	default:
 		sb.WriteString("# Mode: BUILD\n\n")
 		sb.WriteString("You are in BUILD mode. You can create, edit, and delete files and run commands.\n")
 		sb.WriteString("- Use the available tools to implement changes.\n")
 		sb.WriteString("- Be careful with destructive operations.\n")
 		sb.WriteString("- Verify your changes compile/work when possible.\n\n")
 	}
+	if custom != "" {
+		sb.WriteString(custom)
+		sb.WriteString("\n\n")
+	}
 
 	// Available tools
 	sb.WriteString("# Available Tools\n\n")
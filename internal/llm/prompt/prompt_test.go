@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+func TestCorePromptName(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+	}{
+		{"", "You are goder,"},
+		{config.DefaultAssistantName, "You are goder,"},
+		{"Ada", "You are Ada,"},
+	} {
+		got := corePrompt("", tc.name)
+		if !strings.HasPrefix(got, tc.want) {
+			t.Errorf("corePrompt(name %q) starts %q, want %q", tc.name, got[:min(len(got), 30)], tc.want)
+		}
+		if tc.name == "Ada" && strings.Contains(got, "You are goder") {
+			t.Error("corePrompt kept the default name alongside the replacement")
+		}
+	}
+
+	// Unknown models fall back to the default prompt.
+	if corePrompt("no-such-model", "") != corePrompt("", "") {
+		t.Error("an unknown model did not fall back to default.md")
+	}
+}
+
+func TestBuildSystemPromptName(t *testing.T) {
+	got := BuildSystemPrompt("build", "gpt-4o", "Ada", t.TempDir(), "", ModePrompts{}, tools.NewRegistry())
+	if !strings.HasPrefix(got, "You are Ada,") {
+		t.Errorf("system prompt starts %q, want the configured name", got[:min(len(got), 30)])
+	}
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Kinds of provider error, for matching with errors.Is. The errors returned
+// by providers are classified as one of these where possible, so callers can
+// react to the cause without parsing messages.
+var (
+	// ErrAuth is an authentication or authorization failure, such as a
+	// missing or invalid API key.
+	ErrAuth = errors.New("authentication failed")
+
+	// ErrRateLimited is a request refused for exceeding the provider's rate
+	// limits. Retrying later can succeed.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrContextLength is a request too long for the model's context window.
+	ErrContextLength = errors.New("context length exceeded")
+
+	// ErrNetwork is a failure to reach the provider or to read its response,
+	// including timeouts.
+	ErrNetwork = errors.New("network error")
+
+	// ErrPreviousResponse is a request refused because the stored response
+	// it continues from (Request.PreviousResponseID) is unknown, such as
+	// one that expired, was deleted, or belongs to another account.
+	ErrPreviousResponse = errors.New("previous response not found")
+)
+
+// errorKindForCode returns the kind of error an API error code indicates, or
+// nil if it is not one of the classified kinds.
+func errorKindForCode(code string) error {
+	switch code {
+	case "invalid_api_key", "invalid_organization":
+		return ErrAuth
+	case "rate_limit_exceeded":
+		return ErrRateLimited
+	case "context_length_exceeded":
+		return ErrContextLength
+	case "previous_response_not_found":
+		return ErrPreviousResponse
+	}
+	return nil
+}
+
+// APIError is returned when a provider responds with a non-success HTTP status.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // how long the provider asked to wait before retrying, if it said
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (HTTP %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Is classifies e by its status and error code. An exhausted quota is also
+// reported as HTTP 429 but is not a rate limit, since waiting won't help.
+func (e *APIError) Is(target error) bool {
+	var parsed apiErrorBody
+	_ = json.Unmarshal([]byte(e.Body), &parsed)
+	if kind := errorKindForCode(parsed.Error.Code); kind != nil {
+		return target == kind
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrAuth
+	case http.StatusBadRequest, http.StatusNotFound:
+		return target == ErrPreviousResponse && parsed.Error.Param == "previous_response_id"
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited && parsed.Error.Code != "insufficient_quota"
+	}
+	return false
+}
+
+// ResponseError is returned when a request is accepted but the response
+// fails, as reported in the response body or stream.
+type ResponseError struct {
+	Provider string
+	Code     string
+	Message  string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s API error (%s): %s", e.Provider, e.Code, e.Message)
+}
+
+// Is classifies e by its error code.
+func (e *ResponseError) Is(target error) bool {
+	kind := errorKindForCode(e.Code)
+	return kind != nil && target == kind
+}
+
+// NetworkError wraps a failure to reach a provider or read its response. It
+// matches ErrNetwork.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string { return e.Err.Error() }
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+func (e *NetworkError) Is(target error) bool { return target == ErrNetwork }
+
+// networkError wraps err, an error from sending a request, as a
+// *NetworkError unless it is the request being cancelled.
+func networkError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &NetworkError{Err: err}
+}
+
+// ModelError is returned when a provider rejects a request because the
+// requested model does not exist, is not available to the account, or is
+// not supported by the API in use.
+type ModelError struct {
+	Model string
+	Err   *APIError
+}
+
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("model %q is not available: %s", e.Model, e.Err.Error())
+}
+
+func (e *ModelError) Unwrap() error {
+	return e.Err
+}
+
+// apiErrorBody is the error envelope returned by OpenAI-compatible APIs.
+type apiErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+		Param   string `json:"param"`
+	} `json:"error"`
+}
+
+// newAPIError builds the error for a non-success response to a request for
+// model, classifying model rejections as a *ModelError.
+func newAPIError(providerName, model string, status int, header http.Header, body []byte) error {
+	apiErr := &APIError{
+		Provider:   providerName,
+		StatusCode: status,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(header, time.Now()),
+	}
+	if isModelRejection(status, body) {
+		return &ModelError{Model: model, Err: apiErr}
+	}
+	return apiErr
+}
+
+// isModelRejection reports whether an error response says the requested
+// model cannot be used, by its error code or, failing that, its message.
+func isModelRejection(status int, body []byte) bool {
+	if status != http.StatusBadRequest && status != http.StatusNotFound {
+		return false
+	}
+	var parsed apiErrorBody
+	if json.Unmarshal(body, &parsed) != nil {
+		return false
+	}
+	switch parsed.Error.Code {
+	case "model_not_found", "unsupported_model":
+		return true
+	}
+	msg := strings.ToLower(parsed.Error.Message)
+	return parsed.Error.Param == "model" ||
+		(strings.Contains(msg, "model") &&
+			(strings.Contains(msg, "does not exist") || strings.Contains(msg, "not supported")))
+}
+
+// IsAuthError reports whether err is an authentication or authorization
+// failure, which retrying against the same credentials cannot fix.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrAuth)
+}
+
+// IsKeyRejected reports whether err is the provider rejecting the API key
+// itself: HTTP 401 or an invalid_api_key code. Unlike IsAuthError, it does
+// not match HTTP 403, which refuses a valid key access to something.
+func IsKeyRejected(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		var parsed apiErrorBody
+		_ = json.Unmarshal([]byte(apiErr.Body), &parsed)
+		return apiErr.StatusCode == http.StatusUnauthorized || parsed.Error.Code == "invalid_api_key"
+	}
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.Code == "invalid_api_key"
+}
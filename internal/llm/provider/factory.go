@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// Names returns the supported provider names.
+func Names() []string {
+	return []string{"openai"}
+}
+
+// New constructs the named provider.
+func New(name, apiKey, model string, client *http.Client, timeouts Timeouts) (Provider, error) {
+	switch name {
+	case "openai":
+		return NewOpenAIProvider(apiKey, model, client, timeouts), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %q (supported: openai)", name)
+	}
+}
+
+// apiKeyPrefixes are the prefixes the API keys of each provider start with.
+var apiKeyPrefixes = map[string]string{"openai": "sk-"}
+
+// CheckAPIKey reports what looks wrong with key as an API key for the named
+// provider, such as whitespace inside it or the wrong prefix, or nil if it
+// looks right. It only checks the key's form; the provider may still reject
+// it.
+func CheckAPIKey(name, key string) error {
+	if key == "" {
+		return errors.New("the key is empty")
+	}
+	if strings.ContainsFunc(key, unicode.IsSpace) {
+		return errors.New("the key contains whitespace")
+	}
+	if prefix := apiKeyPrefixes[name]; prefix != "" && !strings.HasPrefix(key, prefix) {
+		return fmt.Errorf("%s keys start with %q", name, prefix)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fallback is a provider in a FallbackProvider chain.
+type Fallback struct {
+	Provider Provider
+
+	// Label identifies the provider to the user, e.g. "openai (gpt-4o-mini)".
+	Label string
+}
+
+// FallbackProvider wraps a primary provider and a prioritized list of
+// fallbacks. A request that fails on one provider before producing any
+// output is retried on the next. Authentication errors are not retried,
+// since they indicate a configuration problem rather than an outage.
+//
+// Model and API key changes apply to the primary provider only.
+type FallbackProvider struct {
+	primary   Provider
+	fallbacks []Fallback
+}
+
+// NewFallbackProvider creates a provider that fails over from primary to
+// each of fallbacks in order.
+func NewFallbackProvider(primary Provider, fallbacks ...Fallback) *FallbackProvider {
+	return &FallbackProvider{primary: primary, fallbacks: fallbacks}
+}
+
+func (f *FallbackProvider) Name() string { return f.primary.Name() }
+
+func (f *FallbackProvider) ListModels(ctx context.Context) ([]string, error) {
+	return f.primary.ListModels(ctx)
+}
+
+func (f *FallbackProvider) SetAPIKey(apiKey string) { f.primary.SetAPIKey(apiKey) }
+
+func (f *FallbackProvider) SetModel(model string) { f.primary.SetModel(model) }
+
+// BaseURL returns the primary provider's base URL, whose models ListModels
+// returns.
+func (f *FallbackProvider) BaseURL() string {
+	if b, ok := f.primary.(baseURLer); ok {
+		return b.BaseURL()
+	}
+	return ""
+}
+
+// chain returns the providers in the order they should be tried.
+func (f *FallbackProvider) chain() []Fallback {
+	return append([]Fallback{{Provider: f.primary, Label: f.primary.Name()}}, f.fallbacks...)
+}
+
+// shouldFailOver reports whether err on one provider warrants trying the next.
+func shouldFailOver(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !IsAuthError(err)
+}
+
+// SendMessage sends req to the first provider that starts streaming without
+// an error. When a fallback serves the request, an EventFallback naming it is
+// sent before its first event.
+func (f *FallbackProvider) SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	chain := f.chain()
+
+	var errs []error
+	for i, fb := range chain {
+		last := i == len(chain)-1
+
+		events, err := fb.Provider.SendMessage(ctx, req)
+		if err != nil {
+			if i == 0 && (last || !shouldFailOver(ctx, err)) {
+				return nil, err
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", fb.Label, err))
+			if last || !shouldFailOver(ctx, err) {
+				return nil, errors.Join(errs...)
+			}
+			continue
+		}
+
+		// Wait for the first event: a stream that errors before producing
+		// anything can still be retried elsewhere.
+		var first StreamEvent
+		var ok bool
+		select {
+		case first, ok = <-events:
+		case <-ctx.Done():
+			go drain(events)
+			return nil, ctx.Err()
+		}
+		if ok && first.Type == EventError && !last && shouldFailOver(ctx, first.Error) {
+			errs = append(errs, fmt.Errorf("%s: %w", fb.Label, first.Error))
+			go drain(events)
+			continue
+		}
+
+		out := make(chan StreamEvent, 64)
+		go func() {
+			defer close(out)
+			defer drain(events)
+
+			if i > 0 {
+				notice := StreamEvent{
+					Type: EventFallback,
+					Text: fmt.Sprintf("Response served by fallback provider %s after: %s", fb.Label, describeErrors(errs)),
+				}
+				if !send(ctx, out, notice) {
+					return
+				}
+			}
+			if !ok {
+				return
+			}
+			if !send(ctx, out, first) {
+				return
+			}
+			for ev := range events {
+				if !send(ctx, out, ev) {
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// Complete runs req on the first provider that succeeds.
+func (f *FallbackProvider) Complete(ctx context.Context, req Request) (string, error) {
+	chain := f.chain()
+
+	var errs []error
+	for i, fb := range chain {
+		text, err := fb.Provider.Complete(ctx, req)
+		if err == nil {
+			return text, nil
+		}
+		stop := i == len(chain)-1 || !shouldFailOver(ctx, err)
+		if i == 0 && stop {
+			return "", err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", fb.Label, err))
+		if stop {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+// describeErrors formats the failures of earlier providers on one line.
+func describeErrors(errs []error) string {
+	parts := make([]string, len(errs))
+	for i, err := range errs {
+		parts[i] = err.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// send delivers ev on out unless ctx is cancelled first.
+func send(ctx context.Context, out chan<- StreamEvent, ev StreamEvent) bool {
+	select {
+	case out <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// drain discards the remaining events of an abandoned stream so its producer
+// can finish and release the connection.
+func drain(events <-chan StreamEvent) {
+	for range events {
+	}
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// fakeProvider returns a canned error or stream.
+type fakeProvider struct {
+	name    string
+	sendErr error
+	events  []StreamEvent
+	calls   int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	f.calls++
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	ch := make(chan StreamEvent, len(f.events))
+	for _, ev := range f.events {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeProvider) Complete(ctx context.Context, req Request) (string, error) {
+	f.calls++
+	return f.name, f.sendErr
+}
+
+func (f *fakeProvider) ListModels(ctx context.Context) ([]string, error) { return nil, nil }
+func (f *fakeProvider) SetAPIKey(string)                                 {}
+func (f *fakeProvider) SetModel(string)                                  {}
+
+func collect(t *testing.T, events <-chan StreamEvent) []StreamEvent {
+	t.Helper()
+	var out []StreamEvent
+	for ev := range events {
+		out = append(out, ev)
+	}
+	return out
+}
+
+func TestFallbackProviderFailsOver(t *testing.T) {
+	ok := []StreamEvent{{Type: EventTextDelta, Text: "hi"}, {Type: EventDone}}
+
+	tests := []struct {
+		name    string
+		primary *fakeProvider
+	}{
+		{"request error", &fakeProvider{name: "a", sendErr: &APIError{Provider: "A", StatusCode: http.StatusServiceUnavailable}}},
+		{"stream error before output", &fakeProvider{name: "a", events: []StreamEvent{{Type: EventError, Error: errors.New("overloaded")}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &fakeProvider{name: "b", events: ok}
+			f := NewFallbackProvider(tt.primary, Fallback{Provider: backup, Label: "b (model)"})
+
+			events, err := f.SendMessage(context.Background(), Request{})
+			if err != nil {
+				t.Fatalf("SendMessage: %v", err)
+			}
+			got := collect(t, events)
+			if len(got) != 3 || got[0].Type != EventFallback || got[1].Text != "hi" {
+				t.Fatalf("unexpected events: %+v", got)
+			}
+		})
+	}
+}
+
+func TestFallbackProviderPrimaryServes(t *testing.T) {
+	primary := &fakeProvider{name: "a", events: []StreamEvent{{Type: EventTextDelta, Text: "hi"}, {Type: EventDone}}}
+	backup := &fakeProvider{name: "b"}
+	f := NewFallbackProvider(primary, Fallback{Provider: backup, Label: "b"})
+
+	events, err := f.SendMessage(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if got := collect(t, events); len(got) != 2 || got[0].Type != EventTextDelta {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+	if backup.calls != 0 {
+		t.Errorf("fallback called %d times, want 0", backup.calls)
+	}
+}
+
+func TestFallbackProviderSkipsAuthErrors(t *testing.T) {
+	authErr := &APIError{Provider: "A", StatusCode: http.StatusUnauthorized}
+	primary := &fakeProvider{name: "a", sendErr: authErr}
+	backup := &fakeProvider{name: "b"}
+	f := NewFallbackProvider(primary, Fallback{Provider: backup, Label: "b"})
+
+	if _, err := f.SendMessage(context.Background(), Request{}); !errors.Is(err, authErr) {
+		t.Fatalf("SendMessage error = %v, want the auth error", err)
+	}
+	if _, err := f.Complete(context.Background(), Request{}); !errors.Is(err, authErr) {
+		t.Fatalf("Complete error = %v, want the auth error", err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("fallback called %d times, want 0", backup.calls)
+	}
+}
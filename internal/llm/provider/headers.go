@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimitLowRatio is the share of a rate limit that may remain before it
+// counts as nearly used up.
+const rateLimitLowRatio = 0.1
+
+// RateLimitStatus is what a provider reported about its rate limits in the
+// headers of a response. Counts are -1 when not reported.
+type RateLimitStatus struct {
+	RemainingRequests int
+	LimitRequests     int
+	ResetRequests     time.Duration // until the request limit is replenished
+	RemainingTokens   int
+	LimitTokens       int
+	ResetTokens       time.Duration // until the token limit is replenished
+}
+
+// parseRateLimitHeaders reads the x-ratelimit-* headers OpenAI-compatible APIs
+// send with each response. It returns nil if there are none.
+func parseRateLimitHeaders(h http.Header) *RateLimitStatus {
+	s := &RateLimitStatus{
+		RemainingRequests: headerInt(h, "X-Ratelimit-Remaining-Requests"),
+		LimitRequests:     headerInt(h, "X-Ratelimit-Limit-Requests"),
+		ResetRequests:     headerDuration(h, "X-Ratelimit-Reset-Requests"),
+		RemainingTokens:   headerInt(h, "X-Ratelimit-Remaining-Tokens"),
+		LimitTokens:       headerInt(h, "X-Ratelimit-Limit-Tokens"),
+		ResetTokens:       headerDuration(h, "X-Ratelimit-Reset-Tokens"),
+	}
+	if s.RemainingRequests < 0 && s.RemainingTokens < 0 {
+		return nil
+	}
+	return s
+}
+
+// headerInt returns the integer value of header key, or -1.
+func headerInt(h http.Header, key string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// headerDuration returns the value of header key, a duration such as "1s" or
+// "6m0s", or zero if it is missing or malformed.
+func headerDuration(h http.Header, key string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(h.Get(key)))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// RequestsLow reports whether few of the requests allowed in the current
+// window remain.
+func (s *RateLimitStatus) RequestsLow() bool {
+	return isLow(s.RemainingRequests, s.LimitRequests)
+}
+
+// TokensLow reports whether few of the tokens allowed in the current window
+// remain.
+func (s *RateLimitStatus) TokensLow() bool {
+	return isLow(s.RemainingTokens, s.LimitTokens)
+}
+
+// Low reports whether either limit is nearly used up.
+func (s *RateLimitStatus) Low() bool {
+	return s != nil && (s.RequestsLow() || s.TokensLow())
+}
+
+func isLow(remaining, limit int) bool {
+	if remaining < 0 {
+		return false
+	}
+	if limit <= 0 {
+		return remaining == 0
+	}
+	return float64(remaining) < rateLimitLowRatio*float64(limit)
+}
+
+// parseRetryAfter reads how long to wait before retrying from a
+// retry-after-ms header or a Retry-After header, given either as a number of
+// seconds or as an HTTP date. It returns zero if neither is present and
+// valid, or if the time has already passed.
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(h.Get("Retry-After-Ms")), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
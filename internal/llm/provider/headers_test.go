@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	if s := parseRateLimitHeaders(http.Header{}); s != nil {
+		t.Errorf("no headers parsed as %+v, want nil", s)
+	}
+
+	h := http.Header{}
+	h.Set("x-ratelimit-limit-requests", "500")
+	h.Set("x-ratelimit-remaining-requests", "499")
+	h.Set("x-ratelimit-reset-requests", "120ms")
+	h.Set("x-ratelimit-limit-tokens", "30000")
+	h.Set("x-ratelimit-remaining-tokens", "2000")
+	h.Set("x-ratelimit-reset-tokens", "6m0s")
+	s := parseRateLimitHeaders(h)
+	want := RateLimitStatus{
+		RemainingRequests: 499, LimitRequests: 500, ResetRequests: 120 * time.Millisecond,
+		RemainingTokens: 2000, LimitTokens: 30000, ResetTokens: 6 * time.Minute,
+	}
+	if s == nil || *s != want {
+		t.Fatalf("parsed %+v, want %+v", s, want)
+	}
+	if s.RequestsLow() || !s.TokensLow() || !s.Low() {
+		t.Errorf("RequestsLow = %v, TokensLow = %v, Low = %v; want only tokens low", s.RequestsLow(), s.TokensLow(), s.Low())
+	}
+
+	// Without a limit to compare against, only running out counts as low.
+	h = http.Header{}
+	h.Set("x-ratelimit-remaining-requests", "3")
+	if s := parseRateLimitHeaders(h); s.Low() || s.LimitTokens != -1 {
+		t.Errorf("parsed %+v, want not low with the token limit unknown", s)
+	}
+	h.Set("x-ratelimit-remaining-requests", "0")
+	if s := parseRateLimitHeaders(h); !s.Low() {
+		t.Errorf("no requests left is not low")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header, value string
+		want          time.Duration
+	}{
+		{"Retry-After", "20", 20 * time.Second},
+		{"Retry-After", "1.5", 1500 * time.Millisecond},
+		{"Retry-After", now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{"Retry-After", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"Retry-After", "soon", 0},
+		{"Retry-After-Ms", "250", 250 * time.Millisecond},
+		{"X-Other", "20", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set(tt.header, tt.value)
+		if got := parseRetryAfter(h, now); got != tt.want {
+			t.Errorf("%s: %s = %v, want %v", tt.header, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSendMessageRateLimits(t *testing.T) {
+	limited := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`)
+			return
+		}
+		w.Header().Set("x-ratelimit-limit-requests", "100")
+		w.Header().Set("x-ratelimit-remaining-requests", "2")
+		w.Header().Set("x-ratelimit-reset-requests", "30s")
+		fmt.Fprint(w, "data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"status\":\"completed\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-4o", srv.Client(), Timeouts{})
+	p.baseURL = srv.URL
+
+	events, err := p.SendMessage(context.Background(), Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done StreamEvent
+	for ev := range events {
+		done = ev
+	}
+	if done.Type != EventDone || done.RateLimit == nil || done.RateLimit.RemainingRequests != 2 || !done.RateLimit.Low() {
+		t.Fatalf("last event = %+v, want EventDone with the rate limits", done)
+	}
+
+	limited = true
+	_, err = p.SendMessage(context.Background(), Request{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("err = %v, want an APIError with RetryAfter 7s", err)
+	}
+}
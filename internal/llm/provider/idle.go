@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeoutReader wraps a streaming response body and aborts the stream if
+// no bytes arrive within the timeout. The abort function should cancel the
+// underlying request so that a blocked Read returns promptly.
+type idleTimeoutReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// newIdleTimeoutReader starts the idle timer immediately.
+func newIdleTimeoutReader(r io.Reader, timeout time.Duration, abort func()) *idleTimeoutReader {
+	ir := &idleTimeoutReader{r: r, timeout: timeout}
+	ir.timer = time.AfterFunc(timeout, func() {
+		ir.expired.Store(true)
+		abort()
+	})
+	return ir
+}
+
+func (ir *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if ir.expired.Load() {
+		return n, fmt.Errorf("no data received for %s", ir.timeout)
+	}
+	if n > 0 {
+		ir.timer.Reset(ir.timeout)
+	}
+	return n, err
+}
+
+// Stop releases the idle timer.
+func (ir *idleTimeoutReader) Stop() {
+	ir.timer.Stop()
+}
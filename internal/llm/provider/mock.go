@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// Mock is a Provider for tests that replays scripted responses instead of
+// calling an API. Each SendMessage or Complete call consumes the next entry
+// of Responses, and every request is recorded for inspection.
+type Mock struct {
+	// Responses are the replies to successive requests, in order.
+	Responses []MockResponse
+
+	// Models is returned by ListModels.
+	Models []string
+
+	mu       sync.Mutex
+	requests []Request
+	model    string
+	apiKey   string
+}
+
+// MockResponse is one scripted reply. If Err is set, the request fails with
+// it; otherwise Events are streamed in order.
+type MockResponse struct {
+	Events []StreamEvent
+	Err    error
+}
+
+// NewMock creates a Mock that replies to successive requests with responses.
+func NewMock(responses ...MockResponse) *Mock {
+	return &Mock{Responses: responses}
+}
+
+// TextResponse returns a reply that streams text and finishes.
+func TextResponse(text string) MockResponse {
+	return MockResponse{Events: []StreamEvent{
+		{Type: EventTextDelta, Text: text},
+		{Type: EventDone, Status: "completed"},
+	}}
+}
+
+// ToolCallResponse returns a reply that requests calls and finishes. Each
+// call's input is streamed as a single delta.
+func ToolCallResponse(calls ...message.ToolCall) MockResponse {
+	var events []StreamEvent
+	for _, tc := range calls {
+		events = append(events,
+			StreamEvent{Type: EventToolCallStart, ToolCallID: tc.ID, ToolCallName: tc.Name},
+			StreamEvent{Type: EventToolCallDelta, ToolCallID: tc.ID, ToolCallInput: string(tc.Input)},
+			StreamEvent{Type: EventToolCallEnd, ToolCallID: tc.ID},
+		)
+	}
+	return MockResponse{Events: append(events, StreamEvent{Type: EventDone, Status: "completed"})}
+}
+
+// StreamErrorResponse returns a reply whose stream fails with err.
+func StreamErrorResponse(err error) MockResponse {
+	return MockResponse{Events: []StreamEvent{{Type: EventError, Error: err}}}
+}
+
+func (m *Mock) Name() string { return "mock" }
+
+// SendMessage streams the next scripted response.
+func (m *Mock) SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	resp, err := m.next(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		for _, ev := range resp.Events {
+			if !send(ctx, ch, ev) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Complete returns the text of the next scripted response.
+func (m *Mock) Complete(ctx context.Context, req Request) (string, error) {
+	resp, err := m.next(req)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, ev := range resp.Events {
+		switch ev.Type {
+		case EventTextDelta:
+			text.WriteString(ev.Text)
+		case EventError:
+			return "", ev.Error
+		}
+	}
+	return text.String(), nil
+}
+
+// next records req and returns the response scripted for it.
+func (m *Mock) next(req Request) (MockResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req)
+	n := len(m.requests)
+	if n > len(m.Responses) {
+		return MockResponse{}, fmt.Errorf("mock: no response scripted for request %d", n)
+	}
+	resp := m.Responses[n-1]
+	return resp, resp.Err
+}
+
+// Requests returns the requests received so far, in order.
+func (m *Mock) Requests() []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Request(nil), m.requests...)
+}
+
+func (m *Mock) ListModels(ctx context.Context) ([]string, error) { return m.Models, nil }
+
+func (m *Mock) SetAPIKey(apiKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKey = apiKey
+}
+
+func (m *Mock) SetModel(model string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.model = model
+}
+
+// Model returns the model last set with SetModel.
+func (m *Mock) Model() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.model
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ModelCache keeps the model lists fetched with ListModels, so that showing
+// them again does not need another request. Lists are keyed by
+// ModelCacheKey. With a path, they are also saved to that file and reused
+// by later runs until they are older than the TTL.
+type ModelCache struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	entries map[string]modelCacheEntry
+	loaded  bool
+}
+
+// modelCacheEntry is a cached model list.
+type modelCacheEntry struct {
+	Models    []string  `json:"models"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// NewModelCache creates a model cache. If path is empty the cache is kept in
+// memory only, and its lists do not expire; otherwise lists are saved to
+// path and expire ttl after they were fetched.
+func NewModelCache(path string, ttl time.Duration) *ModelCache {
+	return &ModelCache{path: path, ttl: ttl, entries: make(map[string]modelCacheEntry)}
+}
+
+// baseURLer is implemented by providers that send requests to a
+// configurable API endpoint.
+type baseURLer interface {
+	BaseURL() string
+}
+
+// ModelCacheKey identifies the model list of p: its name and, if it has one,
+// the base URL of its API, since another endpoint may offer other models.
+func ModelCacheKey(p Provider) string {
+	if b, ok := p.(baseURLer); ok && b.BaseURL() != "" {
+		return p.Name() + " " + b.BaseURL()
+	}
+	return p.Name()
+}
+
+// Get returns the cached model list for key, if there is one that has not
+// expired.
+func (c *ModelCache) Get(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	entry, ok := c.entries[key]
+	if !ok || c.expired(entry) {
+		return nil, false
+	}
+	return slices.Clone(entry.Models), true
+}
+
+// Put caches models as the model list for key, saving the cache to disk if
+// it has a path.
+func (c *ModelCache) Put(key string, models []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	c.entries[key] = modelCacheEntry{Models: slices.Clone(models), FetchedAt: time.Now()}
+	return c.save()
+}
+
+// Forget drops the cached model list for key, such as after the API key
+// changes and the account may offer other models.
+func (c *ModelCache) Forget(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	if _, ok := c.entries[key]; !ok {
+		return nil
+	}
+	delete(c.entries, key)
+	return c.save()
+}
+
+// expired reports whether entry is too old to use.
+func (c *ModelCache) expired(entry modelCacheEntry) bool {
+	return c.path != "" && time.Since(entry.FetchedAt) > c.ttl
+}
+
+// load reads the cache file the first time the cache is used. A missing or
+// unreadable file leaves the cache empty; it is rewritten by the next Put.
+func (c *ModelCache) load() {
+	if c.loaded || c.path == "" {
+		return
+	}
+	c.loaded = true
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var entries map[string]modelCacheEntry
+	if json.Unmarshal(data, &entries) != nil {
+		return
+	}
+	for key, entry := range entries {
+		if !c.expired(entry) {
+			c.entries[key] = entry
+		}
+	}
+}
+
+// save writes the cache file, if the cache has one.
+func (c *ModelCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("saving model cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("saving model cache: %w", err)
+	}
+	return nil
+}
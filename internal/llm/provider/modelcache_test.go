@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestModelCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	cache := NewModelCache(path, time.Hour)
+	if _, ok := cache.Get("openai"); ok {
+		t.Fatal("empty cache returned a list")
+	}
+	if err := cache.Put("openai", []string{"gpt-5", "o3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run reads the list back from disk.
+	reloaded := NewModelCache(path, time.Hour)
+	if got, ok := reloaded.Get("openai"); !ok || !slices.Equal(got, []string{"gpt-5", "o3"}) {
+		t.Fatalf("reloaded list = %v, %v", got, ok)
+	}
+	if _, ok := reloaded.Get("openai https://proxy.example.com/v1"); ok {
+		t.Error("list shared across base URLs")
+	}
+
+	if err := reloaded.Forget("openai"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := NewModelCache(path, time.Hour).Get("openai"); ok {
+		t.Error("forgotten list still saved")
+	}
+}
+
+func TestModelCacheExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	old := map[string]modelCacheEntry{"openai": {Models: []string{"gpt-4"}, FetchedAt: time.Now().Add(-2 * time.Hour)}}
+	data, _ := json.Marshal(old)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := NewModelCache(path, time.Hour).Get("openai"); ok {
+		t.Error("expired list returned")
+	}
+	if _, ok := NewModelCache(path, 3*time.Hour).Get("openai"); !ok {
+		t.Error("list within the TTL not returned")
+	}
+
+	// Lists cached in memory only last for the run.
+	memory := NewModelCache("", 0)
+	if err := memory.Put("openai", []string{"gpt-5"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := memory.Get("openai"); !ok {
+		t.Error("in-memory list expired")
+	}
+}
+
+func TestModelCacheKey(t *testing.T) {
+	p := NewOpenAIProvider("key", "gpt-5", nil, Timeouts{})
+	want := "openai https://api.openai.com/v1"
+	if got := ModelCacheKey(p); got != want {
+		t.Errorf("key = %q, want %q", got, want)
+	}
+	if got := ModelCacheKey(NewRateLimitedProvider(NewFallbackProvider(p), 60)); got != want {
+		t.Errorf("key through wrappers = %q, want %q", got, want)
+	}
+	if got := ModelCacheKey(NewMock()); got != "mock" {
+		t.Errorf("mock key = %q", got)
+	}
+}
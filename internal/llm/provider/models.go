@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelInfo describes a model's limits and capabilities.
+type ModelInfo struct {
+	// ContextWindow is the most tokens of input and output together.
+	ContextWindow int `json:"contextWindow"`
+
+	// MaxOutput is the most output tokens in a single response, including
+	// hidden reasoning.
+	MaxOutput int `json:"maxOutput"`
+
+	// Tools, Vision, and Reasoning report whether the model accepts
+	// function tools, accepts images as input, and spends output tokens on
+	// hidden reasoning (and accepts the reasoning parameter).
+	Tools     bool `json:"tools"`
+	Vision    bool `json:"vision"`
+	Reasoning bool `json:"reasoning"`
+}
+
+// defaultOutputTokens caps the output limit used when none is configured,
+// so a model's full output allowance is not requested by default.
+const defaultOutputTokens = 16384
+
+// fallbackOutputTokens is the output limit used when none is configured and
+// nothing is known about the model.
+const fallbackOutputTokens = 4096
+
+// compactionRatio is the largest share of the context window a request's
+// input may use before the conversation should be compacted.
+const compactionRatio = 0.8
+
+// knownModels maps model ID prefixes to what the models can do. The longest
+// matching prefix wins, so "o1-mini" overrides "o1".
+var knownModels = map[string]ModelInfo{
+	"gpt-5":         {ContextWindow: 400000, MaxOutput: 128000, Tools: true, Vision: true, Reasoning: true},
+	"gpt-5-chat":    {ContextWindow: 128000, MaxOutput: 16384, Tools: true, Vision: true},
+	"gpt-4.1":       {ContextWindow: 1047576, MaxOutput: 32768, Tools: true, Vision: true},
+	"gpt-4.5":       {ContextWindow: 128000, MaxOutput: 16384, Tools: true, Vision: true},
+	"gpt-4o":        {ContextWindow: 128000, MaxOutput: 16384, Tools: true, Vision: true},
+	"chatgpt-4o":    {ContextWindow: 128000, MaxOutput: 16384, Vision: true},
+	"gpt-4-turbo":   {ContextWindow: 128000, MaxOutput: 4096, Tools: true, Vision: true},
+	"gpt-4":         {ContextWindow: 8192, MaxOutput: 8192, Tools: true},
+	"gpt-3.5-turbo": {ContextWindow: 16385, MaxOutput: 4096, Tools: true},
+	"o1":            {ContextWindow: 200000, MaxOutput: 100000, Tools: true, Vision: true, Reasoning: true},
+	"o1-mini":       {ContextWindow: 128000, MaxOutput: 65536, Reasoning: true},
+	"o1-preview":    {ContextWindow: 128000, MaxOutput: 32768, Reasoning: true},
+	"o3":            {ContextWindow: 200000, MaxOutput: 100000, Tools: true, Vision: true, Reasoning: true},
+	"o3-mini":       {ContextWindow: 200000, MaxOutput: 100000, Tools: true, Reasoning: true},
+	"o4":            {ContextWindow: 200000, MaxOutput: 100000, Tools: true, Vision: true, Reasoning: true},
+}
+
+var (
+	customModelsMu sync.RWMutex
+	customModels   map[string]ModelInfo
+)
+
+// SetCustomModels adds models to the table LookupModel uses, keyed by ID
+// prefix like the built-in entries, which they take precedence over. It
+// replaces any custom models set before.
+func SetCustomModels(models map[string]ModelInfo) {
+	customModelsMu.Lock()
+	defer customModelsMu.Unlock()
+	customModels = make(map[string]ModelInfo, len(models))
+	for prefix, info := range models {
+		customModels[strings.ToLower(prefix)] = info
+	}
+}
+
+// LookupModel returns what is known about model from the entry with the
+// longest prefix of its ID, preferring custom entries, and whether there is
+// one.
+func LookupModel(model string) (ModelInfo, bool) {
+	id := strings.ToLower(model)
+
+	customModelsMu.RLock()
+	defer customModelsMu.RUnlock()
+	if info, ok := longestPrefixMatch(customModels, id); ok {
+		return info, true
+	}
+	return longestPrefixMatch(knownModels, id)
+}
+
+// longestPrefixMatch returns the entry of table with the longest key that
+// id starts with.
+func longestPrefixMatch(table map[string]ModelInfo, id string) (ModelInfo, bool) {
+	var best string
+	var found bool
+	for prefix := range table {
+		if strings.HasPrefix(id, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	return table[best], found
+}
+
+// OutputTokens returns the output limit to request from model: maxTokens if
+// set, or a default from the model's maximum, and in either case no more
+// than the model's maximum if it is known.
+func OutputTokens(model string, maxTokens int) int {
+	info, ok := LookupModel(model)
+	if maxTokens <= 0 {
+		maxTokens = fallbackOutputTokens
+		if ok && info.MaxOutput > 0 {
+			maxTokens = min(info.MaxOutput, defaultOutputTokens)
+		}
+	}
+	if ok && info.MaxOutput > 0 {
+		maxTokens = min(maxTokens, info.MaxOutput)
+	}
+	return maxTokens
+}
+
+// ResponseTokens returns the output limit to request from model for a
+// response: OutputTokens of maxTokens, with reasoningReserve added for
+// reasoning models, whose hidden reasoning counts against the limit.
+func ResponseTokens(model string, maxTokens, reasoningReserve int) int {
+	tokens := OutputTokens(model, maxTokens)
+	if isReasoningModel(model) {
+		tokens = OutputTokens(model, tokens+max(reasoningReserve, 0))
+	}
+	return tokens
+}
+
+// CompactionThreshold returns the input tokens above which a request to
+// model should be compacted: compactionRatio of its context window, or less
+// if the rest of the window cannot hold the response (ResponseTokens). It
+// returns 0 if the context window is not known.
+func CompactionThreshold(model string, maxTokens, reasoningReserve int) int {
+	info, ok := LookupModel(model)
+	if !ok || info.ContextWindow <= 0 {
+		return 0
+	}
+	threshold := int(compactionRatio * float64(info.ContextWindow))
+	if reserve := ResponseTokens(model, maxTokens, reasoningReserve); reserve < info.ContextWindow {
+		threshold = min(threshold, info.ContextWindow-reserve)
+	}
+	return threshold
+}
+
+// SupportsTools reports whether model accepts function tools. Unknown
+// models are assumed to.
+func SupportsTools(model string) bool {
+	info, ok := LookupModel(model)
+	return !ok || info.Tools
+}
+
+// isReasoningModel reports whether the model spends output tokens on hidden
+// reasoning and accepts the reasoning parameter.
+func isReasoningModel(id string) bool {
+	info, _ := LookupModel(id)
+	return info.Reasoning
+}
+
+// SupportsImageInput reports whether the model accepts images as input.
+func SupportsImageInput(model string) bool {
+	info, _ := LookupModel(model)
+	return info.Vision
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		model  string
+		ok     bool
+		window int
+		tools  bool
+		vision bool
+	}{
+		{"gpt-4o-2024-08-06", true, 128000, true, true},
+		{"gpt-4.1-mini", true, 1047576, true, true},
+		{"gpt-4", true, 8192, true, false},
+		{"o1-mini", true, 128000, false, false},
+		{"O3", true, 200000, true, true},
+		{"llama-3", false, 0, false, false},
+	}
+	for _, tt := range tests {
+		info, ok := LookupModel(tt.model)
+		if ok != tt.ok || info.ContextWindow != tt.window || info.Tools != tt.tools || info.Vision != tt.vision {
+			t.Errorf("LookupModel(%q) = %+v, %v", tt.model, info, ok)
+		}
+	}
+
+	SetCustomModels(map[string]ModelInfo{
+		"llama":  {ContextWindow: 8192, MaxOutput: 2048, Tools: true},
+		"gpt-4o": {ContextWindow: 64000, MaxOutput: 1000},
+	})
+	defer SetCustomModels(nil)
+	if info, ok := LookupModel("llama-3"); !ok || info.ContextWindow != 8192 {
+		t.Errorf("custom model = %+v, %v", info, ok)
+	}
+	if info, _ := LookupModel("gpt-4o-mini"); info.ContextWindow != 64000 || SupportsTools("gpt-4o-mini") {
+		t.Errorf("custom entry did not replace the built-in one: %+v", info)
+	}
+}
+
+func TestOutputTokens(t *testing.T) {
+	tests := []struct {
+		model     string
+		maxTokens int
+		want      int
+	}{
+		{"gpt-4o", 0, 16384},
+		{"gpt-4-turbo", 0, 4096},
+		{"gpt-4-turbo", 10000, 4096},
+		{"gpt-5", 50000, 50000},
+		{"unknown", 0, 4096},
+		{"unknown", 50000, 50000},
+	}
+	for _, tt := range tests {
+		if got := OutputTokens(tt.model, tt.maxTokens); got != tt.want {
+			t.Errorf("OutputTokens(%q, %d) = %d, want %d", tt.model, tt.maxTokens, got, tt.want)
+		}
+	}
+}
+
+func TestCompactionThreshold(t *testing.T) {
+	tests := []struct {
+		model                       string
+		maxTokens, reasoningReserve int
+		want                        int
+	}{
+		{"gpt-4o", 0, 16384, 102400},       // 80% of the window
+		{"gpt-3.5-turbo", 0, 16384, 12289}, // the window less a 4096-token response
+		{"gpt-4", 0, 0, 6553},              // the response could fill the window
+		{"o3", 60000, 16384, 123616},       // reasoning adds to the response
+		{"unknown", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := CompactionThreshold(tt.model, tt.maxTokens, tt.reasoningReserve); got != tt.want {
+			t.Errorf("CompactionThreshold(%q, %d, %d) = %d, want %d", tt.model, tt.maxTokens, tt.reasoningReserve, got, tt.want)
+		}
+	}
+}
+
+func TestNewResponsesRequestOmitsUnsupportedTools(t *testing.T) {
+	req := Request{Tools: []ToolDefinition{{Name: "view", Parameters: []byte(`{}`)}}}
+	for model, want := range map[string]int{"gpt-4o": 1, "o1-mini": 0} {
+		p := NewOpenAIProvider("test-key", model, nil, Timeouts{})
+		httpReq, err := p.newResponsesRequest(context.Background(), req, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body respRequest
+		if err := json.NewDecoder(httpReq.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Tools) != want {
+			t.Errorf("%s: %d tools sent, want %d", model, len(body.Tools), want)
+		}
+	}
+}
@@ -4,30 +4,42 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/tools"
 )
 
 // OpenAIProvider implements the Provider interface for OpenAI's API
 // using the Responses API (POST /v1/responses).
 type OpenAIProvider struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey   string
+	model    string
+	baseURL  string
+	client   *http.Client
+	timeouts Timeouts
 }
 
-// NewOpenAIProvider creates a new OpenAI provider.
-func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+// NewOpenAIProvider creates a new OpenAI provider. If client is nil,
+// http.DefaultClient is used.
+func NewOpenAIProvider(apiKey, model string, client *http.Client, timeouts Timeouts) *OpenAIProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
 	return &OpenAIProvider{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: "https://api.openai.com/v1",
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  "https://api.openai.com/v1",
+		client:   client,
+		timeouts: timeouts,
 	}
 }
 
@@ -39,6 +51,9 @@ func (p *OpenAIProvider) SetAPIKey(apiKey string) { p.apiKey = apiKey }
 // SetModel updates the provider's model at runtime.
 func (p *OpenAIProvider) SetModel(model string) { p.model = model }
 
+// BaseURL returns the URL the API paths are relative to.
+func (p *OpenAIProvider) BaseURL() string { return p.baseURL }
+
 // oaiModelsResponse is the response from GET /v1/models.
 type oaiModelsResponse struct {
 	Data []oaiModelEntry `json:"data"`
@@ -55,22 +70,24 @@ var supportedModelPrefixes = []string{"gpt-", "o1", "o3", "o4", "chatgpt-"}
 // ListModels fetches available models from the OpenAI API and returns
 // only text-generation-capable model IDs, sorted alphabetically.
 func (p *OpenAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := p.withRequestTimeout(ctx)
+	defer cancel()
+
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/models", nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
+	resp, err := p.client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("fetching models: %w", err)
+		return nil, fmt.Errorf("fetching models: %w", networkError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error (HTTP %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, &APIError{Provider: "OpenAI", StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var modelsResp oaiModelsResponse
@@ -131,7 +148,15 @@ type respRequest struct {
 	Tools           []respTool      `json:"tools,omitempty"`
 	Stream          bool            `json:"stream"`
 	MaxOutputTokens int             `json:"max_output_tokens,omitempty"`
+	Reasoning       *respReasoning  `json:"reasoning,omitempty"`
 	Store           bool            `json:"store"`
+
+	PreviousResponseID string `json:"previous_response_id,omitempty"`
+}
+
+// respReasoning configures reasoning models.
+type respReasoning struct {
+	Effort string `json:"effort,omitempty"`
 }
 
 // respStreamEvent is the generic SSE event from the Responses API.
@@ -157,12 +182,23 @@ type respOutputItem struct {
 	Name      string `json:"name,omitempty"`
 	CallID    string `json:"call_id,omitempty"`
 	Arguments string `json:"arguments,omitempty"`
+
+	// For message items
+	Content []respContentPart `json:"content,omitempty"`
+}
+
+// respContentPart is a content part of a message output item.
+type respContentPart struct {
+	Type string `json:"type"` // "output_text", "refusal"
+	Text string `json:"text,omitempty"`
 }
 
-// respResponseBody is the full response object (used in response.completed).
+// respResponseBody is the full response object (used in response.completed
+// and as the body of non-streaming responses).
 type respResponseBody struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+	ID     string           `json:"id"`
+	Status string           `json:"status"`
+	Output []respOutputItem `json:"output,omitempty"`
 	Usage  *struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
@@ -172,37 +208,181 @@ type respResponseBody struct {
 		Message string `json:"message"`
 		Code    string `json:"code"`
 	} `json:"error,omitempty"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"` // "max_output_tokens", "content_filter"
+	} `json:"incomplete_details,omitempty"`
 }
 
 // SendMessage sends a streaming request to OpenAI's Responses API and returns events on a channel.
 func (p *OpenAIProvider) SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	// reqCtx outlives this call: it is cancelled when the stream ends, when
+	// the request timeout fires before headers arrive, or when the stream
+	// goes idle. Events are still emitted against the caller's ctx.
+	reqCtx, cancel := context.WithCancel(ctx)
+
+	httpReq, err := p.newResponsesRequest(reqCtx, req, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The request timeout only covers getting response headers; the stream
+	// itself is bounded by the idle timeout below.
+	var timer *time.Timer
+	if p.timeouts.Request > 0 {
+		timer = time.AfterFunc(p.timeouts.Request, cancel)
+	}
+	resp, err := p.client.Do(httpReq)
+	if timer != nil && !timer.Stop() && ctx.Err() == nil {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("sending request: %w", &NetworkError{Err: fmt.Errorf("timed out after %s", p.timeouts.Request)})
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("sending request: %w", networkError(err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("OpenAI", p.model, resp.StatusCode, resp.Header, bodyBytes)
+	}
+
+	events := make(chan StreamEvent, 64)
+
+	go func() {
+		defer close(events)
+		defer cancel()
+		defer resp.Body.Close()
+
+		var body io.Reader = resp.Body
+		if p.timeouts.StreamIdle > 0 {
+			idle := newIdleTimeoutReader(resp.Body, p.timeouts.StreamIdle, cancel)
+			defer idle.Stop()
+			body = idle
+		}
+
+		progress := newStreamProgress()
+		progress.rateLimit = parseRateLimitHeaders(resp.Header)
+		err := p.processStream(ctx, body, events, progress)
+		if err != nil && req.Store && progress.responseID != "" && ctx.Err() == nil {
+			// The response is stored, so it keeps going server-side and can
+			// be collected once it finishes.
+			if p.resumeStream(ctx, progress, events) == nil {
+				return
+			}
+		}
+		switch {
+		case errors.Is(err, errStreamEnded):
+			// Without a terminal event, take what arrived as the response.
+			send(ctx, events, StreamEvent{Type: EventDone, RateLimit: progress.rateLimit})
+		case err != nil:
+			send(ctx, events, StreamEvent{Type: EventError, Error: err})
+		}
+	}()
+
+	return events, nil
+}
+
+// Complete sends a non-streaming request to OpenAI's Responses API and
+// returns the concatenated output text.
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (string, error) {
+	ctx, cancel := p.withRequestTimeout(ctx)
+	defer cancel()
+
+	httpReq, err := p.newResponsesRequest(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", networkError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", newAPIError("OpenAI", p.requestModel(req), resp.StatusCode, resp.Header, bodyBytes)
+	}
+
+	var respBody respResponseBody
+	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	if respBody.Error != nil {
+		return "", &ResponseError{Provider: "OpenAI", Code: respBody.Error.Code, Message: respBody.Error.Message}
+	}
+
+	var text strings.Builder
+	for _, item := range respBody.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				text.WriteString(part.Text)
+			}
+		}
+	}
+	return text.String(), nil
+}
+
+// withRequestTimeout bounds ctx by the configured request timeout, if any.
+func (p *OpenAIProvider) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeouts.Request <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.timeouts.Request)
+}
+
+// newResponsesRequest builds the HTTP request for POST /v1/responses.
+func (p *OpenAIProvider) newResponsesRequest(ctx context.Context, req Request, stream bool) (*http.Request, error) {
+	model := p.requestModel(req)
+
 	// Build the input array
 	input := p.buildInput(req)
 
-	// Build the tools array in Responses API format (flat)
+	// Build the tools array in Responses API format (flat). Models that
+	// cannot call functions reject requests with tools, so they get none.
 	var tools []respTool
-	for _, t := range req.Tools {
-		tools = append(tools, respTool{
-			Type:        "function",
-			Name:        t.Name,
-			Description: t.Description,
-			Parameters:  t.Parameters,
-		})
+	if SupportsTools(model) {
+		for _, t := range req.Tools {
+			tools = append(tools, respTool{
+				Type:        "function",
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			})
+		}
 	}
 
-	maxTokens := req.MaxTokens
-	if maxTokens <= 0 {
-		maxTokens = 4096
+	// Reasoning tokens count against max_output_tokens, so reasoning models
+	// get extra room to think on top of the answer's budget, up to what the
+	// model allows.
+	maxTokens := ResponseTokens(model, req.MaxTokens, req.ReasoningReserve)
+	var reasoning *respReasoning
+	if isReasoningModel(model) {
+		if req.ReasoningEffort != "" {
+			reasoning = &respReasoning{Effort: req.ReasoningEffort}
+		}
 	}
 
 	respReq := respRequest{
-		Model:           p.model,
+		Model:           model,
 		Instructions:    req.SystemPrompt,
 		Input:           input,
 		Tools:           tools,
-		Stream:          true,
+		Stream:          stream,
 		MaxOutputTokens: maxTokens,
-		Store:           false,
+		Reasoning:       reasoning,
+		Store:           req.Store,
+
+		PreviousResponseID: req.PreviousResponseID,
 	}
 
 	body, err := json.Marshal(respReq)
@@ -218,28 +398,62 @@ func (p *OpenAIProvider) SendMessage(ctx context.Context, req Request) (<-chan S
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(httpReq)
+	return httpReq, nil
+}
+
+// missingToolOutput stands in for the result of a stored tool call whose
+// result was never recorded.
+const missingToolOutput = "Error: this tool call was interrupted and no result was recorded."
+
+// formatToolOutput renders a tool result for the model in format, one of
+// the ToolResult format constants: the output as is, or a JSON object of
+// the output and whether it is an error.
+func formatToolOutput(output string, isError bool, format string) string {
+	if format != ToolResultJSON {
+		return output
+	}
+	data, err := json.Marshal(struct {
+		Output  string `json:"output"`
+		IsError bool   `json:"is_error"`
+	}{output, isError})
 	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
+		return output
 	}
+	return string(data)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error (HTTP %d): %s", resp.StatusCode, string(bodyBytes))
+// userContent returns the content of a user message: its text, or with
+// attachments, a list of content parts. Images are only sent to models that
+// accept them; other models are told an image was left out.
+func (p *OpenAIProvider) userContent(msg message.Message) any {
+	if len(msg.Attachments) == 0 {
+		return msg.Content
 	}
 
-	events := make(chan StreamEvent, 64)
-
-	go func() {
-		defer close(events)
-		defer resp.Body.Close()
-
-		p.processStream(ctx, resp.Body, events)
-	}()
+	parts := []map[string]string{{"type": "input_text", "text": msg.Content}}
+	for _, a := range msg.Attachments {
+		if !SupportsImageInput(p.model) {
+			parts = append(parts, map[string]string{
+				"type": "input_text",
+				"text": fmt.Sprintf("[Attached image %s omitted: model %s does not accept images.]", a.Name, p.model),
+			})
+			continue
+		}
+		parts = append(parts, map[string]string{
+			"type":      "input_image",
+			"image_url": "data:" + a.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(a.Data),
+		})
+	}
+	return parts
+}
 
-	return events, nil
+// requestModel returns the model req is sent to: its own, if it names one,
+// or the provider's.
+func (p *OpenAIProvider) requestModel(req Request) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return p.model
 }
 
 // buildInput converts our message format to the Responses API input format.
@@ -249,12 +463,27 @@ func (p *OpenAIProvider) buildInput(req Request) []respInputItem {
 	// Note: SystemPrompt is handled via the top-level "instructions" field,
 	// so we don't add it as an input item.
 
+	// The API rejects a function call without an output and vice versa.
+	// Either can be left in a stored session if goder exits between
+	// persisting a tool call and its results, so pair them up first. When
+	// continuing a stored response, the calls being answered are part of it.
+	calls := make(map[string]bool)
+	outputs := make(map[string]bool)
+	for _, msg := range req.Messages {
+		for _, tc := range msg.ToolCalls {
+			calls[tc.ID] = true
+		}
+		for _, tr := range msg.ToolResults {
+			outputs[tr.ToolCallID] = true
+		}
+	}
+
 	for _, msg := range req.Messages {
 		switch msg.Role {
 		case message.User:
 			items = append(items, respInputItem{
 				"role":    "user",
-				"content": msg.Content,
+				"content": p.userContent(msg),
 			})
 
 		case message.Assistant:
@@ -274,14 +503,27 @@ func (p *OpenAIProvider) buildInput(req Request) []respInputItem {
 					"arguments": string(tc.Input),
 				})
 			}
+			for _, tc := range msg.ToolCalls {
+				if !outputs[tc.ID] {
+					items = append(items, respInputItem{
+						"type":    "function_call_output",
+						"call_id": tc.ID,
+						"output":  formatToolOutput(missingToolOutput, true, req.ToolResultFormat),
+					})
+				}
+			}
 
 		case message.Tool:
-			// Tool results - one item per result
+			// Tool results - one item per result. Color codes kept for
+			// display are only noise to the model.
 			for _, tr := range msg.ToolResults {
+				if !calls[tr.ToolCallID] && req.PreviousResponseID == "" {
+					continue
+				}
 				items = append(items, respInputItem{
 					"type":    "function_call_output",
 					"call_id": tr.ToolCallID,
-					"output":  tr.Output,
+					"output":  formatToolOutput(tools.StripANSI(tr.ForModel()), tr.IsError, req.ToolResultFormat),
 				})
 			}
 
@@ -297,8 +539,14 @@ func (p *OpenAIProvider) buildInput(req Request) []respInputItem {
 	return items
 }
 
-// processStream reads the SSE stream from the Responses API and emits events.
-func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, events chan<- StreamEvent) {
+// errStreamEnded reports a stream that ended without a terminal event.
+var errStreamEnded = errors.New("stream ended before the response completed")
+
+// processStream reads the SSE stream from the Responses API and emits events,
+// recording them in progress. If the stream breaks off before the response
+// finishes, it returns the read error or errStreamEnded without emitting a
+// terminal event.
+func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, events chan<- StreamEvent, progress *streamProgress) error {
 	// Track function calls being built up across events
 	type funcCallState struct {
 		id        string
@@ -308,14 +556,24 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 	}
 	funcCalls := make(map[string]*funcCallState) // keyed by item_id
 
+	// emit delivers an event unless the context is cancelled first, so this
+	// goroutine never blocks on a consumer that has stopped reading.
+	emit := func(ev StreamEvent) bool {
+		if ev.Type == EventDone {
+			ev.RateLimit = progress.rateLimit
+		}
+		progress.record(ev)
+		return send(ctx, events, ev)
+	}
+
 	scanner := bufio.NewScanner(body)
 	// Increase buffer for large responses
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
 	for scanner.Scan() {
 		if ctx.Err() != nil {
-			events <- StreamEvent{Type: EventError, Error: ctx.Err()}
-			return
+			emit(StreamEvent{Type: EventError, Error: ctx.Err()})
+			return nil
 		}
 
 		line := scanner.Text()
@@ -330,12 +588,12 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 			continue
 		}
 
-		// Parse SSE data lines
-		if !strings.HasPrefix(line, "data: ") {
+		// Parse SSE data lines; the space after the colon is optional.
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
 			continue
 		}
-
-		data := strings.TrimPrefix(line, "data: ")
+		data = strings.TrimPrefix(data, " ")
 
 		var evt respStreamEvent
 		if err := json.Unmarshal([]byte(data), &evt); err != nil {
@@ -344,12 +602,20 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 
 		switch evt.Type {
 
+		case "response.created":
+			var respBody respResponseBody
+			if err := json.Unmarshal(evt.Response, &respBody); err == nil {
+				progress.responseID = respBody.ID
+			}
+
 		// --- Text output events ---
 		case "response.output_text.delta":
 			if evt.Delta != "" {
-				events <- StreamEvent{
+				if !emit(StreamEvent{
 					Type: EventTextDelta,
 					Text: evt.Delta,
+				}) {
+					return nil
 				}
 			}
 
@@ -361,19 +627,23 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 				continue
 			}
 			if item.Type == "function_call" {
-				state := &funcCallState{
-					id:   item.CallID,
-					name: item.Name,
+				// Keep any arguments that arrived before the item did.
+				state, ok := funcCalls[item.ID]
+				if !ok {
+					state = &funcCallState{}
+					funcCalls[item.ID] = state
 				}
-				funcCalls[item.ID] = state
+				state.id, state.name = item.CallID, item.Name
 
 				// Emit start event if we have enough info
 				if state.id != "" && state.name != "" {
 					state.started = true
-					events <- StreamEvent{
+					if !emit(StreamEvent{
 						Type:         EventToolCallStart,
 						ToolCallID:   state.id,
 						ToolCallName: state.name,
+					}) {
+						return nil
 					}
 				}
 			}
@@ -388,11 +658,18 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 				}
 
 				state.arguments.WriteString(evt.Delta)
-				events <- StreamEvent{
+				// Arguments that arrive before the call has started are only
+				// buffered; they still reach the end event.
+				if !state.started {
+					continue
+				}
+				if !emit(StreamEvent{
 					Type:          EventToolCallDelta,
 					ToolCallID:    state.id,
 					ToolCallName:  state.name,
 					ToolCallInput: evt.Delta,
+				}) {
+					return nil
 				}
 			}
 
@@ -405,45 +682,49 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 					// Some implementations send the full args in the done event
 					finalArgs = evt.Delta
 				}
-				events <- StreamEvent{
+				if !emit(StreamEvent{
 					Type:          EventToolCallEnd,
 					ToolCallID:    state.id,
 					ToolCallName:  state.name,
 					ToolCallInput: finalArgs,
+				}) {
+					return nil
 				}
 				delete(funcCalls, evt.ItemID)
 			}
 
 		case "response.output_item.done":
 			// An output item is complete. If it's a function call that wasn't
-			// finalized via arguments.done, handle it here.
+			// finalized via arguments.done, handle it here. The item is
+			// complete, so a call whose earlier events were missed is built
+			// from it alone.
 			var item respOutputItem
 			if err := json.Unmarshal(evt.Item, &item); err != nil {
 				continue
 			}
-			if item.Type == "function_call" {
-				// Check if we already emitted ToolCallEnd
-				if state, ok := funcCalls[item.ID]; ok {
-					finalArgs := state.arguments.String()
-					if item.Arguments != "" {
-						finalArgs = item.Arguments
-					}
-					if !state.started {
-						// Emit start if we haven't yet
-						events <- StreamEvent{
-							Type:         EventToolCallStart,
-							ToolCallID:   item.CallID,
-							ToolCallName: item.Name,
-						}
-					}
-					events <- StreamEvent{
-						Type:          EventToolCallEnd,
-						ToolCallID:    item.CallID,
-						ToolCallName:  item.Name,
-						ToolCallInput: finalArgs,
+			if item.Type == "function_call" && !progress.endedCalls[item.CallID] {
+				finalArgs := item.Arguments
+				if state, ok := funcCalls[item.ID]; ok && finalArgs == "" {
+					finalArgs = state.arguments.String()
+				}
+				if !progress.startedCalls[item.CallID] {
+					if !emit(StreamEvent{
+						Type:         EventToolCallStart,
+						ToolCallID:   item.CallID,
+						ToolCallName: item.Name,
+					}) {
+						return nil
 					}
-					delete(funcCalls, item.ID)
 				}
+				if !emit(StreamEvent{
+					Type:          EventToolCallEnd,
+					ToolCallID:    item.CallID,
+					ToolCallName:  item.Name,
+					ToolCallInput: finalArgs,
+				}) {
+					return nil
+				}
+				delete(funcCalls, item.ID)
 			}
 
 		// --- Response lifecycle events ---
@@ -451,54 +732,51 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 			// Emit end events for any remaining function calls
 			for id, state := range funcCalls {
 				if state.started {
-					events <- StreamEvent{
+					if !emit(StreamEvent{
 						Type:          EventToolCallEnd,
 						ToolCallID:    state.id,
 						ToolCallName:  state.name,
 						ToolCallInput: state.arguments.String(),
+					}) {
+						return nil
 					}
 				}
 				delete(funcCalls, id)
 			}
 
-			usage := Usage{}
-			if len(evt.Response) > 0 {
-				var respBody respResponseBody
-				if err := json.Unmarshal(evt.Response, &respBody); err == nil && respBody.Usage != nil {
-					usage = Usage{
-						InputTokens:  respBody.Usage.InputTokens,
-						OutputTokens: respBody.Usage.OutputTokens,
-						TotalTokens:  respBody.Usage.TotalTokens,
-					}
-				}
-			}
-			events <- StreamEvent{Type: EventDone, Usage: usage}
-			return
+			var respBody respResponseBody
+			_ = json.Unmarshal(evt.Response, &respBody)
+			respBody.Status = "completed"
+			emit(doneEvent(respBody))
+			return nil
 
 		case "response.failed":
 			var respBody respResponseBody
 			if err := json.Unmarshal(evt.Response, &respBody); err == nil && respBody.Error != nil {
-				events <- StreamEvent{
+				emit(StreamEvent{
 					Type:  EventError,
-					Error: fmt.Errorf("OpenAI API error (%s): %s", respBody.Error.Code, respBody.Error.Message),
-				}
+					Error: &ResponseError{Provider: "OpenAI", Code: respBody.Error.Code, Message: respBody.Error.Message},
+				})
 			} else {
-				events <- StreamEvent{
+				emit(StreamEvent{
 					Type:  EventError,
 					Error: fmt.Errorf("response failed"),
-				}
+				})
 			}
-			return
+			return nil
 
 		case "response.incomplete":
-			events <- StreamEvent{
-				Type:  EventError,
-				Error: fmt.Errorf("response incomplete (model stopped early)"),
-			}
-			return
+			// The model was cut off, usually by max_output_tokens. Keep what
+			// was streamed; function calls still in progress have truncated
+			// arguments and are dropped.
+			var respBody respResponseBody
+			_ = json.Unmarshal(evt.Response, &respBody)
+			respBody.Status = "incomplete"
+			emit(doneEvent(respBody))
+			return nil
 
 		// Events we acknowledge but don't need to act on:
-		// response.created, response.in_progress,
+		// response.in_progress,
 		// response.output_item.added (non-function_call),
 		// response.content_part.added, response.content_part.done,
 		// response.output_text.done, response.output_text.annotation.added
@@ -508,10 +786,26 @@ func (p *OpenAIProvider) processStream(ctx context.Context, body io.Reader, even
 	}
 
 	if err := scanner.Err(); err != nil {
-		events <- StreamEvent{Type: EventError, Error: fmt.Errorf("reading stream: %w", err)}
-		return
+		return fmt.Errorf("reading stream: %w", &NetworkError{Err: err})
 	}
+	return errStreamEnded
+}
 
-	// If we got here without response.completed, emit done anyway
-	events <- StreamEvent{Type: EventDone}
+// doneEvent builds the EventDone for a finished response.
+func doneEvent(body respResponseBody) StreamEvent {
+	done := StreamEvent{Type: EventDone, ResponseID: body.ID, Status: body.Status}
+	if body.Usage != nil {
+		done.Usage = Usage{
+			InputTokens:  body.Usage.InputTokens,
+			OutputTokens: body.Usage.OutputTokens,
+			TotalTokens:  body.Usage.TotalTokens,
+		}
+	}
+	if body.Status == "incomplete" {
+		done.Incomplete = "unknown"
+		if body.IncompleteDetails != nil && body.IncompleteDetails.Reason != "" {
+			done.Incomplete = body.IncompleteDetails.Reason
+		}
+	}
+	return done
 }
@@ -0,0 +1,691 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+func TestSendMessageCancelMidStream(t *testing.T) {
+	handlerDone := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"hello\"}\n\n")
+		w.(http.Flusher).Flush()
+
+		// Stall until the client goes away.
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-test", srv.Client(), Timeouts{})
+	p.baseURL = srv.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := p.SendMessage(ctx, Request{
+		Messages: []message.Message{message.NewUserMessage("ses_test", "hi")},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Type != EventTextDelta || ev.Text != "hello" {
+			t.Fatalf("unexpected first event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for first event")
+	}
+
+	cancel()
+	waitClosed(t, events)
+
+	select {
+	case <-handlerDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server handler did not observe the aborted request")
+	}
+}
+
+// waitClosed drains events until the channel closes, failing the test if
+// that takes too long.
+func waitClosed(t *testing.T, events <-chan StreamEvent) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("events channel was not closed after cancellation")
+		}
+	}
+}
+
+func TestSendMessageStreamIdleTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"hello\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-test", srv.Client(), Timeouts{StreamIdle: 100 * time.Millisecond})
+	p.baseURL = srv.URL
+
+	events, err := p.SendMessage(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	var gotErr error
+	deadline := time.After(2 * time.Second)
+	for gotErr == nil {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("events channel closed without an idle timeout error")
+			}
+			if ev.Type == EventError {
+				gotErr = ev.Error
+			}
+		case <-deadline:
+			t.Fatal("stream was not aborted after going idle")
+		}
+	}
+
+	if !strings.Contains(gotErr.Error(), "no data received") {
+		t.Errorf("expected idle timeout error, got %v", gotErr)
+	}
+}
+
+func TestSendMessageModelNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"message":"The model 'gpt-nope' does not exist or you do not have access to it.","type":"invalid_request_error","param":null,"code":"model_not_found"}}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-nope", srv.Client(), Timeouts{})
+	p.baseURL = srv.URL
+
+	_, err := p.SendMessage(context.Background(), Request{})
+
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) {
+		t.Fatalf("expected a *ModelError, got %v", err)
+	}
+	if modelErr.Model != "gpt-nope" {
+		t.Errorf("ModelError.Model = %q, want %q", modelErr.Model, "gpt-nope")
+	}
+
+	// Other bad requests stay plain API errors.
+	if isModelRejection(http.StatusBadRequest, []byte(`{"error":{"message":"Invalid value for 'temperature'.","param":"temperature"}}`)) {
+		t.Error("a non-model 400 was classified as a model rejection")
+	}
+}
+
+func TestBuildInputPairsToolCalls(t *testing.T) {
+	p := NewOpenAIProvider("test-key", "gpt-test", nil, Timeouts{})
+
+	// A call whose result was never stored, and a result whose call is gone.
+	items := p.buildInput(Request{Messages: []message.Message{
+		message.NewUserMessage("s", "list files"),
+		message.NewAssistantMessage("s", "", []message.ToolCall{{ID: "call_1", Name: "ls", Input: []byte(`{}`)}}),
+		message.NewToolResultMessage("s", []message.ToolResult{{ToolCallID: "call_gone", Name: "ls", Output: "x"}}),
+		message.NewUserMessage("s", "again"),
+	}})
+
+	var types []string
+	for _, item := range items {
+		if typ, ok := item["type"].(string); ok {
+			types = append(types, typ+":"+item["call_id"].(string))
+		}
+	}
+	want := []string{"function_call:call_1", "function_call_output:call_1"}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Fatalf("tool items = %v, want %v", types, want)
+	}
+	if out := items[2]["output"]; out != missingToolOutput {
+		t.Errorf("placeholder output = %v", out)
+	}
+}
+
+func TestBuildInputToolResultFormat(t *testing.T) {
+	p := NewOpenAIProvider("test-key", "gpt-test", nil, Timeouts{})
+	messages := []message.Message{
+		message.NewUserMessage("s", "build it"),
+		message.NewAssistantMessage("s", "", []message.ToolCall{{ID: "call_1", Name: "bash", Input: []byte(`{}`)}}),
+		message.NewToolResultMessage("s", []message.ToolResult{{ToolCallID: "call_1", Name: "bash", Output: "\x1b[31mFAIL\x1b[0m \"pkg\"", IsError: true}}),
+	}
+
+	for _, tc := range []struct {
+		format, want string
+	}{
+		{"", `FAIL "pkg"`},
+		{ToolResultText, `FAIL "pkg"`},
+		{ToolResultJSON, `{"output":"FAIL \"pkg\"","is_error":true}`},
+	} {
+		items := p.buildInput(Request{Messages: messages, ToolResultFormat: tc.format})
+		if out := items[2]["output"]; out != tc.want {
+			t.Errorf("format %q: output = %v, want %s", tc.format, out, tc.want)
+		}
+	}
+}
+
+func TestNewResponsesRequestReasoning(t *testing.T) {
+	req := Request{MaxTokens: 1000, ReasoningEffort: "high", ReasoningReserve: 500}
+
+	tests := []struct {
+		model     string
+		maxTokens int
+		effort    string
+	}{
+		{"o4-mini", 1500, "high"},
+		{"gpt-5", 1500, "high"},
+		{"gpt-5-chat-latest", 1000, ""},
+		{"gpt-4.1", 1000, ""},
+	}
+	for _, tt := range tests {
+		p := NewOpenAIProvider("test-key", tt.model, nil, Timeouts{})
+		httpReq, err := p.newResponsesRequest(context.Background(), req, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body respRequest
+		if err := json.NewDecoder(httpReq.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		effort := ""
+		if body.Reasoning != nil {
+			effort = body.Reasoning.Effort
+		}
+		if body.MaxOutputTokens != tt.maxTokens || effort != tt.effort {
+			t.Errorf("%s: max_output_tokens = %d, effort = %q; want %d, %q",
+				tt.model, body.MaxOutputTokens, effort, tt.maxTokens, tt.effort)
+		}
+	}
+}
+
+func TestProcessStreamIncomplete(t *testing.T) {
+	stream := "data: {\"type\":\"response.output_text.delta\",\"delta\":\"partial\"}\n\n" +
+		"data: {\"type\":\"response.output_item.added\",\"item\":{\"type\":\"function_call\",\"id\":\"fc_1\",\"call_id\":\"call_1\",\"name\":\"write\"}}\n\n" +
+		"data: {\"type\":\"response.incomplete\",\"response\":{\"id\":\"resp_1\",\"status\":\"incomplete\"," +
+		"\"incomplete_details\":{\"reason\":\"max_output_tokens\"},\"usage\":{\"input_tokens\":5,\"output_tokens\":7,\"total_tokens\":12}}}\n\n"
+
+	p := NewOpenAIProvider("test-key", "gpt-test", nil, Timeouts{})
+	events := make(chan StreamEvent, 16)
+	p.processStream(context.Background(), strings.NewReader(stream), events, newStreamProgress())
+	close(events)
+
+	var text string
+	var last StreamEvent
+	for ev := range events {
+		switch ev.Type {
+		case EventTextDelta:
+			text += ev.Text
+		case EventToolCallEnd, EventError:
+			t.Errorf("unexpected event %+v", ev)
+		}
+		last = ev
+	}
+	if text != "partial" {
+		t.Errorf("text = %q, want the streamed text kept", text)
+	}
+	if last.Type != EventDone || last.Incomplete != "max_output_tokens" || last.Status != "incomplete" || last.Usage.TotalTokens != 12 {
+		t.Errorf("last event = %+v", last)
+	}
+}
+
+func TestProcessStreamCompleted(t *testing.T) {
+	p := NewOpenAIProvider("test-key", "gpt-test", nil, Timeouts{})
+	for _, tc := range []struct {
+		name, stream string
+		want         error
+	}{
+		{"completed", "data: {\"type\":\"response.output_text.delta\",\"delta\":\"done\"}\n\n" +
+			"data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\"}}\n\n", nil},
+		{"no terminal event", "data: {\"type\":\"response.output_text.delta\",\"delta\":\"done\"}\n\n", errStreamEnded},
+	} {
+		events := make(chan StreamEvent, 16)
+		err := p.processStream(context.Background(), strings.NewReader(tc.stream), events, newStreamProgress())
+		close(events)
+		if err != tc.want {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+		var last StreamEvent
+		for ev := range events {
+			last = ev
+		}
+		if tc.want == nil && (last.Type != EventDone || last.Status != "completed") {
+			t.Errorf("%s: last event = %+v, want done with status completed", tc.name, last)
+		}
+	}
+}
+
+func TestSendMessageResumesStoredResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Path != "/responses/resp_1" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, `{"id":"resp_1","status":"completed","output":[`+
+				`{"type":"message","content":[{"type":"output_text","text":"Hello there"}]},`+
+				`{"type":"function_call","call_id":"call_1","name":"ls","arguments":"{}"}]}`)
+			return
+		}
+		// Drop the connection partway through the response.
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_1\",\"status\":\"in_progress\"}}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"Hello\"}\n\n")
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-test", srv.Client(), Timeouts{})
+	p.baseURL = srv.URL
+
+	events, err := p.SendMessage(context.Background(), Request{
+		Messages: []message.Message{message.NewUserMessage("ses_test", "hi")},
+		Store:    true,
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	var text string
+	var got []StreamEventType
+	for ev := range events {
+		if ev.Type == EventTextDelta {
+			text += ev.Text
+		}
+		if ev.Type == EventError {
+			t.Fatalf("unexpected error: %v", ev.Error)
+		}
+		got = append(got, ev.Type)
+	}
+	if text != "Hello there" {
+		t.Errorf("text = %q, want %q", text, "Hello there")
+	}
+	want := []StreamEventType{EventTextDelta, EventTextDelta, EventToolCallStart, EventToolCallEnd, EventDone}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestBuildInputAttachments(t *testing.T) {
+	msg := message.NewUserMessage("s", "what is this error?")
+	msg.Attachments = []message.Attachment{{Name: "err.png", MIMEType: "image/png", Data: []byte("png")}}
+
+	p := NewOpenAIProvider("test-key", "gpt-4o", nil, Timeouts{})
+	parts, ok := p.buildInput(Request{Messages: []message.Message{msg}})[0]["content"].([]map[string]string)
+	if !ok || len(parts) != 2 {
+		t.Fatalf("content = %#v, want two parts", parts)
+	}
+	if parts[1]["type"] != "input_image" || parts[1]["image_url"] != "data:image/png;base64,cG5n" {
+		t.Errorf("image part = %v", parts[1])
+	}
+
+	// Models without vision get a note instead of the image.
+	p.SetModel("gpt-3.5-turbo")
+	parts = p.buildInput(Request{Messages: []message.Message{msg}})[0]["content"].([]map[string]string)
+	if parts[1]["type"] != "input_text" || !strings.Contains(parts[1]["text"], "err.png omitted") {
+		t.Errorf("fallback part = %v", parts[1])
+	}
+}
+
+func TestSendMessageErrorKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided","code":"invalid_api_key"}}`, ErrAuth},
+		{"forbidden", http.StatusForbidden, `{"error":{"message":"Forbidden"}}`, ErrAuth},
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`, ErrRateLimited},
+		{"quota", http.StatusTooManyRequests, `{"error":{"message":"You exceeded your current quota","code":"insufficient_quota"}}`, nil},
+		{"context length", http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 128000 tokens","code":"context_length_exceeded"}}`, ErrContextLength},
+		{"previous response", http.StatusBadRequest, `{"error":{"message":"Previous response with id 'resp_1' not found.","param":"previous_response_id","code":"previous_response_not_found"}}`, ErrPreviousResponse},
+		{"previous response param", http.StatusNotFound, `{"error":{"message":"Not found.","param":"previous_response_id"}}`, ErrPreviousResponse},
+		{"bad request", http.StatusBadRequest, `{"error":{"message":"Invalid value for 'temperature'.","param":"temperature"}}`, nil},
+		{"server error", http.StatusInternalServerError, `{"error":{"message":"oops"}}`, nil},
+		{"stream failure", http.StatusOK, "data: {\"type\":\"response.failed\",\"response\":{\"error\":{\"code\":\"rate_limit_exceeded\",\"message\":\"slow down\"}}}\n\n", ErrRateLimited},
+	}
+	kinds := []error{ErrAuth, ErrRateLimited, ErrContextLength, ErrNetwork, ErrPreviousResponse}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			p := NewOpenAIProvider("test-key", "gpt-4o", srv.Client(), Timeouts{})
+			p.baseURL = srv.URL
+
+			events, err := p.SendMessage(context.Background(), Request{})
+			if err == nil {
+				for ev := range events {
+					if ev.Type == EventError {
+						err = ev.Error
+					}
+				}
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, kind := range kinds {
+				if got := errors.Is(err, kind); got != (kind == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, kind, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSendMessageNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close()
+
+	p := NewOpenAIProvider("test-key", "gpt-4o", srv.Client(), Timeouts{})
+	p.baseURL = srv.URL
+
+	if _, err := p.SendMessage(context.Background(), Request{}); !errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want ErrNetwork", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.SendMessage(ctx, Request{}); errors.Is(err, ErrNetwork) {
+		t.Errorf("cancelled request reported as a network error: %v", err)
+	}
+}
+
+// streamEvents feeds an SSE stream to processStream and returns the events it
+// emits, each described by describeEvent, and its error.
+func streamEvents(t *testing.T, stream string) ([]string, error) {
+	t.Helper()
+	p := NewOpenAIProvider("test-key", "gpt-test", nil, Timeouts{})
+	events := make(chan StreamEvent, 64)
+	err := p.processStream(context.Background(), strings.NewReader(stream), events, newStreamProgress())
+	close(events)
+	var got []string
+	for ev := range events {
+		got = append(got, describeEvent(ev))
+	}
+	return got, err
+}
+
+// describeEvent renders the fields of ev that matter for its type, for
+// comparing event sequences.
+func describeEvent(ev StreamEvent) string {
+	switch ev.Type {
+	case EventTextDelta:
+		return "text " + ev.Text
+	case EventToolCallStart:
+		return fmt.Sprintf("start %s %s", ev.ToolCallID, ev.ToolCallName)
+	case EventToolCallDelta:
+		return fmt.Sprintf("delta %s %s", ev.ToolCallID, ev.ToolCallInput)
+	case EventToolCallEnd:
+		return fmt.Sprintf("end %s %s %s", ev.ToolCallID, ev.ToolCallName, ev.ToolCallInput)
+	case EventDone:
+		return fmt.Sprintf("done %s %s %d", ev.ResponseID, ev.Status, ev.Usage.TotalTokens)
+	case EventError:
+		return "error " + ev.Error.Error()
+	}
+	return fmt.Sprintf("event %d", ev.Type)
+}
+
+// The streams below are trimmed captures of Responses API streams, keeping
+// the fields processStream reads.
+const (
+	plainTextStream = `event: response.created
+data: {"type":"response.created","sequence_number":0,"response":{"id":"resp_1","status":"in_progress"}}
+
+event: response.in_progress
+data: {"type":"response.in_progress","sequence_number":1,"response":{"id":"resp_1","status":"in_progress"}}
+
+event: response.output_item.added
+data: {"type":"response.output_item.added","sequence_number":2,"output_index":0,"item":{"id":"msg_1","type":"message","status":"in_progress","content":[],"role":"assistant"}}
+
+event: response.content_part.added
+data: {"type":"response.content_part.added","sequence_number":3,"item_id":"msg_1","output_index":0,"content_index":0,"part":{"type":"output_text","text":""}}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","sequence_number":4,"item_id":"msg_1","output_index":0,"content_index":0,"delta":"Hello"}
+
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","sequence_number":5,"item_id":"msg_1","output_index":0,"content_index":0,"delta":" there."}
+
+event: response.output_text.done
+data: {"type":"response.output_text.done","sequence_number":6,"item_id":"msg_1","output_index":0,"content_index":0,"text":"Hello there."}
+
+event: response.output_item.done
+data: {"type":"response.output_item.done","sequence_number":7,"output_index":0,"item":{"id":"msg_1","type":"message","status":"completed","role":"assistant","content":[{"type":"output_text","text":"Hello there."}]}}
+
+event: response.completed
+data: {"type":"response.completed","sequence_number":8,"response":{"id":"resp_1","status":"completed","usage":{"input_tokens":20,"output_tokens":3,"total_tokens":23}}}
+
+`
+
+	functionCallStream = `event: response.created
+data: {"type":"response.created","sequence_number":0,"response":{"id":"resp_2","status":"in_progress"}}
+
+event: response.output_item.added
+data: {"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"id":"fc_1","type":"function_call","status":"in_progress","arguments":"","call_id":"call_1","name":"view"}}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","sequence_number":2,"item_id":"fc_1","output_index":0,"delta":"{\"file_path\":"}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","sequence_number":3,"item_id":"fc_1","output_index":0,"delta":"\"main.go\"}"}
+
+event: response.function_call_arguments.done
+data: {"type":"response.function_call_arguments.done","sequence_number":4,"item_id":"fc_1","output_index":0,"arguments":"{\"file_path\":\"main.go\"}"}
+
+event: response.output_item.done
+data: {"type":"response.output_item.done","sequence_number":5,"output_index":0,"item":{"id":"fc_1","type":"function_call","status":"completed","arguments":"{\"file_path\":\"main.go\"}","call_id":"call_1","name":"view"}}
+
+event: response.completed
+data: {"type":"response.completed","sequence_number":6,"response":{"id":"resp_2","status":"completed","usage":{"input_tokens":40,"output_tokens":12,"total_tokens":52}}}
+
+`
+
+	concurrentCallsStream = `data: {"type":"response.output_item.added","output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"grep"}}
+
+data: {"type":"response.output_item.added","output_index":1,"item":{"id":"fc_2","type":"function_call","call_id":"call_2","name":"ls"}}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"{\"pattern\":"}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_2","output_index":1,"delta":"{\"path\":\".\"}"}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"\"TODO\"}"}
+
+data: {"type":"response.function_call_arguments.done","item_id":"fc_2","output_index":1,"arguments":"{\"path\":\".\"}"}
+
+data: {"type":"response.function_call_arguments.done","item_id":"fc_1","output_index":0,"arguments":"{\"pattern\":\"TODO\"}"}
+
+data: {"type":"response.completed","response":{"id":"resp_3","status":"completed"}}
+
+`
+
+	// The item is finished before its arguments are, and the arguments.done
+	// that follows it must not end the call a second time.
+	itemDoneFirstStream = `data: {"type":"response.output_item.added","output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"bash"}}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"{\"command\":\"ls\"}"}
+
+data: {"type":"response.output_item.done","output_index":0,"item":{"id":"fc_1","type":"function_call","arguments":"{\"command\":\"ls\"}","call_id":"call_1","name":"bash"}}
+
+data: {"type":"response.function_call_arguments.done","item_id":"fc_1","output_index":0,"arguments":"{\"command\":\"ls\"}"}
+
+data: {"type":"response.completed","response":{"id":"resp_4","status":"completed"}}
+
+`
+
+	// Argument deltas arrive before the item they belong to is added.
+	deltaFirstStream = `data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"{\"path\":"}
+
+data: {"type":"response.output_item.added","output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"ls"}}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"\"src\"}"}
+
+data: {"type":"response.function_call_arguments.done","item_id":"fc_1","output_index":0}
+
+data: {"type":"response.completed","response":{"id":"resp_5","status":"completed"}}
+
+`
+
+	// Only the done event of a call arrives; the call is built from it.
+	untrackedDoneStream = `data: {"type":"response.output_text.delta","item_id":"msg_1","output_index":0,"content_index":0,"delta":"Checking."}
+
+data: {"type":"response.output_item.done","output_index":1,"item":{"id":"fc_1","type":"function_call","status":"completed","arguments":"{\"path\":\"src\"}","call_id":"call_1","name":"ls"}}
+
+data: {"type":"response.completed","response":{"id":"resp_10","status":"completed"}}
+
+`
+
+	// A call still open at response.completed is ended there.
+	unfinishedCallStream = `data: {"type":"response.output_item.added","output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"ls"}}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":0,"delta":"{}"}
+
+data: {"type":"response.completed","response":{"id":"resp_6","status":"completed"}}
+
+`
+
+	failedStream = `event: response.output_text.delta
+data: {"type":"response.output_text.delta","item_id":"msg_1","output_index":0,"content_index":0,"delta":"Let me"}
+
+event: response.failed
+data: {"type":"response.failed","response":{"id":"resp_7","status":"failed","error":{"code":"server_error","message":"The server had an error processing your request."}}}
+
+`
+
+	incompleteStream = `data: {"type":"response.output_text.delta","item_id":"msg_1","output_index":0,"content_index":0,"delta":"The answer is"}
+
+data: {"type":"response.output_item.added","output_index":1,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"write"}}
+
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","output_index":1,"delta":"{\"file_path\":\"a.go\",\"content\":\"pack"}
+
+data: {"type":"response.incomplete","response":{"id":"resp_8","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"usage":{"input_tokens":10,"output_tokens":64,"total_tokens":74}}}
+
+`
+
+	malformedStream = `: keep-alive
+
+retry: 1000
+event: response.output_text.delta
+data: {"type":"response.output_text.delta","delta":
+data: [DONE]
+data:{"type":"response.output_text.delta","delta":"no space"}
+id: 7
+data: {"type":"response.output_text.delta","delta":"kept"}
+data: {"type":"response.made_up_event","delta":"ignored"}
+
+data: {"type":"response.completed","response":{"id":"resp_9","status":"completed"}}
+`
+)
+
+func TestProcessStreamSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		want    []string
+		wantErr error
+	}{
+		{"plain text", plainTextStream, []string{
+			"text Hello",
+			"text  there.",
+			"done resp_1 completed 23",
+		}, nil},
+		{"function call", functionCallStream, []string{
+			"start call_1 view",
+			`delta call_1 {"file_path":`,
+			`delta call_1 "main.go"}`,
+			`end call_1 view {"file_path":"main.go"}`,
+			"done resp_2 completed 52",
+		}, nil},
+		{"concurrent function calls", concurrentCallsStream, []string{
+			"start call_1 grep",
+			"start call_2 ls",
+			`delta call_1 {"pattern":`,
+			`delta call_2 {"path":"."}`,
+			`delta call_1 "TODO"}`,
+			`end call_2 ls {"path":"."}`,
+			`end call_1 grep {"pattern":"TODO"}`,
+			"done resp_3 completed 0",
+		}, nil},
+		{"item done before arguments done", itemDoneFirstStream, []string{
+			"start call_1 bash",
+			`delta call_1 {"command":"ls"}`,
+			`end call_1 bash {"command":"ls"}`,
+			"done resp_4 completed 0",
+		}, nil},
+		{"arguments before item added", deltaFirstStream, []string{
+			"start call_1 ls",
+			`delta call_1 "src"}`,
+			`end call_1 ls {"path":"src"}`,
+			"done resp_5 completed 0",
+		}, nil},
+		{"item done without earlier events", untrackedDoneStream, []string{
+			"text Checking.",
+			"start call_1 ls",
+			`end call_1 ls {"path":"src"}`,
+			"done resp_10 completed 0",
+		}, nil},
+		{"call unfinished at completion", unfinishedCallStream, []string{
+			"start call_1 ls",
+			"delta call_1 {}",
+			"end call_1 ls {}",
+			"done resp_6 completed 0",
+		}, nil},
+		{"failed", failedStream, []string{
+			"text Let me",
+			"error OpenAI API error (server_error): The server had an error processing your request.",
+		}, nil},
+		{"incomplete", incompleteStream, []string{
+			"text The answer is",
+			"start call_1 write",
+			`delta call_1 {"file_path":"a.go","content":"pack`,
+			"done resp_8 incomplete 74",
+		}, nil},
+		{"malformed lines", malformedStream, []string{
+			"text no space",
+			"text kept",
+			"done resp_9 completed 0",
+		}, nil},
+		{"no terminal event", `data: {"type":"response.output_text.delta","delta":"Hello"}
+
+`, []string{
+			"text Hello",
+		}, errStreamEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := streamEvents(t, tt.stream)
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
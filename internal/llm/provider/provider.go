@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/webgovernor/goder/internal/message"
 	"github.com/webgovernor/goder/internal/tools"
@@ -18,6 +19,7 @@ const (
 	EventToolCallEnd
 	EventDone
 	EventError
+	EventFallback // a fallback provider is serving the request; Text describes why
 )
 
 // Usage captures token usage for a response.
@@ -31,7 +33,7 @@ type Usage struct {
 type StreamEvent struct {
 	Type StreamEventType
 
-	// For TextDelta events
+	// For TextDelta and Fallback events
 	Text string
 
 	// For ToolCall events
@@ -40,12 +42,22 @@ type StreamEvent struct {
 	ToolCallInput string // accumulated JSON input (for End events, this is the complete input)
 
 	// For Done events
-	Usage Usage
+	Usage      Usage
+	ResponseID string           // provider's ID for the response, for Request.PreviousResponseID
+	Incomplete string           // why the response was cut short, e.g. "max_output_tokens"; empty if it finished
+	Status     string           // the response's final status, "completed" or "incomplete"; empty if the stream ended without one
+	RateLimit  *RateLimitStatus // rate limits reported with the response; nil if none were
 
 	// For Error events
 	Error error
 }
 
+// Tool result formats for Request.ToolResultFormat.
+const (
+	ToolResultText = "text" // the output as is
+	ToolResultJSON = "json" // {"output": ..., "is_error": ...}
+)
+
 // ToolDefinition is the provider-agnostic representation of a tool for the LLM.
 type ToolDefinition struct {
 	Name        string          `json:"name"`
@@ -59,6 +71,38 @@ type Request struct {
 	Messages     []message.Message
 	Tools        []ToolDefinition
 	MaxTokens    int
+
+	// Model, if set, overrides the provider's model for this request, such
+	// as to send an auxiliary request to a cheaper model.
+	Model string
+
+	// ReasoningEffort and ReasoningReserve apply to reasoning models only:
+	// the effort level to request, and extra output tokens added to
+	// MaxTokens to cover the model's hidden reasoning.
+	ReasoningEffort  string
+	ReasoningReserve int
+
+	// ToolResultFormat is how tool results are sent to the model:
+	// ToolResultText (the default if empty) or ToolResultJSON.
+	ToolResultFormat string
+
+	// Store asks the provider to retain the response so that a later request
+	// can continue from it. With PreviousResponseID set, Messages holds only
+	// what came after that response; the provider supplies the rest.
+	Store              bool
+	PreviousResponseID string
+}
+
+// Timeouts bounds how long provider requests may take. Zero disables the
+// corresponding timeout.
+type Timeouts struct {
+	// Request covers connecting and receiving response headers. For
+	// non-streaming calls it covers the whole request.
+	Request time.Duration
+
+	// StreamIdle is the longest a streaming response may go without
+	// receiving any data before it is aborted with an error.
+	StreamIdle time.Duration
 }
 
 // Provider defines the interface for LLM providers.
@@ -69,6 +113,10 @@ type Provider interface {
 	// SendMessage sends a request to the LLM and returns a channel of streaming events.
 	SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error)
 
+	// Complete sends a non-streaming request and returns the response text.
+	// It is intended for short auxiliary requests such as title generation.
+	Complete(ctx context.Context, req Request) (string, error)
+
 	// ListModels returns the available model IDs from the provider.
 	ListModels(ctx context.Context) ([]string, error)
 
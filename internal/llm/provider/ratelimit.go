@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitedProvider wraps a provider so that requests are sent at no more
+// than a set rate, spacing them out evenly instead of in bursts. Requests
+// wait for their turn; a request whose context is cancelled while waiting
+// fails with the context's error and gives its turn back.
+type RateLimitedProvider struct {
+	provider Provider
+	bucket   *tokenBucket
+}
+
+// NewRateLimitedProvider creates a provider that sends at most
+// requestsPerMinute requests to p per minute.
+func NewRateLimitedProvider(p Provider, requestsPerMinute int) *RateLimitedProvider {
+	return &RateLimitedProvider{
+		provider: p,
+		bucket:   newTokenBucket(float64(requestsPerMinute)/60, 1),
+	}
+}
+
+func (r *RateLimitedProvider) Name() string { return r.provider.Name() }
+
+func (r *RateLimitedProvider) ListModels(ctx context.Context) ([]string, error) {
+	return r.provider.ListModels(ctx)
+}
+
+func (r *RateLimitedProvider) SetAPIKey(apiKey string) { r.provider.SetAPIKey(apiKey) }
+
+func (r *RateLimitedProvider) SetModel(model string) { r.provider.SetModel(model) }
+
+// BaseURL returns the wrapped provider's base URL.
+func (r *RateLimitedProvider) BaseURL() string {
+	if b, ok := r.provider.(baseURLer); ok {
+		return b.BaseURL()
+	}
+	return ""
+}
+
+func (r *RateLimitedProvider) SendMessage(ctx context.Context, req Request) (<-chan StreamEvent, error) {
+	if err := r.bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.provider.SendMessage(ctx, req)
+}
+
+func (r *RateLimitedProvider) Complete(ctx context.Context, req Request) (string, error) {
+	if err := r.bucket.wait(ctx); err != nil {
+		return "", err
+	}
+	return r.provider.Complete(ctx, req)
+}
+
+// tokenBucket is a token bucket rate limiter. Tokens are added at rate per
+// second up to burst, and each request takes one.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time // replaced in tests
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, now: time.Now}
+}
+
+// reserve takes a token, going into debt if none is left, and returns how
+// long to wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that was not used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(1, 1) // one request per second
+	b.now = func() time.Time { return now }
+
+	if d := b.reserve(); d != 0 {
+		t.Errorf("first request waits %v, want 0", d)
+	}
+	if d := b.reserve(); d != time.Second {
+		t.Errorf("second request waits %v, want 1s", d)
+	}
+	if d := b.reserve(); d != 2*time.Second {
+		t.Errorf("third request waits %v, want 2s", d)
+	}
+
+	// Idle time refills the bucket, but only up to the burst.
+	now = now.Add(time.Minute)
+	if d := b.reserve(); d != 0 {
+		t.Errorf("request after idling waits %v, want 0", d)
+	}
+	if d := b.reserve(); d != time.Second {
+		t.Errorf("next request waits %v, want 1s", d)
+	}
+}
+
+func TestRateLimitedProviderCancel(t *testing.T) {
+	mock := NewMock(TextResponse("one"), TextResponse("two"))
+	p := NewRateLimitedProvider(mock, 1) // one request per minute
+
+	if _, err := p.Complete(context.Background(), Request{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Complete(ctx, Request{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context deadline while waiting", err)
+	}
+	if n := len(mock.Requests()); n != 1 {
+		t.Errorf("sent %d requests, want the rate-limited one held back", n)
+	}
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// resumePollInterval is how often a stored response is polled while it
+	// is still being generated.
+	resumePollInterval = 2 * time.Second
+
+	// resumeMaxWait bounds how long an interrupted stream waits for its
+	// stored response to finish.
+	resumeMaxWait = 5 * time.Minute
+)
+
+// streamProgress records what a stream has delivered, so that an interrupted
+// stream can be completed from the stored response.
+type streamProgress struct {
+	responseID   string
+	text         strings.Builder
+	startedCalls map[string]bool  // call IDs whose EventToolCallStart was sent
+	endedCalls   map[string]bool  // call IDs whose EventToolCallEnd was sent
+	rateLimit    *RateLimitStatus // from the response headers, for the EventDone
+}
+
+func newStreamProgress() *streamProgress {
+	return &streamProgress{startedCalls: make(map[string]bool), endedCalls: make(map[string]bool)}
+}
+
+// record notes an event about to be sent to the consumer.
+func (sp *streamProgress) record(ev StreamEvent) {
+	switch ev.Type {
+	case EventTextDelta:
+		sp.text.WriteString(ev.Text)
+	case EventToolCallStart:
+		sp.startedCalls[ev.ToolCallID] = true
+	case EventToolCallEnd:
+		sp.endedCalls[ev.ToolCallID] = true
+	}
+}
+
+// resumeStream completes an interrupted stream from the stored response: it
+// waits for the response to finish, then sends the text and tool calls the
+// stream did not deliver, and the terminal event. It returns an error, having
+// sent nothing, if the response cannot be recovered.
+func (p *OpenAIProvider) resumeStream(ctx context.Context, progress *streamProgress, events chan<- StreamEvent) error {
+	body, err := p.awaitResponse(ctx, progress.responseID)
+	if err != nil {
+		return err
+	}
+
+	switch body.Status {
+	case "completed", "incomplete":
+	case "failed":
+		if body.Error != nil {
+			send(ctx, events, StreamEvent{
+				Type:  EventError,
+				Error: fmt.Errorf("OpenAI API error (%s): %s", body.Error.Code, body.Error.Message),
+			})
+		} else {
+			send(ctx, events, StreamEvent{Type: EventError, Error: fmt.Errorf("response failed")})
+		}
+		return nil
+	default:
+		return fmt.Errorf("stored response is %s", body.Status)
+	}
+
+	var text strings.Builder
+	for _, item := range body.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, part := range item.Content {
+			if part.Type == "output_text" {
+				text.WriteString(part.Text)
+			}
+		}
+	}
+	rest, ok := strings.CutPrefix(text.String(), progress.text.String())
+	if !ok {
+		return fmt.Errorf("stored response does not match the streamed text")
+	}
+
+	var pending []StreamEvent
+	if rest != "" {
+		pending = append(pending, StreamEvent{Type: EventTextDelta, Text: rest})
+	}
+	for _, item := range body.Output {
+		if item.Type != "function_call" || progress.endedCalls[item.CallID] {
+			continue
+		}
+		if !progress.startedCalls[item.CallID] {
+			pending = append(pending, StreamEvent{Type: EventToolCallStart, ToolCallID: item.CallID, ToolCallName: item.Name})
+		}
+		pending = append(pending, StreamEvent{
+			Type:          EventToolCallEnd,
+			ToolCallID:    item.CallID,
+			ToolCallName:  item.Name,
+			ToolCallInput: item.Arguments,
+		})
+	}
+	done := doneEvent(*body)
+	done.RateLimit = progress.rateLimit
+	pending = append(pending, done)
+
+	for _, ev := range pending {
+		if !send(ctx, events, ev) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// awaitResponse polls a stored response until it is no longer being
+// generated, or resumeMaxWait passes.
+func (p *OpenAIProvider) awaitResponse(ctx context.Context, id string) (*respResponseBody, error) {
+	deadline := time.Now().Add(resumeMaxWait)
+	for {
+		body, err := p.getResponse(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if body.Status != "queued" && body.Status != "in_progress" {
+			return body, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("stored response still %s after %s", body.Status, resumeMaxWait)
+		}
+
+		select {
+		case <-time.After(resumePollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// getResponse fetches a stored response.
+func (p *OpenAIProvider) getResponse(ctx context.Context, id string) (*respResponseBody, error) {
+	ctx, cancel := p.withRequestTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/responses/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching stored response: %w", networkError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("OpenAI", p.model, resp.StatusCode, resp.Header, bodyBytes)
+	}
+
+	var body respResponseBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding stored response: %w", err)
+	}
+	return &body, nil
+}
@@ -31,6 +31,26 @@ type ToolResult struct {
 	Name       string `json:"name"`
 	Output     string `json:"output"`
 	IsError    bool   `json:"is_error"`
+
+	// ModelOutput, if set, is sent to the model in place of Output, such as
+	// a condensed version of a very long output. Output is still shown.
+	ModelOutput string `json:"model_output,omitempty"`
+}
+
+// ForModel returns the output to send to the model.
+func (r ToolResult) ForModel() string {
+	if r.ModelOutput != "" {
+		return r.ModelOutput
+	}
+	return r.Output
+}
+
+// Attachment is a file sent along with a user message, such as a screenshot
+// for a vision model.
+type Attachment struct {
+	Name     string `json:"name"`      // file name, for display
+	MIMEType string `json:"mime_type"` // e.g. "image/png"
+	Data     []byte `json:"data"`
 }
 
 // Message represents a single message in a conversation.
@@ -41,9 +61,12 @@ type Message struct {
 	Content      string       `json:"content"`
 	ToolCalls    []ToolCall   `json:"tool_calls,omitempty"`
 	ToolResults  []ToolResult `json:"tool_results,omitempty"`
+	Attachments  []Attachment `json:"attachments,omitempty"`
 	InputTokens  int          `json:"input_tokens,omitempty"`
 	OutputTokens int          `json:"output_tokens,omitempty"`
 	TotalTokens  int          `json:"total_tokens,omitempty"`
+	ResponseID   string       `json:"response_id,omitempty"` // provider response that produced an assistant message, if stored
+	Hidden       bool         `json:"hidden,omitempty"`      // sent to the model but left out of the transcript, e.g. notes about mode changes
 	CreatedAt    time.Time    `json:"created_at"`
 }
 
@@ -60,7 +83,7 @@ func (m Message) IsToolResult() bool {
 // NewUserMessage creates a new user message.
 func NewUserMessage(sessionID, content string) Message {
 	return Message{
-		ID:        generateID(),
+		ID:        NewID(),
 		SessionID: sessionID,
 		Role:      User,
 		Content:   content,
@@ -71,7 +94,7 @@ func NewUserMessage(sessionID, content string) Message {
 // NewAssistantMessage creates a new assistant message.
 func NewAssistantMessage(sessionID, content string, toolCalls []ToolCall) Message {
 	return Message{
-		ID:        generateID(),
+		ID:        NewID(),
 		SessionID: sessionID,
 		Role:      Assistant,
 		Content:   content,
@@ -83,7 +106,7 @@ func NewAssistantMessage(sessionID, content string, toolCalls []ToolCall) Messag
 // NewToolResultMessage creates a new tool result message.
 func NewToolResultMessage(sessionID string, results []ToolResult) Message {
 	return Message{
-		ID:          generateID(),
+		ID:          NewID(),
 		SessionID:   sessionID,
 		Role:        Tool,
 		ToolResults: results,
@@ -94,7 +117,7 @@ func NewToolResultMessage(sessionID string, results []ToolResult) Message {
 // NewSystemMessage creates a new system message.
 func NewSystemMessage(sessionID, content string) Message {
 	return Message{
-		ID:        generateID(),
+		ID:        NewID(),
 		SessionID: sessionID,
 		Role:      System,
 		Content:   content,
@@ -102,8 +125,8 @@ func NewSystemMessage(sessionID, content string) Message {
 	}
 }
 
-// generateID produces a unique ID using timestamp + random bytes.
-func generateID() string {
+// NewID produces a unique message ID using timestamp + random bytes.
+func NewID() string {
 	b := make([]byte, 8)
 	_, _ = rand.Read(b)
 	return fmt.Sprintf("msg_%s_%s",
@@ -2,6 +2,7 @@ package permission
 
 import (
 	"context"
+	"fmt"
 	"sync"
 )
 
@@ -12,20 +13,60 @@ const (
 	Allow Response = iota
 	Deny
 	AllowForSession
+
+	// AskEach answers a batch request (see Service.CheckBatch) by asking
+	// about each of its calls separately.
+	AskEach
 )
 
+// BatchItem is one of the tool calls in a batch request.
+type BatchItem struct {
+	ToolName string
+	Input    string
+	Preview  string // optional description of the effect, e.g. a diff
+}
+
+// ReviewResult is the user's decision on a reviewed file change.
+type ReviewResult struct {
+	Response Response // Allow to write the file, Deny to skip the change
+	Content  string   // content to write; differs from the proposal if edited
+}
+
 // Request represents a tool asking for user permission.
 type Request struct {
 	ToolName    string
 	Description string
 	Input       string
+	Preview     string // optional description of the effect, e.g. a diff
+	Warning     string // why the call is dangerous; see Service.CheckDangerous
 	ResponseCh  chan Response
+
+	// Review requests (see Service.Review) carry the proposed file content
+	// and are answered on ReviewCh instead of ResponseCh.
+	Content  string
+	ReviewCh chan ReviewResult
+
+	// Batch requests (see Service.CheckBatch) list the calls they ask
+	// about, and are answered with Allow, Deny, or AskEach.
+	Batch []BatchItem
+}
+
+// IsReview reports whether r asks the user to review a file change rather
+// than for permission to run a tool.
+func (r Request) IsReview() bool {
+	return r.ReviewCh != nil
+}
+
+// IsBatch reports whether r asks about several tool calls at once.
+func (r Request) IsBatch() bool {
+	return len(r.Batch) > 0
 }
 
 // Service manages tool execution permissions.
 type Service struct {
 	mu             sync.RWMutex
 	sessionAllowed map[string]bool // tools allowed for the entire session
+	autoApprove    bool            // allow every tool without asking
 	requestCh      chan Request    // channel to send permission requests to the TUI
 }
 
@@ -42,25 +83,88 @@ func (s *Service) RequestCh() <-chan Request {
 	return s.requestCh
 }
 
+// SetAutoApprove turns auto-approval on or off. While on, Check allows every
+// tool without asking. Tools are only checked in build mode, so plan mode is
+// unaffected.
+func (s *Service) SetAutoApprove(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.autoApprove = on
+}
+
+// AutoApprove reports whether auto-approval is on.
+func (s *Service) AutoApprove() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.autoApprove
+}
+
 // Check checks if a tool is allowed to execute. If the tool has been allowed
-// for the session, returns Allow immediately. Otherwise, sends a request to
-// the TUI and blocks until the user responds or the context is cancelled.
-func (s *Service) Check(ctx context.Context, toolName string, input string) Response {
+// for the session, or auto-approval is on, returns Allow immediately.
+// Otherwise, sends a request to the TUI and blocks until the user responds or
+// the context is cancelled. preview, if non-empty, is shown to the user in
+// place of the raw input.
+func (s *Service) Check(ctx context.Context, toolName string, input string, preview string) Response {
 	s.mu.RLock()
-	if s.sessionAllowed[toolName] {
+	if s.autoApprove || s.sessionAllowed[toolName] {
 		s.mu.RUnlock()
 		return Allow
 	}
 	s.mu.RUnlock()
 
-	// Send a permission request and wait for the response
-	respCh := make(chan Response, 1)
-	req := Request{
+	resp := s.ask(ctx, Request{
 		ToolName:    toolName,
 		Description: toolName,
 		Input:       input,
-		ResponseCh:  respCh,
+		Preview:     preview,
+	})
+	if resp == AllowForSession {
+		s.mu.Lock()
+		s.sessionAllowed[toolName] = true
+		s.mu.Unlock()
+		return Allow
 	}
+	return resp
+}
+
+// CheckBatch asks the user about several tool calls at once, such as all
+// those in one model response that need permission, so they can be approved
+// or denied together. It returns Allow or Deny for the whole set, or AskEach
+// if the user wants to be asked about each call separately. With
+// auto-approval on, it returns Allow without asking.
+func (s *Service) CheckBatch(ctx context.Context, items []BatchItem) Response {
+	if s.AutoApprove() {
+		return Allow
+	}
+	return s.ask(ctx, Request{
+		Description: fmt.Sprintf("%d tool calls", len(items)),
+		Batch:       items,
+	})
+}
+
+// CheckDangerous asks the user about a call its tool flagged as dangerous,
+// showing warning prominently. Unlike Check it always asks, even if the tool
+// is allowed for the session or auto-approval is on, and an answer of
+// AllowForSession allows only this call.
+func (s *Service) CheckDangerous(ctx context.Context, toolName string, input string, preview string, warning string) Response {
+	resp := s.ask(ctx, Request{
+		ToolName:    toolName,
+		Description: toolName,
+		Input:       input,
+		Preview:     preview,
+		Warning:     warning,
+	})
+	if resp == AllowForSession {
+		return Allow
+	}
+	return resp
+}
+
+// ask sends req to the TUI and waits for the user's response. A cancelled
+// context denies the request.
+func (s *Service) ask(ctx context.Context, req Request) Response {
+	respCh := make(chan Response, 1)
+	req.ResponseCh = respCh
 
 	// Try to send the request, but respect cancellation
 	select {
@@ -72,18 +176,40 @@ func (s *Service) Check(ctx context.Context, toolName string, input string) Resp
 	// Wait for the user's response, but respect cancellation
 	select {
 	case resp := <-respCh:
-		if resp == AllowForSession {
-			s.mu.Lock()
-			s.sessionAllowed[toolName] = true
-			s.mu.Unlock()
-			return Allow
-		}
 		return resp
 	case <-ctx.Done():
 		return Deny
 	}
 }
 
+// Review asks the user to approve, skip, or amend the content a tool is
+// about to write, showing diff as the change. It blocks until the user
+// responds; a cancelled context skips the change. Reviews are never
+// remembered for the session.
+func (s *Service) Review(ctx context.Context, toolName string, diff string, content string) ReviewResult {
+	reviewCh := make(chan ReviewResult, 1)
+	req := Request{
+		ToolName:    toolName,
+		Description: toolName,
+		Preview:     diff,
+		Content:     content,
+		ReviewCh:    reviewCh,
+	}
+
+	select {
+	case s.requestCh <- req:
+	case <-ctx.Done():
+		return ReviewResult{Response: Deny}
+	}
+
+	select {
+	case result := <-reviewCh:
+		return result
+	case <-ctx.Done():
+		return ReviewResult{Response: Deny}
+	}
+}
+
 // Reset clears all session-level permissions.
 func (s *Service) Reset() {
 	s.mu.Lock()
@@ -0,0 +1,59 @@
+package permission
+
+import (
+	"context"
+	"testing"
+)
+
+// answer replies resp to the next request on svc and then delivers the
+// request on the returned channel.
+func answer(svc *Service, resp Response) <-chan Request {
+	asked := make(chan Request, 1)
+	go func() {
+		req := <-svc.RequestCh()
+		req.ResponseCh <- resp
+		asked <- req
+	}()
+	return asked
+}
+
+func TestAutoApprove(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	// Off by default: Check asks.
+	asked := answer(svc, Deny)
+	if got := svc.Check(ctx, "bash", `{"command":"ls"}`, ""); got != Deny {
+		t.Fatalf("Check = %v, want the user's Deny", got)
+	}
+	<-asked
+
+	// On: Check and CheckBatch allow without asking.
+	svc.SetAutoApprove(true)
+	if !svc.AutoApprove() {
+		t.Fatal("AutoApprove() = false after SetAutoApprove(true)")
+	}
+	if got := svc.Check(ctx, "bash", `{"command":"ls"}`, ""); got != Allow {
+		t.Errorf("Check = %v, want Allow", got)
+	}
+	if got := svc.CheckBatch(ctx, []BatchItem{{ToolName: "write"}, {ToolName: "bash"}}); got != Allow {
+		t.Errorf("CheckBatch = %v, want Allow", got)
+	}
+
+	// Dangerous calls are still confirmed.
+	asked = answer(svc, Deny)
+	if got := svc.CheckDangerous(ctx, "bash", `{"command":"rm -rf /"}`, "", "deletes everything"); got != Deny {
+		t.Errorf("CheckDangerous = %v, want the user's Deny", got)
+	}
+	if req := <-asked; req.Warning != "deletes everything" {
+		t.Errorf("dangerous request = %+v", req)
+	}
+
+	// Turning it off asks again.
+	svc.SetAutoApprove(false)
+	asked = answer(svc, Allow)
+	if got := svc.Check(ctx, "bash", `{"command":"ls"}`, ""); got != Allow {
+		t.Errorf("Check = %v, want the user's Allow", got)
+	}
+	<-asked
+}
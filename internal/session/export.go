@@ -0,0 +1,154 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/webgovernor/goder/internal/db"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// exportVersion is the version of the export format written by ExportJSON.
+// ImportJSON rejects documents with any other version.
+const exportVersion = 1
+
+// exportDoc is the JSON document written by ExportJSON.
+type exportDoc struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Session    exportSession     `json:"session"`
+	Messages   []message.Message `json:"messages"`
+}
+
+// exportSession is the session metadata in an export.
+type exportSession struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary,omitempty"`
+	Instructions string    `json:"instructions,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ExportJSON returns a JSON document of the session's metadata and all of
+// its messages, which ImportJSON can load back as a new session.
+func (s *Service) ExportJSON(sessionID string) ([]byte, error) {
+	sess, err := s.db.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting session %s: %w", sessionID, err)
+	}
+	messages, err := s.db.GetMessages(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("exporting session %s: %w", sessionID, err)
+	}
+	if messages == nil {
+		messages = []message.Message{}
+	}
+	doc := exportDoc{
+		Version:    exportVersion,
+		ExportedAt: time.Now(),
+		Session: exportSession{
+			ID:           sess.ID,
+			Title:        sess.Title,
+			Summary:      sess.Summary,
+			Instructions: sess.Instructions,
+			CreatedAt:    sess.CreatedAt,
+			UpdatedAt:    sess.UpdatedAt,
+		},
+		Messages: messages,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ImportJSON creates a new session from a document written by ExportJSON and
+// returns it. The session and its messages get fresh IDs, so a session can
+// be imported into the database it was exported from, or more than once.
+// Stored provider responses are not carried over, since they may belong to
+// another account. The current session is not changed.
+func (s *Service) ImportJSON(data []byte) (*db.Session, error) {
+	doc, err := parseExport(data)
+	if err != nil {
+		return nil, fmt.Errorf("importing session: %w", err)
+	}
+
+	sess := &db.Session{
+		ID:           newID(),
+		Title:        doc.Session.Title,
+		Summary:      doc.Session.Summary,
+		Instructions: doc.Session.Instructions,
+		CreatedAt:    doc.Session.CreatedAt,
+		UpdatedAt:    time.Now(),
+	}
+	if sess.Title == "" {
+		sess.Title = DefaultTitle
+	}
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = sess.UpdatedAt
+	}
+
+	messages := make([]message.Message, len(doc.Messages))
+	for i, msg := range doc.Messages {
+		msg.ID = message.NewID()
+		msg.SessionID = sess.ID
+		msg.ResponseID = ""
+		messages[i] = msg
+	}
+	if err := s.db.ImportSession(sess, messages); err != nil {
+		return nil, fmt.Errorf("importing session: %w", err)
+	}
+	return sess, nil
+}
+
+// parseExport decodes and validates an export document.
+func parseExport(data []byte) (*exportDoc, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var doc exportDoc
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not a goder session export: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("not a goder session export: unexpected data after the document")
+	}
+	switch {
+	case doc.Version == 0:
+		return nil, errors.New("not a goder session export: missing version")
+	case doc.Version != exportVersion:
+		return nil, fmt.Errorf("unsupported export version %d; this version of goder reads version %d", doc.Version, exportVersion)
+	}
+	for i, msg := range doc.Messages {
+		if err := validateMessage(msg); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+	}
+	return &doc, nil
+}
+
+// validateMessage checks that msg is something goder could have stored.
+func validateMessage(msg message.Message) error {
+	switch msg.Role {
+	case message.User, message.Assistant, message.System, message.Tool:
+	case "":
+		return errors.New("missing role")
+	default:
+		return fmt.Errorf("unknown role %q", msg.Role)
+	}
+	if msg.CreatedAt.IsZero() {
+		return errors.New("missing created_at")
+	}
+	for _, tc := range msg.ToolCalls {
+		if tc.ID == "" || tc.Name == "" {
+			return errors.New("tool call without an id or name")
+		}
+	}
+	for _, tr := range msg.ToolResults {
+		if tr.ToolCallID == "" {
+			return errors.New("tool result without a tool_call_id")
+		}
+	}
+	return nil
+}
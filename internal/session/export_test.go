@@ -0,0 +1,124 @@
+package session
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webgovernor/goder/internal/db"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "goder.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewService(database)
+}
+
+func TestExportImportJSON(t *testing.T) {
+	svc := newTestService(t)
+	orig, err := svc.Create("Fix the parser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetSummary("Parser work so far."); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetInstructions("Only touch parser.go."); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	call := message.ToolCall{ID: "call_1", Name: "view", Input: json.RawMessage(`{"path":"main.go"}`)}
+	assistant := message.NewAssistantMessage(orig.ID, "", []message.ToolCall{call})
+	assistant.ResponseID = "resp_1"
+	msgs := []message.Message{
+		message.NewUserMessage(orig.ID, "Why does parsing fail?"),
+		assistant,
+		message.NewToolResultMessage(orig.ID, []message.ToolResult{{ToolCallID: "call_1", Name: "view", Output: "package main"}}),
+	}
+	for i := range msgs {
+		msgs[i].CreatedAt = start.Add(time.Duration(i) * time.Second)
+		if err := svc.AddMessage(msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := svc.ExportJSON(orig.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing into the same database, twice, must not collide.
+	for range 2 {
+		imported, err := svc.ImportJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported.ID == orig.ID || imported.Title != "Fix the parser" || imported.Summary != "Parser work so far." || imported.Instructions != "Only touch parser.go." {
+			t.Errorf("imported session = %+v", imported)
+		}
+		if svc.CurrentID() != orig.ID {
+			t.Error("import changed the current session")
+		}
+
+		got, err := svc.db.GetMessages(imported.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(msgs) {
+			t.Fatalf("imported %d messages, want %d", len(got), len(msgs))
+		}
+		for i, msg := range got {
+			if msg.ID == msgs[i].ID || msg.SessionID != imported.ID || msg.Role != msgs[i].Role || msg.Content != msgs[i].Content {
+				t.Errorf("message %d = %+v, want a copy of %+v with new ids", i, msg, msgs[i])
+			}
+			if msg.ResponseID != "" {
+				t.Errorf("message %d kept response id %q", i, msg.ResponseID)
+			}
+		}
+		if got[1].ToolCalls[0].ID != "call_1" || string(got[1].ToolCalls[0].Input) != `{"path":"main.go"}` || got[2].ToolResults[0].Output != "package main" {
+			t.Errorf("tool calls not preserved: %+v, %+v", got[1].ToolCalls, got[2].ToolResults)
+		}
+	}
+}
+
+func TestImportJSONValidates(t *testing.T) {
+	svc := newTestService(t)
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"not json", `hello`, "not a goder session export"},
+		{"no version", `{"session":{"title":"x"},"messages":[]}`, "missing version"},
+		{"future version", `{"version":2,"session":{},"messages":[]}`, "unsupported export version 2"},
+		{"unknown field", `{"version":1,"session":{},"messages":[],"extra":true}`, "unknown field"},
+		{"bad role", `{"version":1,"session":{},"messages":[{"role":"robot","created_at":"2025-01-01T00:00:00Z"}]}`, `message 1: unknown role "robot"`},
+		{"no time", `{"version":1,"session":{},"messages":[{"role":"user","content":"hi"}]}`, "message 1: missing created_at"},
+		{"bad tool call", `{"version":1,"session":{},"messages":[{"role":"assistant","tool_calls":[{"name":"view"}],"created_at":"2025-01-01T00:00:00Z"}]}`, "tool call without an id"},
+	}
+	for _, tt := range tests {
+		if _, err := svc.ImportJSON([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+	sessions, err := svc.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("failed imports left %d sessions behind", len(sessions))
+	}
+
+	sess, err := svc.ImportJSON([]byte(`{"version":1,"session":{},"messages":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Title != DefaultTitle {
+		t.Errorf("untitled import got title %q", sess.Title)
+	}
+}
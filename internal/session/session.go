@@ -10,6 +10,9 @@ import (
 	"github.com/webgovernor/goder/internal/message"
 )
 
+// DefaultTitle is the title given to sessions before one is generated or set.
+const DefaultTitle = "New Session"
+
 // Service manages conversation sessions.
 type Service struct {
 	db        *db.DB
@@ -23,12 +26,7 @@ func NewService(database *db.DB) *Service {
 
 // Create starts a new session and makes it current.
 func (s *Service) Create(title string) (*db.Session, error) {
-	b := make([]byte, 8)
-	_, _ = rand.Read(b)
-	id := fmt.Sprintf("ses_%s_%s",
-		time.Now().Format("20060102150405"),
-		hex.EncodeToString(b),
-	)
+	id := newID()
 	session, err := s.db.CreateSession(id, title)
 	if err != nil {
 		return nil, fmt.Errorf("creating session: %w", err)
@@ -37,6 +35,16 @@ func (s *Service) Create(title string) (*db.Session, error) {
 	return session, nil
 }
 
+// newID produces a unique session ID using timestamp + random bytes.
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("ses_%s_%s",
+		time.Now().Format("20060102150405"),
+		hex.EncodeToString(b),
+	)
+}
+
 // Switch changes the current session.
 func (s *Service) Switch(id string) (*db.Session, error) {
 	session, err := s.db.GetSession(id)
@@ -50,7 +58,7 @@ func (s *Service) Switch(id string) (*db.Session, error) {
 // Current returns the current session, creating one if none exists.
 func (s *Service) Current() (*db.Session, error) {
 	if s.currentID == "" {
-		return s.Create("New Session")
+		return s.Create(DefaultTitle)
 	}
 	return s.db.GetSession(s.currentID)
 }
@@ -104,3 +112,36 @@ func (s *Service) UpdateTitle(title string) error {
 	}
 	return s.db.UpdateSessionTitle(s.currentID, title)
 }
+
+// GetSummary returns the stored summary of the current session.
+func (s *Service) GetSummary() (string, error) {
+	if s.currentID == "" {
+		return "", nil
+	}
+	return s.db.GetSessionSummary(s.currentID)
+}
+
+// SetSummary stores a summary for the current session.
+func (s *Service) SetSummary(summary string) error {
+	if s.currentID == "" {
+		return fmt.Errorf("no current session")
+	}
+	return s.db.UpdateSessionSummary(s.currentID, summary)
+}
+
+// GetInstructions returns the instructions set for the current session.
+func (s *Service) GetInstructions() (string, error) {
+	if s.currentID == "" {
+		return "", nil
+	}
+	return s.db.GetSessionInstructions(s.currentID)
+}
+
+// SetInstructions stores instructions for the current session, or clears
+// them if instructions is empty.
+func (s *Service) SetInstructions(instructions string) error {
+	if s.currentID == "" {
+		return fmt.Errorf("no current session")
+	}
+	return s.db.UpdateSessionInstructions(s.currentID, instructions)
+}
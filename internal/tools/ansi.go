@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+// escapeSeq matches terminal escape sequences: CSI sequences such as colors
+// and cursor movement, OSC sequences such as hyperlinks and window titles,
+// and short escapes such as character set selection. An escape that starts
+// none of these, such as one whose sequence was cut off, matches on its own.
+var escapeSeq = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[ -/]*[0-~])?`)
+
+// isSGR reports whether seq, an escape sequence, sets colors or text style.
+func isSGR(seq string) bool {
+	return strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m")
+}
+
+// CleanTerminalOutput makes output written for a terminal, such as that of
+// "ls --color" or a test runner, safe to show in a panel and to send to the
+// model. Escape sequences are removed, except those setting colors and text
+// style if keepColors is set, along with the rest of any sequence cut short
+// by truncation. Each line keeps only what is left visible after the last
+// carriage return, as with progress bars that redraw themselves.
+func CleanTerminalOutput(s string, keepColors bool) string {
+	if !strings.ContainsAny(s, "\x1b\r") {
+		return s
+	}
+	s = escapeSeq.ReplaceAllStringFunc(s, func(seq string) string {
+		if keepColors && isSGR(seq) {
+			return seq
+		}
+		return ""
+	})
+
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StripANSI removes every terminal escape sequence from s.
+func StripANSI(s string) string {
+	return CleanTerminalOutput(s, false)
+}
@@ -0,0 +1,28 @@
+package tools
+
+import "testing"
+
+func TestCleanTerminalOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		keepColors bool
+		want       string
+	}{
+		{"plain", "a.go\nb.go\n", false, "a.go\nb.go\n"},
+		{"colors", "\x1b[01;34mdir\x1b[0m  \x1b[32mok\x1b[m", false, "dir  ok"},
+		{"colors kept", "\x1b[01;34mdir\x1b[0m", true, "\x1b[01;34mdir\x1b[0m"},
+		{"cursor movement", "\x1b[2K\x1b[1Gdone\x1b[?25h", true, "done"},
+		{"hyperlink", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", false, "link"},
+		{"window title", "\x1b]0;title\x07text", false, "text"},
+		{"two-byte escape", "\x1b=\x1b(Btext", false, "text"},
+		{"cut off", "ok\x1b[3", true, "ok3"},
+		{"progress", "10%\r50%\r100%\nnext", false, "100%\nnext"},
+		{"crlf", "one\r\ntwo\r\n", false, "one\ntwo\n"},
+	}
+	for _, tt := range tests {
+		if got := CleanTerminalOutput(tt.in, tt.keepColors); got != tt.want {
+			t.Errorf("%s: CleanTerminalOutput(%q, %v) = %q, want %q", tt.name, tt.in, tt.keepColors, got, tt.want)
+		}
+	}
+}
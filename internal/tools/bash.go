@@ -4,20 +4,41 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 )
 
+// bashWaitDelay is how long a killed command's output pipes may stay open,
+// held by processes it started, before they are closed and the call returns.
+const bashWaitDelay = 2 * time.Second
+
+// exitCodeMarker introduces the exit status appended to the output of a
+// command that exited with an error.
+const exitCodeMarker = "\n(exit code: "
+
+// BashOptions controls the bash tool.
+type BashOptions struct {
+	// RetryPatterns are regular expressions matched against the output of
+	// failed commands; a match makes the command retryable. Invalid
+	// patterns are skipped.
+	RetryPatterns []string
+}
+
 // BashTool executes shell commands.
 type BashTool struct {
 	workDir string
+
+	// retryPatterns match the output of failed commands worth retrying.
+	retryPatterns []*regexp.Regexp
 }
 
 // NewBashTool creates a new bash tool.
-func NewBashTool(workDir string) *BashTool {
-	return &BashTool{workDir: workDir}
+func NewBashTool(workDir string, opts BashOptions) *BashTool {
+	return &BashTool{workDir: workDir, retryPatterns: compileRetryPatterns(opts.RetryPatterns)}
 }
 
 func (t *BashTool) Name() string { return "bash" }
@@ -66,6 +87,8 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (string,
 
 	cmd := exec.CommandContext(ctx, "bash", "-c", params.Command)
 	cmd.Dir = t.workDir
+	cmd.WaitDelay = bashWaitDelay
+	killProcessGroup(cmd)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -73,16 +96,20 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (string,
 
 	err := cmd.Run()
 
+	dec := newTextDecoder("")
 	var result strings.Builder
 	if stdout.Len() > 0 {
-		result.WriteString(stdout.String())
+		result.WriteString(dec.decode(stdout.Bytes()))
 	}
 	if stderr.Len() > 0 {
 		if result.Len() > 0 {
 			result.WriteString("\n")
 		}
 		result.WriteString("STDERR:\n")
-		result.WriteString(stderr.String())
+		result.WriteString(dec.decode(stderr.Bytes()))
+	}
+	if note := dec.note(); note != "" {
+		result.WriteString("\n" + note)
 	}
 
 	output := result.String()
@@ -95,13 +122,23 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (string,
 
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return output + "\n(command timed out)", fmt.Errorf("command timed out after %ds", params.Timeout)
+			if output == "" {
+				output = "(no output)"
+			}
+			return output + fmt.Sprintf("\n(command killed after timing out at %ds; the output above is partial)", params.Timeout),
+				fmt.Errorf("command timed out after %ds", params.Timeout)
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			if output == "" {
+				output = "(no output)"
+			}
+			return output + "\n(command killed when cancelled; the output above is partial)", ctx.Err()
 		}
 		if output == "" {
 			return "", fmt.Errorf("command failed: %w", err)
 		}
 		// Include the output even on error (exit code != 0)
-		return output + fmt.Sprintf("\n(exit code: %s)", err), nil
+		return output + exitCodeMarker + err.Error() + ")", nil
 	}
 
 	if output == "" {
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tools
+
+import "os/exec"
+
+// killProcessGroup leaves cmd unchanged: without process groups, cancelling
+// the command kills only the shell itself.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBashTimeoutKeepsPartialOutput(t *testing.T) {
+	tool := NewBashTool(t.TempDir(), BashOptions{})
+
+	// The background sleep keeps the output pipe open after bash is killed.
+	start := time.Now()
+	out, err := tool.Execute(context.Background(), []byte(`{"command":"echo started; sleep 30 & sleep 30","timeout":1}`))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if !strings.HasPrefix(out, "started\n") || !strings.Contains(out, "partial") {
+		t.Errorf("output = %q, want the partial output marked as such", out)
+	}
+	if elapsed := time.Since(start); elapsed > 1*time.Second+bashWaitDelay+2*time.Second {
+		t.Errorf("Execute returned after %s, want it bounded by the timeout", elapsed)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBashTimeoutKillsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewBashTool(dir, BashOptions{})
+
+	_, err := tool.Execute(context.Background(), []byte(`{"command":"sleep 30 & echo $! > bg.pid; wait","timeout":1}`))
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "bg.pid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The killed process may take a moment to be reaped.
+	deadline := time.Now().Add(2 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("background process %d outlived the timed-out command", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
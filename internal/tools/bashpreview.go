@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Preview implements Previewer. It shows the command with a best-effort
+// guess at whether it modifies files, to help decide whether to allow it.
+func (t *BashTool) Preview(input json.RawMessage) (string, error) {
+	var params struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || strings.TrimSpace(params.Command) == "" {
+		return "", nil
+	}
+
+	var note string
+	switch effect, reasons := classifyCommand(params.Command); effect {
+	case effectReadOnly:
+		note = "This command appears to be read-only."
+	case effectWrites:
+		note = fmt.Sprintf("This command appears to modify files (%s).", strings.Join(reasons, ", "))
+	default:
+		note = fmt.Sprintf("Could not tell whether this command modifies files (%s).", strings.Join(reasons, ", "))
+	}
+	return "$ " + strings.TrimSpace(params.Command) + "\n\n" + note, nil
+}
+
+// commandEffect is what a command line appears to do to files.
+type commandEffect int
+
+const (
+	effectUnknown commandEffect = iota
+	effectReadOnly
+	effectWrites
+)
+
+// maxEffectReasons caps how many reasons classifyCommand returns.
+const maxEffectReasons = 4
+
+// classifyCommand guesses whether a shell command line modifies files, from
+// the commands it runs, their subcommands and flags, and where their output
+// is redirected. For effectWrites the reasons name what writes; for
+// effectUnknown they name the commands it does not know. The guess is only
+// as good as the lists below: scripts and unknown programs can do anything.
+func classifyCommand(command string) (commandEffect, []string) {
+	var writes, unknown []string
+	for _, cmd := range parseShell(command) {
+		for _, out := range cmd.outputs {
+			if !harmlessOutputs[out] {
+				writes = appendReason(writes, "> "+out)
+			}
+		}
+		words := commandWords(cmd.words)
+		if len(words) == 0 {
+			continue
+		}
+		switch simpleCommandEffect(words) {
+		case effectWrites:
+			writes = appendReason(writes, commandLabel(words))
+		case effectUnknown:
+			unknown = appendReason(unknown, commandLabel(words))
+		}
+	}
+
+	switch {
+	case len(writes) > 0:
+		return effectWrites, writes
+	case len(unknown) > 0:
+		return effectUnknown, unknown
+	case len(parseShell(command)) == 0:
+		return effectUnknown, []string{"empty command"}
+	}
+	return effectReadOnly, nil
+}
+
+// appendReason adds r to reasons unless it is already there or the list is
+// full.
+func appendReason(reasons []string, r string) []string {
+	if slices.Contains(reasons, r) || len(reasons) >= maxEffectReasons {
+		return reasons
+	}
+	return append(reasons, r)
+}
+
+// commandLabel names a command for a reason: the program, with its
+// subcommand for programs that have them.
+func commandLabel(words []string) string {
+	name, args := filepath.Base(words[0]), words[1:]
+	if name == "git" {
+		args = skipGitOptions(args)
+	}
+	if _, ok := subcommandPrograms[name]; ok {
+		if sub := firstOperand(args); sub != "" {
+			return name + " " + sub
+		}
+	}
+	return name
+}
+
+var (
+	// harmlessOutputs are redirection targets that are not files.
+	harmlessOutputs = map[string]bool{
+		"/dev/null": true, "/dev/stdout": true, "/dev/stderr": true, "/dev/tty": true,
+	}
+
+	// readOnlyCommands never modify files, whatever their arguments; the
+	// exceptions are handled in simpleCommandEffect.
+	readOnlyCommands = wordSet(
+		"ls", "ll", "cat", "head", "tail", "less", "more", "grep", "egrep", "fgrep", "rg", "ag",
+		"wc", "echo", "printf", "pwd", "cd", "which", "whereis", "type", "file", "stat", "du",
+		"df", "tree", "diff", "cmp", "comm", "uniq", "cut", "tr", "nl", "fold", "column",
+		"basename", "dirname", "realpath", "readlink", "date", "whoami", "id", "uname",
+		"hostname", "printenv", "ps", "true", "false", "test", "[", "jq", "seq", "sleep",
+		"md5sum", "sha1sum", "sha256sum", "cksum", "hexdump", "xxd", "od", "strings",
+		"export", "set", "unset", "exit", "wait",
+		"lsof", "top", "free", "uptime", "man", "help", "history",
+	)
+
+	// writeCommands modify files whenever they do anything.
+	writeCommands = wordSet(
+		"rm", "rmdir", "mv", "cp", "mkdir", "touch", "chmod", "chown", "chgrp", "ln",
+		"dd", "truncate", "install", "patch", "unzip", "gunzip", "gzip", "zip", "rsync",
+		"scp", "shred", "mkfs", "mktemp",
+	)
+
+	// subcommandPrograms maps programs whose effect depends on their
+	// subcommand to the subcommands that are read-only.
+	subcommandPrograms = map[string]map[string]bool{
+		"git": wordSet("status", "log", "diff", "show", "blame", "grep", "ls-files", "ls-tree",
+			"rev-parse", "rev-list", "describe", "shortlog", "cat-file", "help", "version"),
+		"go":     wordSet("vet", "list", "doc", "version", "help"),
+		"cargo":  wordSet("check", "tree", "metadata", "search", "help", "version"),
+		"docker": wordSet("ps", "images", "inspect", "logs", "version", "info"),
+		"npm":    wordSet("ls", "list", "view", "info", "outdated", "search", "help"),
+		"yarn":   wordSet("list", "info", "outdated", "help"),
+		"pnpm":   wordSet("ls", "list", "outdated", "help"),
+		"pip":    wordSet("list", "show", "freeze", "check", "help"),
+		"pip3":   wordSet("list", "show", "freeze", "check", "help"),
+	}
+)
+
+// wordSet returns a set of words.
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// simpleCommandEffect classifies one command, without wrappers.
+func simpleCommandEffect(words []string) commandEffect {
+	name, args := filepath.Base(words[0]), words[1:]
+	switch {
+	case strings.HasPrefix(name, "mkfs."):
+		return effectWrites
+	case name == "sed":
+		return writesIf(hasFlag(args, 'i', "in-place") || hasPrefixArg(args, "--in-place"))
+	case name == "awk" || name == "gawk":
+		return writesIf(slices.Contains(args, "inplace") || hasPrefixArg(args, "-i"))
+	case name == "sort":
+		return writesIf(hasFlag(args, 'o', "output") || hasPrefixArg(args, "--output"))
+	case name == "find":
+		return writesIf(slices.ContainsFunc(args, func(a string) bool {
+			return a == "-delete" || a == "-exec" || a == "-execdir" || a == "-ok" || strings.HasPrefix(a, "-fprint")
+		}))
+	case name == "tee":
+		return writesIf(firstOperand(args) != "")
+	case name == "gofmt" || name == "goimports":
+		return writesIf(hasFlag(args, 'w', "write"))
+	case name == "curl":
+		return writesIf(hasFlag(args, 'o', "output") || hasFlag(args, 'O', "remote-name"))
+	case name == "wget":
+		return writesIf(!slices.Contains(args, "-O-") && !(slices.Contains(args, "-O") && slices.Contains(args, "-")) && !slices.Contains(args, "--spider"))
+	case name == "tar":
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			// Old-style bundled options, as in "tar xzf".
+			return writesIf(!strings.ContainsRune(args[0], 't'))
+		}
+		return writesIf(!hasFlag(args, 't', "list"))
+	case name == "xargs", name == "time", name == "nice", name == "nohup", name == "timeout":
+		// Classify the command these run, after timeout's duration.
+		ops := operands(args)
+		if name == "timeout" && len(ops) > 0 {
+			ops = ops[1:]
+		}
+		if len(ops) == 0 {
+			return effectReadOnly
+		}
+		return simpleCommandEffect(args[slices.Index(args, ops[0]):])
+	case readOnlyCommands[name]:
+		return effectReadOnly
+	case writeCommands[name]:
+		return effectWrites
+	}
+
+	readOnly, ok := subcommandPrograms[name]
+	if !ok {
+		return effectUnknown
+	}
+	if name == "git" {
+		args = skipGitOptions(args)
+	}
+	sub := firstOperand(args)
+	switch {
+	case sub == "":
+		return effectReadOnly
+	case name == "git" && sub == "branch", name == "git" && sub == "tag", name == "git" && sub == "remote":
+		// Listing unless given a name to create, rename, or delete.
+		return writesIf(len(operands(args)) > 1 || hasFlag(args, 'd', "delete") || hasFlag(args, 'D', "delete") || hasFlag(args, 'm', "move"))
+	case name == "git" && sub == "stash":
+		rest := operands(args)[1:]
+		return writesIf(len(rest) == 0 || (rest[0] != "list" && rest[0] != "show"))
+	case name == "git" && sub == "config":
+		return writesIf(!hasPrefixArg(args, "--get") && !hasFlag(args, 'l', "list"))
+	case readOnly[sub]:
+		return effectReadOnly
+	case sub == "run", sub == "exec", sub == "test", sub == "tool", sub == "clippy":
+		// Runs a script, test, build script, or program that could do
+		// anything.
+		return effectUnknown
+	}
+	return effectWrites
+}
+
+// skipGitOptions drops git's global options, such as -C dir, from before
+// its subcommand.
+func skipGitOptions(args []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if (args[0] == "-C" || args[0] == "-c") && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// writesIf returns effectWrites if writes is set, and effectReadOnly
+// otherwise.
+func writesIf(writes bool) commandEffect {
+	if writes {
+		return effectWrites
+	}
+	return effectReadOnly
+}
+
+// operands returns the arguments that are not flags.
+func operands(args []string) []string {
+	var ops []string
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			ops = append(ops, a)
+		}
+	}
+	return ops
+}
+
+// firstOperand returns the first argument that is not a flag, or "".
+func firstOperand(args []string) string {
+	if ops := operands(args); len(ops) > 0 {
+		return ops[0]
+	}
+	return ""
+}
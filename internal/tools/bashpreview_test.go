@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestClassifyCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    commandEffect
+	}{
+		{"ls -la && cat go.mod | grep module", effectReadOnly},
+		{"git status && git log --oneline -5 && git -C sub diff", effectReadOnly},
+		{"go vet ./... 2>&1 | tail -20", effectReadOnly},
+		{"find . -name '*.go' | xargs wc -l", effectReadOnly},
+		{"echo '> not a redirect; rm -rf x'", effectReadOnly},
+		{"sed -n '1,10p' main.go", effectReadOnly},
+		{"git branch -a", effectReadOnly},
+		{"echo hello > out.txt", effectWrites},
+		{"sed -i 's/a/b/' main.go", effectWrites},
+		{"git commit -m 'msg'", effectWrites},
+		{"git branch feature", effectWrites},
+		{"mkdir -p build && cp a b", effectWrites},
+		{"find . -name '*.tmp' -delete", effectWrites},
+		{"go build -o bin/app .", effectWrites},
+		{"timeout 10 rm file", effectWrites},
+		{"echo x | tee log.txt", effectWrites},
+		{"tar xzf archive.tgz", effectWrites},
+		{"tar tzf archive.tgz", effectReadOnly},
+		{"make", effectUnknown},
+		{"./script.sh", effectUnknown},
+		{"go run ./cmd/tool", effectUnknown},
+		{"go test ./...", effectUnknown},
+		{"go tool cover -html=c.out", effectUnknown},
+		{"npm test", effectUnknown},
+		{"yarn test --watch", effectUnknown},
+		{"pnpm test", effectUnknown},
+		{"cargo test", effectUnknown},
+		{"cargo clippy --fix", effectUnknown},
+		{"go test ./... && rm out", effectWrites},
+	}
+	for _, tt := range tests {
+		if got, reasons := classifyCommand(tt.command); got != tt.want {
+			t.Errorf("classifyCommand(%q) = %d (%v), want %d", tt.command, got, reasons, tt.want)
+		}
+	}
+}
+
+func TestBashPreview(t *testing.T) {
+	tool := NewBashTool(t.TempDir(), BashOptions{})
+	preview := func(command string) string {
+		input, _ := json.Marshal(map[string]string{"command": command})
+		preview, err := tool.Preview(input)
+		if err != nil {
+			t.Fatalf("Preview(%q): %v", command, err)
+		}
+		return preview
+	}
+
+	got := preview("rm notes.txt && echo done > log")
+	if !strings.HasPrefix(got, "$ rm notes.txt") || !strings.Contains(got, "appears to modify files (rm, > log)") {
+		t.Errorf("Preview = %q", got)
+	}
+	if got := preview("git diff"); !strings.Contains(got, "appears to be read-only") {
+		t.Errorf("Preview = %q", got)
+	}
+	if got := preview("npm run build"); !strings.Contains(got, "Could not tell whether this command modifies files (npm run)") {
+		t.Errorf("Preview = %q", got)
+	}
+}
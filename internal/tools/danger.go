@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DangerChecker is implemented by tools whose calls can be destructive
+// beyond what permission for the tool in general should cover. Dangerous
+// calls are confirmed every time, even if the tool is allowed for the
+// session.
+type DangerChecker interface {
+	// Danger returns why the call is dangerous, or "" if it is not.
+	Danger(input json.RawMessage) string
+}
+
+// Danger implements DangerChecker.
+func (t *BashTool) Danger(input json.RawMessage) string {
+	var params struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return ""
+	}
+	return dangerousCommand(params.Command)
+}
+
+var (
+	// diskDeviceRe matches the paths of disk devices.
+	diskDeviceRe = regexp.MustCompile(`^/dev/(sd|hd|vd|xvd|nvme|mmcblk|disk)`)
+
+	// forkBombRe matches the classic shell fork bomb.
+	forkBombRe = regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}`)
+)
+
+// dangerousCommand returns a description of the first destructive operation
+// in a shell command line, or "" if none is recognized. It is a backstop
+// against obvious mistakes, not a sandbox: commands can always be written in
+// ways it does not recognize.
+func dangerousCommand(command string) string {
+	if forkBombRe.MatchString(command) {
+		return "fork bomb"
+	}
+	for _, cmd := range parseShell(command) {
+		for _, out := range cmd.outputs {
+			if diskDeviceRe.MatchString(out) {
+				return "writes directly to a disk device"
+			}
+		}
+		if reason := dangerousSimpleCommand(cmd.words); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// dangerousSimpleCommand checks one command and its arguments.
+func dangerousSimpleCommand(words []string) string {
+	words = commandWords(words)
+	if len(words) == 0 {
+		return ""
+	}
+	name, args := filepath.Base(words[0]), words[1:]
+
+	switch {
+	case name == "rm" && (hasFlag(args, 'r', "recursive") || hasFlag(args, 'R', "recursive")) && hasFlag(args, 'f', "force"):
+		return "rm -rf deletes files recursively without asking"
+	case name == "git" && len(args) > 0:
+		switch sub, rest := args[0], args[1:]; {
+		case sub == "reset" && slices.Contains(rest, "--hard"):
+			return "git reset --hard discards uncommitted changes"
+		case sub == "clean" && hasFlag(rest, 'f', "force"):
+			return "git clean -f deletes untracked files"
+		case sub == "push" && (hasFlag(rest, 'f', "force") || hasPrefixArg(rest, "--force")):
+			return "git push --force overwrites remote history"
+		case sub == "checkout" && slices.Contains(rest, "--") && slices.Contains(rest, "."):
+			return "git checkout -- . discards uncommitted changes"
+		}
+	case name == "dd" && hasPrefixArg(args, "of="):
+		return "dd overwrites its output file or device"
+	case name == "mkfs" || strings.HasPrefix(name, "mkfs."), name == "wipefs", name == "fdisk", name == "parted":
+		return name + " can erase a disk or partition"
+	case name == "shred":
+		return "shred irrecoverably overwrites files"
+	case name == "find" && (slices.Contains(args, "-delete") || slices.Contains(args, "-exec") && slices.Contains(args, "rm")):
+		return "find deletes every file it matches"
+	case (name == "chmod" || name == "chown") && hasFlag(args, 'R', "recursive") && (slices.Contains(args, "/") || slices.Contains(args, "~")):
+		return name + " -R on the whole filesystem or home directory"
+	case name == "shutdown", name == "reboot", name == "halt", name == "poweroff":
+		return name + " stops the machine"
+	}
+	return ""
+}
+
+// commandWords returns words without leading privilege wrappers and
+// environment assignments, so that they start with the command that runs.
+func commandWords(words []string) []string {
+	for len(words) > 0 && (words[0] == "sudo" || words[0] == "doas" || words[0] == "env" || words[0] == "command" ||
+		(strings.Contains(words[0], "=") && !strings.HasPrefix(words[0], "-"))) {
+		words = words[1:]
+	}
+	return words
+}
+
+// hasFlag reports whether args contain the single-letter flag short, alone
+// or in a group such as -rf, or the long flag --long.
+func hasFlag(args []string, short byte, long string) bool {
+	for _, a := range args {
+		if a == "--" {
+			return false
+		}
+		if a == "--"+long {
+			return true
+		}
+		if len(a) > 1 && a[0] == '-' && a[1] != '-' && strings.IndexByte(a[1:], short) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefixArg reports whether any of args starts with prefix.
+func hasPrefixArg(args []string, prefix string) bool {
+	for _, a := range args {
+		if strings.HasPrefix(a, prefix) {
+			return true
+		}
+	}
+	return false
+}
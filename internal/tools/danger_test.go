@@ -0,0 +1,47 @@
+package tools
+
+import "testing"
+
+func TestDangerousCommand(t *testing.T) {
+	dangerous := []string{
+		"rm -rf build",
+		"cd /tmp && sudo rm -r -f /var/lib/thing",
+		"rm -Rf ~",
+		"rm --recursive --force dist",
+		"git reset --hard HEAD~1",
+		"git clean -fdx",
+		"git push --force origin main",
+		"git push --force-with-lease",
+		"dd if=/dev/zero of=/dev/sda bs=1M",
+		"mkfs.ext4 /dev/sdb1",
+		"cat image > /dev/nvme0n1",
+		"find . -name '*.o' -delete",
+		`find . -type f -exec rm {} \;`,
+		"chmod -R 777 /",
+		"echo $(shutdown -h now)",
+		":(){ :|:& };:",
+	}
+	for _, cmd := range dangerous {
+		if dangerousCommand(cmd) == "" {
+			t.Errorf("dangerousCommand(%q) = \"\", want a warning", cmd)
+		}
+	}
+
+	safe := []string{
+		"rm file.txt",
+		"rm -r build",
+		"ls -rf",
+		"git reset HEAD file",
+		"git push -u origin feature",
+		"git clean -n",
+		"echo 'rm -rf is dangerous' | grep rm",
+		"find . -name '*.go'",
+		"chmod -R 755 scripts",
+		"go test ./...",
+	}
+	for _, cmd := range safe {
+		if got := dangerousCommand(cmd); got != "" {
+			t.Errorf("dangerousCommand(%q) = %q, want \"\"", cmd, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// deletePreviewMaxFiles bounds how many files a directory deletion preview lists.
+	deletePreviewMaxFiles = 20
+
+	// deleteMaxTracked bounds how many files of a deleted directory are
+	// reported as changed.
+	deleteMaxTracked = 1000
+)
+
+// DeleteTool removes files and directories within the working directory.
+type DeleteTool struct {
+	workDir string
+	paths   PathPolicy
+}
+
+// NewDeleteTool creates a new delete tool. Deletions are always confined to
+// workDir.
+func NewDeleteTool(workDir string) *DeleteTool {
+	return &DeleteTool{workDir: workDir, paths: PathPolicy{WorkDir: workDir, Confine: true}}
+}
+
+func (t *DeleteTool) Name() string { return "delete" }
+
+func (t *DeleteTool) Description() string {
+	return "Delete a file or directory within the working directory. Non-empty directories require recursive to be set. Prefer this over running rm through bash."
+}
+
+func (t *DeleteTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "The file or directory to delete (relative to working directory).",
+			},
+			"recursive": {
+				Type:        "boolean",
+				Description: "If true, delete a directory and everything in it. Default is false.",
+			},
+		},
+		Required: []string{"path"},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *DeleteTool) RequiresPermission() bool { return true }
+
+type deleteParams struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// ChangedPaths implements FileChanger. Deleting a directory reports the
+// files inside it.
+func (t *DeleteTool) ChangedPaths(input json.RawMessage) ([]string, error) {
+	var params deleteParams
+	if err := json.Unmarshal(input, &params); err != nil || params.Path == "" {
+		return nil, nil
+	}
+	target, err := t.paths.ResolveEntry(params.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil || !info.IsDir() {
+		return []string{target}, nil
+	}
+	files, _ := listFiles(target, deleteMaxTracked)
+	return files, nil
+}
+
+// Preview implements Previewer.
+func (t *DeleteTool) Preview(input json.RawMessage) (string, error) {
+	var params deleteParams
+	if err := json.Unmarshal(input, &params); err != nil || params.Path == "" {
+		return "", nil
+	}
+	target, err := t.paths.ResolveEntry(params.Path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(t.workDir, target)
+	if err != nil {
+		rel = target
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		return fmt.Sprintf("delete %s (does not exist)", rel), nil
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("delete file %s (%d bytes)", rel, info.Size()), nil
+	}
+
+	files, truncated := listFiles(target, deletePreviewMaxFiles)
+	if len(files) == 0 {
+		return fmt.Sprintf("delete empty directory %s/", rel), nil
+	}
+	if !params.Recursive {
+		return fmt.Sprintf("delete directory %s/ (not empty; will be refused without recursive)", rel), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "delete directory %s/ and everything in it:\n", rel)
+	for _, f := range files {
+		fileRel, err := filepath.Rel(t.workDir, f)
+		if err != nil {
+			fileRel = f
+		}
+		fmt.Fprintf(&b, "  %s\n", fileRel)
+	}
+	if truncated {
+		b.WriteString("  ...\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (t *DeleteTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params deleteParams
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("parsing delete parameters: %w", err)
+	}
+	if params.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	target, err := t.paths.ResolveEntry(params.Path)
+	if err != nil {
+		return "", err
+	}
+	if target == filepath.Clean(t.workDir) {
+		return "", fmt.Errorf("refusing to delete the working directory")
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		return "", fmt.Errorf("reading path: %w", err)
+	}
+	rel, _ := filepath.Rel(t.workDir, target)
+
+	if !info.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return "", fmt.Errorf("deleting file: %w", err)
+		}
+		return fmt.Sprintf("Deleted %s", rel), nil
+	}
+
+	if !params.Recursive {
+		if err := os.Remove(target); err != nil {
+			return "", fmt.Errorf("deleting directory %s: not empty (set recursive to delete its contents)", rel)
+		}
+		return fmt.Sprintf("Deleted empty directory %s", rel), nil
+	}
+
+	if err := os.RemoveAll(target); err != nil {
+		return "", fmt.Errorf("deleting directory: %w", err)
+	}
+	return fmt.Sprintf("Deleted directory %s and its contents", rel), nil
+}
+
+// listFiles returns up to limit regular files beneath dir in walk order,
+// and whether more were found.
+func listFiles(dir string, limit int) ([]string, bool) {
+	var files []string
+	truncated := false
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if len(files) >= limit {
+			truncated = true
+			return filepath.SkipAll
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, truncated
+}
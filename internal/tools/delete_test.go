@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDeleteTool(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewDeleteTool(dir)
+	input := func(params map[string]any) json.RawMessage {
+		data, _ := json.Marshal(params)
+		return data
+	}
+	for _, name := range []string{"notes.txt", "build/a.o", "build/obj/b.o"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting a directory reports the files inside it as changed.
+	paths, err := tool.ChangedPaths(input(map[string]any{"path": "build", "recursive": true}))
+	want := []string{filepath.Join(dir, "build/a.o"), filepath.Join(dir, "build/obj/b.o")}
+	if err != nil || !slices.Equal(paths, want) {
+		t.Errorf("ChangedPaths = %q, %v, want %q", paths, err, want)
+	}
+
+	for _, tt := range []struct {
+		params map[string]any
+		want   string
+	}{
+		{map[string]any{"path": "notes.txt"}, "Deleted notes.txt"},
+		{map[string]any{"path": "empty"}, "Deleted empty directory empty"},
+		{map[string]any{"path": "build", "recursive": true}, "Deleted directory build and its contents"},
+	} {
+		out, err := tool.Execute(context.Background(), input(tt.params))
+		if err != nil || out != tt.want {
+			t.Errorf("delete %v = %q, %v, want %q", tt.params, out, err, tt.want)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("%d entries left after deleting everything", len(entries))
+	}
+}
+
+func TestDeleteToolRefusals(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewDeleteTool(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		params  map[string]any
+		wantErr string
+	}{
+		{map[string]any{"path": "src"}, "not empty"},
+		{map[string]any{"path": ".", "recursive": true}, "working directory"},
+		{map[string]any{"path": "../", "recursive": true}, "outside the working directory"},
+		{map[string]any{"path": "missing.txt"}, "reading path"},
+	} {
+		input, _ := json.Marshal(tt.params)
+		if _, err := tool.Execute(context.Background(), input); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("delete %v: error %v, want %q", tt.params, err, tt.wantErr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "main.go")); err != nil {
+		t.Errorf("refused deletes removed files: %v", err)
+	}
+}
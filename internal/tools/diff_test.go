@@ -0,0 +1,65 @@
+package tools
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", ""},
+		{
+			"single change",
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+		},
+		{
+			"append",
+			"a\n",
+			"a\nb\n",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,1 +1,2 @@\n a\n+b",
+		},
+		{
+			"context is trimmed",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\n2\n3\n4\n5\n6\n7\nX\n",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -5,4 +5,4 @@\n 5\n 6\n 7\n-8\n+X",
+		},
+		{
+			"separate hunks",
+			"a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			"A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B",
+		},
+		{
+			"newline removed at end",
+			"a\nb\n",
+			"a\nb",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file",
+		},
+		{
+			"newline added at end",
+			"a\nb",
+			"a\nb\n",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b",
+		},
+		{
+			"change before a missing newline",
+			"a\nb",
+			"A\nb",
+			"--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n\\ No newline at end of file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("f.txt", tt.old, tt.new)
+			if got != tt.expected {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
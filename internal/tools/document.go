@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// maxPDFText caps the amount of text extracted from a PDF.
+const maxPDFText = 1 << 20
+
+// textContentTypes are sniffed content types that are safe to show as text
+// even though they are not text/*.
+var textContentTypes = []string{"application/json", "application/xml", "application/javascript"}
+
+// isBinaryContent reports whether a file should be described rather than
+// shown, based on its sniffed content type and leading bytes.
+func isBinaryContent(contentType string, head []byte) bool {
+	if strings.HasPrefix(contentType, "text/") {
+		return false
+	}
+	for _, t := range textContentTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	if contentType == "application/octet-stream" {
+		// Unknown types are only binary if they contain NUL bytes;
+		// DetectContentType is conservative with unusual text encodings.
+		return bytes.IndexByte(head, 0) >= 0
+	}
+	return true
+}
+
+// extractPDFText returns the plain text content of a PDF.
+func extractPDFText(r io.ReaderAt, size int64) (text string, err error) {
+	// The PDF parser can panic on malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return "", fmt.Errorf("opening PDF: %w", err)
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("extracting PDF text: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(plain, maxPDFText))
+	if err != nil {
+		return "", fmt.Errorf("extracting PDF text: %w", err)
+	}
+	return string(data), nil
+}
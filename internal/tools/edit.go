@@ -11,12 +11,13 @@ import (
 
 // EditTool performs find-and-replace edits on files.
 type EditTool struct {
-	workDir string
+	paths PathPolicy
+	opts  WriteOptions
 }
 
 // NewEditTool creates a new edit tool.
-func NewEditTool(workDir string) *EditTool {
-	return &EditTool{workDir: workDir}
+func NewEditTool(paths PathPolicy, opts WriteOptions) *EditTool {
+	return &EditTool{paths: paths, opts: opts}
 }
 
 func (t *EditTool) Name() string { return "edit" }
@@ -54,7 +55,38 @@ func (t *EditTool) Parameters() json.RawMessage {
 
 func (t *EditTool) RequiresPermission() bool { return true }
 
-func (t *EditTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+// ChangedPaths implements FileChanger.
+func (t *EditTool) ChangedPaths(input json.RawMessage) ([]string, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || params.FilePath == "" {
+		return nil, nil
+	}
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return []string{filePath}, nil
+}
+
+// Propose implements Reviewer.
+func (t *EditTool) Propose(input json.RawMessage) (FileProposal, error) {
+	filePath, original, newContent, err := t.apply(input)
+	if err != nil {
+		return FileProposal{}, err
+	}
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
+	return FileProposal{
+		Path:    filePath,
+		Content: newContent,
+		Diff:    UnifiedDiff(relPath, original, newContent),
+	}, nil
+}
+
+// apply resolves the file an edit call targets and returns its current and
+// edited content, without writing anything.
+func (t *EditTool) apply(input json.RawMessage) (filePath, original, newContent string, err error) {
 	var params struct {
 		FilePath   string `json:"file_path"`
 		OldString  string `json:"old_string"`
@@ -62,45 +94,87 @@ func (t *EditTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		ReplaceAll bool   `json:"replace_all"`
 	}
 	if err := json.Unmarshal(input, &params); err != nil {
-		return "", fmt.Errorf("parsing edit parameters: %w", err)
+		return "", "", "", fmt.Errorf("parsing edit parameters: %w", err)
 	}
 
-	filePath := params.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(t.workDir, filePath)
+	filePath, err = t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return "", "", "", err
 	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("reading file: %w", err)
+		return "", "", "", fmt.Errorf("reading file: %w", err)
 	}
 
-	original := string(content)
+	original = string(content)
 
-	if !strings.Contains(original, params.OldString) {
-		return "", fmt.Errorf("oldString not found in %s", params.FilePath)
+	// In a CRLF file, match and replace on LF text, then restore CRLF, since
+	// the strings in the call usually use LF.
+	text, oldString, newString := original, params.OldString, params.NewString
+	crlf := t.opts.PreserveLineEndings && usesCRLF(original)
+	if crlf {
+		text, oldString, newString = toLF(text), toLF(oldString), toLF(newString)
+	}
+
+	// Let oldString end with a newline the file lacks at EOF; the missing
+	// newline is restored below.
+	trailing := strings.HasSuffix(text, "\n")
+	if !trailing && !strings.Contains(text, oldString) && strings.HasSuffix(text+"\n", oldString) {
+		text += "\n"
+	}
+
+	if !strings.Contains(text, oldString) {
+		return "", "", "", fmt.Errorf("oldString not found in %s", params.FilePath)
 	}
 
-	var newContent string
 	if params.ReplaceAll {
-		newContent = strings.ReplaceAll(original, params.OldString, params.NewString)
+		newContent = strings.ReplaceAll(text, oldString, newString)
 	} else {
 		// Check for multiple matches when not using replace_all
-		count := strings.Count(original, params.OldString)
+		count := strings.Count(text, oldString)
 		if count > 1 {
-			return "", fmt.Errorf("found %d matches for oldString in %s. Use replace_all=true to replace all, or provide more context to make the match unique", count, params.FilePath)
+			return "", "", "", fmt.Errorf("found %d matches for oldString in %s. Use replace_all=true to replace all, or provide more context to make the match unique", count, params.FilePath)
 		}
-		newContent = strings.Replace(original, params.OldString, params.NewString, 1)
+		newContent = strings.Replace(text, oldString, newString, 1)
+	}
+	newContent = keepTrailingNewline(newContent, trailing)
+	if crlf {
+		newContent = toCRLF(newContent)
+	}
+	return filePath, original, newContent, nil
+}
+
+func (t *EditTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	filePath, original, newContent, err := t.apply(input)
+	if err != nil {
+		return "", err
 	}
 
 	if newContent == original {
-		return "No changes made (old_string equals new_string).", nil
+		return "No changes made (the edit leaves the file unchanged).", nil
 	}
 
-	if err := os.WriteFile(filePath, []byte(newContent), 0o644); err != nil {
+	if err := writeFile(filePath, []byte(newContent), 0o644, t.opts); err != nil {
 		return "", fmt.Errorf("writing file: %w", err)
 	}
 
-	relPath, _ := filepath.Rel(t.workDir, filePath)
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
 	return fmt.Sprintf("Successfully edited %s", relPath), nil
 }
+
+// keepTrailingNewline adds or removes the newline at the end of text so that
+// it ends with one exactly when trailing is set. Whether a file ends with a
+// newline is rarely what an edit means to change, and old and new strings
+// often disagree about it by accident.
+func keepTrailingNewline(text string, trailing bool) string {
+	switch {
+	case text == "":
+		return text
+	case trailing && !strings.HasSuffix(text, "\n"):
+		return text + "\n"
+	case !trailing:
+		return strings.TrimSuffix(text, "\n")
+	}
+	return text
+}
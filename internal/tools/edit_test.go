@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditKeepsTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		old, new string
+		want     string
+	}{
+		{"new string drops the final newline", "a\nb\n", "b\n", "c", "a\nc\n"},
+		{"new string adds a final newline", "a\nb", "b", "c\n", "a\nc"},
+		{"old string has a newline the file lacks", "a\nb", "b\n", "c\n", "a\nc"},
+		{"CRLF file", "a\r\nb\r\n", "b\n", "c", "a\r\nc\r\n"},
+		{"middle of the file", "a\nb\nc", "a\n", "", "b\nc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "f.txt")
+			if err := os.WriteFile(target, []byte(tt.original), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			tool := NewEditTool(PathPolicy{WorkDir: dir, Confine: true}, WriteOptions{PreserveLineEndings: true})
+			input, _ := json.Marshal(map[string]string{"file_path": "f.txt", "old_string": tt.old, "new_string": tt.new})
+			if _, err := tool.Execute(context.Background(), input); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
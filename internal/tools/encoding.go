@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// textDecoder converts tool output that may be in a legacy encoding, such as
+// Latin-1 command output or a Windows-1252 file, to UTF-8. The encoding is
+// determined once, from the first chunk of text that needs it, and used for
+// every later chunk.
+type textDecoder struct {
+	contentType string // declared Content-Type, if any
+
+	enc   encoding.Encoding // nil until determined
+	name  string            // canonical name of enc
+	from  string            // encoding the text was converted from, if any
+	lossy bool              // invalid bytes were replaced
+}
+
+// newTextDecoder creates a decoder that honors the charset of contentType,
+// which may be empty.
+func newTextDecoder(contentType string) *textDecoder {
+	return &textDecoder{contentType: contentType}
+}
+
+// decode returns data as UTF-8. Valid UTF-8 is returned unchanged unless
+// another charset is declared or data starts with a byte order mark. Bytes
+// that cannot be decoded are replaced with U+FFFD.
+func (d *textDecoder) decode(data []byte) string {
+	if d.enc == nil {
+		enc, name, certain := charset.DetermineEncoding(data, d.contentType)
+		if !certain && utf8.Valid(data) {
+			return string(data)
+		}
+		d.enc, d.name = enc, name
+	}
+
+	if d.name == "utf-8" {
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		d.lossy = true
+		return strings.ToValidUTF8(string(data), string(utf8.RuneError))
+	}
+
+	out, _, err := transform.Bytes(unicode.BOMOverride(d.enc.NewDecoder()), data)
+	if err != nil {
+		d.lossy = true
+		return strings.ToValidUTF8(string(data), string(utf8.RuneError))
+	}
+	if !bytes.Equal(out, data) {
+		d.from = d.name
+	}
+	return string(out)
+}
+
+// newReader returns r converted to UTF-8 if head, the start of its content,
+// begins with a UTF-16 byte order mark, since UTF-16 text cannot be split
+// into lines before it is decoded. Otherwise r is returned unchanged.
+func (d *textDecoder) newReader(r io.Reader, head []byte) io.Reader {
+	enc, name, _ := charset.DetermineEncoding(head, "")
+	if !strings.HasPrefix(name, "utf-16") {
+		return r
+	}
+	d.enc, d.name, d.from = unicode.UTF8, "utf-8", name
+	return transform.NewReader(r, unicode.BOMOverride(enc.NewDecoder()))
+}
+
+// note describes any conversion done, for appending to the tool output. It
+// is empty if the text was already valid UTF-8.
+func (d *textDecoder) note() string {
+	switch {
+	case d.lossy:
+		return "(not valid UTF-8 and the encoding could not be determined; invalid bytes were replaced)"
+	case d.from != "":
+		return fmt.Sprintf("(converted to UTF-8 from %s)", d.from)
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextDecoder(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        string
+		want        string
+		wantNote    string
+	}{
+		{"utf-8", "", "café", "café", ""},
+		{"latin-1 detected", "", "caf\xe9", "café", "windows-1252"},
+		{"declared charset", "text/plain; charset=ISO-8859-2", "\xb1", "ą", "iso-8859-2"},
+		{"declared but ascii", "text/html; charset=iso-8859-1", "plain", "plain", ""},
+		{"invalid declared utf-8", "text/plain; charset=utf-8", "caf\xe9", "caf�", "replaced"},
+		{"utf-16 bom", "", "\xff\xfeh\x00i\x00", "hi", "utf-16le"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newTextDecoder(tt.contentType)
+			if got := dec.decode([]byte(tt.data)); got != tt.want {
+				t.Errorf("decode = %q, want %q", got, tt.want)
+			}
+			note := dec.note()
+			if (tt.wantNote == "") != (note == "") || !strings.Contains(note, tt.wantNote) {
+				t.Errorf("note = %q, want it to mention %q", note, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestViewLegacyEncodings(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"latin1.txt": "first\ncaf\xe9\n",
+		"utf16.txt":  "\xff\xfea\x00\n\x00b\x00\n\x00",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tool := NewViewTool(PathPolicy{WorkDir: dir}, 0)
+
+	out, err := tool.Execute(context.Background(), []byte(`{"file_path":"latin1.txt"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1: first\n2: café\n(converted to UTF-8 from windows-1252)"; out != want {
+		t.Errorf("latin1.txt = %q, want %q", out, want)
+	}
+
+	out, err = tool.Execute(context.Background(), []byte(`{"file_path":"utf16.txt"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1: a\n2: b\n(converted to UTF-8 from utf-16le)"; out != want {
+		t.Errorf("utf16.txt = %q, want %q", out, want)
+	}
+}
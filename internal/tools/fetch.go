@@ -11,11 +11,17 @@ import (
 )
 
 // FetchTool fetches content from URLs.
-type FetchTool struct{}
+type FetchTool struct {
+	client *http.Client
+}
 
-// NewFetchTool creates a new fetch tool.
-func NewFetchTool() *FetchTool {
-	return &FetchTool{}
+// NewFetchTool creates a new fetch tool. If client is nil, http.DefaultClient
+// is used.
+func NewFetchTool(client *http.Client) *FetchTool {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &FetchTool{client: client}
 }
 
 func (t *FetchTool) Name() string { return "fetch" }
@@ -64,9 +70,8 @@ func (t *FetchTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		url = "https://" + url
 	}
 
-	client := &http.Client{
-		Timeout: time.Duration(params.Timeout) * time.Second,
-	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Second)
+	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -74,26 +79,34 @@ func (t *FetchTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	}
 	req.Header.Set("User-Agent", "goder/1.0")
 
-	resp, err := client.Do(req)
+	resp, err := t.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("fetching URL: %w", err)
+		return "", transient(fmt.Errorf("fetching URL: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			err = transient(err)
+		}
+		return "", err
 	}
 
 	// Limit reading to 1MB
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		return "", fmt.Errorf("reading response: %w", err)
+		return "", transient(fmt.Errorf("reading response: %w", err))
 	}
 
-	result := string(body)
-	if len(result) == 0 {
+	if len(body) == 0 {
 		return "(empty response)", nil
 	}
 
+	dec := newTextDecoder(resp.Header.Get("Content-Type"))
+	result := dec.decode(body)
+	if note := dec.note(); note != "" {
+		result += "\n\n" + note
+	}
 	return result, nil
 }
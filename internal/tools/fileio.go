@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeBufferSize is the buffer used when writing files.
+const writeBufferSize = 64 * 1024
+
+// renameFile moves the finished temporary file over the target. Tests
+// replace it to simulate a failure before the target is touched.
+var renameFile = os.Rename
+
+// WriteOptions controls how the file tools write files.
+type WriteOptions struct {
+	// Atomic writes each file to a temporary file in the same directory and
+	// renames it over the target, so a failure part way through leaves the
+	// original untouched instead of truncated.
+	Atomic bool
+
+	// PreserveLineEndings keeps the line endings of a file that uses CRLF:
+	// edits match and write text in its style, and overwrites are converted
+	// to it.
+	PreserveLineEndings bool
+}
+
+// matchLineEndings returns content with its line breaks converted to CRLF if
+// PreserveLineEndings is set and existing, the current file content, mostly
+// uses CRLF. Otherwise content is returned unchanged.
+func (o WriteOptions) matchLineEndings(existing, content string) string {
+	if !o.PreserveLineEndings || !usesCRLF(existing) {
+		return content
+	}
+	return toCRLF(content)
+}
+
+// usesCRLF reports whether most line breaks in text are CRLF.
+func usesCRLF(text string) bool {
+	crlf := strings.Count(text, "\r\n")
+	return crlf > 0 && crlf >= strings.Count(text, "\n")-crlf
+}
+
+// toLF converts CRLF line breaks in text to LF.
+func toLF(text string) string {
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// toCRLF converts the line breaks in text, which may mix LF and CRLF, to
+// CRLF.
+func toCRLF(text string) string {
+	return strings.ReplaceAll(toLF(text), "\n", "\r\n")
+}
+
+// writeFile writes data to path, creating it with perm if it does not exist.
+// An existing file keeps its permissions.
+func writeFile(path string, data []byte, perm fs.FileMode, opts WriteOptions) error {
+	if !opts.Atomic {
+		return os.WriteFile(path, data, perm)
+	}
+
+	// Write through a symlink rather than replacing it.
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	w := bufio.NewWriterSize(f, writeBufferSize)
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := renameFile(tmp, path); err != nil {
+		return fmt.Errorf("replacing %s: %w", filepath.Base(path), err)
+	}
+	committed = true
+	return nil
+}
+
+// formatSize formats a byte count for display, e.g. "512 bytes" or "1.5 MB".
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d bytes", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
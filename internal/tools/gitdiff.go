@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// gitDiffTimeout bounds each git invocation.
+	gitDiffTimeout = 30 * time.Second
+
+	// gitDiffMaxOutput caps the diff returned to the model, in bytes.
+	gitDiffMaxOutput = 50000
+)
+
+// GitDiffTool shows uncommitted changes relative to HEAD, so the agent can
+// review its own work.
+type GitDiffTool struct {
+	workDir string
+}
+
+// NewGitDiffTool creates a new git diff tool.
+func NewGitDiffTool(workDir string) *GitDiffTool {
+	return &GitDiffTool{workDir: workDir}
+}
+
+func (t *GitDiffTool) Name() string { return "gitdiff" }
+
+func (t *GitDiffTool) Description() string {
+	return "Show uncommitted changes in the git repository relative to HEAD: a per-file summary, the unified diff, and any new untracked files. Use this to review your changes before declaring a task done."
+}
+
+func (t *GitDiffTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Optional file or directory to limit the diff to, relative to the working directory.",
+			},
+			"staged": {
+				Type:        "boolean",
+				Description: "If true, show only staged changes instead of all changes since HEAD.",
+			},
+		},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *GitDiffTool) RequiresPermission() bool { return false }
+
+func (t *GitDiffTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params struct {
+		Path   string `json:"path"`
+		Staged bool   `json:"staged"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("parsing gitdiff parameters: %w", err)
+	}
+	if strings.HasPrefix(params.Path, "-") {
+		return "", fmt.Errorf("invalid path")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git not found in PATH")
+	}
+	if _, err := t.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return "", fmt.Errorf("no HEAD commit to diff against (not a git repository, or no commits yet)")
+	}
+
+	var pathspec []string
+	if params.Path != "" {
+		pathspec = []string{"--", params.Path}
+	}
+	base := []string{"diff", "--no-color", "--no-ext-diff"}
+	if params.Staged {
+		base = append(base, "--cached")
+	}
+	base = append(base, "HEAD")
+
+	stat, err := t.git(ctx, append(append(base, "--stat"), pathspec...)...)
+	if err != nil {
+		return "", err
+	}
+	diff, err := t.git(ctx, append(base, pathspec...)...)
+	if err != nil {
+		return "", err
+	}
+
+	var untracked string
+	if !params.Staged {
+		untracked, err = t.git(ctx, append([]string{"ls-files", "--others", "--exclude-standard"}, pathspec...)...)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if strings.TrimSpace(diff) == "" && strings.TrimSpace(untracked) == "" {
+		return "No changes relative to HEAD.", nil
+	}
+
+	var b strings.Builder
+	if s := strings.TrimSpace(stat); s != "" {
+		b.WriteString(s + "\n\n")
+	}
+	if len(diff) > gitDiffMaxOutput {
+		b.WriteString(diff[:gitDiffMaxOutput])
+		b.WriteString("\n... (diff truncated; pass a path to see one file at a time)\n")
+	} else {
+		b.WriteString(diff)
+	}
+	if u := strings.TrimSpace(untracked); u != "" {
+		b.WriteString("\nUntracked files (not in the diff):\n" + u + "\n")
+	}
+	return b.String(), nil
+}
+
+// git runs a git command in the working directory and returns its stdout.
+func (t *GitDiffTool) git(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitDiffTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = t.workDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("git %s timed out after %s", args[0], gitDiffTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitDiffTool(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@t", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@t")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tool := NewGitDiffTool(dir)
+	run("init", "-q")
+	if _, err := tool.Execute(context.Background(), []byte(`{}`)); err == nil {
+		t.Error("expected an error before the first commit")
+	}
+
+	write("main.go", "package main\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+
+	out, err := tool.Execute(context.Background(), []byte(`{}`))
+	if err != nil || out != "No changes relative to HEAD." {
+		t.Fatalf("clean tree: %q, %v", out, err)
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("new.go", "package main\n")
+	out, err = tool.Execute(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"main.go | 2 ++", "+func main() {}", "Untracked files (not in the diff):\nnew.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Nothing is staged yet.
+	out, err = tool.Execute(context.Background(), []byte(`{"staged":true}`))
+	if err != nil || out != "No changes relative to HEAD." {
+		t.Errorf("staged: %q, %v", out, err)
+	}
+}
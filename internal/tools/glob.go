@@ -6,18 +6,17 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
-
-	"github.com/bmatcuk/doublestar/v4"
 )
 
 // GlobTool finds files matching a glob pattern.
 type GlobTool struct {
-	workDir string
+	paths  PathPolicy
+	ignore *IgnoreList
 }
 
-// NewGlobTool creates a new glob tool.
-func NewGlobTool(workDir string) *GlobTool {
-	return &GlobTool{workDir: workDir}
+// NewGlobTool creates a new glob tool. Paths matched by ignore are skipped.
+func NewGlobTool(paths PathPolicy, ignore *IgnoreList) *GlobTool {
+	return &GlobTool{paths: paths, ignore: ignore}
 }
 
 func (t *GlobTool) Name() string { return "glob" }
@@ -56,17 +55,12 @@ func (t *GlobTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return "", fmt.Errorf("parsing glob parameters: %w", err)
 	}
 
-	baseDir := t.workDir
-	if params.Path != "" {
-		if filepath.IsAbs(params.Path) {
-			baseDir = params.Path
-		} else {
-			baseDir = filepath.Join(t.workDir, params.Path)
-		}
+	baseDir, err := t.paths.ResolveRead(params.Path)
+	if err != nil {
+		return "", err
 	}
 
-	pattern := filepath.Join(baseDir, params.Pattern)
-	matches, err := doublestar.FilepathGlob(pattern)
+	matches, err := walkMatches(ctx, t.paths, baseDir, params.Pattern, t.ignore, true)
 	if err != nil {
 		return "", fmt.Errorf("glob error: %w", err)
 	}
@@ -78,7 +72,7 @@ func (t *GlobTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	// Make paths relative to workDir for cleaner output
 	var relative []string
 	for _, m := range matches {
-		rel, err := filepath.Rel(t.workDir, m)
+		rel, err := filepath.Rel(t.paths.WorkDir, m)
 		if err != nil {
 			rel = m
 		}
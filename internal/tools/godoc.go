@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// goDocTimeout bounds a single go doc invocation.
+const goDocTimeout = 30 * time.Second
+
+// GoDocTool shows Go package and symbol documentation via `go doc`.
+type GoDocTool struct {
+	workDir string
+}
+
+// NewGoDocTool creates a new Go doc tool.
+func NewGoDocTool(workDir string) *GoDocTool {
+	return &GoDocTool{workDir: workDir}
+}
+
+func (t *GoDocTool) Name() string { return "godoc" }
+
+func (t *GoDocTool) Description() string {
+	return "Show Go documentation for a package or symbol using `go doc`, resolved against the current module and its dependencies. Use this to check real APIs instead of guessing."
+}
+
+func (t *GoDocTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"package": {
+				Type:        "string",
+				Description: "The package import path (e.g. \"net/http\", \"github.com/charmbracelet/lipgloss\").",
+			},
+			"symbol": {
+				Type:        "string",
+				Description: "Optional symbol within the package (e.g. \"Client\", \"Client.Do\").",
+			},
+			"all": {
+				Type:        "boolean",
+				Description: "If true, show documentation for all exported symbols in the package.",
+			},
+		},
+		Required: []string{"package"},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *GoDocTool) RequiresPermission() bool { return false }
+
+func (t *GoDocTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params struct {
+		Package string `json:"package"`
+		Symbol  string `json:"symbol"`
+		All     bool   `json:"all"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("parsing godoc parameters: %w", err)
+	}
+	if params.Package == "" {
+		return "", fmt.Errorf("package is required")
+	}
+	if strings.HasPrefix(params.Package, "-") || strings.HasPrefix(params.Symbol, "-") {
+		return "", fmt.Errorf("invalid package or symbol")
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		return "", fmt.Errorf("go toolchain not found in PATH")
+	}
+
+	target := params.Package
+	if params.Symbol != "" {
+		target += "." + params.Symbol
+	}
+
+	args := []string{"doc"}
+	if params.All {
+		args = append(args, "-all")
+	}
+	args = append(args, target)
+
+	ctx, cancel := context.WithTimeout(ctx, goDocTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = t.workDir
+	// Never reach out to the network; missing packages are reported instead.
+	cmd.Env = append(os.Environ(), "GOPROXY=off")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("go doc timed out after %s", goDocTimeout)
+		}
+		msg := goDocError(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("go doc failed: %w", err)
+		}
+		if isMissingPackage(msg) && !strings.Contains(msg, "go get") {
+			return "", fmt.Errorf("%s\npackage %s is not available to this module; add it with `go get %s` first", msg, params.Package, params.Package)
+		}
+		return "", fmt.Errorf("%s", msg)
+	}
+
+	output := stdout.String()
+	const maxOutput = 50000
+	if len(output) > maxOutput {
+		output = output[:maxOutput] + "\n... (output truncated)"
+	}
+	if strings.TrimSpace(output) == "" {
+		return "(no documentation)", nil
+	}
+	return output, nil
+}
+
+// goDocError extracts the meaningful lines from go doc's stderr, dropping the
+// noise produced by running with GOPROXY=off.
+func goDocError(stderr string) string {
+	var lines []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.TrimSpace(line) == "" || strings.Contains(line, "module lookup disabled by GOPROXY=off") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isMissingPackage reports whether a go doc error means the package could
+// not be found in the module or the module cache.
+func isMissingPackage(msg string) bool {
+	for _, s := range []string{
+		"no required module provides package",
+		"cannot find module providing package",
+		"is not in std",
+		"no Go files in",
+		"missing go.sum entry",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGoDocTool(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not in PATH")
+	}
+	tool := NewGoDocTool(t.TempDir())
+	godoc := func(params map[string]any) (string, error) {
+		input, _ := json.Marshal(params)
+		return tool.Execute(context.Background(), input)
+	}
+
+	out, err := godoc(map[string]any{"package": "strings", "symbol": "Cut"})
+	if err != nil || !strings.Contains(out, "func Cut(s, sep string)") {
+		t.Errorf("go doc strings.Cut = %q, %v", out, err)
+	}
+
+	_, err = godoc(map[string]any{"package": "example.com/not/a/module"})
+	if err == nil || !strings.Contains(err.Error(), "go get example.com/not/a/module") {
+		t.Errorf("missing package: error = %v, want a go get hint", err)
+	}
+
+	for _, params := range []map[string]any{
+		{"package": ""},
+		{"package": "-u"},
+		{"package": "strings", "symbol": "-all"},
+	} {
+		if _, err := godoc(params); err == nil {
+			t.Errorf("go doc %v succeeded, want it refused", params)
+		}
+	}
+}
+
+func TestGoDocError(t *testing.T) {
+	stderr := "go: module lookup disabled by GOPROXY=off\n\ndoc: no required module provides package example.com/x\n"
+	msg := goDocError(stderr)
+	if msg != "doc: no required module provides package example.com/x" {
+		t.Errorf("goDocError = %q", msg)
+	}
+	if !isMissingPackage(msg) {
+		t.Errorf("isMissingPackage(%q) = false", msg)
+	}
+	if isMissingPackage("doc: no symbol Foo in package strings") {
+		t.Error("a missing symbol was reported as a missing package")
+	}
+}
@@ -9,18 +9,17 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-
-	"github.com/bmatcuk/doublestar/v4"
 )
 
 // GrepTool searches file contents using regular expressions.
 type GrepTool struct {
-	workDir string
+	paths  PathPolicy
+	ignore *IgnoreList
 }
 
-// NewGrepTool creates a new grep tool.
-func NewGrepTool(workDir string) *GrepTool {
-	return &GrepTool{workDir: workDir}
+// NewGrepTool creates a new grep tool. Paths matched by ignore are skipped.
+func NewGrepTool(paths PathPolicy, ignore *IgnoreList) *GrepTool {
+	return &GrepTool{paths: paths, ignore: ignore}
 }
 
 func (t *GrepTool) Name() string { return "grep" }
@@ -69,13 +68,9 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return "", fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
-	baseDir := t.workDir
-	if params.Path != "" {
-		if filepath.IsAbs(params.Path) {
-			baseDir = params.Path
-		} else {
-			baseDir = filepath.Join(t.workDir, params.Path)
-		}
+	baseDir, err := t.paths.ResolveRead(params.Path)
+	if err != nil {
+		return "", err
 	}
 
 	// Find files to search
@@ -84,8 +79,7 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		filePattern = "**/" + params.Include
 	}
 
-	fullPattern := filepath.Join(baseDir, filePattern)
-	files, err := doublestar.FilepathGlob(fullPattern)
+	files, err := walkMatches(ctx, t.paths, baseDir, filePattern, t.ignore, false)
 	if err != nil {
 		return "", fmt.Errorf("finding files: %w", err)
 	}
@@ -98,6 +92,11 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 			break
 		}
 
+		// Reading follows symlinks, so check where the file really is.
+		if !t.paths.Readable(filePath) {
+			continue
+		}
+
 		// Skip directories and binary files
 		info, err := os.Stat(filePath)
 		if err != nil || info.IsDir() {
@@ -113,7 +112,7 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 			continue
 		}
 
-		relPath, _ := filepath.Rel(t.workDir, filePath)
+		relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
 		scanner := bufio.NewScanner(f)
 		lineNum := 0
 		for scanner.Scan() {
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchToolsSkipEscapingSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("TOPSECRET=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "inside.txt"), []byte("TOPSECRET=0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "inside.txt"), filepath.Join(root, "alias.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := PathPolicy{WorkDir: root, Confine: true}
+	run := func(tool Tool, input string) string {
+		t.Helper()
+		out, err := tool.Execute(context.Background(), json.RawMessage(input))
+		if err != nil {
+			t.Fatalf("%s: %v", tool.Name(), err)
+		}
+		return out
+	}
+
+	grep := run(NewGrepTool(paths, nil), `{"pattern":"TOPSECRET"}`)
+	if strings.Contains(grep, "TOPSECRET=1") || !strings.Contains(grep, "alias.txt:1: TOPSECRET=0") {
+		t.Errorf("grep output:\n%s\nwant the in-tree files only", grep)
+	}
+	glob := run(NewGlobTool(paths, nil), `{"pattern":"*.txt"}`)
+	if strings.Contains(glob, "link.txt") || !strings.Contains(glob, "alias.txt") {
+		t.Errorf("glob output:\n%s\nwant the escaping symlink left out", glob)
+	}
+	ls := run(NewLsTool(paths, nil), `{}`)
+	if strings.Contains(ls, "link.txt") || !strings.Contains(ls, "alias.txt") {
+		t.Errorf("ls output:\n%s\nwant the escaping symlink left out", ls)
+	}
+
+	// Unconfined, the symlink is followed as before.
+	if out := run(NewGrepTool(PathPolicy{WorkDir: root}, nil), `{"pattern":"TOPSECRET=1"}`); !strings.Contains(out, "link.txt:1:") {
+		t.Errorf("unconfined grep output:\n%s\nwant the symlinked file searched", out)
+	}
+}
@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFileName is the project-local file listing additional ignore patterns.
+const IgnoreFileName = ".goderignore"
+
+// defaultIgnorePatterns are always excluded from filesystem tool results.
+var defaultIgnorePatterns = []string{
+	".git/",
+	".hg/",
+	".svn/",
+	"node_modules/",
+	"__pycache__/",
+	".DS_Store",
+}
+
+// IgnoreList matches paths that filesystem tools should skip.
+//
+// Patterns use gitignore-like semantics: a pattern without a slash matches
+// any path component by name, a pattern containing a slash matches the path
+// relative to the root, and a trailing slash restricts it to directories.
+// Everything beneath an ignored directory is ignored as well.
+type IgnoreList struct {
+	root     string
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob     string
+	anchored bool
+	dirOnly  bool
+}
+
+// NewIgnoreList creates an ignore list rooted at root from the given patterns.
+// Blank patterns and patterns that are not valid globs are dropped.
+func NewIgnoreList(root string, patterns []string) *IgnoreList {
+	l := &IgnoreList{root: root}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		var ip ignorePattern
+		if strings.HasSuffix(p, "/") {
+			ip.dirOnly = true
+			p = strings.TrimRight(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			ip.anchored = true
+			p = strings.TrimPrefix(p, "/")
+		}
+		if p == "" || !doublestar.ValidatePattern(p) {
+			continue
+		}
+		ip.glob = p
+		l.patterns = append(l.patterns, ip)
+	}
+	return l
+}
+
+// LoadIgnoreList builds the ignore list for workDir from the built-in
+// defaults, the configured patterns, and the project's .goderignore file.
+func LoadIgnoreList(workDir string, configured []string) (*IgnoreList, error) {
+	patterns := append([]string{}, defaultIgnorePatterns...)
+	patterns = append(patterns, configured...)
+
+	fromFile, err := readIgnoreFile(filepath.Join(workDir, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	patterns = append(patterns, fromFile...)
+
+	return NewIgnoreList(workDir, patterns), nil
+}
+
+// readIgnoreFile returns the patterns in path, one per line. A missing file
+// yields no patterns.
+func readIgnoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", IgnoreFileName, err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", IgnoreFileName, err)
+	}
+	return patterns, nil
+}
+
+// Match reports whether path, or any directory containing it, is ignored.
+// isDir reports whether path itself is a directory. A nil list matches nothing.
+func (l *IgnoreList) Match(path string, isDir bool) bool {
+	if l == nil || len(l.patterns) == 0 {
+		return false
+	}
+
+	rel := path
+	if filepath.IsAbs(path) {
+		if r, err := filepath.Rel(l.root, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+		} else {
+			rel = strings.TrimPrefix(path, string(filepath.Separator))
+		}
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		componentIsDir := isDir || i < len(parts)-1
+		prefix := strings.Join(parts[:i+1], "/")
+		for _, p := range l.patterns {
+			if p.dirOnly && !componentIsDir {
+				continue
+			}
+			name := parts[i]
+			if p.anchored {
+				name = prefix
+			}
+			if doublestar.MatchUnvalidated(p.glob, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkMatches walks baseDir and returns the paths whose location relative to
+// baseDir matches pattern, skipping anything in ignore. Ignored directories are pruned
+// rather than walked and filtered afterwards. Directories are only returned
+// when includeDirs is set.
+//
+// As when patterns were joined onto baseDir, "./" and a leading "/" are
+// dropped. Patterns that climb out of baseDir with ".." are refused: the
+// caller checked baseDir, not its parents. For the same reason, symlinks
+// that lead outside the roots paths allows reading are skipped.
+func walkMatches(ctx context.Context, paths PathPolicy, baseDir, pattern string, ignore *IgnoreList, includeDirs bool) ([]string, error) {
+	if slices.Contains(strings.Split(filepath.ToSlash(pattern), "/"), "..") {
+		return nil, fmt.Errorf("pattern %q must not contain \"..\"; set path to search another directory", pattern)
+	}
+	pattern = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(pattern)), "/")
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, doublestar.ErrBadPattern
+	}
+
+	var matches []string
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped, but a missing base is an error.
+			if path == baseDir {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path == baseDir {
+			return nil
+		}
+		if ignore.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && !includeDirs {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 && !paths.Readable(path) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return nil
+		}
+		if doublestar.MatchUnvalidated(pattern, filepath.ToSlash(rel)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// WorkspaceFiles returns up to limit regular files beneath root as
+// slash-separated paths relative to root, skipping anything in ignore. If ctx
+// ends first, the files found so far are returned along with its error.
+func WorkspaceFiles(ctx context.Context, root string, ignore *IgnoreList, limit int) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path == root {
+			return nil
+		}
+		if ignore.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(files) >= limit {
+			return filepath.SkipAll
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnoreListMatch(t *testing.T) {
+	l := NewIgnoreList("/repo", []string{".git/", "node_modules/", "*.log", "build/out", "# comment", ""})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/repo/.git", true, true},
+		{"/repo/.git/config", false, true},
+		{"/repo/web/node_modules/react/index.js", false, true},
+		{"/repo/node_modules", false, false}, // dir-only pattern, plain file
+		{"/repo/logs/app.log", false, true},
+		{"/repo/build/out/bin", false, true},
+		{"/repo/src/build/out", true, false}, // anchored to the root
+		{"/repo/main.go", false, false},
+		{"/repo", true, false},
+		{"internal/app.log", false, true},
+	}
+	for _, tt := range tests {
+		if got := l.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	var nilList *IgnoreList
+	if nilList.Match("/repo/.git", true) {
+		t.Error("nil list should match nothing")
+	}
+}
+
+func TestWalkMatchesPatterns(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "base")
+	for _, name := range []string{"base/src/main.go", "base/README.md", "secret.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.go", []string{"src/main.go"}},
+		{"./src/*.go", []string{"src/main.go"}},
+		{"/README.md", []string{"README.md"}},
+		{"src/", []string{"src"}},
+	}
+	for _, tt := range tests {
+		matches, err := walkMatches(context.Background(), PathPolicy{WorkDir: base}, base, tt.pattern, nil, true)
+		if err != nil {
+			t.Errorf("walkMatches(%q): %v", tt.pattern, err)
+			continue
+		}
+		var got []string
+		for _, m := range matches {
+			rel, _ := filepath.Rel(base, m)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("walkMatches(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	// Patterns may not climb out of the directory the caller checked.
+	for _, pattern := range []string{"../*.go", "src/../../secret.go", "**/../*"} {
+		if matches, err := walkMatches(context.Background(), PathPolicy{WorkDir: base}, base, pattern, nil, true); err == nil {
+			t.Errorf("walkMatches(%q) = %q, want an error", pattern, matches)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,12 +12,13 @@ import (
 
 // LsTool lists directory contents.
 type LsTool struct {
-	workDir string
+	paths  PathPolicy
+	ignore *IgnoreList
 }
 
-// NewLsTool creates a new ls tool.
-func NewLsTool(workDir string) *LsTool {
-	return &LsTool{workDir: workDir}
+// NewLsTool creates a new ls tool. Entries matched by ignore are omitted.
+func NewLsTool(paths PathPolicy, ignore *IgnoreList) *LsTool {
+	return &LsTool{paths: paths, ignore: ignore}
 }
 
 func (t *LsTool) Name() string { return "ls" }
@@ -49,13 +51,9 @@ func (t *LsTool) Execute(ctx context.Context, input json.RawMessage) (string, er
 		return "", fmt.Errorf("parsing ls parameters: %w", err)
 	}
 
-	dir := t.workDir
-	if params.Path != "" {
-		if filepath.IsAbs(params.Path) {
-			dir = params.Path
-		} else {
-			dir = filepath.Join(t.workDir, params.Path)
-		}
+	dir, err := t.paths.ResolveRead(params.Path)
+	if err != nil {
+		return "", err
 	}
 
 	entries, err := os.ReadDir(dir)
@@ -65,6 +63,13 @@ func (t *LsTool) Execute(ctx context.Context, input json.RawMessage) (string, er
 
 	var lines []string
 	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if t.ignore.Match(path, entry.IsDir()) {
+			continue
+		}
+		if entry.Type()&fs.ModeSymlink != 0 && !t.paths.Readable(path) {
+			continue
+		}
 		name := entry.Name()
 		if entry.IsDir() {
 			name += "/"
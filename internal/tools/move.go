@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// MoveTool renames or moves files and directories within the working directory.
+type MoveTool struct {
+	workDir string
+	paths   PathPolicy
+}
+
+// NewMoveTool creates a new move tool. Moves are always confined to workDir.
+func NewMoveTool(workDir string) *MoveTool {
+	return &MoveTool{workDir: workDir, paths: PathPolicy{WorkDir: workDir, Confine: true}}
+}
+
+func (t *MoveTool) Name() string { return "move" }
+
+func (t *MoveTool) Description() string {
+	return "Move or rename a file or directory within the working directory. Parent directories of the destination are created automatically. Refuses to replace an existing destination unless overwrite is set."
+}
+
+func (t *MoveTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"source": {
+				Type:        "string",
+				Description: "The file or directory to move (relative to working directory).",
+			},
+			"destination": {
+				Type:        "string",
+				Description: "The new path (relative to working directory).",
+			},
+			"overwrite": {
+				Type:        "boolean",
+				Description: "If true, replace an existing file at the destination. Default is false.",
+			},
+		},
+		Required: []string{"source", "destination"},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *MoveTool) RequiresPermission() bool { return true }
+
+type moveParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Overwrite   bool   `json:"overwrite"`
+}
+
+// resolve returns the absolute source and destination of a move, refusing
+// paths outside the working directory.
+func (t *MoveTool) resolve(params moveParams) (src, dst string, err error) {
+	if src, err = t.paths.ResolveEntry(params.Source); err != nil {
+		return "", "", err
+	}
+	if dst, err = t.paths.ResolveEntry(params.Destination); err != nil {
+		return "", "", err
+	}
+	return src, dst, nil
+}
+
+// ChangedPaths implements FileChanger.
+func (t *MoveTool) ChangedPaths(input json.RawMessage) ([]string, error) {
+	var params moveParams
+	if err := json.Unmarshal(input, &params); err != nil || params.Source == "" || params.Destination == "" {
+		return nil, nil
+	}
+	src, dst, err := t.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	return []string{src, dst}, nil
+}
+
+// Preview implements Previewer.
+func (t *MoveTool) Preview(input json.RawMessage) (string, error) {
+	var params moveParams
+	if err := json.Unmarshal(input, &params); err != nil || params.Source == "" || params.Destination == "" {
+		return "", nil
+	}
+	_, dst, err := t.resolve(params)
+	if err != nil {
+		return "", err
+	}
+	preview := fmt.Sprintf("move %s -> %s", params.Source, params.Destination)
+	if _, err := os.Stat(dst); err == nil {
+		if params.Overwrite {
+			preview += " (replacing existing destination)"
+		} else {
+			preview += " (destination exists; will be refused)"
+		}
+	}
+	return preview, nil
+}
+
+func (t *MoveTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params moveParams
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("parsing move parameters: %w", err)
+	}
+	if params.Source == "" || params.Destination == "" {
+		return "", fmt.Errorf("source and destination are required")
+	}
+
+	src, dst, err := t.resolve(params)
+	if err != nil {
+		return "", err
+	}
+	if src == filepath.Clean(t.workDir) || dst == filepath.Clean(t.workDir) {
+		return "", fmt.Errorf("cannot move the working directory itself")
+	}
+	if src == dst {
+		return "", fmt.Errorf("source and destination are the same")
+	}
+
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return "", fmt.Errorf("reading source: %w", err)
+	}
+
+	if dstInfo, err := os.Lstat(dst); err == nil {
+		if !params.Overwrite {
+			return "", fmt.Errorf("destination %s already exists (set overwrite to replace it)", params.Destination)
+		}
+		if dstInfo.IsDir() {
+			return "", fmt.Errorf("destination %s is a directory and cannot be overwritten", params.Destination)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("checking destination: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", fmt.Errorf("creating directories: %w", err)
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		if !errors.Is(err, syscall.EXDEV) {
+			return "", fmt.Errorf("moving: %w", err)
+		}
+		// Rename cannot cross filesystems; copy and remove the original instead.
+		if err := copyPath(src, dst, srcInfo); err != nil {
+			return "", fmt.Errorf("copying across filesystems: %w", err)
+		}
+		if err := os.RemoveAll(src); err != nil {
+			return "", fmt.Errorf("removing source after copy: %w", err)
+		}
+	}
+
+	srcRel, _ := filepath.Rel(t.workDir, src)
+	dstRel, _ := filepath.Rel(t.workDir, dst)
+	return fmt.Sprintf("Moved %s to %s", srcRel, dstRel), nil
+}
+
+// copyPath copies a file, symlink, or directory tree from src to dst,
+// preserving permission bits.
+func copyPath(src, dst string, info fs.FileInfo) error {
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+
+	case info.IsDir():
+		if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			childInfo, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), childInfo); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
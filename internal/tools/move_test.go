@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveTool(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewMoveTool(dir)
+	move := func(params map[string]any) (string, error) {
+		input, _ := json.Marshal(params)
+		return tool.Execute(context.Background(), input)
+	}
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "pkg/x.go": "x"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Missing parent directories of the destination are created.
+	out, err := move(map[string]any{"source": "a.txt", "destination": "docs/notes/a.txt"})
+	if err != nil || out != "Moved a.txt to docs/notes/a.txt" {
+		t.Fatalf("move = %q, %v", out, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+		t.Error("source still exists after the move")
+	}
+
+	// An existing destination is only replaced with overwrite.
+	if _, err := move(map[string]any{"source": "b.txt", "destination": "docs/notes/a.txt"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("move onto an existing file: %v, want a refusal", err)
+	}
+	if _, err := move(map[string]any{"source": "b.txt", "destination": "docs/notes/a.txt", "overwrite": true}); err != nil {
+		t.Fatalf("move with overwrite: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "docs/notes/a.txt")); string(got) != "b" {
+		t.Errorf("destination = %q, want the overwritten content", got)
+	}
+
+	// Directories move with their contents.
+	if _, err := move(map[string]any{"source": "pkg", "destination": "internal/pkg"}); err != nil {
+		t.Fatalf("moving a directory: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "internal/pkg/x.go")); string(got) != "x" {
+		t.Errorf("moved directory content = %q", got)
+	}
+
+	for _, params := range []map[string]any{
+		{"source": ".", "destination": "elsewhere"},
+		{"source": "internal", "destination": "internal"},
+		{"source": "missing.txt", "destination": "found.txt"},
+		{"source": "internal", "destination": "../escaped"},
+	} {
+		if _, err := move(params); err == nil {
+			t.Errorf("move %v succeeded, want an error", params)
+		}
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "tree")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("echo hi\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub/run.sh", filepath.Join(src, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "copy")
+	if err := copyPath(src, dst, info); err != nil {
+		t.Fatal(err)
+	}
+
+	fileInfo, err := os.Stat(filepath.Join(dst, "sub", "run.sh"))
+	if err != nil || fileInfo.Mode().Perm() != 0o755 {
+		t.Errorf("copied file = %v, %v, want mode 0755", fileInfo, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "sub/run.sh" {
+		t.Errorf("copied symlink = %q, %v", target, err)
+	}
+}
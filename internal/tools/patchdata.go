@@ -0,0 +1,370 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PatchDataTool sets or deletes a single value in a JSON, YAML, or TOML
+// file. Only the text of that value changes, so the rest of the file keeps
+// its formatting, key order, and comments.
+type PatchDataTool struct {
+	paths PathPolicy
+	opts  WriteOptions
+}
+
+// NewPatchDataTool creates a new patchdata tool.
+func NewPatchDataTool(paths PathPolicy, opts WriteOptions) *PatchDataTool {
+	return &PatchDataTool{paths: paths, opts: opts}
+}
+
+func (t *PatchDataTool) Name() string { return "patchdata" }
+
+func (t *PatchDataTool) Description() string {
+	return "Set or delete a single value in a JSON, YAML, or TOML file, addressed by a dotted path such as \"compilerOptions.strict\", \"servers[0].port\", or \"tool.poetry.version\". Only that value changes; the rest of the file keeps its formatting and comments. Prefer this over edit for changing config values. Missing parent objects are created when setting."
+}
+
+func (t *PatchDataTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"file_path": {
+				Type:        "string",
+				Description: "The path to the .json, .yaml, .yml, or .toml file (absolute or relative to working directory).",
+			},
+			"operation": {
+				Type:        "string",
+				Description: "\"set\" to add or replace the value, or \"delete\" to remove it.",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Location of the value: keys separated by dots, array indexes in brackets, and keys containing dots quoted in brackets, e.g. a.b[0][\"c.d\"].",
+			},
+			"value": {
+				Type:        "string",
+				Description: "For set, the new value as JSON, e.g. 8080, \"text\", true, [1, 2], or {\"key\": \"value\"}.",
+			},
+		},
+		Required: []string{"file_path", "operation", "path"},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *PatchDataTool) RequiresPermission() bool { return true }
+
+// ChangedPaths implements FileChanger.
+func (t *PatchDataTool) ChangedPaths(input json.RawMessage) ([]string, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || params.FilePath == "" {
+		return nil, nil
+	}
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return []string{filePath}, nil
+}
+
+// Propose implements Reviewer.
+func (t *PatchDataTool) Propose(input json.RawMessage) (FileProposal, error) {
+	filePath, original, newContent, err := t.apply(input)
+	if err != nil {
+		return FileProposal{}, err
+	}
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
+	return FileProposal{
+		Path:    filePath,
+		Content: newContent,
+		Diff:    UnifiedDiff(relPath, original, newContent),
+	}, nil
+}
+
+// patchDataParams are the parameters of a patchdata call.
+type patchDataParams struct {
+	FilePath  string          `json:"file_path"`
+	Operation string          `json:"operation"`
+	Path      string          `json:"path"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// dataPatcher sets the value at keys in src to value, given as JSON, or
+// deletes it if value is nil.
+type dataPatcher func(src string, keys []dataKey, value json.RawMessage) (string, error)
+
+// dataPatchers maps file extensions to the patcher for their format.
+var dataPatchers = map[string]dataPatcher{
+	".json": patchJSON,
+	".yaml": patchYAML,
+	".yml":  patchYAML,
+	".toml": patchTOML,
+}
+
+// apply resolves the file a patchdata call targets and returns its current
+// and patched content, without writing anything.
+func (t *PatchDataTool) apply(input json.RawMessage) (filePath, original, newContent string, err error) {
+	var params patchDataParams
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", "", "", fmt.Errorf("parsing patchdata parameters: %w", err)
+	}
+
+	patch, ok := dataPatchers[strings.ToLower(filepath.Ext(params.FilePath))]
+	if !ok {
+		return "", "", "", fmt.Errorf("%s is not a .json, .yaml, .yml, or .toml file", params.FilePath)
+	}
+	keys, err := parseDataPath(params.Path)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var value json.RawMessage
+	switch params.Operation {
+	case "set":
+		if value, err = parseDataValue(params.Value); err != nil {
+			return "", "", "", err
+		}
+	case "delete":
+	default:
+		return "", "", "", fmt.Errorf("unknown operation %q (use \"set\" or \"delete\")", params.Operation)
+	}
+
+	filePath, err = t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return "", "", "", err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", "", "", fmt.Errorf("reading file: %w", err)
+	}
+	original = string(content)
+
+	// Patch LF text, then restore CRLF, as the edit tool does.
+	text := original
+	crlf := t.opts.PreserveLineEndings && usesCRLF(original)
+	if crlf {
+		text = toLF(text)
+	}
+	newContent, err = patch(text, keys, value)
+	if err != nil {
+		return "", "", "", fmt.Errorf("%s: %w", params.FilePath, err)
+	}
+	if crlf {
+		newContent = toCRLF(newContent)
+	}
+	return filePath, original, newContent, nil
+}
+
+func (t *PatchDataTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	filePath, original, newContent, err := t.apply(input)
+	if err != nil {
+		return "", err
+	}
+
+	if newContent == original {
+		return "No changes made (the value is already set).", nil
+	}
+
+	if err := writeFile(filePath, []byte(newContent), 0o644, t.opts); err != nil {
+		return "", fmt.Errorf("writing file: %w", err)
+	}
+
+	var params patchDataParams
+	_ = json.Unmarshal(input, &params)
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
+	if params.Operation == "delete" {
+		return fmt.Sprintf("Successfully deleted %s from %s", params.Path, relPath), nil
+	}
+	return fmt.Sprintf("Successfully set %s in %s", params.Path, relPath), nil
+}
+
+// dataKey is one step of a data path: an object key, or an array index if
+// isIndex is set.
+type dataKey struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// parseDataPath parses a path such as `a.b[0]["c.d"]`. A leading "$" or "$."
+// as in JSONPath is accepted.
+func parseDataPath(path string) ([]dataKey, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var keys []dataKey
+	for i := 0; i < len(p); {
+		if p[i] == '[' {
+			if i+1 < len(p) && (p[i+1] == '"' || p[i+1] == '\'') {
+				end := strings.IndexByte(p[i+2:], p[i+1])
+				if end < 0 || i+end+3 >= len(p) || p[i+end+3] != ']' {
+					return nil, fmt.Errorf("invalid path %q: unterminated quoted key", path)
+				}
+				keys = append(keys, dataKey{name: p[i+2 : i+2+end]})
+				i += end + 4
+				continue
+			}
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			n, err := strconv.Atoi(p[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid path %q: %q is not an array index", path, p[i+1:i+end])
+			}
+			keys = append(keys, dataKey{index: n, isIndex: true})
+			i += end + 1
+			continue
+		}
+
+		if p[i] == '.' {
+			i++
+		}
+		end := i
+		for end < len(p) && p[end] != '.' && p[end] != '[' {
+			end++
+		}
+		if end == i {
+			return nil, fmt.Errorf("invalid path %q: empty key", path)
+		}
+		keys = append(keys, dataKey{name: p[i:end]})
+		i = end
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("path is empty")
+	}
+	return keys, nil
+}
+
+// formatDataPath renders keys for messages; no keys is the whole document.
+func formatDataPath(keys []dataKey) string {
+	if len(keys) == 0 {
+		return "the document"
+	}
+	var b strings.Builder
+	for i, k := range keys {
+		switch {
+		case k.isIndex:
+			fmt.Fprintf(&b, "[%d]", k.index)
+		case i > 0:
+			b.WriteString("." + k.name)
+		default:
+			b.WriteString(k.name)
+		}
+	}
+	return b.String()
+}
+
+// dataKeyNames returns the names of keys, failing if any is an array index,
+// for formats whose arrays the patchers cannot address.
+func dataKeyNames(keys []dataKey, format string) ([]string, error) {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		if k.isIndex {
+			return nil, fmt.Errorf("array indexes are not supported in %s files; use the edit tool", format)
+		}
+		names[i] = k.name
+	}
+	return names, nil
+}
+
+// parseDataValue returns the JSON of a value to set. The value is normally a
+// string holding JSON; a string that is not valid JSON is taken as text, and
+// a value given directly as JSON is used as is.
+func parseDataValue(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, errors.New("value is required for set")
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return raw, nil
+	}
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s), nil
+	}
+	return json.RawMessage(jsonQuote(s)), nil
+}
+
+// dataObject is a JSON object with its fields in their original order.
+type dataObject []dataField
+
+// dataField is one field of a dataObject.
+type dataField struct {
+	key   string
+	value any
+}
+
+// decodeDataValue decodes JSON into nil, bool, json.Number, string, []any,
+// or dataObject values.
+func decodeDataValue(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return decodeDataToken(dec)
+}
+
+func decodeDataToken(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := dataObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeDataToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, dataField{key: key.(string), value: v})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeDataToken(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// nestDataValue wraps v in objects for each of names, innermost last.
+func nestDataValue(names []string, v any) any {
+	for i := len(names) - 1; i >= 0; i-- {
+		v = dataObject{{key: names[i], value: v}}
+	}
+	return v
+}
+
+// jsonQuote returns s as a JSON string, without escaping HTML characters.
+func jsonQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// replaceLines returns lines with lines[from:to] replaced by repl.
+func replaceLines(lines []string, from, to int, repl []string) []string {
+	out := make([]string, 0, len(lines)-(to-from)+len(repl))
+	out = append(out, lines[:from]...)
+	out = append(out, repl...)
+	return append(out, lines[to:]...)
+}
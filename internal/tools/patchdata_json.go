@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// jsonMember is a member of a JSON object or an element of an array, as
+// offsets into the source.
+type jsonMember struct {
+	key        string // unquoted key; empty for array elements
+	start      int    // start of the key, or of the value for array elements
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// patchJSON implements dataPatcher for JSON. Values are re-indented to match
+// the object they are placed in.
+func patchJSON(src string, keys []dataKey, value json.RawMessage) (string, error) {
+	if !json.Valid([]byte(src)) {
+		return "", errors.New("not valid JSON (comments and trailing commas are not supported)")
+	}
+	out, err := patchJSONText(src, keys, value)
+	if err == nil && !json.Valid([]byte(out)) {
+		return "", errors.New("the patch would produce invalid JSON; use the edit tool")
+	}
+	return out, err
+}
+
+// patchJSONText patches src, which must be valid JSON.
+func patchJSONText(src string, keys []dataKey, value json.RawMessage) (string, error) {
+	unit := jsonIndentUnit(src)
+
+	pos := skipJSONSpace(src, 0)
+	for i, k := range keys {
+		at := formatDataPath(keys[:i])
+		switch {
+		case src[pos] != '{' && src[pos] != '[':
+			return "", fmt.Errorf("%s is not an object or array", at)
+		case k.isIndex && src[pos] == '{':
+			return "", fmt.Errorf("%s is an object; use a key rather than [%d]", at, k.index)
+		case !k.isIndex && src[pos] == '[':
+			return "", fmt.Errorf("%s is an array; use an index such as [0]", at)
+		}
+
+		members, closing, err := parseJSONContainer(src, pos)
+		if err != nil {
+			return "", err
+		}
+		idx := -1
+		for j, m := range members {
+			if (k.isIndex && j == k.index) || (!k.isIndex && m.key == k.name) {
+				idx = j
+			}
+		}
+
+		if idx >= 0 && i < len(keys)-1 {
+			pos = members[idx].valueStart
+			continue
+		}
+
+		multiline := strings.Contains(src[pos:closing], "\n") || (len(members) == 0 && unit != "")
+		if idx >= 0 {
+			m := members[idx]
+			if value == nil {
+				return deleteJSONMember(src, pos, closing, members, idx), nil
+			}
+			return src[:m.valueStart] + formatJSON(value, lineIndent(src, m.start), unit, multiline) + src[m.valueEnd:], nil
+		}
+
+		if value == nil {
+			return "", fmt.Errorf("%s not found", formatDataPath(keys[:i+1]))
+		}
+		if k.isIndex && k.index != len(members) {
+			return "", fmt.Errorf("index %d is out of range for %s (length %d; use [%d] to append)", k.index, at, len(members), len(members))
+		}
+		nested, err := nestJSON(keys[i+1:], value)
+		if err != nil {
+			return "", err
+		}
+		return insertJSONMember(src, pos, closing, members, k, nested, unit, multiline), nil
+	}
+	return "", errors.New("path is empty")
+}
+
+// nestJSON wraps value in the objects and arrays keys describe, for creating
+// a missing path. New arrays can only be created with index 0.
+func nestJSON(keys []dataKey, value json.RawMessage) (json.RawMessage, error) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		k := keys[i]
+		switch {
+		case k.isIndex && k.index != 0:
+			return nil, fmt.Errorf("cannot create %s: a new array starts at [0]", formatDataPath(keys[:i+1]))
+		case k.isIndex:
+			value = json.RawMessage("[" + string(value) + "]")
+		default:
+			value = json.RawMessage("{" + jsonQuote(k.name) + ":" + string(value) + "}")
+		}
+	}
+	return value, nil
+}
+
+// insertJSONMember adds k with value to the container spanning open to
+// closing, after its last member, following the separators it already uses.
+func insertJSONMember(src string, open, closing int, members []jsonMember, k dataKey, value json.RawMessage, unit string, multiline bool) string {
+	var keyPart string
+	if !k.isIndex {
+		colon := ": "
+		if len(members) > 0 {
+			colon = src[members[0].keyEnd:members[0].valueStart]
+		}
+		keyPart = jsonQuote(k.name) + colon
+	}
+
+	if len(members) == 0 {
+		if !multiline {
+			return src[:open+1] + keyPart + formatJSON(value, "", unit, false) + src[closing:]
+		}
+		outer := lineIndent(src, open)
+		inner := outer + unit
+		return src[:open+1] + "\n" + inner + keyPart + formatJSON(value, inner, unit, true) + "\n" + outer + src[closing:]
+	}
+
+	last := members[len(members)-1]
+	var sep string
+	switch {
+	case len(members) > 1:
+		sep = src[members[len(members)-2].valueEnd:last.start]
+	case multiline:
+		sep = ",\n" + lineIndent(src, last.start)
+	case strings.HasSuffix(keyPart, " ") || k.isIndex:
+		sep = ", "
+	default:
+		sep = ","
+	}
+	item := keyPart + formatJSON(value, lineIndent(src, last.start), unit, multiline)
+	return src[:last.valueEnd] + sep + item + src[last.valueEnd:]
+}
+
+// deleteJSONMember removes members[idx], with the separator before or after
+// it, from the container spanning open to closing.
+func deleteJSONMember(src string, open, closing int, members []jsonMember, idx int) string {
+	switch {
+	case len(members) == 1:
+		return src[:open+1] + src[closing:]
+	case idx < len(members)-1:
+		return src[:members[idx].start] + src[members[idx+1].start:]
+	default:
+		return src[:members[idx-1].valueEnd] + src[members[idx].valueEnd:]
+	}
+}
+
+// formatJSON formats value to be placed on a line indented by prefix, either
+// indented by unit or, if not multiline, compactly.
+func formatJSON(value json.RawMessage, prefix, unit string, multiline bool) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, value); err != nil {
+		return string(value)
+	}
+	if !multiline || unit == "" {
+		return compact.String()
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, compact.Bytes(), prefix, unit); err != nil {
+		return compact.String()
+	}
+	return b.String()
+}
+
+// jsonIndentUnit returns the indentation of the first indented line of src,
+// taken as one level, or "" if src is not indented.
+func jsonIndentUnit(src string) string {
+	for _, line := range strings.Split(src, "\n")[1:] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func lineIndent(src string, pos int) string {
+	start := strings.LastIndexByte(src[:pos], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return src[start:end]
+}
+
+// parseJSONContainer returns the members of the object, or the elements of
+// the array, whose opening bracket is at open, and the offset of its closing
+// bracket.
+func parseJSONContainer(src string, open int) ([]jsonMember, int, error) {
+	isObject := src[open] == '{'
+	closeCh := byte(']')
+	if isObject {
+		closeCh = '}'
+	}
+
+	var members []jsonMember
+	pos := skipJSONSpace(src, open+1)
+	if pos < len(src) && src[pos] == closeCh {
+		return nil, pos, nil
+	}
+	for {
+		m := jsonMember{start: pos}
+		if isObject {
+			end, err := scanJSONString(src, pos)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := json.Unmarshal([]byte(src[pos:end]), &m.key); err != nil {
+				return nil, 0, err
+			}
+			m.keyEnd = end
+			pos = skipJSONSpace(src, end)
+			if pos >= len(src) || src[pos] != ':' {
+				return nil, 0, fmt.Errorf("expected ':' at offset %d", pos)
+			}
+			pos = skipJSONSpace(src, pos+1)
+		}
+
+		m.valueStart = pos
+		end, err := scanJSONValue(src, pos)
+		if err != nil {
+			return nil, 0, err
+		}
+		m.valueEnd = end
+		members = append(members, m)
+
+		pos = skipJSONSpace(src, end)
+		switch {
+		case pos < len(src) && src[pos] == ',':
+			pos = skipJSONSpace(src, pos+1)
+		case pos < len(src) && src[pos] == closeCh:
+			return members, pos, nil
+		default:
+			return nil, 0, fmt.Errorf("expected ',' or '%c' at offset %d", closeCh, pos)
+		}
+	}
+}
+
+// scanJSONValue returns the offset just past the JSON value starting at pos.
+func scanJSONValue(src string, pos int) (int, error) {
+	if pos >= len(src) {
+		return 0, errors.New("unexpected end of JSON")
+	}
+	switch src[pos] {
+	case '"':
+		return scanJSONString(src, pos)
+	case '{', '[':
+		depth := 0
+		for i := pos; i < len(src); i++ {
+			switch src[i] {
+			case '"':
+				end, err := scanJSONString(src, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, errors.New("unterminated JSON object or array")
+	}
+
+	end := pos
+	for end < len(src) && !strings.ContainsRune(",}] \t\r\n", rune(src[end])) {
+		end++
+	}
+	if end == pos {
+		return 0, fmt.Errorf("unexpected %q at offset %d", src[pos], pos)
+	}
+	return end, nil
+}
+
+// scanJSONString returns the offset just past the JSON string starting at
+// pos.
+func scanJSONString(src string, pos int) (int, error) {
+	if pos >= len(src) || src[pos] != '"' {
+		return 0, fmt.Errorf("expected a string at offset %d", pos)
+	}
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated JSON string")
+}
+
+// skipJSONSpace returns the offset of the first non-whitespace byte at or
+// after pos.
+func skipJSONSpace(src string, pos int) int {
+	for pos < len(src) && strings.IndexByte(" \t\r\n", src[pos]) >= 0 {
+		pos++
+	}
+	return pos
+}
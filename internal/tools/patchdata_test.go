@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPatchData(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		original  string
+		operation string
+		path      string
+		value     string
+		want      string
+	}{
+		{
+			"json set keeps indentation", "a.json",
+			"{\n  \"name\": \"x\",\n  \"port\": 80\n}\n", "set", "port", "8080",
+			"{\n  \"name\": \"x\",\n  \"port\": 8080\n}\n",
+		},
+		{
+			"json insert nested object", "a.json",
+			"{\n    \"a\": 1\n}\n", "set", "b.c", "true",
+			"{\n    \"a\": 1,\n    \"b\": {\n        \"c\": true\n    }\n}\n",
+		},
+		{
+			"json compact", "a.json",
+			`{"a":1,"b":[1,2]}`, "set", "b[2]", "3",
+			`{"a":1,"b":[1,2,3]}`,
+		},
+		{
+			"json delete", "a.json",
+			"{\n  \"a\": 1,\n  \"b\": 2\n}\n", "delete", "$.a", "",
+			"{\n  \"b\": 2\n}\n",
+		},
+		{
+			"json text value", "a.json",
+			"{\"a\": \"x\"}", "set", `["a"]`, "hello world",
+			"{\"a\": \"hello world\"}",
+		},
+		{
+			"yaml set keeps comment", "a.yaml",
+			"# config\nserver:\n  port: 80 # http\n  host: localhost\n", "set", "server.port", "8080",
+			"# config\nserver:\n  port: 8080 # http\n  host: localhost\n",
+		},
+		{
+			"yaml insert", "a.yml",
+			"server:\n    port: 80\nname: x\n", "set", "server.tls.enabled", "true",
+			"server:\n    port: 80\n    tls:\n        enabled: true\nname: x\n",
+		},
+		{
+			"yaml set block", "a.yaml",
+			"tags:\n  - a\n  - b\nname: x\n", "set", "tags", `["c"]`,
+			"tags:\n  - c\nname: x\n",
+		},
+		{
+			"yaml delete nested block", "a.yaml",
+			"a:\n  b: 1\n  c:\n    d: 2\n  e: 3\n", "delete", "a.c", "",
+			"a:\n  b: 1\n  e: 3\n",
+		},
+		{
+			"yaml quotes ambiguous strings", "a.yaml",
+			"v: 1\n", "set", "v", `"yes"`,
+			"v: \"yes\"\n",
+		},
+		{
+			"yaml sibling of unindented sequence", "a.yaml",
+			"items:\n- a\n- b\nother: 1\n", "set", "other", "2",
+			"items:\n- a\n- b\nother: 2\n",
+		},
+		{
+			"yaml insert after unindented sequence", "a.yaml",
+			"a:\n  items:\n  - x\n  b: 1\n", "set", "a.c", "3",
+			"a:\n  items:\n  - x\n  b: 1\n  c: 3\n",
+		},
+		{
+			"yaml set under anchor", "a.yaml",
+			"base: &base\n  port: 80\nprod:\n  <<: *base\n", "set", "base.port", "8080",
+			"base: &base\n  port: 8080\nprod:\n  <<: *base\n",
+		},
+		{
+			"yaml set anchored value", "a.yaml",
+			"port: &p 80 # http\nalt: *p\n", "set", "port", "8080",
+			"port: &p 8080 # http\nalt: *p\n",
+		},
+		{
+			"yaml insert into anchored empty mapping", "a.yaml",
+			"opts: &o {}\n", "set", "opts.debug", "true",
+			"opts: &o\n  debug: true\n",
+		},
+		{
+			"toml set in section", "a.toml",
+			"[tool.poetry]\nname = \"x\"\nversion = \"0.1.0\" # bump\n\n[deps]\na = 1\n", "set", "tool.poetry.version", `"0.2.0"`,
+			"[tool.poetry]\nname = \"x\"\nversion = \"0.2.0\" # bump\n\n[deps]\na = 1\n",
+		},
+		{
+			"toml insert", "a.toml",
+			"title = \"t\"\n\n[deps]\na = 1\n\n[dev]\nb = 2\n", "set", "deps.c", `[1, "two"]`,
+			"title = \"t\"\n\n[deps]\na = 1\nc = [1, \"two\"]\n\n[dev]\nb = 2\n",
+		},
+		{
+			"toml multi-line array", "a.toml",
+			"a = [\n  1, # one\n  2,\n]\nb = 3\n", "set", "a", "[]",
+			"a = []\nb = 3\n",
+		},
+		{
+			"toml delete section", "a.toml",
+			"[a]\nx = 1\n\n[b]\ny = 2\n", "delete", "a", "",
+			"\n[b]\ny = 2\n",
+		},
+		{
+			"toml delete section with subtables", "a.toml",
+			"[a]\nx = 1\n\n[a.c]\nz = 3\n\n[b]\ny = 2\n\n[[a.items]]\nn = 1\n", "delete", "a", "",
+			"\n\n[b]\ny = 2\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(target, []byte(tt.original), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			tool := NewPatchDataTool(PathPolicy{WorkDir: dir, Confine: true}, WriteOptions{PreserveLineEndings: true})
+			params := map[string]string{"file_path": tt.file, "operation": tt.operation, "path": tt.path}
+			if tt.operation == "set" {
+				params["value"] = tt.value
+			}
+			input, _ := json.Marshal(params)
+			if _, err := tool.Execute(context.Background(), input); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchDataErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		original string
+		path     string
+		wantErr  string
+	}{
+		{"json index on object", "a.json", `{"a": {}}`, "a[0]", "is an object"},
+		{"json index out of range", "a.json", `{"a": [1]}`, "a[3]", "out of range"},
+		{"yaml sequence", "a.yaml", "- a\n- b\n", "x", "sequences are not supported"},
+		{"yaml scalar parent", "a.yaml", "a: 1\n", "a.b", "not a mapping"},
+		{"yaml into unindented sequence", "a.yaml", "items:\n- a\nother: 1\n", "items.x", "sequences are not supported"},
+		{"toml inline table", "a.toml", "a = { b = 1 }\n", "a.b", "not a table"},
+		{"toml array of tables", "a.toml", "[[a]]\nb = 1\n", "a.b", "array of tables"},
+		{"unsupported file", "a.txt", "a=1\n", "a", "is not a .json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.original), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			tool := NewPatchDataTool(PathPolicy{WorkDir: dir, Confine: true}, WriteOptions{})
+			input, _ := json.Marshal(map[string]string{"file_path": tt.file, "operation": "set", "path": tt.path, "value": "2"})
+			_, err := tool.Execute(context.Background(), input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDataPath(t *testing.T) {
+	keys, err := parseDataPath(`$.a.b[2]["c.d"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dataKey{{name: "a"}, {name: "b"}, {index: 2, isIndex: true}, {name: "c.d"}}
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %+v, want %+v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("keys[%d] = %+v, want %+v", i, keys[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"", "a..b", "a[x]", `a["b`} {
+		if _, err := parseDataPath(bad); err == nil {
+			t.Errorf("parseDataPath(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tomlSection is the root table or a [table] or [[array]] section.
+type tomlSection struct {
+	name    []string // nil for the root table
+	array   bool
+	header  int // index of the header line; -1 for the root table
+	end     int // index just past the section's last line
+	entries []tomlEntry
+}
+
+// tomlEntry is a "key = value" pair. The value spans from valueStart in
+// lines[line] to valueEnd in lines[endLine].
+type tomlEntry struct {
+	key        []string
+	line       int
+	endLine    int
+	valueStart int
+	valueEnd   int
+}
+
+// patchTOML implements dataPatcher for TOML. Values can be set in the root
+// table, [table] sections, and dotted keys; arrays of tables and the inside
+// of inline tables are left to the edit tool.
+func patchTOML(src string, keys []dataKey, value json.RawMessage) (string, error) {
+	names, err := dataKeyNames(keys, "TOML")
+	if err != nil {
+		return "", err
+	}
+	var repl string
+	if value != nil {
+		v, err := decodeDataValue(value)
+		if err != nil {
+			return "", err
+		}
+		if repl, err = tomlValue(v); err != nil {
+			return "", err
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	sections, err := parseTOML(lines)
+	if err != nil {
+		return "", err
+	}
+
+	// Deleting a table takes its subtables with it, [a.b] and [[a.c]] along
+	// with [a], so that none of its keys are left behind.
+	if value == nil {
+		var doomed []tomlSection
+		for _, s := range sections {
+			if s.name == nil || !hasTOMLPrefix(s.name, names) {
+				continue
+			}
+			if s.array && len(s.name) == len(names) {
+				return "", fmt.Errorf("%s is an array of tables; use the edit tool", strings.Join(s.name, "."))
+			}
+			doomed = append(doomed, s)
+		}
+		for i := len(doomed) - 1; i >= 0; i-- {
+			lines = replaceLines(lines, doomed[i].header, doomed[i].end, nil)
+		}
+		if len(doomed) > 0 {
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+
+	// The section to insert into is the one with the longest name that the
+	// path starts with.
+	target := &sections[0]
+	for i := range sections {
+		s := &sections[i]
+		if s.name == nil || !hasTOMLPrefix(names, s.name) {
+			continue
+		}
+		if s.array {
+			return "", fmt.Errorf("%s is an array of tables; use the edit tool", strings.Join(s.name, "."))
+		}
+		if len(s.name) == len(names) {
+			return "", fmt.Errorf("%s is a table; set its keys individually", strings.Join(names, "."))
+		}
+		if len(s.name) > len(target.name) {
+			target = s
+		}
+	}
+
+	for _, s := range sections {
+		if s.name != nil && !hasTOMLPrefix(names, s.name) {
+			continue
+		}
+		for _, e := range s.entries {
+			full := append(append([]string{}, s.name...), e.key...)
+			switch {
+			case equalStrings(full, names):
+				if value == nil {
+					return strings.Join(replaceLines(lines, e.line, e.endLine+1, nil), "\n"), nil
+				}
+				merged := lines[e.line][:e.valueStart] + repl + lines[e.endLine][e.valueEnd:]
+				return strings.Join(replaceLines(lines, e.line, e.endLine+1, []string{merged}), "\n"), nil
+			case hasTOMLPrefix(names, full):
+				return "", fmt.Errorf("%s is not a table; use the edit tool", strings.Join(full, "."))
+			}
+		}
+	}
+
+	if value == nil {
+		return "", fmt.Errorf("%s not found", formatDataPath(keys))
+	}
+	at := target.header + 1
+	if len(target.entries) > 0 {
+		at = target.entries[len(target.entries)-1].endLine + 1
+	}
+	line := tomlKey(names[len(target.name):]) + " = " + repl
+	return strings.Join(replaceLines(lines, at, at, []string{line}), "\n"), nil
+}
+
+// parseTOML splits lines into the root table and the sections that follow,
+// recording where each entry's value starts and ends.
+func parseTOML(lines []string) ([]tomlSection, error) {
+	sections := []tomlSection{{header: -1}}
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		cur := &sections[len(sections)-1]
+
+		if trimmed[0] == '[' {
+			array := strings.HasPrefix(trimmed, "[[")
+			open, closing := "[", "]"
+			if array {
+				open, closing = "[[", "]]"
+			}
+			name, rest, err := parseTOMLKey(strings.TrimSpace(trimmed[len(open):]))
+			if err != nil || !strings.HasPrefix(strings.TrimSpace(rest), closing) {
+				return nil, fmt.Errorf("line %d: invalid table header", i+1)
+			}
+			cur.end = i
+			sections = append(sections, tomlSection{name: name, array: array, header: i})
+			continue
+		}
+
+		indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " \t"))
+		key, rest, err := parseTOMLKey(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		eq := strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(eq, "=") {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", i+1)
+		}
+		col := indent + len(trimmed) - len(strings.TrimLeft(eq[1:], " \t"))
+		endLine, endCol, err := scanTOMLValue(lines, i, col)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		cur.entries = append(cur.entries, tomlEntry{key: key, line: i, endLine: endLine, valueStart: col, valueEnd: endCol})
+		i = endLine
+	}
+	sections[len(sections)-1].end = len(lines)
+
+	// A section ends after its last content line, so comments and blank
+	// lines before the next header stay in place when it is deleted.
+	for i := range sections[1:] {
+		s := &sections[i+1]
+		last := s.header
+		for l := s.header + 1; l < s.end; l++ {
+			if t := strings.TrimSpace(lines[l]); t != "" && t[0] != '#' {
+				last = l
+			}
+		}
+		for _, e := range s.entries {
+			last = max(last, e.endLine)
+		}
+		s.end = last + 1
+	}
+	return sections, nil
+}
+
+// tomlBareKeyRe matches the characters allowed in a bare key.
+var tomlBareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+`)
+
+// parseTOMLKey parses a possibly dotted, possibly quoted key at the start of
+// s and returns its parts and the rest of s.
+func parseTOMLKey(s string) ([]string, string, error) {
+	var parts []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		switch {
+		case strings.HasPrefix(s, `"`):
+			end, err := scanJSONString(s, 0)
+			if err != nil {
+				return nil, "", errors.New("unterminated quoted key")
+			}
+			var part string
+			if err := json.Unmarshal([]byte(s[:end]), &part); err != nil {
+				return nil, "", fmt.Errorf("invalid quoted key %s", s[:end])
+			}
+			parts, s = append(parts, part), s[end:]
+		case strings.HasPrefix(s, "'"):
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, "", errors.New("unterminated quoted key")
+			}
+			parts, s = append(parts, s[1:end+1]), s[end+2:]
+		default:
+			bare := tomlBareKeyRe.FindString(s)
+			if bare == "" {
+				return nil, "", errors.New("expected a key")
+			}
+			parts, s = append(parts, bare), s[len(bare):]
+		}
+
+		rest := strings.TrimLeft(s, " \t")
+		if !strings.HasPrefix(rest, ".") {
+			return parts, s, nil
+		}
+		s = rest[1:]
+	}
+}
+
+// scanTOMLValue finds the end of the value starting at column col of
+// lines[line], returning the line and column just past it. Arrays, inline
+// tables, and multi-line strings may span several lines.
+func scanTOMLValue(lines []string, line, col int) (int, int, error) {
+	s := lines[line]
+	switch {
+	case col >= len(s):
+		return 0, 0, errors.New("missing value")
+	case strings.HasPrefix(s[col:], `"""`) || strings.HasPrefix(s[col:], "'''"):
+		return scanTOMLMultiline(lines, line, col+3, s[col:col+3])
+	case s[col] == '"' || s[col] == '\'':
+		end, err := scanTOMLString(s, col)
+		return line, end, err
+	case s[col] != '[' && s[col] != '{':
+		// A scalar runs to a comment or the end of the line.
+		end := len(s)
+		if c := strings.IndexByte(s[col:], '#'); c >= 0 {
+			end = col + c
+		}
+		return line, len(strings.TrimRight(s[:end], " \t\r")), nil
+	}
+
+	depth := 0
+	for l := line; l < len(lines); l++ {
+		s := lines[l]
+		i := 0
+		if l == line {
+			i = col
+		}
+		for i < len(s) {
+			switch c := s[i]; {
+			case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], "'''"):
+				endL, endC, err := scanTOMLMultiline(lines, l, i+3, s[i:i+3])
+				if err != nil {
+					return 0, 0, err
+				}
+				l, s, i = endL, lines[endL], endC
+			case c == '"' || c == '\'':
+				end, err := scanTOMLString(s, i)
+				if err != nil {
+					return 0, 0, err
+				}
+				i = end
+			case c == '#':
+				i = len(s)
+			case c == '[' || c == '{':
+				depth++
+				i++
+			case c == ']' || c == '}':
+				depth--
+				i++
+				if depth == 0 {
+					return l, i, nil
+				}
+			default:
+				i++
+			}
+		}
+	}
+	return 0, 0, errors.New("unterminated array or inline table")
+}
+
+// scanTOMLString returns the offset just past the single-line basic or
+// literal string starting at pos.
+func scanTOMLString(s string, pos int) (int, error) {
+	if s[pos] == '"' {
+		end, err := scanJSONString(s, pos)
+		if err != nil {
+			return 0, errors.New("unterminated string")
+		}
+		return end, nil
+	}
+	end := strings.IndexByte(s[pos+1:], '\'')
+	if end < 0 {
+		return 0, errors.New("unterminated string")
+	}
+	return pos + end + 2, nil
+}
+
+// scanTOMLMultiline returns the line and column just past the delimiter
+// closing a multi-line string that starts at column col of lines[line].
+func scanTOMLMultiline(lines []string, line, col int, delim string) (int, int, error) {
+	for l := line; l < len(lines); l++ {
+		s := lines[l]
+		from := 0
+		if l == line {
+			from = col
+		}
+		for i := from; i+len(delim) <= len(s); i++ {
+			if delim == `"""` && s[i] == '\\' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(s[i:], delim) {
+				end := i + len(delim)
+				// Up to two quotes directly before the delimiter belong to
+				// the string.
+				for n := 0; n < 2 && end < len(s) && s[end] == delim[0]; n++ {
+					end++
+				}
+				return l, end, nil
+			}
+		}
+	}
+	return 0, 0, errors.New("unterminated multi-line string")
+}
+
+// tomlValue renders v as an inline TOML value.
+func tomlValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", errors.New("TOML has no null; use the delete operation")
+	case bool, json.Number:
+		return fmt.Sprint(v), nil
+	case string:
+		return jsonQuote(v), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case dataObject:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		parts := make([]string, len(v))
+		for i, f := range v {
+			s, err := tomlValue(f.value)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = tomlKey([]string{f.key}) + " = " + s
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// tomlKey renders a dotted key, quoting parts that are not bare keys.
+func tomlKey(parts []string) string {
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		if p != "" && tomlBareKeyRe.FindString(p) == p {
+			quoted[i] = p
+		} else {
+			quoted[i] = jsonQuote(p)
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// hasTOMLPrefix reports whether path starts with prefix.
+func hasTOMLPrefix(path, prefix []string) bool {
+	return len(prefix) <= len(path) && equalStrings(path[:len(prefix)], prefix)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
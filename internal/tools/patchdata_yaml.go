@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlEntry is a "key: value" line of a block mapping and the lines of its
+// nested block, if any.
+type yamlEntry struct {
+	key        string // unquoted key
+	rawKey     string // key as written
+	indent     int
+	line       int // index of the key's line
+	end        int // index just past the entry's last line
+	valueStart int // offset of the inline value in the key's line
+	inline     string
+	anchor     string // "&name" anchor before the value, if any
+	comment    string // trailing comment on the key's line, with its "#"
+}
+
+// patchYAML implements dataPatcher for YAML. It works on block mappings line
+// by line, like the Taskfile parser, and does not support sequences or flow
+// collections along the path.
+func patchYAML(src string, keys []dataKey, value json.RawMessage) (string, error) {
+	names, err := dataKeyNames(keys, "YAML")
+	if err != nil {
+		return "", err
+	}
+	var v any
+	if value != nil {
+		if v, err = decodeDataValue(value); err != nil {
+			return "", err
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	unit := strings.Repeat(" ", yamlIndentUnit(lines))
+
+	// The mapping being searched spans lines[from:to], indented past parent.
+	from, to, parent := 0, len(lines), -1
+	if i := firstYAMLContent(lines); i >= 0 && strings.TrimSpace(lines[i]) == "---" {
+		from = i + 1
+	}
+	for i, name := range names {
+		entries, err := yamlEntries(lines, from, to)
+		if err != nil {
+			return "", err
+		}
+		idx := -1
+		for j, e := range entries {
+			if e.key == name {
+				idx = j
+			}
+		}
+
+		if idx >= 0 {
+			e := entries[idx]
+			if i == len(names)-1 {
+				if value == nil {
+					lines = replaceLines(lines, e.line, e.end, nil)
+					return strings.Join(lines, "\n"), nil
+				}
+				repl := yamlEntryLines(strings.Repeat(" ", e.indent), unit, e.rawKey, v)
+				if e.anchor != "" {
+					// Keep the anchor so that aliases to it still resolve.
+					prefix := strings.Repeat(" ", e.indent) + e.rawKey + ":"
+					repl[0] = prefix + " " + e.anchor + repl[0][len(prefix):]
+				}
+				if e.comment != "" {
+					repl[0] += " " + e.comment
+				}
+				lines = replaceLines(lines, e.line, e.end, repl)
+				return strings.Join(lines, "\n"), nil
+			}
+
+			switch e.inline {
+			case "":
+			case "{}":
+				// Drop the empty flow mapping so keys can be added below.
+				lines[e.line] = strings.TrimRight(lines[e.line][:e.valueStart], " ")
+				if e.comment != "" {
+					lines[e.line] += " " + e.comment
+				}
+			default:
+				return "", fmt.Errorf("%s is not a mapping", formatDataPath(keys[:i+1]))
+			}
+			from, to, parent = e.line+1, e.end, e.indent
+			continue
+		}
+
+		if value == nil {
+			return "", fmt.Errorf("%s not found", formatDataPath(keys[:i+1]))
+		}
+		indent, at := parent+len(unit), from
+		if parent < 0 {
+			indent = 0
+		}
+		if len(entries) > 0 {
+			indent, at = entries[0].indent, entries[len(entries)-1].end
+		}
+		repl := yamlEntryLines(strings.Repeat(" ", indent), unit, yamlKey(name), nestDataValue(names[i+1:], v))
+		lines = replaceLines(lines, at, at, repl)
+		return strings.Join(lines, "\n"), nil
+	}
+	return "", errors.New("path is empty")
+}
+
+// yamlEntries returns the entries of the block mapping in lines[from:to].
+func yamlEntries(lines []string, from, to int) ([]yamlEntry, error) {
+	var entries []yamlEntry
+	childIndent := -1
+	for i := from; i < to; i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if !isYAMLContent(trimmed) {
+			continue
+		}
+		indent := len(lines[i]) - len(trimmed)
+		if childIndent < 0 {
+			childIndent = indent
+		}
+		if indent > childIndent {
+			continue // part of the previous entry's value
+		}
+		if indent < childIndent {
+			return nil, fmt.Errorf("line %d: inconsistent indentation", i+1)
+		}
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			// A block sequence may sit at its key's own indentation.
+			if n := len(entries); n > 0 && entries[n-1].inline == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: sequences are not supported; use the edit tool", i+1)
+		}
+
+		key, rest, ok := parseYAMLKey(trimmed)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a \"key: value\" entry", i+1)
+		}
+		e := yamlEntry{
+			key:        key,
+			rawKey:     trimmed[:len(trimmed)-len(rest)-1],
+			indent:     indent,
+			line:       i,
+			valueStart: len(lines[i]) - len(rest),
+		}
+		e.inline, e.comment = splitYAMLComment(strings.TrimSpace(rest))
+		if strings.HasPrefix(e.inline, "&") {
+			e.anchor, e.inline, _ = strings.Cut(e.inline, " ")
+			e.inline = strings.TrimSpace(e.inline)
+			e.valueStart = len(lines[i]) - len(strings.TrimLeft(rest, " ")) + len(e.anchor)
+		}
+		entries = append(entries, e)
+	}
+
+	// An entry ends after its last content line; comments and blank lines
+	// before the next entry are left with that entry.
+	for j := range entries {
+		next := to
+		if j+1 < len(entries) {
+			next = entries[j+1].line
+		}
+		entries[j].end = entries[j].line + 1
+		for l := entries[j].end; l < next; l++ {
+			if isYAMLContent(strings.TrimLeft(lines[l], " ")) {
+				entries[j].end = l + 1
+			}
+		}
+	}
+	return entries, nil
+}
+
+// parseYAMLKey splits a mapping entry into its unquoted key and the text
+// after the colon.
+func parseYAMLKey(line string) (key, rest string, ok bool) {
+	if line[0] == '"' || line[0] == '\'' {
+		end := strings.IndexByte(line[1:], line[0])
+		for line[0] == '"' && end > 0 && line[end] == '\\' {
+			next := strings.IndexByte(line[end+2:], '"')
+			if next < 0 {
+				return "", "", false
+			}
+			end += next + 1
+		}
+		if end < 0 {
+			return "", "", false
+		}
+		after := line[end+2:]
+		if !strings.HasPrefix(after, ":") || (len(after) > 1 && after[1] != ' ') {
+			return "", "", false
+		}
+		key = line[1 : end+1]
+		if line[0] == '"' {
+			_ = json.Unmarshal([]byte(line[:end+2]), &key)
+		}
+		return key, after[1:], true
+	}
+
+	colon := strings.Index(line, ": ")
+	if colon < 0 {
+		if !strings.HasSuffix(line, ":") {
+			return "", "", false
+		}
+		colon = len(line) - 1
+	}
+	return strings.TrimSpace(line[:colon]), line[colon+1:], true
+}
+
+// splitYAMLComment splits an inline value from a trailing comment.
+func splitYAMLComment(v string) (value, comment string) {
+	start := 0
+	if v != "" && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end >= 0 {
+			start = end + 2
+		}
+	}
+	for i := start; i < len(v); i++ {
+		if v[i] == '#' && (i == 0 || v[i-1] == ' ' || v[i-1] == '\t') {
+			return strings.TrimSpace(v[:i]), v[i:]
+		}
+	}
+	return v, ""
+}
+
+// yamlEntryLines renders key: v at indent. Non-empty mappings and sequences
+// are written as blocks; sequence items and everything else on one line.
+func yamlEntryLines(indent, unit, key string, v any) []string {
+	switch v := v.(type) {
+	case dataObject:
+		if len(v) > 0 {
+			lines := []string{indent + key + ":"}
+			for _, f := range v {
+				lines = append(lines, yamlEntryLines(indent+unit, unit, yamlKey(f.key), f.value)...)
+			}
+			return lines
+		}
+	case []any:
+		if len(v) > 0 {
+			lines := []string{indent + key + ":"}
+			for _, item := range v {
+				lines = append(lines, indent+unit+"- "+yamlFlow(item))
+			}
+			return lines
+		}
+	}
+	return []string{indent + key + ": " + yamlFlow(v)}
+}
+
+// yamlFlow renders v on a single line.
+func yamlFlow(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = yamlFlow(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case dataObject:
+		parts := make([]string, len(v))
+		for i, f := range v {
+			parts[i] = yamlKey(f.key) + ": " + yamlFlow(f.value)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+var (
+	// yamlPlainRe matches strings that can be written unquoted, subject to
+	// the further checks in yamlString.
+	yamlPlainRe = regexp.MustCompile(`^[A-Za-z_./][A-Za-z0-9_ ./:@+-]*$`)
+
+	// yamlKeyRe matches keys that can be written unquoted.
+	yamlKeyRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_./-]*$`)
+
+	// yamlReserved are plain scalars that YAML reads as something other than
+	// a string.
+	yamlReserved = map[string]bool{
+		"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+		"y": true, "n": true, "null": true, "~": true,
+	}
+)
+
+// yamlString renders s plain if YAML would read it back as the same string,
+// and double-quoted otherwise.
+func yamlString(s string) string {
+	if yamlPlainRe.MatchString(s) && !yamlReserved[strings.ToLower(s)] &&
+		!strings.Contains(s, ": ") && !strings.HasSuffix(s, ":") && !strings.HasSuffix(s, " ") {
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return s
+		}
+	}
+	return jsonQuote(s)
+}
+
+// yamlKey renders a mapping key.
+func yamlKey(s string) string {
+	if yamlKeyRe.MatchString(s) && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return jsonQuote(s)
+}
+
+// yamlIndentUnit returns the smallest indentation in lines, taken as one
+// level, defaulting to 2.
+func yamlIndentUnit(lines []string) int {
+	unit := 0
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && isYAMLContent(trimmed) && (unit == 0 || indent < unit) {
+			unit = indent
+		}
+	}
+	if unit == 0 {
+		return 2
+	}
+	return unit
+}
+
+// firstYAMLContent returns the index of the first content line, or -1.
+func firstYAMLContent(lines []string) int {
+	for i, line := range lines {
+		if isYAMLContent(strings.TrimLeft(line, " ")) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isYAMLContent reports whether a line, without its indentation, is neither
+// blank nor a comment.
+func isYAMLContent(trimmed string) bool {
+	trimmed = strings.TrimSpace(trimmed)
+	return trimmed != "" && trimmed[0] != '#'
+}
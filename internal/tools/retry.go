@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Retrier is implemented by tools whose calls can fail transiently, such as
+// on a network error, so that running the same call again may succeed.
+type Retrier interface {
+	// Retryable reports whether a call with input that returned output and
+	// err failed in a way worth retrying.
+	Retryable(input json.RawMessage, output string, err error) bool
+}
+
+// transientError marks a failure that may not recur, such as a dropped
+// connection or an overloaded server.
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// transient marks err as a transient failure.
+func transient(err error) error {
+	return &transientError{err: err}
+}
+
+// isTransient reports whether err is, or wraps, a transient failure.
+func isTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t)
+}
+
+// Retryable implements Retrier: network errors, rate limiting, and server
+// errors are worth retrying.
+func (t *FetchTool) Retryable(_ json.RawMessage, _ string, err error) bool {
+	return isTransient(err)
+}
+
+// Retryable implements Retrier: a command that exited with an error is
+// worth retrying if its output matches one of the configured retry
+// patterns, such as a DNS failure or a dropped connection.
+func (t *BashTool) Retryable(_ json.RawMessage, output string, err error) bool {
+	if err != nil || !strings.Contains(output, exitCodeMarker) {
+		return false
+	}
+	for _, re := range t.retryPatterns {
+		if re.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileRetryPatterns compiles the bash retry patterns, skipping any that
+// are invalid; the config rejects those before they get here.
+func compileRetryPatterns(patterns []string) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		if re, err := regexp.Compile(p); err == nil {
+			res = append(res, re)
+		}
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchRetryable(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	tool := NewFetchTool(srv.Client())
+	for _, tc := range []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusServiceUnavailable, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusNotFound, false},
+	} {
+		status = tc.status
+		input, _ := json.Marshal(map[string]string{"url": srv.URL})
+		out, err := tool.Execute(context.Background(), input)
+		if err == nil {
+			t.Fatalf("HTTP %d: no error", tc.status)
+		}
+		if got := tool.Retryable(input, out, err); got != tc.want {
+			t.Errorf("HTTP %d: Retryable = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+
+	// Bad input is not worth retrying.
+	input := json.RawMessage(`{"url": 1}`)
+	out, err := tool.Execute(context.Background(), input)
+	if tool.Retryable(input, out, err) {
+		t.Errorf("invalid parameters are retryable: %v", err)
+	}
+}
+
+func TestBashRetryable(t *testing.T) {
+	tool := NewBashTool(t.TempDir(), BashOptions{RetryPatterns: []string{"Could not resolve host", "("}})
+
+	for _, tc := range []struct {
+		command string
+		want    bool
+	}{
+		{"echo 'fatal: Could not resolve host: example.com' >&2; exit 128", true},
+		{"echo 'fatal: repository not found' >&2; exit 128", false},
+		{"echo 'Could not resolve host (but it worked)'", false},
+	} {
+		input, _ := json.Marshal(map[string]string{"command": tc.command})
+		out, err := tool.Execute(context.Background(), input)
+		if got := tool.Retryable(input, out, err); got != tc.want {
+			t.Errorf("%s: Retryable = %v, want %v (output %q)", tc.command, got, tc.want, out)
+		}
+	}
+}
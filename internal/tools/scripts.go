@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// scriptsMaxFileSize is the largest script file parsed, in bytes.
+const scriptsMaxFileSize = 1 << 20
+
+// ScriptsTool lists the commands a project defines in package.json, a
+// Makefile, a justfile, or a Taskfile, so the agent can find the right build,
+// test, or lint command instead of guessing.
+type ScriptsTool struct {
+	paths PathPolicy
+}
+
+// NewScriptsTool creates a new scripts tool.
+func NewScriptsTool(paths PathPolicy) *ScriptsTool {
+	return &ScriptsTool{paths: paths}
+}
+
+func (t *ScriptsTool) Name() string { return "scripts" }
+
+func (t *ScriptsTool) Description() string {
+	return "List the project's runnable scripts with their definitions: package.json scripts, Makefile targets, justfile recipes, and Taskfile tasks. Use this to find the right build, test, or lint command."
+}
+
+func (t *ScriptsTool) Parameters() json.RawMessage {
+	schema := ToolDef{
+		Type: "object",
+		Properties: map[string]Property{
+			"path": {
+				Type:        "string",
+				Description: "Optional directory to look in, relative to the working directory. Defaults to the working directory.",
+			},
+		},
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func (t *ScriptsTool) RequiresPermission() bool { return false }
+
+// scriptSource is a kind of file that defines project scripts.
+type scriptSource struct {
+	names []string // file names to look for, in order of preference
+	usage string   // how the scripts are run
+	parse func(data []byte) ([]script, error)
+}
+
+// script is a named command and its definition.
+type script struct {
+	name       string
+	definition string
+}
+
+var scriptSources = []scriptSource{
+	{[]string{"package.json"}, "npm run <name>", parsePackageScripts},
+	{[]string{"GNUmakefile", "makefile", "Makefile"}, "make <target>", parseMakeTargets},
+	{[]string{"justfile", "Justfile", ".justfile"}, "just <recipe>", parseJustRecipes},
+	{[]string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"}, "task <name>", parseTaskfileTasks},
+}
+
+func (t *ScriptsTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return "", fmt.Errorf("parsing scripts parameters: %w", err)
+	}
+
+	dir, err := t.paths.ResolveRead(params.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, src := range scriptSources {
+		for _, name := range src.names {
+			file, err := t.paths.ResolveRead(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			data, err := readScriptFile(file)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err == nil {
+				var scripts []script
+				if scripts, err = src.parse(data); err == nil {
+					writeScripts(&b, name, src.usage, scripts)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(&b, "%s: %s\n\n", name, err)
+			}
+			break
+		}
+	}
+
+	if b.Len() == 0 {
+		return "No package.json, Makefile, justfile, or Taskfile found.", nil
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// readScriptFile reads a script file, refusing directories and oversized
+// files.
+func readScriptFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file")
+	}
+	if info.Size() > scriptsMaxFileSize {
+		return nil, fmt.Errorf("file too large to parse (%d bytes)", info.Size())
+	}
+	return os.ReadFile(path)
+}
+
+// writeScripts formats the scripts found in file.
+func writeScripts(b *strings.Builder, file, usage string, scripts []script) {
+	if len(scripts) == 0 {
+		fmt.Fprintf(b, "%s: no scripts defined\n\n", file)
+		return
+	}
+	fmt.Fprintf(b, "%s (run with `%s`):\n", file, usage)
+	for _, s := range scripts {
+		if s.definition == "" {
+			fmt.Fprintf(b, "  %s\n", s.name)
+			continue
+		}
+		lines := strings.Split(s.definition, "\n")
+		if len(lines) == 1 {
+			fmt.Fprintf(b, "  %s: %s\n", s.name, lines[0])
+			continue
+		}
+		fmt.Fprintf(b, "  %s:\n", s.name)
+		for _, line := range lines {
+			fmt.Fprintf(b, "    %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+}
+
+// parsePackageScripts returns the scripts of a package.json, sorted by name.
+func parsePackageScripts(data []byte) ([]script, error) {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing package.json: %w", err)
+	}
+
+	scripts := make([]script, 0, len(pkg.Scripts))
+	for name, cmd := range pkg.Scripts {
+		scripts = append(scripts, script{name: name, definition: cmd})
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].name < scripts[j].name })
+	return scripts, nil
+}
+
+// makeTargetRe matches a rule line, capturing its targets. Variable
+// assignments (":=", "::=") are excluded by the caller.
+var makeTargetRe = regexp.MustCompile(`^([^\s:#=][^:#=]*?)\s*::?(?:[^=]|$)`)
+
+// parseMakeTargets returns the explicit targets of a Makefile with their
+// recipes. Special targets such as .PHONY and pattern rules are skipped.
+func parseMakeTargets(data []byte) ([]script, error) {
+	var scripts []script
+	var current []int // indices of the targets of the rule being read
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			for _, i := range current {
+				scripts[i].definition = joinLine(scripts[i].definition, strings.TrimSpace(line))
+			}
+			continue
+		}
+		current = current[:0]
+
+		m := makeTargetRe.FindStringSubmatch(line)
+		if m == nil || strings.Contains(line, ":=") {
+			continue
+		}
+		for _, target := range strings.Fields(m[1]) {
+			if strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$") {
+				continue
+			}
+			current = append(current, len(scripts))
+			scripts = append(scripts, script{name: target})
+		}
+	}
+	return scripts, scanner.Err()
+}
+
+// justRecipeRe matches a recipe header, capturing its name.
+var justRecipeRe = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)(?:\s[^:]*)?:(?:[^=]|$)`)
+
+// justKeywords start justfile lines that are not recipes.
+var justKeywords = []string{"alias", "export", "import", "mod", "set"}
+
+// parseJustRecipes returns the recipes of a justfile with their bodies.
+func parseJustRecipes(data []byte) ([]script, error) {
+	var scripts []script
+	var current *script
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && (line[0] == ' ' || line[0] == '\t') {
+			if current != nil {
+				current.definition = joinLine(current.definition, strings.TrimSpace(line))
+			}
+			continue
+		}
+		current = nil
+
+		m := justRecipeRe.FindStringSubmatch(line)
+		if m == nil || isJustKeyword(line) {
+			continue
+		}
+		scripts = append(scripts, script{name: m[1]})
+		current = &scripts[len(scripts)-1]
+	}
+	return scripts, scanner.Err()
+}
+
+// isJustKeyword reports whether line is a justfile setting or directive
+// rather than a recipe.
+func isJustKeyword(line string) bool {
+	word, _, _ := strings.Cut(line, " ")
+	for _, k := range justKeywords {
+		if word == k {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTaskfileTasks returns the tasks of a Taskfile with their YAML
+// definitions. It reads the tasks section by indentation rather than parsing
+// the whole file as YAML.
+func parseTaskfileTasks(data []byte) ([]script, error) {
+	var scripts []script
+	var current *script
+	inTasks := false
+	taskIndent := -1
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		if indent == 0 {
+			inTasks = trimmed == "tasks:"
+			current = nil
+			continue
+		}
+		if !inTasks {
+			continue
+		}
+		if taskIndent < 0 {
+			taskIndent = indent
+		}
+
+		switch {
+		case indent == taskIndent:
+			name, rest, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				current = nil
+				continue
+			}
+			scripts = append(scripts, script{name: strings.Trim(name, `"'`)})
+			current = &scripts[len(scripts)-1]
+			if rest = strings.TrimSpace(rest); rest != "" {
+				current.definition = rest
+			}
+		case indent > taskIndent && current != nil:
+			current.definition = joinLine(current.definition, line[min(indent, taskIndent+2):])
+		}
+	}
+	return scripts, scanner.Err()
+}
+
+// joinLine appends line to text on a new line.
+func joinLine(text, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + "\n" + line
+}
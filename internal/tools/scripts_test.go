@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptsTool(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name": "app", "scripts": {"test": "jest", "build": "tsc -p ."}}`,
+		"Makefile": "BIN := app\n" +
+			".PHONY: build test\n" +
+			"\n" +
+			"build: deps\n" +
+			"\tgo build -o $(BIN) ./cmd/app\n" +
+			"\n" +
+			"%.o: %.c\n" +
+			"\tcc -c $<\n" +
+			"\n" +
+			"test lint:\n" +
+			"\tgo test ./...\n",
+		"justfile": "set shell := [\"bash\", \"-c\"]\n" +
+			"alias t := test\n" +
+			"\n" +
+			"# Run the tests\n" +
+			"test *args:\n" +
+			"    go test {{args}} ./...\n" +
+			"@fmt:\n" +
+			"    gofmt -w .\n",
+		"Taskfile.yml": "version: '3'\n" +
+			"\n" +
+			"tasks:\n" +
+			"  build:\n" +
+			"    desc: Build the app\n" +
+			"    cmds:\n" +
+			"      - go build ./...\n" +
+			"  clean: rm -rf dist\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := NewScriptsTool(PathPolicy{WorkDir: dir, Confine: true}).Execute(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package.json (run with `npm run <name>`):\n" +
+		"  build: tsc -p .\n" +
+		"  test: jest\n" +
+		"\n" +
+		"Makefile (run with `make <target>`):\n" +
+		"  build: go build -o $(BIN) ./cmd/app\n" +
+		"  test: go test ./...\n" +
+		"  lint: go test ./...\n" +
+		"\n" +
+		"justfile (run with `just <recipe>`):\n" +
+		"  test: go test {{args}} ./...\n" +
+		"  fmt: gofmt -w .\n" +
+		"\n" +
+		"Taskfile.yml (run with `task <name>`):\n" +
+		"  build:\n" +
+		"    desc: Build the app\n" +
+		"    cmds:\n" +
+		"      - go build ./...\n" +
+		"  clean: rm -rf dist\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestScriptsToolConfined(t *testing.T) {
+	tool := NewScriptsTool(PathPolicy{WorkDir: t.TempDir(), Confine: true})
+	if _, err := tool.Execute(context.Background(), []byte(`{"path":"../"}`)); err == nil {
+		t.Error("expected an error for a directory outside the working directory")
+	}
+	out, err := tool.Execute(context.Background(), []byte(`{}`))
+	if err != nil || out != "No package.json, Makefile, justfile, or Taskfile found." {
+		t.Errorf("empty project: %q, %v", out, err)
+	}
+
+	// Directories outside are readable through the read roots, or when the
+	// tools are not confined.
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "Makefile"), []byte("test:\n\tgo test ./...\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input, _ := json.Marshal(map[string]string{"path": outside})
+	for _, paths := range []PathPolicy{
+		{WorkDir: t.TempDir(), Confine: true, ReadRoots: []string{outside}},
+		{WorkDir: t.TempDir()},
+	} {
+		out, err := NewScriptsTool(paths).Execute(context.Background(), input)
+		if err != nil || !strings.Contains(out, "test: go test ./...") {
+			t.Errorf("%+v: %q, %v", paths, out, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"strings"
+)
+
+// shellCommand is a simple command from a shell command line: its words
+// after quote removal, and the files its output is redirected to.
+type shellCommand struct {
+	words   []string
+	outputs []string
+}
+
+// shellParser splits a command line into simple commands; see parseShell.
+type shellParser struct {
+	src  string
+	pos  int
+	cmds []shellCommand
+
+	cur     shellCommand
+	word    strings.Builder
+	inWord  bool
+	target  int      // what the next word is: 0 a word, 1 an output file, 2 an input file or fd
+	heredoc []string // delimiters of here-documents whose bodies start at the next newline
+}
+
+// Where the next word goes, for shellParser.target.
+const (
+	shellWord = iota
+	shellOutput
+	shellSkip
+)
+
+// parseShell splits a command line into simple commands. It handles quoting,
+// backslash escapes, comments, the separators ; & && || | and newlines,
+// redirections, and here-documents. Subshells and command substitutions are
+// split out as commands of their own. It does not expand anything and is
+// meant for best-effort inspection, not execution.
+func parseShell(line string) []shellCommand {
+	p := &shellParser{src: line}
+	p.parse()
+	return p.cmds
+}
+
+func (p *shellParser) parse() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t':
+			p.endWord()
+			p.pos++
+		case c == '\n':
+			p.endCommand()
+			p.pos++
+			p.skipHeredocs()
+		case c == ';' || c == '|' || c == '(' || c == ')' || c == '`':
+			p.endCommand()
+			p.pos++
+		case c == '&':
+			if strings.HasPrefix(p.src[p.pos:], "&>") {
+				p.redirect()
+				continue
+			}
+			p.endCommand()
+			p.pos++
+		case c == '$' && strings.HasPrefix(p.src[p.pos:], "$("):
+			p.endCommand()
+			p.pos += 2
+		case c == '#' && !p.inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '>' || c == '<':
+			p.redirect()
+		case c == '\'':
+			p.inWord = true
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				end = len(p.src) - p.pos - 1
+			}
+			p.word.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			p.pos += end + 2
+		case c == '"':
+			p.inWord = true
+			p.pos++
+			for p.pos < len(p.src) && p.src[p.pos] != '"' {
+				if p.src[p.pos] == '\\' && p.pos+1 < len(p.src) && strings.IndexByte("\\\"$`\n", p.src[p.pos+1]) >= 0 {
+					p.pos++
+				}
+				p.word.WriteByte(p.src[p.pos])
+				p.pos++
+			}
+			p.pos++
+		case c == '\\':
+			p.inWord = true
+			if p.pos+1 < len(p.src) && p.src[p.pos+1] != '\n' {
+				p.word.WriteByte(p.src[p.pos+1])
+			}
+			p.pos += 2
+		default:
+			p.inWord = true
+			p.word.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.endCommand()
+}
+
+// redirect consumes a redirection operator at p.pos. A file descriptor
+// number written just before it, as in 2>, is dropped from the words.
+func (p *shellParser) redirect() {
+	if p.inWord && isDigits(p.word.String()) {
+		p.word.Reset()
+		p.inWord = false
+	}
+	p.endWord()
+
+	rest := p.src[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, "<<<"):
+		p.pos += 3
+		p.target = shellSkip
+	case strings.HasPrefix(rest, "<<"):
+		p.pos += 2
+		if p.pos < len(p.src) && p.src[p.pos] == '-' {
+			p.pos++
+		}
+		p.readHeredocDelimiter()
+	case strings.HasPrefix(rest, ">&") || strings.HasPrefix(rest, "<&"):
+		p.pos += 2
+		p.target = shellSkip
+	case strings.HasPrefix(rest, "&>>"):
+		p.pos += 3
+		p.target = shellOutput
+	case strings.HasPrefix(rest, "&>"), strings.HasPrefix(rest, ">>"), strings.HasPrefix(rest, ">|"):
+		p.pos += 2
+		p.target = shellOutput
+	case rest[0] == '>':
+		p.pos++
+		p.target = shellOutput
+	default:
+		p.pos++
+		p.target = shellSkip
+	}
+}
+
+// readHeredocDelimiter reads the word after << and queues its body to be
+// skipped.
+func (p *shellParser) readHeredocDelimiter() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n;&|<>()", p.src[p.pos]) < 0 {
+		p.pos++
+	}
+	delim := strings.NewReplacer(`'`, "", `"`, "", `\`, "").Replace(p.src[start:p.pos])
+	if delim != "" {
+		p.heredoc = append(p.heredoc, delim)
+	}
+}
+
+// skipHeredocs skips the bodies of pending here-documents, which start at
+// p.pos.
+func (p *shellParser) skipHeredocs() {
+	for _, delim := range p.heredoc {
+		for p.pos < len(p.src) {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			line := p.src[p.pos : p.pos+end]
+			p.pos += end + 1
+			if strings.TrimLeft(line, "\t") == delim {
+				break
+			}
+		}
+	}
+	p.heredoc = nil
+	p.pos = min(p.pos, len(p.src))
+}
+
+// endWord finishes the word being read, if any.
+func (p *shellParser) endWord() {
+	if !p.inWord {
+		return
+	}
+	w := p.word.String()
+	switch p.target {
+	case shellOutput:
+		p.cur.outputs = append(p.cur.outputs, w)
+	case shellWord:
+		p.cur.words = append(p.cur.words, w)
+	}
+	p.word.Reset()
+	p.inWord = false
+	p.target = shellWord
+}
+
+// endCommand finishes the command being read, if it has any words or
+// redirections.
+func (p *shellParser) endCommand() {
+	p.endWord()
+	p.target = shellWord
+	if len(p.cur.words) > 0 || len(p.cur.outputs) > 0 {
+		p.cmds = append(p.cmds, p.cur)
+	}
+	p.cur = shellCommand{}
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShell(t *testing.T) {
+	tests := []struct {
+		line string
+		want []shellCommand
+	}{
+		{`ls -la`, []shellCommand{{words: []string{"ls", "-la"}}}},
+		{`echo "a; b" 'c | d' e\ f`, []shellCommand{{words: []string{"echo", "a; b", "c | d", "e f"}}}},
+		{`cd dir && make || echo failed; true & wait`, []shellCommand{
+			{words: []string{"cd", "dir"}},
+			{words: []string{"make"}},
+			{words: []string{"echo", "failed"}},
+			{words: []string{"true"}},
+			{words: []string{"wait"}},
+		}},
+		{`grep -r foo . 2>/dev/null | sort > out.txt`, []shellCommand{
+			{words: []string{"grep", "-r", "foo", "."}, outputs: []string{"/dev/null"}},
+			{words: []string{"sort"}, outputs: []string{"out.txt"}},
+		}},
+		{`go test ./... 2>&1 >>log &>all < in`, []shellCommand{
+			{words: []string{"go", "test", "./..."}, outputs: []string{"log", "all"}},
+		}},
+		{`echo $(rm x) ` + "`touch y`", []shellCommand{
+			{words: []string{"echo"}},
+			{words: []string{"rm", "x"}},
+			{words: []string{"touch", "y"}},
+		}},
+		{"cat > notes.md <<'EOF'\nrm -rf /\nEOF\nls # a comment", []shellCommand{
+			{words: []string{"cat"}, outputs: []string{"notes.md"}},
+			{words: []string{"ls"}},
+		}},
+		{`FOO=1 "unterminated`, []shellCommand{{words: []string{"FOO=1", "unterminated"}}}},
+	}
+	for _, tt := range tests {
+		if got := parseShell(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseShell(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -3,7 +3,14 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -25,6 +32,178 @@ type Tool interface {
 	Execute(ctx context.Context, input json.RawMessage) (string, error)
 }
 
+// FileChanger is implemented by tools that create, modify, or delete files,
+// so callers can report which paths a call touches.
+type FileChanger interface {
+	// ChangedPaths returns the absolute paths the given input would modify,
+	// or an error if the call would be refused, such as for a path outside
+	// the allowed roots.
+	ChangedPaths(input json.RawMessage) ([]string, error)
+}
+
+// Previewer is implemented by tools that can describe what a call will do,
+// shown to the user when asking for permission.
+type Previewer interface {
+	// Preview returns a human-readable description of the call's effect,
+	// such as a diff, or "" if none is available. It returns an error if the
+	// call would be refused, so the user is not asked about it.
+	Preview(input json.RawMessage) (string, error)
+}
+
+// FileProposal describes the content a tool call would write to a file.
+type FileProposal struct {
+	Path    string // absolute path of the file
+	Content string // the file's content after the call
+	Diff    string // unified diff against the current content
+}
+
+// Reviewer is implemented by tools whose effect is writing one file, so the
+// user can review the resulting content, and amend it, before it is written.
+type Reviewer interface {
+	// Propose computes what the call would write without writing it.
+	Propose(input json.RawMessage) (FileProposal, error)
+}
+
+// resolvePath returns path as an absolute path, interpreting relative paths
+// against workDir.
+func resolvePath(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
+
+// PathPolicy resolves the path arguments of the file tools.
+type PathPolicy struct {
+	// WorkDir is the directory relative paths are resolved against.
+	WorkDir string
+
+	// Confine rejects paths that resolve outside WorkDir.
+	Confine bool
+
+	// ReadRoots are additional absolute directories that read-only tools may
+	// access when confined. Writes stay confined to WorkDir.
+	ReadRoots []string
+}
+
+// Resolve returns path as a clean absolute path. When the policy is confined,
+// paths that escape the working directory, either lexically or through a
+// symlink, are rejected.
+func (p PathPolicy) Resolve(path string) (string, error) {
+	abs := filepath.Clean(resolvePath(p.WorkDir, path))
+	if !p.Confine {
+		return abs, nil
+	}
+	if !withinDir(p.WorkDir, abs) {
+		return "", fmt.Errorf("%s is outside the working directory %s; set confineToWorkDir to false in the config to allow access", path, p.WorkDir)
+	}
+
+	realRoot, err := realPath(p.WorkDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving working directory: %w", err)
+	}
+	realTarget, err := realPath(abs)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+	if !withinDir(realRoot, realTarget) {
+		return "", fmt.Errorf("%s escapes the working directory via symlink (resolves to %s)", path, realTarget)
+	}
+	return abs, nil
+}
+
+// ResolveRead is like Resolve, but also accepts paths inside one of the
+// policy's read roots. Relative paths are still resolved against WorkDir.
+func (p PathPolicy) ResolveRead(path string) (string, error) {
+	resolved, err := p.Resolve(path)
+	if err == nil || !p.Confine {
+		return resolved, err
+	}
+	abs := filepath.Clean(resolvePath(p.WorkDir, path))
+	for _, root := range p.ReadRoots {
+		if resolved, rerr := (PathPolicy{WorkDir: root, Confine: true}).Resolve(abs); rerr == nil {
+			return resolved, nil
+		}
+	}
+	if len(p.ReadRoots) > 0 {
+		return "", fmt.Errorf("%s is outside the working directory and the extra read roots", path)
+	}
+	return "", err
+}
+
+// Readable reports whether ResolveRead accepts path. The search tools use it
+// to skip entries, such as symlinks, that lead outside the allowed roots.
+func (p PathPolicy) Readable(path string) bool {
+	_, err := p.ResolveRead(path)
+	return err == nil
+}
+
+// ResolveEntry is like Resolve, but only resolves symlinks in the parent
+// directory, so a symlink itself can be moved or deleted without following it.
+func (p PathPolicy) ResolveEntry(path string) (string, error) {
+	abs := filepath.Clean(resolvePath(p.WorkDir, path))
+	if !p.Confine || abs == filepath.Clean(p.WorkDir) {
+		return abs, nil
+	}
+	if !withinDir(p.WorkDir, abs) {
+		return "", fmt.Errorf("%s is outside the working directory %s", path, p.WorkDir)
+	}
+	if _, err := p.Resolve(filepath.Dir(abs)); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// realPath resolves symlinks in path. Trailing components that do not exist
+// yet (e.g. a file about to be created) are kept as-is beneath the resolved
+// location of their nearest existing ancestor. Dangling symlinks are followed
+// to their target, since writing through one creates the target.
+func realPath(path string) (string, error) {
+	var missing []string
+	for hops := 0; ; hops++ {
+		if hops > 255 {
+			return "", fmt.Errorf("too many levels of symbolic links")
+		}
+
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if info, lerr := os.Lstat(path); lerr == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			path = target
+			continue
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(path))
+		path = parent
+	}
+}
+
+// withinDir reports whether the absolute path lies inside dir (or is dir).
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
 // ToolDef is a convenience struct for building JSON Schema tool parameter definitions.
 type ToolDef struct {
 	Type       string              `json:"type"`
@@ -86,6 +265,43 @@ func (r *Registry) All() []Tool {
 	return result
 }
 
+// Names returns the names of all registered tools in insertion order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string(nil), r.order...)
+}
+
+// Remove unregisters the named tool, reporting whether it was registered.
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return false
+	}
+	delete(r.tools, name)
+	r.order = slices.DeleteFunc(r.order, func(n string) bool { return n == name })
+	return true
+}
+
+// Clone returns a new registry holding the same tools. Later changes to
+// either registry do not affect the other.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := &Registry{
+		tools: make(map[string]Tool, len(r.tools)),
+		order: append([]string(nil), r.order...),
+	}
+	for name, t := range r.tools {
+		c.tools[name] = t
+	}
+	return c
+}
+
 // Execute looks up and executes a tool by name.
 func (r *Registry) Execute(ctx context.Context, name string, input json.RawMessage) (string, error) {
 	t, ok := r.Get(name)
@@ -95,23 +311,71 @@ func (r *Registry) Execute(ctx context.Context, name string, input json.RawMessa
 	return t.Execute(ctx, input)
 }
 
+// Options configures the built-in tools.
+type Options struct {
+	// WorkDir is the directory relative paths are resolved against.
+	WorkDir string
+
+	// HTTPClient is used by tools that make outbound HTTP requests.
+	HTTPClient *http.Client
+
+	// Ignore lists paths skipped by the filesystem search tools.
+	Ignore *IgnoreList
+
+	// ConfineToWorkDir restricts the file tools to paths inside WorkDir.
+	ConfineToWorkDir bool
+
+	// ExtraReadRoots are absolute directories the read-only tools may also
+	// access when confined.
+	ExtraReadRoots []string
+
+	// AtomicWrite makes the write and edit tools replace files atomically.
+	AtomicWrite bool
+
+	// PreserveLineEndings makes the write and edit tools keep CRLF line
+	// endings in files that use them.
+	PreserveLineEndings bool
+
+	// MaxViewBytes is the largest file the view tool reads whole; larger
+	// files need an offset or limit. 0 disables the check.
+	MaxViewBytes int64
+
+	// BashRetryPatterns are regular expressions matched against the output
+	// of failed bash commands; a match makes the command retryable (see
+	// Retrier).
+	BashRetryPatterns []string
+}
+
 // DefaultRegistry creates a registry with all built-in tools pre-registered.
-func DefaultRegistry(workDir string) *Registry {
+func DefaultRegistry(opts Options) *Registry {
 	r := NewRegistry()
+	workDir := opts.WorkDir
+	paths := PathPolicy{
+		WorkDir:   workDir,
+		Confine:   opts.ConfineToWorkDir,
+		ReadRoots: opts.ExtraReadRoots,
+	}
 
 	// Read-only tools
-	r.Register(NewGlobTool(workDir))
-	r.Register(NewGrepTool(workDir))
-	r.Register(NewLsTool(workDir))
-	r.Register(NewViewTool(workDir))
+	r.Register(NewGlobTool(paths, opts.Ignore))
+	r.Register(NewGrepTool(paths, opts.Ignore))
+	r.Register(NewLsTool(paths, opts.Ignore))
+	r.Register(NewViewTool(paths, opts.MaxViewBytes))
+	r.Register(NewGoDocTool(workDir))
+	r.Register(NewGitDiffTool(workDir))
+	r.Register(NewScriptsTool(paths))
 
 	// Write tools (require permission)
-	r.Register(NewBashTool(workDir))
-	r.Register(NewWriteTool(workDir))
-	r.Register(NewEditTool(workDir))
+	r.Register(NewBashTool(workDir, BashOptions{RetryPatterns: opts.BashRetryPatterns}))
+	writeOpts := WriteOptions{Atomic: opts.AtomicWrite, PreserveLineEndings: opts.PreserveLineEndings}
+	r.Register(NewWriteTool(paths, writeOpts))
+	r.Register(NewEditTool(paths, writeOpts))
+	r.Register(NewPatchDataTool(paths, writeOpts))
+	r.Register(NewMoveTool(workDir))
+	r.Register(NewDeleteTool(workDir))
 
 	// Network tools
-	r.Register(NewFetchTool())
+	r.Register(NewFetchTool(opts.HTTPClient))
 
 	return r
 }
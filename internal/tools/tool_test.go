@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPathPolicyResolve(t *testing.T) {
+	confined := PathPolicy{WorkDir: "/repo", Confine: true}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"", "/repo", false},
+		{"main.go", "/repo/main.go", false},
+		{"/repo/internal/x.go", "/repo/internal/x.go", false},
+		{"internal/../main.go", "/repo/main.go", false},
+		{"../other/secret", "", true},
+		{"/home/user/.ssh/id_rsa", "", true},
+		{"/repository/file", "", true},
+	}
+	for _, tt := range tests {
+		got, err := confined.Resolve(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	open := PathPolicy{WorkDir: "/repo"}
+	if got, err := open.Resolve("/etc/hosts"); err != nil || got != "/etc/hosts" {
+		t.Errorf("unconfined Resolve = %q, %v", got, err)
+	}
+}
+
+func TestPathPolicyResolveSymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"out":      outside,
+		"inner":    filepath.Join(root, "src"),
+		"dangling": filepath.Join(outside, "new-file"),
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	p := PathPolicy{WorkDir: root, Confine: true}
+
+	escapes := []string{"out/secret", "out/new/file.go", "out", "dangling"}
+	for _, path := range escapes {
+		_, err := p.Resolve(path)
+		if err == nil || !strings.Contains(err.Error(), "via symlink") {
+			t.Errorf("Resolve(%q) error = %v, want symlink escape", path, err)
+		}
+	}
+
+	allowed := []string{"inner/main.go", "src/new/file.go", "missing.txt"}
+	for _, path := range allowed {
+		if _, err := p.Resolve(path); err != nil {
+			t.Errorf("Resolve(%q) unexpected error: %v", path, err)
+		}
+	}
+
+	if _, err := (PathPolicy{WorkDir: root}).Resolve("out/secret"); err != nil {
+		t.Errorf("unconfined Resolve through symlink: %v", err)
+	}
+}
+
+func TestPathPolicyResolveEntry(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	p := PathPolicy{WorkDir: root, Confine: true}
+	if _, err := p.ResolveEntry("out"); err != nil {
+		t.Errorf("ResolveEntry on the symlink itself: %v", err)
+	}
+	if _, err := p.ResolveEntry("out/file"); err == nil {
+		t.Error("ResolveEntry through an escaping symlink should fail")
+	}
+}
+
+func TestPathPolicyResolveRead(t *testing.T) {
+	p := PathPolicy{WorkDir: "/repo", Confine: true, ReadRoots: []string{"/libs/shared"}}
+
+	if got, err := p.ResolveRead("/libs/shared/pkg/x.go"); err != nil || got != "/libs/shared/pkg/x.go" {
+		t.Errorf("ResolveRead in read root = %q, %v", got, err)
+	}
+	if got, err := p.ResolveRead("main.go"); err != nil || got != "/repo/main.go" {
+		t.Errorf("ResolveRead in work dir = %q, %v", got, err)
+	}
+	if _, err := p.ResolveRead("/libs/other/x.go"); err == nil {
+		t.Error("ResolveRead outside all roots should fail")
+	}
+	if _, err := p.Resolve("/libs/shared/pkg/x.go"); err == nil {
+		t.Error("Resolve (write) in a read root should fail")
+	}
+}
+
+func TestPreviewRefusesOutsidePaths(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := []struct {
+		tool interface {
+			Previewer
+			FileChanger
+		}
+		input map[string]string
+	}{
+		{NewMoveTool(root), map[string]string{"source": outside, "destination": "inside"}},
+		{NewMoveTool(root), map[string]string{"source": "inside", "destination": "../escape"}},
+		{NewDeleteTool(root), map[string]string{"path": outside}},
+		{NewWriteTool(PathPolicy{WorkDir: root, Confine: true}, WriteOptions{}), map[string]string{"file_path": outside}},
+	}
+	for _, c := range calls {
+		input, _ := json.Marshal(c.input)
+		if preview, err := c.tool.Preview(input); err == nil {
+			t.Errorf("%T.Preview(%s) = %q, want an error", c.tool, input, preview)
+		}
+		if paths, err := c.tool.ChangedPaths(input); err == nil {
+			t.Errorf("%T.ChangedPaths(%s) = %v, want an error", c.tool, input, paths)
+		}
+	}
+
+	input, _ := json.Marshal(map[string]string{"path": "missing.txt"})
+	if preview, err := NewDeleteTool(root).Preview(input); err != nil || preview != "delete missing.txt (does not exist)" {
+		t.Errorf("Preview inside the working directory = %q, %v", preview, err)
+	}
+}
+
+func TestRegistryRemoveAndClone(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewLsTool(PathPolicy{}, nil))
+	r.Register(NewBashTool("", BashOptions{}))
+	r.Register(NewFetchTool(nil))
+
+	clone := r.Clone()
+	if !r.Remove("bash") || r.Remove("bash") {
+		t.Error("Remove should report whether the tool was registered")
+	}
+	if got := r.Names(); !slices.Equal(got, []string{"ls", "fetch"}) {
+		t.Errorf("Names() = %v after removing bash", got)
+	}
+	if _, ok := r.Get("bash"); ok {
+		t.Error("removed tool is still returned by Get")
+	}
+	if got := clone.Names(); !slices.Equal(got, []string{"ls", "bash", "fetch"}) {
+		t.Errorf("clone Names() = %v, want it unaffected by Remove", got)
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := NewRegistry()
+	ls := NewLsTool(PathPolicy{WorkDir: t.TempDir()}, nil)
+	r.Register(ls)
+	r.Register(NewFetchTool(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			r.Remove("ls")
+			r.Register(ls)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		snapshot := r.Clone()
+		if _, ok := snapshot.Get("fetch"); !ok {
+			t.Fatal("fetch missing from snapshot")
+		}
+		if len(snapshot.All()) != len(snapshot.Names()) {
+			t.Fatal("snapshot changed while being read")
+		}
+		r.Get("ls")
+		r.Names()
+	}
+	cancel()
+	wg.Wait()
+}
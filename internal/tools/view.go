@@ -5,25 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
 // ViewTool reads file contents with optional offset and limit.
 type ViewTool struct {
-	workDir string
+	paths    PathPolicy
+	maxBytes int64
 }
 
-// NewViewTool creates a new view tool.
-func NewViewTool(workDir string) *ViewTool {
-	return &ViewTool{workDir: workDir}
+// NewViewTool creates a new view tool. Files larger than maxBytes are only
+// read in parts, with an explicit offset or limit; 0 means no limit.
+func NewViewTool(paths PathPolicy, maxBytes int64) *ViewTool {
+	return &ViewTool{paths: paths, maxBytes: maxBytes}
 }
 
 func (t *ViewTool) Name() string { return "view" }
 
 func (t *ViewTool) Description() string {
-	return "Read a file's contents. Returns lines prefixed with line numbers. Use offset and limit to read specific sections of large files."
+	return "Read a file's contents. Returns lines prefixed with line numbers, or the raw text with line_numbers set to false. Use offset and limit to read specific sections of large files. Text is extracted from PDFs; other binary files are described by type and size."
 }
 
 func (t *ViewTool) Parameters() json.RawMessage {
@@ -42,6 +45,10 @@ func (t *ViewTool) Parameters() json.RawMessage {
 				Type:        "number",
 				Description: "The maximum number of lines to read. Defaults to 2000.",
 			},
+			"line_numbers": {
+				Type:        "boolean",
+				Description: "If false, return the lines as they are in the file, without line number prefixes or notes, such as to copy them into another file. Fails instead of returning text that differs from the file: pass a limit for files over 2000 lines. Default is true.",
+			},
 		},
 		Required: []string{"file_path"},
 	}
@@ -53,14 +60,18 @@ func (t *ViewTool) RequiresPermission() bool { return false }
 
 func (t *ViewTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params struct {
-		FilePath string `json:"file_path"`
-		Offset   int    `json:"offset"`
-		Limit    int    `json:"limit"`
+		FilePath    string `json:"file_path"`
+		Offset      int    `json:"offset"`
+		Limit       int    `json:"limit"`
+		LineNumbers *bool  `json:"line_numbers"`
 	}
 	if err := json.Unmarshal(input, &params); err != nil {
 		return "", fmt.Errorf("parsing view parameters: %w", err)
 	}
 
+	partial := params.Offset > 0 || params.Limit > 0
+	limited := params.Limit > 0
+	numbered := params.LineNumbers == nil || *params.LineNumbers
 	if params.Offset <= 0 {
 		params.Offset = 1
 	}
@@ -68,9 +79,9 @@ func (t *ViewTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		params.Limit = 2000
 	}
 
-	filePath := params.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(t.workDir, filePath)
+	filePath, err := t.paths.ResolveRead(params.FilePath)
+	if err != nil {
+		return "", err
 	}
 
 	f, err := os.Open(filePath)
@@ -79,27 +90,75 @@ func (t *ViewTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	}
 	defer f.Close()
 
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	tooLarge := t.maxBytes > 0 && info.Size() > t.maxBytes
+
+	// Sniff the content type to handle documents and binary files.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	var r io.Reader = f
+	contentType := http.DetectContentType(head)
+	switch {
+	case contentType == "application/pdf":
+		if tooLarge {
+			return "", fmt.Errorf("%s is %s, over the %s view limit for PDFs", params.FilePath, formatSize(int(info.Size())), formatSize(int(t.maxBytes)))
+		}
+		text, err := extractPDFText(f, info.Size())
+		if err != nil {
+			return "", err
+		}
+		r = strings.NewReader(text)
+	case isBinaryContent(contentType, head):
+		return binaryFileNote(contentType, info.Size()), nil
+	case tooLarge && !partial:
+		return "", fmt.Errorf("%s is %s, over the %s limit for viewing whole files; pass offset and limit to read part of it, or search it with grep", params.FilePath, formatSize(int(info.Size())), formatSize(int(t.maxBytes)))
+	}
+
+	dec := newTextDecoder("")
+	tail := &lastByteReader{r: dec.newReader(r, head)}
+
 	var lines []string
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(tail)
 	// Increase buffer size for long lines
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
 	lineNum := 0
+	atEOF := true
 	for scanner.Scan() {
 		lineNum++
 		if lineNum < params.Offset {
 			continue
 		}
 		if lineNum >= params.Offset+params.Limit {
+			atEOF = false
 			break
 		}
 
-		line := scanner.Text()
-		// Truncate very long lines
-		if len(line) > 2000 {
+		line := dec.decode(scanner.Bytes())
+		// NUL bytes past the sniffed head still mean the file is binary.
+		if strings.IndexByte(line, 0) >= 0 {
+			return binaryFileNote("application/octet-stream", info.Size()), nil
+		}
+		// Truncate very long lines, except in raw output, which must match
+		// the file.
+		if numbered && len(line) > 2000 {
 			line = line[:2000] + "... (truncated)"
 		}
-		lines = append(lines, fmt.Sprintf("%d: %s", lineNum, line))
+		if numbered {
+			line = fmt.Sprintf("%d: %s", lineNum, line)
+		}
+		lines = append(lines, line)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -109,6 +168,50 @@ func (t *ViewTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	if len(lines) == 0 {
 		return "(empty file or offset beyond end of file)", nil
 	}
+	// Raw lines are meant to be written out again as they are, so they
+	// keep the file's final newline and get no notes. Output that would not
+	// match the file is refused rather than returned with a note that might
+	// be copied along with it.
+	if !numbered {
+		if !atEOF && !limited {
+			return "", fmt.Errorf("%s has more than %d lines; pass offset and limit to read it raw in parts", params.FilePath, params.Limit)
+		}
+		if note := dec.note(); note != "" {
+			return "", fmt.Errorf("%s cannot be shown raw %s; view it with line numbers instead", params.FilePath, note)
+		}
+		text := strings.Join(lines, "\n")
+		if !atEOF || tail.last == '\n' {
+			text += "\n"
+		}
+		return text, nil
+	}
 
+	// Edits that touch the last line need to know whether it ends with a
+	// newline, which the numbered lines don't show.
+	if atEOF && contentType != "application/pdf" && tail.last != '\n' {
+		lines = append(lines, "(no newline at end of file)")
+	}
+	if note := dec.note(); note != "" {
+		lines = append(lines, note)
+	}
 	return strings.Join(lines, "\n"), nil
 }
+
+// binaryFileNote describes a binary file in place of its contents.
+func binaryFileNote(contentType string, size int64) string {
+	return fmt.Sprintf("Binary file (%s, %d bytes); contents not shown.", contentType, size)
+}
+
+// lastByteReader records the last byte read through it.
+type lastByteReader struct {
+	r    io.Reader
+	last byte
+}
+
+func (l *lastByteReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.last = p[n-1]
+	}
+	return n, err
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestViewNoNewlineAtEOF(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewViewTool(PathPolicy{WorkDir: dir}, 0)
+	const note = "(no newline at end of file)"
+
+	tests := []struct {
+		content string
+		input   string
+		want    bool
+	}{
+		{"a\nb\n", `{"file_path":"f.txt"}`, false},
+		{"a\nb", `{"file_path":"f.txt"}`, true},
+		{"a\nb", `{"file_path":"f.txt","limit":1}`, false},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := tool.Execute(context.Background(), []byte(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.HasSuffix(out, note); got != tt.want {
+			t.Errorf("view %q with %s = %q, want the note %v", tt.content, tt.input, out, tt.want)
+		}
+	}
+}
+
+func TestViewLargeAndBinaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewViewTool(PathPolicy{WorkDir: dir}, 64)
+
+	big := strings.Repeat("line\n", 100)
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(big), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(context.Background(), []byte(`{"file_path":"big.txt"}`)); err == nil || !strings.Contains(err.Error(), "offset and limit") {
+		t.Errorf("viewing a file over the limit: error = %v", err)
+	}
+	out, err := tool.Execute(context.Background(), []byte(`{"file_path":"big.txt","offset":10,"limit":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "10: line\n11: line" {
+		t.Errorf("partial view = %q", out)
+	}
+
+	// A NUL past the sniffed head still marks the file as binary.
+	late := strings.Repeat("text\n", 200) + "\x00\x01"
+	if err := os.WriteFile(filepath.Join(dir, "late.bin"), []byte(late), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = tool.Execute(context.Background(), []byte(`{"file_path":"late.bin","offset":1,"limit":500}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "Binary file") {
+		t.Errorf("view of a file with a late NUL = %q", out[:min(len(out), 40)])
+	}
+}
+
+func TestViewWithoutLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewViewTool(PathPolicy{WorkDir: dir}, 0)
+
+	long := strings.Repeat("x", 3000) + "\n"
+	many := strings.Repeat("line\n", 2001)
+
+	tests := []struct {
+		content string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"a\n\tb\n", `{"file_path":"f.txt","line_numbers":false}`, "a\n\tb\n", ""},
+		{"a\nb", `{"file_path":"f.txt","line_numbers":false}`, "a\nb", ""},
+		{"a\nb\nc", `{"file_path":"f.txt","offset":2,"limit":1,"line_numbers":false}`, "b\n", ""},
+		{"a\nb\n", `{"file_path":"f.txt","line_numbers":true}`, "1: a\n2: b", ""},
+		// Raw output is never altered: long lines are kept whole, and output
+		// that would be cut or re-encoded is refused.
+		{long, `{"file_path":"f.txt","line_numbers":false}`, long, ""},
+		{many, `{"file_path":"f.txt","line_numbers":false}`, "", "more than 2000 lines"},
+		{many, `{"file_path":"f.txt","limit":2,"line_numbers":false}`, "line\nline\n", ""},
+		{"caf\xe9\n", `{"file_path":"f.txt","line_numbers":false}`, "", "converted to UTF-8 from windows-1252"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := tool.Execute(context.Background(), []byte(tt.input))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("view with %s: error = %v, want one containing %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tt.want {
+			t.Errorf("view %.40q with %s = %.80q, want %.80q", tt.content, tt.input, out, tt.want)
+		}
+	}
+}
+
+// minimalPDF returns a one-page PDF showing text.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestViewDocuments(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewViewTool(PathPolicy{WorkDir: dir}, 0)
+	files := map[string][]byte{
+		"report.pdf": minimalPDF("Quarterly results"),
+		"broken.pdf": []byte("%PDF-1.4\nnot really a pdf"),
+		"logo.png":   append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...),
+		"data.json":  []byte(`{"name": "goder"}`),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	view := func(name string) (string, error) {
+		return tool.Execute(context.Background(), []byte(fmt.Sprintf(`{"file_path":%q}`, name)))
+	}
+
+	if out, err := view("report.pdf"); err != nil || !strings.Contains(out, "Quarterly results") {
+		t.Errorf("view of a PDF = %q, %v, want its text", out, err)
+	}
+	if _, err := view("broken.pdf"); err == nil {
+		t.Error("view of a malformed PDF succeeded")
+	}
+	if out, err := view("logo.png"); err != nil || out != "Binary file (image/png, 40 bytes); contents not shown." {
+		t.Errorf("view of a PNG = %q, %v", out, err)
+	}
+	if out, err := view("data.json"); err != nil || !strings.Contains(out, `"name": "goder"`) {
+		t.Errorf("view of JSON = %q, %v, want it shown as text", out, err)
+	}
+}
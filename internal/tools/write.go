@@ -3,19 +3,23 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // WriteTool creates or overwrites files.
 type WriteTool struct {
-	workDir string
+	paths PathPolicy
+	opts  WriteOptions
 }
 
 // NewWriteTool creates a new write tool.
-func NewWriteTool(workDir string) *WriteTool {
-	return &WriteTool{workDir: workDir}
+func NewWriteTool(paths PathPolicy, opts WriteOptions) *WriteTool {
+	return &WriteTool{paths: paths, opts: opts}
 }
 
 func (t *WriteTool) Name() string { return "write" }
@@ -45,6 +49,90 @@ func (t *WriteTool) Parameters() json.RawMessage {
 
 func (t *WriteTool) RequiresPermission() bool { return true }
 
+// ChangedPaths implements FileChanger.
+func (t *WriteTool) ChangedPaths(input json.RawMessage) ([]string, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || params.FilePath == "" {
+		return nil, nil
+	}
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return []string{filePath}, nil
+}
+
+// Preview implements Previewer. Overwrites show a diff against the current
+// file contents; new files show their size.
+func (t *WriteTool) Preview(input json.RawMessage) (string, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil || params.FilePath == "" {
+		return "", nil
+	}
+
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(t.paths.WorkDir, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+
+	existing, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		lines := strings.Count(params.Content, "\n")
+		if params.Content != "" && !strings.HasSuffix(params.Content, "\n") {
+			lines++
+		}
+		return fmt.Sprintf("creating new file %s (%d lines)", relPath, lines), nil
+	}
+	if err != nil {
+		return "", nil
+	}
+
+	content := t.opts.matchLineEndings(string(existing), params.Content)
+	diff := UnifiedDiff(relPath, string(existing), content)
+	if diff == "" {
+		return fmt.Sprintf("%s is unchanged", relPath), nil
+	}
+	return diff, nil
+}
+
+// Propose implements Reviewer.
+func (t *WriteTool) Propose(input json.RawMessage) (FileProposal, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return FileProposal{}, fmt.Errorf("parsing write parameters: %w", err)
+	}
+
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return FileProposal{}, err
+	}
+
+	existing, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return FileProposal{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	content := t.opts.matchLineEndings(string(existing), params.Content)
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
+	return FileProposal{
+		Path:    filePath,
+		Content: content,
+		Diff:    UnifiedDiff(relPath, string(existing), content),
+	}, nil
+}
+
 func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params struct {
 		FilePath string `json:"file_path"`
@@ -54,9 +142,9 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return "", fmt.Errorf("parsing write parameters: %w", err)
 	}
 
-	filePath := params.FilePath
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(t.workDir, filePath)
+	filePath, err := t.paths.Resolve(params.FilePath)
+	if err != nil {
+		return "", err
 	}
 
 	// Create parent directories if needed
@@ -65,10 +153,24 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return "", fmt.Errorf("creating directories: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, []byte(params.Content), 0o644); err != nil {
+	content := params.Content
+	if t.opts.PreserveLineEndings {
+		existing, err := os.ReadFile(filePath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("reading file: %w", err)
+		}
+		content = t.opts.matchLineEndings(string(existing), content)
+	}
+
+	// New files get 0644; an existing file keeps its mode.
+	if err := writeFile(filePath, []byte(content), 0o644, t.opts); err != nil {
 		return "", fmt.Errorf("writing file: %w", err)
 	}
 
-	relPath, _ := filepath.Rel(t.workDir, filePath)
-	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(params.Content), relPath), nil
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	relPath, _ := filepath.Rel(t.paths.WorkDir, filePath)
+	return fmt.Sprintf("Successfully wrote %s (%d lines) to %s", formatSize(len(content)), lines, relPath), nil
 }
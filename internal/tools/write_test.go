@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWriteToolAtomic(t *testing.T) {
+	for _, atomic := range []bool{true, false} {
+		dir := t.TempDir()
+		tool := NewWriteTool(PathPolicy{WorkDir: dir, Confine: true}, WriteOptions{Atomic: atomic})
+
+		script := filepath.Join(dir, "run.sh")
+		if err := os.WriteFile(script, []byte("old\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("run.sh", filepath.Join(dir, "link.sh")); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range []string{"run.sh", "link.sh", "sub/new.txt"} {
+			input, _ := json.Marshal(map[string]string{"file_path": name, "content": "line 1\nline 2\n"})
+			out, err := tool.Execute(context.Background(), input)
+			if err != nil {
+				t.Fatalf("atomic=%v: writing %s: %v", atomic, name, err)
+			}
+			if !strings.Contains(out, "14 bytes (2 lines)") {
+				t.Errorf("atomic=%v: output = %q, want the size and line count", atomic, out)
+			}
+		}
+
+		if info, err := os.Lstat(filepath.Join(dir, "link.sh")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("atomic=%v: writing through a symlink replaced it", atomic)
+		}
+		info, err := os.Stat(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+			t.Errorf("atomic=%v: mode = %v, want the original 0755 kept", atomic, info.Mode().Perm())
+		}
+
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if strings.Contains(e.Name(), ".tmp-") {
+				t.Errorf("atomic=%v: temporary file %s left behind", atomic, e.Name())
+			}
+		}
+	}
+}
+
+func TestAtomicWriteFailureKeepsOriginal(t *testing.T) {
+	renameFile = func(string, string) error { return errors.New("simulated crash") }
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	dir := t.TempDir()
+	paths := PathPolicy{WorkDir: dir, Confine: true}
+	opts := WriteOptions{Atomic: true}
+	target := filepath.Join(dir, "main.go")
+	const original = "package main\n\nfunc main() {}\n"
+	if err := os.WriteFile(target, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := map[Tool]string{
+		NewWriteTool(paths, opts): `{"file_path":"main.go","content":"package broken"}`,
+		NewEditTool(paths, opts):  `{"file_path":"main.go","old_string":"func main() {}","new_string":"func main() { run() }"}`,
+	}
+	for tool, input := range calls {
+		if _, err := tool.Execute(context.Background(), json.RawMessage(input)); err == nil {
+			t.Errorf("%s: expected the simulated failure to be reported", tool.Name())
+		}
+
+		got, err := os.ReadFile(target)
+		if err != nil || string(got) != original {
+			t.Errorf("%s: file = %q, %v; want the original intact", tool.Name(), got, err)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("%s: %d entries in the directory, want the temporary file removed", tool.Name(), len(entries))
+		}
+	}
+}
+
+func TestPreserveLineEndings(t *testing.T) {
+	const original = "one\r\ntwo\r\nthree\r\n"
+	tests := []struct {
+		name     string
+		preserve bool
+		tool     func(PathPolicy, WriteOptions) Tool
+		input    string
+		want     string
+	}{
+		{
+			name:     "edit matches LF text in a CRLF file",
+			preserve: true,
+			tool:     func(p PathPolicy, o WriteOptions) Tool { return NewEditTool(p, o) },
+			input:    `{"file_path":"f.txt","old_string":"one\ntwo","new_string":"one\n1.5\ntwo"}`,
+			want:     "one\r\n1.5\r\ntwo\r\nthree\r\n",
+		},
+		{
+			name:     "write keeps CRLF",
+			preserve: true,
+			tool:     func(p PathPolicy, o WriteOptions) Tool { return NewWriteTool(p, o) },
+			input:    `{"file_path":"f.txt","content":"a\nb\n"}`,
+			want:     "a\r\nb\r\n",
+		},
+		{
+			name:  "write as given when disabled",
+			tool:  func(p PathPolicy, o WriteOptions) Tool { return NewWriteTool(p, o) },
+			input: `{"file_path":"f.txt","content":"a\nb\n"}`,
+			want:  "a\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "f.txt")
+			if err := os.WriteFile(target, []byte(original), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			tool := tt.tool(PathPolicy{WorkDir: dir, Confine: true}, WriteOptions{Atomic: true, PreserveLineEndings: tt.preserve})
+			if _, err := tool.Execute(context.Background(), json.RawMessage(tt.input)); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := os.ReadFile(target)
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+				t.Errorf("mode = %v, want the original 0755 kept", info.Mode().Perm())
+			}
+		})
+	}
+}
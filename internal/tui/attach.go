@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// attachMaxBytes bounds the size of an attached file.
+const attachMaxBytes = 20 << 20
+
+// attachImageTypes are the image formats vision models accept.
+var attachImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// readAttachment loads an image to attach to a prompt. Relative paths are
+// resolved against workDir, after expandUserPath. Backslash-escaped spaces,
+// as left by dropping a file onto most terminals, are unescaped.
+func readAttachment(workDir, path string) (message.Attachment, error) {
+	path, err := expandUserPath(path)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	path = strings.ReplaceAll(path, `\ `, " ")
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	if info.IsDir() {
+		return message.Attachment{}, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > attachMaxBytes {
+		return message.Attachment{}, fmt.Errorf("%s is larger than %d MB", path, attachMaxBytes>>20)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	mimeType := http.DetectContentType(data)
+	if !attachImageTypes[mimeType] {
+		return message.Attachment{}, fmt.Errorf("%s is not a PNG, JPEG, GIF, or WebP image (detected %s)", filepath.Base(path), mimeType)
+	}
+	return message.Attachment{Name: filepath.Base(path), MIMEType: mimeType, Data: data}, nil
+}
+
+// cmdAttach queues an image to be sent with the next prompt. Without
+// arguments it lists the queued images; "/attach clear" drops them.
+func (m *Model) cmdAttach(args string) tea.Cmd {
+	switch args {
+	case "":
+		if len(m.attachments) == 0 {
+			m.msgs.Add(message.System, "Usage: /attach <image path>. The image is sent with your next prompt.")
+			return nil
+		}
+		m.msgs.Add(message.System, "Attached to the next prompt: "+attachmentNames(m.attachments))
+		return nil
+	case "clear":
+		m.attachments = nil
+		m.msgs.Add(message.System, "Attachments cleared.")
+		return nil
+	}
+
+	if model := m.cfg.ModelID(); !provider.SupportsImageInput(model) {
+		m.msgs.Add(message.System, fmt.Sprintf("Model %s does not accept images; switch to a vision model such as gpt-4o first.", model))
+		return nil
+	}
+
+	a, err := readAttachment(m.cfg.WorkDir, args)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Cannot attach: %s", err))
+		return nil
+	}
+	m.attachments = append(m.attachments, a)
+	m.msgs.Add(message.System, fmt.Sprintf("Attached %s (%d KB); it will be sent with your next prompt.", a.Name, (len(a.Data)+1023)/1024))
+	return nil
+}
+
+// attachmentNames lists the names of attachments for display.
+func attachmentNames(attachments []message.Attachment) string {
+	names := make([]string, len(attachments))
+	for i, a := range attachments {
+		names[i] = a.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadAttachment(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(filepath.Join(dir, "my shot.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A path dropped onto the terminal arrives with escaped spaces.
+	a, err := readAttachment(dir, `my\ shot.png`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "my shot.png" || a.MIMEType != "image/png" || len(a.Data) != len(png) {
+		t.Errorf("attachment = %s %s (%d bytes)", a.Name, a.MIMEType, len(a.Data))
+	}
+
+	if _, err := readAttachment(dir, "'"+filepath.Join(dir, "notes.txt")+"'"); err == nil || !strings.Contains(err.Error(), "not a PNG") {
+		t.Errorf("text file error = %v", err)
+	}
+	if _, err := readAttachment(dir, "missing.png"); err == nil {
+		t.Error("missing file attached without error")
+	}
+}
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/permission"
+)
+
+// batchPreviewMinLines is the fewest preview lines the batch dialog shows
+// for each call, however many calls it lists.
+const batchPreviewMinLines = 4
+
+// handleBatchKey handles keys in the dialog asking about several tool calls
+// at once: allow them all, deny them all, or step through them one by one.
+func (m Model) handleBatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var resp permission.Response
+	switch msg.String() {
+	case "y", "Y":
+		resp = permission.Allow
+	case "n", "N":
+		resp = permission.Deny
+	case "s", "S":
+		resp = permission.AskEach
+	case "d", "D":
+		return m.openPreviewDiff()
+	default:
+		return m, nil
+	}
+
+	m.permReq.ResponseCh <- resp
+	m.phase, m.phaseTool = phaseRunningTool, m.permReq.Batch[0].ToolName
+	m.permReq = nil
+	return m, m.listenForPermissions()
+}
+
+// renderBatchDialog renders the dialog asking about several tool calls at
+// once, listing each with its preview or input. The permission preview
+// budget is shared among the calls.
+func (m Model) renderBatchDialog() string {
+	items := m.permReq.Batch
+	maxLines := max(batchPreviewMinLines, permissionPreviewMaxLines/len(items))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %d tool calls need permission:", len(items))
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n\n  %d. Tool: %s\n", i+1, item.ToolName)
+		if item.Preview != "" {
+			b.WriteString(renderPreview(item.Preview, maxLines))
+			continue
+		}
+		input := item.Input
+		if len(input) > 200 {
+			input = input[:200] + "..."
+		}
+		b.WriteString("  Input: " + input)
+	}
+
+	var hint string
+	if len(batchPreviewEntries(items)) > 0 {
+		hint = "  [d] Full previews"
+	}
+	fmt.Fprintf(&b, "\n\n  [y] Allow all  [n] Deny all  [s] Step through%s", hint)
+	return permissionStyle.Width(m.width - 4).Render(b.String())
+}
+
+// batchPreviewEntries returns the previews of a batch request's calls for
+// the diff viewer, one entry per call that has one.
+func batchPreviewEntries(items []permission.BatchItem) []diffEntry {
+	var entries []diffEntry
+	for _, item := range items {
+		if item.Preview != "" {
+			entries = append(entries, previewEntry(item.ToolName, item.Preview))
+		}
+	}
+	return entries
+}
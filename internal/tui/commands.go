@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// slashCommand is a command entered at the prompt with a leading "/".
+type slashCommand struct {
+	name        string
+	description string
+	run         func(m *Model, args string) tea.Cmd
+}
+
+// slashCommands returns the available slash commands in display order.
+func slashCommands() []slashCommand {
+	return []slashCommand{
+		{
+			name:        "summarize",
+			description: "Summarize the session into long-term memory",
+			run:         (*Model).cmdSummarize,
+		},
+		{
+			name:        "model",
+			description: "Show or set the model (accepts aliases from modelAliases)",
+			run:         (*Model).cmdModel,
+		},
+		{
+			name:        "attach",
+			description: "Attach an image to the next prompt (clear to drop attachments)",
+			run:         (*Model).cmdAttach,
+		},
+		{
+			name:        "system",
+			description: "Show or set instructions sent with every request in this session (clear to remove)",
+			run:         (*Model).cmdSystem,
+		},
+		{
+			name:        "pin",
+			description: "Send a file's current contents with every request (no path lists pinned files)",
+			run:         (*Model).cmdPin,
+		},
+		{
+			name:        "unpin",
+			description: "Stop sending a pinned file (no path unpins all)",
+			run:         (*Model).cmdUnpin,
+		},
+		{
+			name:        "execute",
+			description: "Switch to build mode and implement the assistant's last plan",
+			run:         (*Model).cmdExecute,
+		},
+		{
+			name:        "continue",
+			description: "Ask the agent to carry on with an unfinished task",
+			run:         (*Model).cmdContinue,
+		},
+		{
+			name:        "usage",
+			description: "Show token usage per turn and for the session",
+			run:         (*Model).cmdUsage,
+		},
+		{
+			name:        "stats",
+			description: "Show how often each tool ran and how long it took",
+			run:         (*Model).cmdStats,
+		},
+		{
+			name:        "diff",
+			description: "Show the diffs of files changed in the last turn",
+			run:         (*Model).cmdDiff,
+		},
+		{
+			name:        "cwd",
+			description: "Show or change the working directory for tools",
+			run:         (*Model).cmdCwd,
+		},
+		{
+			name:        "export",
+			description: "Save the session to a JSON file (default goder-<session id>.json)",
+			run:         (*Model).cmdExport,
+		},
+		{
+			name:        "import",
+			description: "Load a session saved by /export as a new session and switch to it",
+			run:         (*Model).cmdImport,
+		},
+		{
+			name:        "verbosity",
+			description: "Show or set how tool calls are shown: quiet (one line per turn) or verbose",
+			run:         (*Model).cmdVerbosity,
+		},
+		{
+			name:        "config",
+			description: "Show the config file in use, where settings are saved, and the data directory",
+			run:         (*Model).cmdConfig,
+		},
+	}
+}
+
+// runSlashCommand executes a "/name args" prompt. It reports false if input
+// is not a slash command, so it can be sent to the agent as a normal prompt.
+func (m *Model) runSlashCommand(input string) (tea.Cmd, bool) {
+	if !strings.HasPrefix(input, "/") {
+		return nil, false
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	for _, c := range slashCommands() {
+		if c.name == name {
+			return c.run(m, strings.TrimSpace(args)), true
+		}
+	}
+
+	m.msgs.Add(message.System, fmt.Sprintf("Unknown command: /%s", name))
+	return nil, true
+}
+
+// cmdSummarize stores a model-generated summary of the session, which is
+// injected into the system prompt of later turns.
+func (m *Model) cmdSummarize(string) tea.Cmd {
+	if m.prov == nil || m.cfg.APIKey == "" {
+		m.msgs.Add(message.System, "No API key configured. Press ctrl+k to open settings.")
+		return nil
+	}
+
+	history, err := m.sessions.GetMessages()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if len(history) == 0 {
+		m.msgs.Add(message.System, "Nothing to summarize yet.")
+		return nil
+	}
+
+	previous, err := m.sessions.GetSummary()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+
+	m.msgs.Add(message.System, "Summarizing session...")
+	return generateSummaryCmd(m.prov, m.sessions.CurrentID(), history, previous)
+}
+
+// cmdModel switches the model, or lists the current model and the configured
+// aliases when called without arguments.
+func (m *Model) cmdModel(args string) tea.Cmd {
+	if args == "" {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Model: %s", m.cfg.ModelLabel())
+		if len(m.cfg.ModelAliases) > 0 {
+			names := make([]string, 0, len(m.cfg.ModelAliases))
+			for name := range m.cfg.ModelAliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			b.WriteString("\nAliases:")
+			for _, name := range names {
+				fmt.Fprintf(&b, "\n  %s -> %s", name, m.cfg.ModelAliases[name])
+			}
+		}
+		m.msgs.Add(message.System, b.String())
+		return nil
+	}
+
+	m.cfg.Model = args
+	if m.prov != nil {
+		m.prov.SetModel(m.cfg.ModelID())
+	}
+	if err := config.Save(m.cfg); err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Model set to %s, but saving the config failed: %s", m.cfg.ModelLabel(), err))
+		return nil
+	}
+	m.msgs.Add(message.System, fmt.Sprintf("Model set to %s", m.cfg.ModelLabel()))
+	return nil
+}
+
+// cmdSystem sets instructions for the current session, which the agent
+// sends in the system prompt of every request. Without arguments it
+// shows them; "clear" removes them.
+func (m *Model) cmdSystem(args string) tea.Cmd {
+	switch args {
+	case "":
+		instructions, err := m.sessions.GetInstructions()
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		if instructions == "" {
+			m.msgs.Add(message.System, "No session instructions set. Use /system <text> to steer this conversation.")
+		} else {
+			m.msgs.Add(message.System, "Session instructions:\n\n"+instructions)
+		}
+		return nil
+	case "clear":
+		args = ""
+	}
+
+	if err := m.sessions.SetInstructions(args); err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Failed to save the session instructions: %s", err))
+		return nil
+	}
+	if args == "" {
+		m.msgs.Add(message.System, "Session instructions cleared.")
+	} else {
+		m.msgs.Add(message.System, "Session instructions set. They are sent with every request in this session.")
+	}
+	return nil
+}
+
+// cmdUsage prints the token usage of each turn in the session and the total.
+func (m *Model) cmdUsage(string) tea.Cmd {
+	history, err := m.sessions.GetMessages()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.msgs.Add(message.System, usageReport(history))
+	return nil
+}
+
+// cmdStats prints call counts and execution times for the tools used since
+// goder started.
+func (m *Model) cmdStats(string) tea.Cmd {
+	m.msgs.Add(message.System, statsReport(m.toolMetrics.Snapshot()))
+	return nil
+}
+
+// cmdDiff opens the diff viewer on the files changed in the last turn.
+func (m *Model) cmdDiff(string) tea.Cmd {
+	m.openChangesDiff()
+	return nil
+}
+
+// continuePrompt is sent by /continue to nudge an agent that ended its turn
+// before finishing the task.
+const continuePrompt = "Continue with the task from where you left off. If it is already complete, say so briefly."
+
+// cmdContinue resumes the agent loop on the existing history with a canned
+// prompt. Any arguments are appended as extra instructions.
+func (m *Model) cmdContinue(args string) tea.Cmd {
+	if m.thinking {
+		return nil
+	}
+
+	history, err := m.sessions.GetMessages()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if len(history) == 0 {
+		m.msgs.Add(message.System, "Nothing to continue yet.")
+		return nil
+	}
+
+	prompt := continuePrompt
+	if args != "" {
+		prompt += "\n\n" + args
+	}
+	return m.submitPrompt(prompt)
+}
+
+// executePrompt is sent by /execute, followed by the plan to carry out.
+const executePrompt = "You are now in BUILD mode. Implement the plan below, which you wrote in PLAN mode. Follow it step by step, adjusting only where the code turns out to differ from what the plan assumed, and summarize what you changed when done."
+
+// cmdExecute hands the latest plan over to build mode: it switches modes if
+// needed and asks the agent to implement the last assistant message. Any
+// arguments are appended as extra instructions.
+func (m *Model) cmdExecute(args string) tea.Cmd {
+	if m.thinking {
+		return nil
+	}
+
+	history, err := m.sessions.GetMessages()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	plan := lastAssistantText(history)
+	if plan == "" {
+		m.msgs.Add(message.System, "No plan to execute yet. Ask for one in plan mode first.")
+		return nil
+	}
+
+	if m.mode != BuildMode {
+		m.setMode(BuildMode)
+	}
+	prompt := executePrompt + "\n\n<plan>\n" + plan + "\n</plan>"
+	if args != "" {
+		prompt += "\n\n" + args
+	}
+	return m.submitPrompt(prompt)
+}
+
+// lastAssistantText returns the text of the latest assistant message that
+// has any, skipping messages that only call tools.
+func lastAssistantText(history []message.Message) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == message.Assistant {
+			if text := strings.TrimSpace(history[i].Content); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// cmdVerbosity shows or switches how tool activity is shown for the rest of
+// the run: "quiet" collapses it to a line per turn, "verbose" shows it all.
+func (m *Model) cmdVerbosity(args string) tea.Cmd {
+	switch args {
+	case "":
+		current := "verbose"
+		if m.msgs.Quiet() {
+			current = "quiet"
+		}
+		m.msgs.Add(message.System, fmt.Sprintf("Verbosity is %s. Use /verbosity quiet or /verbosity verbose to switch.", current))
+	case "quiet":
+		m.msgs.SetQuiet(true)
+		m.msgs.Add(message.System, "Quiet: each turn's tool calls are collapsed into one line. ctrl+o shows them.")
+	case "verbose":
+		m.msgs.SetQuiet(false)
+		m.msgs.Add(message.System, "Verbose: every tool call and result is shown.")
+	default:
+		m.msgs.Add(message.System, fmt.Sprintf("Unknown verbosity %q: use quiet or verbose.", args))
+	}
+	return nil
+}
+
+// cmdConfig shows where settings are loaded from and saved to.
+func (m *Model) cmdConfig(string) tea.Cmd {
+	m.msgs.Add(message.System, m.configPathsReport())
+	return nil
+}
+
+// configPathsReport lists the resolved config and data paths.
+func (m Model) configPathsReport() string {
+	var b strings.Builder
+	if m.cfg.Path != "" {
+		fmt.Fprintf(&b, "Config file: %s", m.cfg.Path)
+	} else {
+		b.WriteString("Config file: none found, using defaults")
+	}
+	fmt.Fprintf(&b, "\nSaves to:    %s", m.cfg.SavePath)
+	fmt.Fprintf(&b, "\nData dir:    %s", m.cfg.DataDir)
+	fmt.Fprintf(&b, "\nDatabase:    %s", m.cfg.DBPath())
+	b.WriteString("\nSearched, first found wins:")
+	for _, path := range config.SearchPaths(m.cfg.WorkDir) {
+		fmt.Fprintf(&b, "\n  %s", path)
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+const (
+	// completionMaxItems bounds the number of suggestions shown at once.
+	completionMaxItems = 8
+
+	// completionFileLimit bounds how many workspace files are listed for
+	// @file suggestions.
+	completionFileLimit = 5000
+
+	// completionWalkTimeout bounds the workspace walk, which runs on the UI
+	// loop when an @ reference is started.
+	completionWalkTimeout = 500 * time.Millisecond
+)
+
+// completion tracks the suggestion popup for the token being typed at the end
+// of the input: "/name" at the start of the prompt completes slash commands,
+// and "@path" anywhere completes workspace file paths.
+type completion struct {
+	commands []string        // slash command names, without the slash
+	files    func() []string // lists workspace files, relative to WorkDir
+
+	fileCache   []string // files listed when the current @ token was started
+	filesLoaded bool
+
+	token  string   // text being completed, including its trigger character
+	items  []string // candidate replacements for token
+	cursor int
+}
+
+// refresh recomputes the suggestions for the trailing token of value.
+func (c *completion) refresh(value string) {
+	c.token, c.items, c.cursor = "", nil, 0
+
+	start := strings.LastIndexAny(value, " \t\n") + 1
+	tok := value[start:]
+
+	switch {
+	case start == 0 && strings.HasPrefix(tok, "/"):
+		c.token = tok
+		for _, name := range c.commands {
+			if cand := "/" + name; cand != tok && strings.HasPrefix(cand, tok) {
+				c.items = append(c.items, cand)
+			}
+		}
+
+	case strings.HasPrefix(tok, "@"):
+		if !c.filesLoaded && c.files != nil {
+			c.fileCache = c.files()
+			c.filesLoaded = true
+		}
+		c.token = tok
+		for _, path := range matchFiles(c.fileCache, tok[1:], completionMaxItems) {
+			if cand := "@" + path; cand != tok {
+				c.items = append(c.items, cand)
+			}
+		}
+		return
+	}
+
+	// The file list is refreshed the next time an @ reference is started.
+	c.fileCache, c.filesLoaded = nil, false
+}
+
+// active reports whether there are suggestions to show.
+func (c *completion) active() bool {
+	return len(c.items) > 0
+}
+
+// move shifts the selection by delta, wrapping around.
+func (c *completion) move(delta int) {
+	if n := len(c.items); n > 0 {
+		c.cursor = (c.cursor + delta + n) % n
+	}
+}
+
+// dismiss hides the suggestions until the input changes again.
+func (c *completion) dismiss() {
+	c.token, c.items, c.cursor = "", nil, 0
+}
+
+// apply returns value with its trailing token replaced by the selected
+// suggestion, followed by a space so typing can continue.
+func (c *completion) apply(value string) string {
+	if !c.active() {
+		return value
+	}
+	return strings.TrimSuffix(value, c.token) + c.items[c.cursor] + " "
+}
+
+// view renders the suggestion list.
+func (c *completion) view(width int) string {
+	lines := make([]string, len(c.items))
+	for i, item := range c.items {
+		if i == c.cursor {
+			lines[i] = completionSelectedStyle.Render("> " + item)
+		} else {
+			lines[i] = completionItemStyle.Render("  " + item)
+		}
+	}
+	return completionStyle.Width(width - 4).Render(strings.Join(lines, "\n"))
+}
+
+// height returns the rendered height of the suggestion list, or 0 if hidden.
+func (c *completion) height() int {
+	if !c.active() {
+		return 0
+	}
+	return len(c.items)
+}
+
+// matchFiles returns up to limit paths containing query, case-insensitively.
+// Paths whose file name starts with query rank first, then paths that start
+// with it, then any other match; shorter paths win ties.
+func matchFiles(files []string, query string, limit int) []string {
+	query = strings.ToLower(query)
+
+	type match struct {
+		path string
+		rank int
+	}
+	var matches []match
+	for _, f := range files {
+		lower := strings.ToLower(f)
+		base := lower[strings.LastIndexByte(lower, '/')+1:]
+		switch {
+		case strings.HasPrefix(base, query):
+			matches = append(matches, match{f, 0})
+		case strings.HasPrefix(lower, query):
+			matches = append(matches, match{f, 1})
+		case strings.Contains(lower, query):
+			matches = append(matches, match{f, 2})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return len(matches[i].path) < len(matches[j].path)
+	})
+
+	out := make([]string, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		out = append(out, m.path)
+	}
+	return out
+}
+
+// workspaceFileLister returns a function listing the files in cfg.WorkDir for
+// @file suggestions, honoring the same ignore list as the filesystem tools.
+func workspaceFileLister(cfg config.Config) func() []string {
+	return func() []string {
+		ignore, err := tools.LoadIgnoreList(cfg.WorkDir, cfg.Ignore)
+		if err != nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), completionWalkTimeout)
+		defer cancel()
+
+		// A walk cut short by the timeout still yields useful suggestions.
+		files, _ := tools.WorkspaceFiles(ctx, cfg.WorkDir, ignore, completionFileLimit)
+		return files
+	}
+}
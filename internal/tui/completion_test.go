@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMatchFiles(t *testing.T) {
+	files := []string{
+		"internal/tui/model.go",
+		"internal/tui/messages.go",
+		"cmd/goder/main.go",
+		"docs/models.md",
+		"README.md",
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"mod", []string{"docs/models.md", "internal/tui/model.go"}},
+		{"cmd/", []string{"cmd/goder/main.go"}},
+		{"MAIN", []string{"cmd/goder/main.go"}},
+		{"tui/m", []string{"internal/tui/model.go", "internal/tui/messages.go"}},
+		{"nope", []string{}},
+	}
+	for _, tt := range tests {
+		if got := matchFiles(files, tt.query, 10); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchFiles(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	if got := matchFiles(files, "", 2); len(got) != 2 {
+		t.Errorf("matchFiles with limit 2 returned %d paths", len(got))
+	}
+}
+
+func TestInputCompletion(t *testing.T) {
+	input := NewInput()
+	input.SetWidth(80)
+	input.SetCompletions([]string{"summarize", "model"}, func() []string {
+		return []string{"internal/tui/model.go", "go.mod"}
+	})
+
+	for _, ch := range "/mo" {
+		typeChar(&input, ch)
+	}
+	if !input.Completing() || !reflect.DeepEqual(input.complete.items, []string{"/model"}) {
+		t.Fatalf("suggestions for /mo = %v", input.complete.items)
+	}
+	if !input.HandleCompletionKey(tea.KeyMsg{Type: tea.KeyTab}) {
+		t.Fatal("tab was not consumed by the completion popup")
+	}
+	if got := input.Value(); got != "/model " {
+		t.Fatalf("value after accepting = %q, want %q", got, "/model ")
+	}
+	if input.Completing() {
+		t.Error("popup still showing after accepting")
+	}
+
+	// A slash later in the prompt is not a command.
+	input.Reset()
+	for _, ch := range "see /mo" {
+		typeChar(&input, ch)
+	}
+	if input.Completing() {
+		t.Errorf("unexpected suggestions %v", input.complete.items)
+	}
+
+	// @ references complete file paths anywhere in the prompt.
+	input.Reset()
+	for _, ch := range "look at @mod" {
+		typeChar(&input, ch)
+	}
+	want := []string{"@internal/tui/model.go", "@go.mod"}
+	if !reflect.DeepEqual(input.complete.items, want) {
+		t.Fatalf("suggestions for @mod = %v, want %v", input.complete.items, want)
+	}
+	input.HandleCompletionKey(tea.KeyMsg{Type: tea.KeyDown})
+	input.HandleCompletionKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := input.Value(); got != "look at @go.mod " {
+		t.Fatalf("value after accepting = %q", got)
+	}
+
+	// Esc hides the popup without touching the input.
+	input.Reset()
+	typeChar(&input, '@')
+	if !input.HandleCompletionKey(tea.KeyMsg{Type: tea.KeyEsc}) || input.Completing() {
+		t.Error("esc did not dismiss the popup")
+	}
+	if input.HandleCompletionKey(tea.KeyMsg{Type: tea.KeyEnter}) {
+		t.Error("enter was consumed with no popup showing")
+	}
+}
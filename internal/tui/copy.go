@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// writeClipboard copies text to the system clipboard. Tests replace it.
+var writeClipboard = clipboard.WriteAll
+
+// copyResultMsg carries the result of copying a command to the clipboard.
+type copyResultMsg struct {
+	command string
+	err     error
+}
+
+// Command returns the command of a bash tool call, or "" if dm is not one
+// or its input has no command yet.
+func (dm *DisplayMessage) Command() string {
+	if !dm.IsToolCall || dm.ToolName != "bash" {
+		return ""
+	}
+	var params struct {
+		Command string `json:"command"`
+	}
+	if json.Unmarshal([]byte(dm.ToolInput), &params) != nil {
+		return ""
+	}
+	return params.Command
+}
+
+// LastCommand returns the command of the most recent bash tool call, or ""
+// if there is none.
+func (ml *MessageList) LastCommand() string {
+	for i := len(ml.messages) - 1; i >= 0; i-- {
+		if cmd := ml.messages[i].Command(); cmd != "" {
+			return cmd
+		}
+	}
+	return ""
+}
+
+// copyLastCommand copies the command of the most recent bash tool call to
+// the clipboard, for running it by hand. The clipboard tool runs off the UI
+// loop; the result arrives as a copyResultMsg.
+func (m *Model) copyLastCommand() tea.Cmd {
+	command := m.msgs.LastCommand()
+	if command == "" {
+		m.msgs.Add(message.System, "No bash command to copy yet.")
+		return nil
+	}
+	return func() tea.Msg {
+		return copyResultMsg{command: command, err: writeClipboard(command)}
+	}
+}
+
+// handleCopyResult reports a finished copy. Without a clipboard tool, such as
+// over SSH, it asks the terminal to copy the command with an OSC 52
+// sequence, which may be ignored. The sequence is printed through the
+// program so it cannot interleave with a frame being rendered.
+func (m *Model) handleCopyResult(msg copyResultMsg) tea.Cmd {
+	if msg.err == nil {
+		m.msgs.Add(message.System, "Copied the last command:\n"+msg.command)
+		return nil
+	}
+	m.msgs.Add(message.System, fmt.Sprintf("Asked the terminal to copy the last command (no clipboard tool: %s):\n%s", msg.err, msg.command))
+	return tea.Println(osc52Sequence(msg.command))
+}
+
+// osc52Sequence returns the escape sequence asking the terminal to copy
+// text, wrapped for screen when running inside it.
+func osc52Sequence(text string) string {
+	seq := osc52.New(text)
+	if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	return seq.String()
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// cmdCwd changes the working directory the tools and system prompt use, or
+// shows it when called without arguments.
+func (m *Model) cmdCwd(args string) tea.Cmd {
+	if args == "" {
+		m.msgs.Add(message.System, "Working directory: "+m.cfg.WorkDir)
+		return nil
+	}
+	if m.thinking {
+		m.msgs.Add(message.System, "Wait for the agent to finish before changing the working directory.")
+		return nil
+	}
+	if m.newRegistry == nil {
+		m.msgs.Add(message.System, "Changing the working directory is not available.")
+		return nil
+	}
+
+	dir, err := resolveWorkDir(m.cfg.WorkDir, args)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Cannot change directory: %s", err))
+		return nil
+	}
+	registry, err := m.newRegistry(dir)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Cannot change directory: %s", err))
+		return nil
+	}
+
+	m.cfg.WorkDir = dir
+	m.registry = registry
+	m.input.SetCompletions(m.input.complete.commands, workspaceFileLister(m.cfg))
+	m.msgs.Add(message.System, "Working directory: "+dir)
+	// The new directory's project config is not loaded, so settings keep
+	// going to the file they came from rather than overwriting it.
+	if m.saveTarget() == "project" && m.cfg.SavePath != config.ProjectConfigPath(dir) {
+		m.msgs.Add(message.System, "Settings are still saved to "+m.cfg.SavePath)
+	}
+	m.recordContextChange(fmt.Sprintf("The user changed the working directory to %s. Relative paths now resolve against it; paths mentioned earlier in the conversation may refer to the previous directory.", dir))
+	return nil
+}
+
+// resolveWorkDir resolves path against the current working directory, after
+// expandUserPath, and checks it is a directory.
+func resolveWorkDir(current, path string) (string, error) {
+	path, err := expandUserPath(path)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(current, path)
+	}
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	return path, nil
+}
+
+// expandUserPath removes the spaces and quotes a path may be typed or pasted
+// with, and expands a leading "~" or "~/" to the home directory.
+func expandUserPath(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), `"'`)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	return path, nil
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// moveDataDir copies the database into the directory at path and switches
+// to the copy, updating m.cfg.DataDir. It returns the path of the old
+// database, which is left in place.
+func (m *Model) moveDataDir(path string) (string, error) {
+	if m.thinking {
+		return "", errors.New("wait for the agent to finish first")
+	}
+	if m.database == nil {
+		return "", errors.New("no database is open")
+	}
+
+	dir, err := resolveDataDir(path)
+	if err != nil {
+		return "", err
+	}
+	if dir == filepath.Clean(m.cfg.DataDir) {
+		return "", fmt.Errorf("already using %s", dir)
+	}
+	if err := checkWritableDir(dir); err != nil {
+		return "", err
+	}
+
+	oldPath := m.database.Path()
+	newCfg := m.cfg
+	newCfg.DataDir = dir
+	if err := m.database.MoveTo(newCfg.DBPath()); err != nil {
+		return "", err
+	}
+	m.cfg.DataDir = dir
+	return oldPath, nil
+}
+
+// resolveDataDir applies expandUserPath and requires the result to be
+// absolute, since a data directory relative to the working directory would
+// change with it.
+func resolveDataDir(path string) (string, error) {
+	path, err := expandUserPath(path)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("%s is not an absolute path", path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// checkWritableDir creates dir if needed and checks that files can be
+// created in it.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".goder-write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/agent"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// diffEntry is one diff shown in the diff viewer.
+type diffEntry struct {
+	title string
+	lines []string
+}
+
+// diffViewer is a scrollable overlay that shows unified diffs one file at a
+// time, in place of the message list.
+type diffViewer struct {
+	entries []diffEntry
+	file    int // index into entries
+	offset  int // first visible line
+}
+
+// newDiffViewer creates a viewer showing the first of entries.
+func newDiffViewer(entries []diffEntry) diffViewer {
+	return diffViewer{entries: entries}
+}
+
+// changeEntries builds viewer entries for the files changed by a turn.
+func changeEntries(changes []agent.FileChange) []diffEntry {
+	entries := make([]diffEntry, 0, len(changes))
+	for _, c := range changes {
+		title := c.Path
+		switch {
+		case c.Created:
+			title += " (new)"
+		case c.Deleted:
+			title += " (deleted)"
+		}
+
+		diff := strings.TrimSuffix(c.Diff, "\n")
+		if diff == "" {
+			diff = "(no diff available for binary or very large files)"
+		}
+		entries = append(entries, diffEntry{title: title, lines: strings.Split(diff, "\n")})
+	}
+	return entries
+}
+
+// previewEntry builds a viewer entry for a pending tool call's preview.
+func previewEntry(toolName, preview string) diffEntry {
+	return diffEntry{
+		title: "Proposed by " + toolName,
+		lines: strings.Split(strings.TrimSuffix(preview, "\n"), "\n"),
+	}
+}
+
+// switchFile moves to the next (delta 1) or previous (delta -1) file,
+// wrapping around.
+func (d *diffViewer) switchFile(delta int) {
+	if len(d.entries) == 0 {
+		return
+	}
+	d.file = (d.file + delta + len(d.entries)) % len(d.entries)
+	d.offset = 0
+}
+
+// scroll moves the view by delta lines, keeping a full page in view when the
+// diff is longer than height.
+func (d *diffViewer) scroll(delta, height int) {
+	if len(d.entries) == 0 {
+		return
+	}
+	maxOffset := max(len(d.entries[d.file].lines)-height, 0)
+	d.offset = min(max(d.offset+delta, 0), maxOffset)
+}
+
+// View renders the current file's diff in a width x height area.
+func (d diffViewer) View(width, height int) string {
+	if len(d.entries) == 0 {
+		return ""
+	}
+	entry := d.entries[d.file]
+
+	bodyHeight := max(height-2, 1) // title and key hints
+	end := min(d.offset+bodyHeight, len(entry.lines))
+
+	var b strings.Builder
+	title := fmt.Sprintf("  [%d/%d] %s", d.file+1, len(d.entries), entry.title)
+	if len(entry.lines) > bodyHeight {
+		title += fmt.Sprintf("  (lines %d-%d of %d)", d.offset+1, end, len(entry.lines))
+	}
+	b.WriteString(diffTitleStyle.Render(title))
+
+	lineWidth := max(width-2, 1)
+	for _, line := range entry.lines[d.offset:end] {
+		if r := []rune(line); len(r) > lineWidth {
+			line = string(r[:lineWidth])
+		}
+		b.WriteString("\n  " + renderDiffLine(line))
+	}
+	for i := end - d.offset; i < bodyHeight; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n" + dimStyle.Render("  up/down scroll  pgup/pgdn page  tab/shift+tab file  esc close"))
+	return b.String()
+}
+
+// renderDiffLine colorizes a line of a unified diff.
+func renderDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return dimStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return diffRemoveStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return diffHunkStyle.Render(line)
+	default:
+		return line
+	}
+}
+
+// openChangesDiff opens the diff viewer on the files changed by the latest
+// turn.
+func (m *Model) openChangesDiff() {
+	if len(m.lastChanges) == 0 {
+		m.msgs.Add(message.System, "No file changes to show yet.")
+		return
+	}
+	m.diffView = newDiffViewer(changeEntries(m.lastChanges))
+	m.diffOpen = true
+}
+
+// handleDiffKey handles keys while the diff viewer is open.
+func (m Model) handleDiffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	height := max(m.messageHeight()-2, 1)
+
+	switch msg.String() {
+	case "esc", "q":
+		m.diffOpen = false
+	case "tab", "right", "l":
+		m.diffView.switchFile(1)
+	case "shift+tab", "left", "h":
+		m.diffView.switchFile(-1)
+	case "up", "k":
+		m.diffView.scroll(-1, height)
+	case "down", "j":
+		m.diffView.scroll(1, height)
+	case "pgup":
+		m.diffView.scroll(-height, height)
+	case "pgdown", "pgdn", " ":
+		m.diffView.scroll(height, height)
+	case "home", "g":
+		m.diffView.scroll(-len(m.diffView.entries[m.diffView.file].lines), height)
+	case "end", "G":
+		m.diffView.scroll(len(m.diffView.entries[m.diffView.file].lines), height)
+	default:
+		if key.Matches(msg, m.keys.Quit) {
+			m.confirmQuit = true
+		}
+	}
+	return m, nil
+}
+
+// openPreviewDiff opens the diff viewer on the pending request's preview,
+// which the dialog shows truncated, or on each preview of a batch request.
+func (m Model) openPreviewDiff() (tea.Model, tea.Cmd) {
+	if m.permReq.IsBatch() {
+		if entries := batchPreviewEntries(m.permReq.Batch); len(entries) > 0 {
+			m.diffView = newDiffViewer(entries)
+			m.diffOpen = true
+		}
+		return m, nil
+	}
+	if m.permReq.Preview != "" {
+		m.diffView = newDiffViewer([]diffEntry{previewEntry(m.permReq.ToolName, m.permReq.Preview)})
+		m.diffOpen = true
+	}
+	return m, nil
+}
+
+// fullPreviewHint returns the dialog hint for viewing the pending request's
+// preview in full, if the dialog truncates it.
+func (m Model) fullPreviewHint() string {
+	if strings.Count(m.permReq.Preview, "\n") < permissionPreviewMaxLines {
+		return ""
+	}
+	return "  [d] Full preview"
+}
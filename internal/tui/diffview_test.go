@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/webgovernor/goder/internal/llm/agent"
+)
+
+func TestDiffViewer(t *testing.T) {
+	var diff strings.Builder
+	diff.WriteString("--- a/big.go\n+++ b/big.go\n@@ -1,20 +1,20 @@\n")
+	for i := 0; i < 20; i++ {
+		diff.WriteString("+line\n")
+	}
+	d := newDiffViewer(changeEntries([]agent.FileChange{
+		{Path: "big.go", Diff: diff.String()},
+		{Path: "logo.png", Created: true},
+	}))
+
+	if got := len(d.entries[0].lines); got != 23 {
+		t.Fatalf("big.go has %d lines, want 23", got)
+	}
+
+	// Scrolling stops once the last page is in view.
+	d.scroll(100, 10)
+	if d.offset != 13 {
+		t.Errorf("offset after scrolling past the end = %d, want 13", d.offset)
+	}
+	if view := d.View(80, 12); !strings.Contains(view, "[1/2] big.go  (lines 14-23 of 23)") {
+		t.Errorf("view title missing:\n%s", view)
+	}
+
+	d.switchFile(-1)
+	if d.file != 1 || d.offset != 0 {
+		t.Fatalf("after switching back from the first file: file %d, offset %d", d.file, d.offset)
+	}
+	if view := d.View(80, 12); !strings.Contains(view, "logo.png (new)") || !strings.Contains(view, "no diff available") {
+		t.Errorf("binary entry view:\n%s", view)
+	}
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/text/language"
+	textmessage "golang.org/x/text/message"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// estimateDelay is how long typing must pause before the token estimate for
+// the prompt is refreshed.
+const estimateDelay = 300 * time.Millisecond
+
+// estimateMsg asks for the token estimate to be refreshed. seq identifies
+// the edit that scheduled it; only the latest edit's message is acted on.
+type estimateMsg struct{ seq int }
+
+// scheduleEstimate debounces a refresh of the token estimate after an edit
+// to the prompt.
+func (m *Model) scheduleEstimate() tea.Cmd {
+	m.estimateSeq++
+	seq := m.estimateSeq
+	return tea.Tick(estimateDelay, func(time.Time) tea.Msg { return estimateMsg{seq: seq} })
+}
+
+// refreshEstimate estimates the tokens the prompt being typed would send: the
+// prompt itself plus the history that goes with it.
+func (m *Model) refreshEstimate() {
+	m.tokenEstimate = 0
+	prompt := m.input.Value()
+	if prompt == "" {
+		return
+	}
+	m.tokenEstimate = estimateTokens(prompt)
+	if m.sessions == nil {
+		return
+	}
+	history, err := m.sessions.GetMessages()
+	if err != nil {
+		return
+	}
+	if limit := m.cfg.HistoryLimit; limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	for _, msg := range history {
+		m.tokenEstimate += messageTokens(msg)
+	}
+}
+
+// estimateTokens approximates the number of tokens in text, at about four
+// characters per token for English text and code.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// messageTokens approximates the tokens msg takes up in a request.
+func messageTokens(msg message.Message) int {
+	n := estimateTokens(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		n += estimateTokens(tc.Name) + estimateTokens(string(tc.Input))
+	}
+	for _, tr := range msg.ToolResults {
+		n += estimateTokens(tr.ForModel())
+	}
+	return n
+}
+
+// estimateLabel formats a token estimate for the status bar, with the share
+// of the model's context window it would use if that is known.
+func estimateLabel(tokens int, model string) string {
+	p := textmessage.NewPrinter(language.English)
+	if info, ok := provider.LookupModel(model); ok && info.ContextWindow > 0 {
+		return p.Sprintf("~%d tokens (%d%% of context)", tokens, tokens*100/info.ContextWindow)
+	}
+	return p.Sprintf("~%d tokens", tokens)
+}
+
+// checkContextUsage tells the user, once per session, when a request passed
+// the model's compaction threshold, before requests start failing.
+func (m *Model) checkContextUsage(inputTokens int) {
+	threshold := provider.CompactionThreshold(m.cfg.ModelID(), m.cfg.MaxTokens, m.cfg.ReasoningReserve)
+	if m.contextWarned || threshold <= 0 || inputTokens < threshold {
+		return
+	}
+	info, _ := provider.LookupModel(m.cfg.ModelID())
+	m.contextWarned = true
+	m.msgs.Add(message.System, textmessage.NewPrinter(language.English).Sprintf(
+		"The last request used %d of the model's %d-token context window, leaving too little room for long responses. Run /summarize and set historyLimit in the config to send fewer messages, or start a new session.",
+		inputTokens, info.ContextWindow))
+}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/permission"
+)
+
+func TestTokenEstimateDebounced(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	m.width = 120
+
+	var seqs []int
+	for _, r := range "hello world!" {
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+		if cmd == nil {
+			t.Fatal("typing scheduled no estimate refresh")
+		}
+		seqs = append(seqs, m.estimateSeq)
+	}
+
+	// Refreshes scheduled by earlier keystrokes are dropped.
+	next, _ := m.Update(estimateMsg{seq: seqs[0]})
+	m = next.(Model)
+	if m.tokenEstimate != 0 {
+		t.Fatalf("stale refresh set the estimate to %d", m.tokenEstimate)
+	}
+
+	next, _ = m.Update(estimateMsg{seq: seqs[len(seqs)-1]})
+	m = next.(Model)
+	if m.tokenEstimate != 3 {
+		t.Errorf("estimate = %d, want 3 for 12 characters", m.tokenEstimate)
+	}
+	if !strings.Contains(m.View(), "~3 tokens") {
+		t.Error("estimate not shown in the status bar")
+	}
+}
+
+func TestContextUsageWarning(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	if got := estimateLabel(12800, "gpt-4o"); got != "~12,800 tokens (10% of context)" {
+		t.Errorf("estimateLabel = %q", got)
+	}
+	if got := estimateLabel(100, "unknown-model"); got != "~100 tokens" {
+		t.Errorf("estimateLabel for an unknown model = %q", got)
+	}
+
+	count := m.msgs.Count()
+	m.checkContextUsage(50000)
+	if m.msgs.Count() != count {
+		t.Fatal("warned well within the context window")
+	}
+	m.checkContextUsage(110000)
+	m.checkContextUsage(120000)
+	if m.msgs.Count() != count+1 || !strings.Contains(m.msgs.messages[count].Content, "/summarize") {
+		t.Errorf("want one warning suggesting /summarize, got %d messages", m.msgs.Count()-count)
+	}
+}
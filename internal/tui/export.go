@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// cmdExport writes the current session to a JSON file that /import can load,
+// named after the session in the working directory unless a path is given.
+// An existing file is only overwritten if the same export is repeated.
+func (m *Model) cmdExport(args string) tea.Cmd {
+	id := m.sessions.CurrentID()
+	if id == "" {
+		m.msgs.Add(message.System, "No session to export yet.")
+		return nil
+	}
+	if args == "" {
+		args = "goder-" + id + ".json"
+	}
+	path, err := m.resolveSessionFile(args)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Export failed: %s", err))
+		return nil
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) && m.exportOverwrite != path {
+		m.exportOverwrite = path
+		m.msgs.Add(message.System, fmt.Sprintf("%s already exists. Run the same /export again to overwrite it.", m.displayPath(path)))
+		return nil
+	}
+	m.exportOverwrite = ""
+
+	data, err := m.sessions.ExportJSON(id)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Export failed: %s", err))
+		return nil
+	}
+	// Sessions can contain file contents and command output, so keep the
+	// export private like the database.
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Export failed: %s", err))
+		return nil
+	}
+	m.msgs.Add(message.System, fmt.Sprintf("Exported the session to %s; load it with /import.", m.displayPath(path)))
+	return nil
+}
+
+// cmdImport loads a session written by /export as a new session and switches
+// to it.
+func (m *Model) cmdImport(args string) tea.Cmd {
+	if m.thinking {
+		m.msgs.Add(message.System, "Wait for the current turn to finish before importing a session.")
+		return nil
+	}
+	if args == "" {
+		m.msgs.Add(message.System, "Usage: /import <path>. Loads a file written by /export as a new session.")
+		return nil
+	}
+	path, err := m.resolveSessionFile(args)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Import failed: %s", err))
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Import failed: %s", err))
+		return nil
+	}
+	sess, err := m.sessions.ImportJSON(data)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Import failed: %s", err))
+		return nil
+	}
+	if _, err := m.sessions.Switch(sess.ID); err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Imported %q, but switching to it failed: %s", sess.Title, err))
+		return nil
+	}
+	m.lastChanges = nil
+	notice := fmt.Sprintf("Imported %q from %s as a new session.", sess.Title, m.displayPath(path))
+	return func() tea.Msg { return sessionLoadedMsg{session: sess, notice: notice} }
+}
+
+// resolveSessionFile resolves the path given to /export or /import, after
+// expandUserPath, taking a relative path from the working directory.
+func (m *Model) resolveSessionFile(path string) (string, error) {
+	path, err := expandUserPath(path)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.cfg.WorkDir, path)
+	}
+	return filepath.Clean(path), nil
+}
@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// focusStep returns how far msg moves focus: 1 for tab, -1 for shift+tab,
+// and 0 for any other key.
+func focusStep(msg tea.KeyMsg) int {
+	switch msg.Type {
+	case tea.KeyTab:
+		return 1
+	case tea.KeyShiftTab:
+		return -1
+	}
+	return 0
+}
+
+// cycleFocus moves index by step among n elements, wrapping at either end.
+func cycleFocus(index, step, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return ((index+step)%n + n) % n
+}
+
+// Focusable elements of an input sub-view, in tab order.
+const (
+	inputFocusField = iota // the text input
+	inputFocusSave         // the button that submits the input
+	inputFocusBack         // the button that goes back
+	inputFocusCount
+)
+
+// viewInputControls renders a text input framed by a focus ring, which is
+// highlighted while the input has focus, followed by a button labelled
+// action that submits it and a back button.
+func viewInputControls(input textinput.Model, focus int, action string) string {
+	ring := inputBorderStyle
+	if focus == inputFocusField {
+		ring = inputFocusedBorderStyle
+	}
+	field := ring.Width(lipgloss.Width(input.Prompt) + input.Width + 3).Render(input.View())
+
+	var b strings.Builder
+	for _, line := range strings.Split(field, "\n") {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("  " + viewButton(action, focus == inputFocusSave) + " " + viewButton("Back", focus == inputFocusBack) + "\n")
+	return b.String()
+}
+
+// viewButton renders a button, highlighted if it has focus.
+func viewButton(label string, focused bool) string {
+	if focused {
+		return settingsFocusedButtonStyle.Render("[ " + label + " ]")
+	}
+	return settingsButtonStyle.Render("[ " + label + " ]")
+}
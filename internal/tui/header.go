@@ -2,15 +2,27 @@ package tui
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/charmbracelet/lipgloss"
+	rw "github.com/mattn/go-runewidth"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
 
-// HeaderView renders the top header bar showing the logo and persistent status.
-func HeaderView(mode Mode, model string, tokenTotal int, width int) string {
-	logo := logoStyle.Render("goder")
+const (
+	// headerTitleMaxWidth caps the session title shown in the header.
+	headerTitleMaxWidth = 40
+
+	// headerNameMaxWidth caps the assistant's name shown as the logo.
+	headerNameMaxWidth = 20
+)
+
+// HeaderView renders the top header bar showing the logo, the assistant's
+// name, and persistent status. workDir is shown by its base name.
+// autoApprove adds a warning that tools run without asking.
+func HeaderView(name string, mode Mode, title string, workDir string, model string, tokenTotal int, autoApprove bool, width int) string {
+	logo := logoStyle.Render(rw.Truncate(name, headerNameMaxWidth, "..."))
 
 	var modeLabel string
 	switch mode {
@@ -23,9 +35,19 @@ func HeaderView(mode Mode, model string, tokenTotal int, width int) string {
 	printer := message.NewPrinter(language.English)
 	modelLabel := fmt.Sprintf("%s %s", statusKeyStyle.Render("model:"), statusDescStyle.Render(model))
 	tokensLabel := fmt.Sprintf("%s %s", statusKeyStyle.Render("tokens:"), statusDescStyle.Render(printer.Sprintf("%d", tokenTotal)))
-	right := fmt.Sprintf("%s  %s", modelLabel, tokensLabel)
+	dirLabel := fmt.Sprintf("%s %s", statusKeyStyle.Render("dir:"), statusDescStyle.Render(filepath.Base(workDir)))
+	right := fmt.Sprintf("%s  %s  %s", dirLabel, modelLabel, tokensLabel)
 
 	left := fmt.Sprintf("%s  %s", logo, modeLabel)
+	if autoApprove {
+		left += "  " + autoApproveStyle.Render("AUTO-APPROVE")
+	}
+	if title != "" {
+		if rw.StringWidth(title) > headerTitleMaxWidth {
+			title = rw.Truncate(title, headerTitleMaxWidth, "...")
+		}
+		left += "  " + dimStyle.Render(title)
+	}
 	gap := width - lipgloss.Width(left) - lipgloss.Width(right) - 2
 	if gap < 1 {
 		gap = 1
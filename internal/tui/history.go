@@ -0,0 +1,60 @@
+package tui
+
+// inputHistorySize is the number of submitted prompts kept for recall.
+const inputHistorySize = 100
+
+// inputHistory holds recently submitted prompts, oldest first, and a cursor
+// for stepping through them with up/down.
+type inputHistory struct {
+	entries []string
+	index   int // entry being shown; len(entries) when not browsing
+}
+
+// Add records a submitted prompt and stops browsing. Consecutive duplicates
+// are stored once.
+func (h *inputHistory) Add(prompt string) {
+	if n := len(h.entries); n == 0 || h.entries[n-1] != prompt {
+		h.entries = append(h.entries, prompt)
+		if len(h.entries) > inputHistorySize {
+			h.entries = h.entries[len(h.entries)-inputHistorySize:]
+		}
+	}
+	h.Reset()
+}
+
+// Reset stops browsing, so the next Prev starts from the newest entry.
+func (h *inputHistory) Reset() {
+	h.index = len(h.entries)
+}
+
+// Browsing reports whether an entry is currently recalled.
+func (h *inputHistory) Browsing() bool {
+	return h.index < len(h.entries)
+}
+
+// Current returns the recalled entry, or "" when not browsing.
+func (h *inputHistory) Current() string {
+	if !h.Browsing() {
+		return ""
+	}
+	return h.entries[h.index]
+}
+
+// Prev steps to the next older entry. It reports false if there is none.
+func (h *inputHistory) Prev() (string, bool) {
+	if h.index == 0 {
+		return "", false
+	}
+	h.index--
+	return h.entries[h.index], true
+}
+
+// Next steps to the next newer entry. Stepping past the newest entry stops
+// browsing and returns "". It reports false if not browsing.
+func (h *inputHistory) Next() (string, bool) {
+	if !h.Browsing() {
+		return "", false
+	}
+	h.index++
+	return h.Current(), true
+}
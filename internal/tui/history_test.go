@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInputHistory(t *testing.T) {
+	var h inputHistory
+	if _, ok := h.Prev(); ok {
+		t.Fatal("Prev on empty history should fail")
+	}
+
+	h.Add("one")
+	h.Add("two")
+	h.Add("two") // consecutive duplicate
+	h.Add("three")
+
+	for _, want := range []string{"three", "two", "one"} {
+		got, ok := h.Prev()
+		if !ok || got != want {
+			t.Fatalf("Prev() = %q, %v; want %q", got, ok, want)
+		}
+	}
+	if _, ok := h.Prev(); ok {
+		t.Error("Prev past the oldest entry should fail")
+	}
+
+	for _, want := range []string{"two", "three", ""} {
+		got, ok := h.Next()
+		if !ok || got != want {
+			t.Fatalf("Next() = %q, %v; want %q", got, ok, want)
+		}
+	}
+	if h.Browsing() {
+		t.Error("stepping past the newest entry should stop browsing")
+	}
+	if _, ok := h.Next(); ok {
+		t.Error("Next when not browsing should fail")
+	}
+}
+
+func TestInputHistoryBounded(t *testing.T) {
+	var h inputHistory
+	for i := 0; i < inputHistorySize+10; i++ {
+		h.Add(fmt.Sprintf("prompt %d", i))
+	}
+	if len(h.entries) != inputHistorySize {
+		t.Fatalf("kept %d entries, want %d", len(h.entries), inputHistorySize)
+	}
+	if h.entries[0] != "prompt 10" {
+		t.Errorf("oldest entry = %q, want %q", h.entries[0], "prompt 10")
+	}
+}
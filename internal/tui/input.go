@@ -13,11 +13,17 @@ import (
 
 const maxInputHeight = 6
 
+// maxInputChars bounds the prompt length. It is generous so that pasted
+// snippets and logs are not silently truncated.
+const maxInputChars = 64 * 1024
+
 // Input wraps a bubbles textarea for the prompt area.
 type Input struct {
 	textArea textarea.Model
 	focused  bool
 	width    int // total available width, set via SetWidth
+
+	complete completion // suggestions for the token being typed
 }
 
 // NewInput creates a new text area input with the appropriate styling.
@@ -25,7 +31,7 @@ func NewInput() Input {
 	ta := textarea.New()
 	ta.Placeholder = "Ask anything..."
 	ta.Focus()
-	ta.CharLimit = 4096
+	ta.CharLimit = maxInputChars
 	ta.MaxHeight = maxInputHeight
 	ta.ShowLineNumbers = false
 	ta.Prompt = "  "
@@ -79,10 +85,78 @@ func (i *Input) Update(msg tea.Msg) tea.Cmd {
 	// top lines until the user moves the cursor back up.
 	i.textArea.SetHeight(maxInputHeight)
 
+	// Bracketed paste arrives as a single KeyMsg holding the whole block, so
+	// its newlines are inserted as text rather than handled as enter presses.
+	if k, ok := msg.(tea.KeyMsg); ok && k.Paste {
+		msg = normalizePaste(k)
+	}
+
 	var cmd tea.Cmd
 	i.textArea, cmd = i.textArea.Update(msg)
 
-	// Shrink back to fit the actual content, accounting for soft-wrapped lines.
+	i.fitHeight()
+
+	// Only edits change the token being completed; refreshing on other
+	// messages (e.g. cursor blinks) would reset the selection.
+	if _, ok := msg.(tea.KeyMsg); ok {
+		i.refreshCompletion()
+	}
+	return cmd
+}
+
+// SetCompletions sets the sources for suggestions: slash command names, and
+// a function listing workspace files for @ references.
+func (i *Input) SetCompletions(commands []string, files func() []string) {
+	i.complete.commands = commands
+	i.complete.files = files
+}
+
+// Completing reports whether the suggestion popup is showing.
+func (i *Input) Completing() bool {
+	return i.complete.active()
+}
+
+// HandleCompletionKey handles navigation keys while the suggestion popup is
+// showing: tab or enter accepts, up/down move the selection, and esc
+// dismisses it. It reports whether the key was consumed.
+func (i *Input) HandleCompletionKey(msg tea.KeyMsg) bool {
+	if !i.complete.active() {
+		return false
+	}
+
+	switch msg.Type {
+	case tea.KeyTab, tea.KeyEnter:
+		i.SetValue(i.complete.apply(i.textArea.Value()))
+	case tea.KeyUp, tea.KeyShiftTab:
+		i.complete.move(-1)
+	case tea.KeyDown:
+		i.complete.move(1)
+	case tea.KeyEsc:
+		i.complete.dismiss()
+	default:
+		return false
+	}
+	return true
+}
+
+// refreshCompletion recomputes suggestions for the token before the cursor.
+// Completion only applies while typing at the end of the input.
+func (i *Input) refreshCompletion() {
+	value := i.textArea.Value()
+	lines := strings.Split(value, "\n")
+	info := i.textArea.LineInfo()
+	atEnd := i.textArea.Line() == len(lines)-1 &&
+		info.StartColumn+info.ColumnOffset == len([]rune(lines[len(lines)-1]))
+	if !atEnd {
+		i.complete.dismiss()
+		return
+	}
+	i.complete.refresh(value)
+}
+
+// fitHeight shrinks the textarea to fit its content, accounting for
+// soft-wrapped lines.
+func (i *Input) fitHeight() {
 	lines := displayLineCount(i.textArea.Value(), i.textArea.Width())
 
 	if lines < 1 {
@@ -92,8 +166,6 @@ func (i *Input) Update(msg tea.Msg) tea.Cmd {
 		lines = maxInputHeight
 	}
 	i.textArea.SetHeight(lines)
-
-	return cmd
 }
 
 // SetWidth stores the total available width so that Update can apply it to
@@ -121,7 +193,11 @@ func (i *Input) View(width int, mode Mode) string {
 		style = inputFocusedBorderStyle.BorderForeground(borderColor)
 	}
 
-	return style.Width(width - 4).Render(i.textArea.View())
+	box := style.Width(width - 4).Render(i.textArea.View())
+	if i.complete.active() {
+		return i.complete.view(width) + "\n" + box
+	}
+	return box
 }
 
 // Value returns the current text in the input.
@@ -129,10 +205,22 @@ func (i *Input) Value() string {
 	return i.textArea.Value()
 }
 
+// SetValue replaces the input text, leaving the cursor at the end.
+func (i *Input) SetValue(s string) {
+	if i.width > 0 {
+		i.textArea.SetWidth(i.width - 6)
+	}
+	i.textArea.SetHeight(maxInputHeight)
+	i.textArea.SetValue(s)
+	i.fitHeight()
+	i.complete.dismiss()
+}
+
 // Reset clears the input and shrinks it back to a single line.
 func (i *Input) Reset() {
 	i.textArea.Reset()
 	i.textArea.SetHeight(1)
+	i.complete.dismiss()
 }
 
 // Focus gives focus to the input.
@@ -147,10 +235,22 @@ func (i *Input) Blur() {
 	i.textArea.Blur()
 }
 
-// Height returns the current rendered height of the input area including borders.
+// Height returns the current rendered height of the input area including
+// borders and any suggestion popup.
 func (i *Input) Height() int {
 	// textarea height + 2 for top/bottom border
-	return i.textArea.Height() + 2
+	return i.textArea.Height() + 2 + i.complete.height()
+}
+
+// normalizePaste converts CRLF and lone CR line endings in a pasted block to
+// LF. The textarea treats each '\r' and '\n' as a line break, so CRLF text
+// would otherwise gain a blank line after every line.
+func normalizePaste(msg tea.KeyMsg) tea.KeyMsg {
+	text := string(msg.Runes)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	msg.Runes = []rune(text)
+	return msg
 }
 
 // displayLineCount returns the total number of display rows the text occupies,
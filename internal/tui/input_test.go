@@ -135,3 +135,40 @@ func TestDisplayLineCount(t *testing.T) {
 		})
 	}
 }
+
+func TestInputPasteMultiLine(t *testing.T) {
+	const totalWidth = 80
+
+	input := NewInput()
+	input.SetWidth(totalWidth)
+	input.View(totalWidth, PlanMode)
+
+	// Terminals commonly send CRLF line endings inside a bracketed paste.
+	input.Update(tea.KeyMsg{
+		Type:  tea.KeyRunes,
+		Runes: []rune("func main() {\r\n\tfmt.Println(\"hi\")\r\n}"),
+		Paste: true,
+	})
+
+	want := "func main() {\n    fmt.Println(\"hi\")\n}"
+	if got := input.Value(); got != want {
+		t.Fatalf("pasted value = %q, want %q", got, want)
+	}
+	if h := input.textArea.Height(); h != 3 {
+		t.Errorf("textarea height = %d after pasting 3 lines, want 3", h)
+	}
+
+	// A long paste grows the input only up to its maximum height.
+	input.Reset()
+	input.Update(tea.KeyMsg{
+		Type:  tea.KeyRunes,
+		Runes: []rune(strings.Repeat("line\n", 20)),
+		Paste: true,
+	})
+	if got := strings.Count(input.Value(), "\n"); got != 20 {
+		t.Errorf("pasted %d newlines, want 20", got)
+	}
+	if h := input.textArea.Height(); h != maxInputHeight {
+		t.Errorf("textarea height = %d, want max %d", h, maxInputHeight)
+	}
+}
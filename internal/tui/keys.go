@@ -13,6 +13,9 @@ type KeyMap struct {
 	NewLine    key.Binding
 	Help       key.Binding
 	Settings   key.Binding
+	ExpandTool key.Binding
+	Diff       key.Binding
+	CopyCmd    key.Binding
 }
 
 // DefaultKeyMap returns the default set of key bindings.
@@ -54,5 +57,17 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+k"),
 			key.WithHelp("ctrl+k", "settings"),
 		),
+		ExpandTool: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "expand tools"),
+		),
+		Diff: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "view changes"),
+		),
+		CopyCmd: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "copy last command"),
+		),
 	}
 }
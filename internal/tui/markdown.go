@@ -1,34 +1,174 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
 )
 
-var markdownRenderer = newMarkdownRenderer()
+// markdown holds the glamour renderer. Glamour wraps text itself, so the
+// renderer is built for the width messages are shown at and rebuilt when
+// the terminal is resized.
+var markdown struct {
+	mu       sync.Mutex
+	theme    string // see SetMarkdownTheme
+	width    int
+	renderer *glamour.TermRenderer // nil until first used, or if building it failed
+	built    bool
+}
 
-func newMarkdownRenderer() *glamour.TermRenderer {
-	renderer, err := glamour.NewTermRenderer(
-		glamour.WithEnvironmentConfig(),
-		glamour.WithWordWrap(0),
-	)
-	if err != nil {
-		return nil
+// SetMarkdownTheme sets the glamour style markdown is rendered with: a
+// standard style name such as "dark", "light" or "notty", or the path of a
+// JSON style file. Empty uses the GLAMOUR_STYLE environment variable, or
+// picks dark or light to suit the terminal.
+func SetMarkdownTheme(theme string) error {
+	if _, err := newMarkdownRenderer(theme, 0); err != nil {
+		return fmt.Errorf("markdownTheme %q: %w", theme, err)
+	}
+	markdown.mu.Lock()
+	defer markdown.mu.Unlock()
+	markdown.theme = theme
+	markdown.renderer, markdown.built = nil, false
+	return nil
+}
+
+func newMarkdownRenderer(theme string, width int) (*glamour.TermRenderer, error) {
+	style := glamour.WithEnvironmentConfig()
+	if theme != "" {
+		style = glamour.WithStylePath(theme)
 	}
-	return renderer
+	return glamour.NewTermRenderer(style, glamour.WithWordWrap(width))
 }
 
-func renderMarkdown(content string) string {
+// renderMarkdown renders content wrapped to width columns, or unwrapped if
+// width is 0. Content that fails to render is returned as it is.
+func renderMarkdown(content string, width int) string {
 	if strings.TrimSpace(content) == "" {
 		return content
 	}
-	if markdownRenderer == nil {
+
+	markdown.mu.Lock()
+	defer markdown.mu.Unlock()
+	if !markdown.built || markdown.width != width {
+		markdown.renderer, _ = newMarkdownRenderer(markdown.theme, width)
+		markdown.width, markdown.built = width, true
+	}
+	if markdown.renderer == nil {
 		return content
 	}
-	rendered, err := markdownRenderer.Render(content)
+	rendered, err := markdown.renderer.Render(content)
 	if err != nil {
 		return content
 	}
 	return strings.TrimSuffix(rendered, "\n")
 }
+
+// completeBlocksLen returns the length of the prefix of content that consists
+// of complete top-level markdown blocks: everything up to the last blank line
+// or closing code fence that is not inside an open fence. The remainder is
+// the block still being streamed.
+func completeBlocksLen(content string) int {
+	var s blockScanner
+	return s.scan(content)
+}
+
+// blockScanner finds the end of the complete blocks of a growing markdown
+// document, as completeBlocksLen does, resuming where the last scan stopped
+// so each line is only looked at once however many times it is called.
+type blockScanner struct {
+	pos       int // start of the first line not yet scanned
+	boundary  int
+	inFence   bool
+	fenceChar byte
+}
+
+// scan scans the lines of content completed since the last call, which must
+// have been given a prefix of content, and returns the end of its complete
+// blocks.
+func (s *blockScanner) scan(content string) int {
+	for {
+		nl := strings.IndexByte(content[s.pos:], '\n')
+		if nl < 0 {
+			break // a trailing partial line is never complete
+		}
+		line := strings.TrimSpace(content[s.pos : s.pos+nl])
+		next := s.pos + nl + 1
+
+		switch {
+		case s.inFence:
+			if len(line) >= 3 && strings.Trim(line, string(s.fenceChar)) == "" {
+				s.inFence = false
+				s.boundary = next
+			}
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			s.inFence = true
+			s.fenceChar = line[0]
+		case line == "":
+			s.boundary = next
+		}
+		s.pos = next
+	}
+
+	return s.boundary
+}
+
+// streamRender is the markdown rendering of a message being streamed. Each
+// block is rendered once, when it completes, rather than the whole message
+// on every delta; blocks still being streamed are shown as they are.
+//
+// Rendering blocks separately only approximates rendering them together,
+// e.g. a list with blank lines between its items renders as several lists,
+// which is fine until the finished message is rendered whole.
+type streamRender struct {
+	scanner     blockScanner
+	width       int    // width the blocks were rendered at
+	rendered    string // rendering of content[:renderedLen]
+	renderedLen int
+
+	// rendered with the message style applied. Laying out the whole message
+	// on every delta costs more than rendering it.
+	styled string
+}
+
+// render returns the rendered complete blocks of content, which must extend
+// the content of earlier calls, followed by the raw text of the block still
+// being streamed, laid out with style. Blocks completed since the last call
+// are rendered wrapped to width, and all of them again if width changed.
+// style must be the same for every call at a given width; it works line by
+// line, so laying out the blocks and the tail separately gives the same
+// result as laying them out together.
+func (sr *streamRender) render(content string, style lipgloss.Style, width int) string {
+	if len(content) < sr.scanner.pos {
+		*sr = streamRender{} // not an extension; start over
+	}
+	if width != sr.width {
+		sr.rendered, sr.renderedLen, sr.styled = "", 0, ""
+		sr.width = width
+	}
+
+	if n := sr.scanner.scan(content); n > sr.renderedLen {
+		if chunk := strings.Trim(renderMarkdown(content[sr.renderedLen:n], width), "\n"); chunk != "" {
+			if sr.rendered != "" {
+				sr.rendered += "\n\n"
+			}
+			sr.rendered += chunk
+			sr.styled = ""
+		}
+		sr.renderedLen = n
+	}
+
+	tail := strings.TrimLeft(content[sr.renderedLen:], "\n")
+	if sr.rendered == "" {
+		return style.Render(tail)
+	}
+	if sr.styled == "" {
+		sr.styled = style.Render(sr.rendered)
+	}
+	if tail == "" {
+		return sr.styled
+	}
+	return sr.styled + "\n" + style.Render("") + "\n" + style.Render(tail)
+}
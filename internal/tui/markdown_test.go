@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+func TestCompleteBlocksLen(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{"empty", "", 0},
+		{"partial line", "Hello wor", 0},
+		{"single line no blank", "Hello world\n", 0},
+		{"paragraph then partial", "Para one.\n\nPara tw", len("Para one.\n\n")},
+		{"open fence", "Intro\n\n```go\nfunc main() {\n", len("Intro\n\n")},
+		{"blank line inside fence", "```go\na := 1\n\nb := 2\n", 0},
+		{"closed fence", "```go\na := 1\n```\nmore", len("```go\na := 1\n```\n")},
+		{"tilde fence", "~~~\ncode\n~~~\n", len("~~~\ncode\n~~~\n")},
+		{"backticks do not close tilde fence", "~~~\ncode\n```\n\n", 0},
+		{"fence partial closing line", "```\ncode\n``", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completeBlocksLen(tt.content)
+			if got != tt.expected {
+				t.Errorf("completeBlocksLen(%q) = %d, want %d", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+// sampleMarkdown is a response with the usual mix of blocks.
+const sampleMarkdown = "Here is the plan.\n\n" +
+	"1. Read the config\n2. Validate it\n3. Save it\n\n" +
+	"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n\n\treturn\n}\n```\n" +
+	"Some **bold** text and `code` spans, with a [link](https://example.com).\n\n" +
+	"- one\n- two\n\n" +
+	"Done."
+
+// streamDeltas splits content into deltas of n bytes.
+func streamDeltas(content string, n int) []string {
+	var deltas []string
+	for len(content) > n {
+		deltas = append(deltas, content[:n])
+		content = content[n:]
+	}
+	return append(deltas, content)
+}
+
+func TestStreamRenderIncremental(t *testing.T) {
+	const width = 30
+	style := msgContentStyle.Width(width + 2)
+
+	var sr streamRender
+	var content, body string
+	for _, delta := range streamDeltas(sampleMarkdown, 3) {
+		content += delta
+		body = sr.render(content, style, width)
+		if want := completeBlocksLen(content); sr.renderedLen != want {
+			t.Fatalf("after %q: rendered %d bytes, want %d", content, sr.renderedLen, want)
+		}
+		want := strings.TrimLeft(content[sr.renderedLen:], "\n")
+		if sr.rendered != "" && want != "" {
+			want = sr.rendered + "\n\n" + want
+		} else if sr.rendered != "" {
+			want = sr.rendered
+		}
+		if want = style.Render(want); body != want {
+			t.Fatalf("after %q: body\n%q\nwant\n%q", content, body, want)
+		}
+	}
+	for _, text := range []string{"Here is the plan.", "Validate it", "fmt.Println", "bold", "two", "Done."} {
+		if !strings.Contains(body, text) {
+			t.Errorf("body is missing %q: %q", text, body)
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if w := lipgloss.Width(line); w > width+2 {
+			t.Errorf("line %q is %d columns wide, want at most %d", line, w, width+2)
+		}
+	}
+
+	// A new width renders the complete blocks again.
+	wide := sr.render(content, msgContentStyle.Width(62), 60)
+	if sr.width != 60 || wide == body {
+		t.Error("changing the width did not re-render")
+	}
+
+	// A shorter content is a new message, not an extension.
+	if got := sr.render("New", style, width); sr.renderedLen != 0 || got != style.Render("New") {
+		t.Errorf("after restart: renderedLen %d, body %q", sr.renderedLen, got)
+	}
+}
+
+func TestRenderMarkdownWraps(t *testing.T) {
+	text := strings.Repeat("word ", 40)
+	for _, width := range []int{40, 80} {
+		for _, line := range strings.Split(renderMarkdown(text, width), "\n") {
+			if w := lipgloss.Width(line); w > width {
+				t.Errorf("at width %d: line %q is %d columns wide", width, line, w)
+			}
+		}
+	}
+	if got := renderMarkdown(text, 0); strings.Count(got, "word") != 40 || strings.Count(strings.TrimSpace(got), "\n") != 0 {
+		t.Errorf("unwrapped rendering = %q", got)
+	}
+
+	if err := SetMarkdownTheme("no-such-theme"); err == nil {
+		t.Error("SetMarkdownTheme accepted an unknown theme")
+	}
+	if err := SetMarkdownTheme("notty"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetMarkdownTheme("")
+	if got := renderMarkdown("**bold**", 40); strings.Contains(got, "\x1b[") {
+		t.Errorf("notty rendering has escape codes: %q", got)
+	}
+}
+
+func TestMessageViewCache(t *testing.T) {
+	ml := NewMessageList()
+	ml.Add(message.Assistant, "Some **markdown**")
+	ml.AddToolCall("view", `{"path":"a.go"}`)
+
+	first := ml.View(80, 20)
+	if ml.messages[0].view == "" || ml.messages[0].viewWidth != 80 {
+		t.Fatal("View did not cache the rendering")
+	}
+	if again := ml.View(80, 20); again != first {
+		t.Error("cached rendering differs from the first")
+	}
+
+	ml.UpdateLastToolCall("view", `{"path":"b.go"}`)
+	if view := ml.View(80, 20); !strings.Contains(view, "b.go") {
+		t.Errorf("view after updating the tool call still shows the old input: %q", view)
+	}
+	ml.View(60, 20)
+	if ml.messages[0].viewWidth != 60 {
+		t.Error("resizing did not re-render")
+	}
+}
+
+// BenchmarkStreamingView measures the work per text delta while a response
+// streams in below an existing conversation: one update of the streaming
+// message and one render of the message list, as each delta causes.
+func BenchmarkStreamingView(b *testing.B) {
+	response := strings.Repeat(sampleMarkdown+"\n\n", 20)
+	deltas := streamDeltas(response, 4)
+	for b.Loop() {
+		ml := NewMessageList()
+		for range 10 {
+			ml.Add(message.User, "Explain this")
+			ml.Add(message.Assistant, sampleMarkdown)
+		}
+		var content string
+		for _, delta := range deltas {
+			content += delta
+			ml.UpdateStreaming(content)
+			ml.View(100, 40)
+		}
+		ml.FinalizeStreaming(content)
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(deltas)), "ns/delta")
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/webgovernor/goder/internal/config"
 	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/tools"
 )
 
 // DisplayMessage represents a message as displayed in the TUI.
@@ -27,6 +29,30 @@ type DisplayMessage struct {
 
 	// Streaming state
 	IsStreaming bool
+	Interrupted bool // streaming stopped before the response was complete
+
+	// Markdown rendering of a streaming message, extended as blocks complete.
+	stream streamRender
+
+	// The message as last rendered by View, reused while the message and the
+	// width it was rendered at stay the same. Streaming messages change with
+	// every delta and are not cached.
+	view       string
+	viewWidth  int
+	viewExpand bool
+}
+
+// render renders the message for View, from the cache if it is still good.
+// assistant labels assistant messages.
+func (dm *DisplayMessage) render(width int, expandTools bool, assistant string) string {
+	if dm.IsStreaming {
+		return renderDisplayMessage(dm, width, expandTools, assistant)
+	}
+	if dm.view == "" || dm.viewWidth != width || dm.viewExpand != expandTools {
+		dm.view = renderDisplayMessage(dm, width, expandTools, assistant)
+		dm.viewWidth, dm.viewExpand = width, expandTools
+	}
+	return dm.view
 }
 
 // MessageList holds the conversation display state.
@@ -34,6 +60,17 @@ type MessageList struct {
 	messages  []DisplayMessage
 	offset    int // scroll offset (lines from bottom)
 	streaming int // index of the current streaming message, or -1
+
+	expandTools bool // show tool call inputs in full
+
+	assistantName string // labels assistant messages; empty means config.DefaultAssistantName
+
+	quiet bool // collapse each turn's tool calls and results into one line
+
+	// summaries holds the quiet mode summary of each turn's tool activity
+	// (toolSummaries). It is rebuilt when tool steps are added or the list
+	// is reloaded, not on every View.
+	summaries map[int]string
 }
 
 // NewMessageList creates an empty message list.
@@ -41,6 +78,25 @@ func NewMessageList() MessageList {
 	return MessageList{streaming: -1}
 }
 
+// SetAssistantName sets the name assistant messages are labelled with, or
+// restores config.DefaultAssistantName if name is empty. It applies to
+// messages rendered from now on.
+func (ml *MessageList) SetAssistantName(name string) {
+	ml.assistantName = name
+}
+
+// SetQuiet turns quiet mode on or off. In quiet mode, each turn's tool
+// calls and results are shown as a single summary line unless tool details
+// are expanded (ToggleToolInputs).
+func (ml *MessageList) SetQuiet(quiet bool) {
+	ml.quiet = quiet
+}
+
+// Quiet reports whether quiet mode is on.
+func (ml *MessageList) Quiet() bool {
+	return ml.quiet
+}
+
 // Count returns the number of messages.
 func (ml *MessageList) Count() int {
 	return len(ml.messages)
@@ -60,19 +116,31 @@ func (ml *MessageList) Add(role message.Role, content string) {
 func (ml *MessageList) AddMessage(msg message.Message) {
 	ml.messages = append(ml.messages, DisplayMessage{
 		Role:      msg.Role,
-		Content:   msg.Content,
+		Content:   displayContent(msg),
 		Timestamp: msg.CreatedAt,
 	})
 	ml.scrollToBottom()
 }
 
-// LoadFromMessages replaces the message list with messages from the database.
+// displayContent returns the text shown for msg, noting any attachments.
+func displayContent(msg message.Message) string {
+	if len(msg.Attachments) == 0 {
+		return msg.Content
+	}
+	return msg.Content + "\n[attached: " + attachmentNames(msg.Attachments) + "]"
+}
+
+// LoadFromMessages replaces the message list with messages from the
+// database, leaving out those marked hidden.
 func (ml *MessageList) LoadFromMessages(msgs []message.Message) {
 	ml.messages = nil
 	for _, msg := range msgs {
+		if msg.Hidden {
+			continue
+		}
 		dm := DisplayMessage{
 			Role:      msg.Role,
-			Content:   msg.Content,
+			Content:   displayContent(msg),
 			Timestamp: msg.CreatedAt,
 		}
 		// Render tool calls and results from persisted messages
@@ -105,6 +173,7 @@ func (ml *MessageList) LoadFromMessages(msgs []message.Message) {
 			ml.messages = append(ml.messages, dm)
 		}
 	}
+	ml.updateSummaries()
 	ml.scrollToBottom()
 }
 
@@ -133,6 +202,22 @@ func (ml *MessageList) FinalizeStreaming(finalContent string) {
 	ml.streaming = -1
 }
 
+// InterruptStreaming ends the streaming message, if any, keeping the text
+// received so far and marking it as interrupted. A message that received no
+// text is removed.
+func (ml *MessageList) InterruptStreaming() {
+	if ml.streaming >= 0 && ml.streaming < len(ml.messages) {
+		if ml.messages[ml.streaming].Content == "" {
+			ml.messages = append(ml.messages[:ml.streaming], ml.messages[ml.streaming+1:]...)
+			ml.updateSummaries()
+		} else {
+			ml.messages[ml.streaming].IsStreaming = false
+			ml.messages[ml.streaming].Interrupted = true
+		}
+	}
+	ml.streaming = -1
+}
+
 // AddToolCall adds a tool call indicator message.
 func (ml *MessageList) AddToolCall(toolName, input string) {
 	ml.messages = append(ml.messages, DisplayMessage{
@@ -142,6 +227,7 @@ func (ml *MessageList) AddToolCall(toolName, input string) {
 		ToolName:   toolName,
 		ToolInput:  input,
 	})
+	ml.updateSummaries()
 	ml.scrollToBottom()
 }
 
@@ -150,6 +236,7 @@ func (ml *MessageList) UpdateLastToolCall(toolName, input string) {
 	for i := len(ml.messages) - 1; i >= 0; i-- {
 		if ml.messages[i].IsToolCall && ml.messages[i].ToolName == toolName {
 			ml.messages[i].ToolInput = input
+			ml.messages[i].view = ""
 			break
 		}
 	}
@@ -165,9 +252,22 @@ func (ml *MessageList) AddToolResult(toolName, output string, isError bool) {
 		ToolOutput:   output,
 		ToolIsError:  isError,
 	})
+	ml.updateSummaries()
 	ml.scrollToBottom()
 }
 
+// updateSummaries rebuilds the quiet mode tool summaries.
+func (ml *MessageList) updateSummaries() {
+	ml.summaries = toolSummaries(ml.messages)
+}
+
+// ToggleToolInputs switches tool call inputs between their collapsed and
+// full forms. In quiet mode it also shows or hides the tool calls and
+// results themselves.
+func (ml *MessageList) ToggleToolInputs() {
+	ml.expandTools = !ml.expandTools
+}
+
 func (ml *MessageList) scrollToBottom() {
 	ml.offset = 0
 }
@@ -198,9 +298,22 @@ func (ml *MessageList) View(width, height int) string {
 		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, empty)
 	}
 
-	var rendered []string
-	for _, msg := range ml.messages {
-		rendered = append(rendered, renderDisplayMessage(msg, width))
+	// The cached renderings are stored in the shared backing array, so they
+	// outlive the copy of the list that View is called on.
+	assistant := ml.assistantName
+	if assistant == "" {
+		assistant = config.DefaultAssistantName
+	}
+	collapse := ml.quiet && !ml.expandTools
+	rendered := make([]string, 0, len(ml.messages))
+	for i := range ml.messages {
+		if collapse && isToolStep(&ml.messages[i]) {
+			if summary, ok := ml.summaries[i]; ok {
+				rendered = append(rendered, summary)
+			}
+			continue
+		}
+		rendered = append(rendered, ml.messages[i].render(width, ml.expandTools, assistant))
 	}
 
 	content := strings.Join(rendered, "\n\n")
@@ -233,26 +346,33 @@ func (ml *MessageList) View(width, height int) string {
 	return result
 }
 
-func renderDisplayMessage(msg DisplayMessage, width int) string {
+func renderDisplayMessage(msg *DisplayMessage, width int, expandTools bool, assistant string) string {
 	// Tool call message
 	if msg.IsToolCall {
 		label := toolCallStyle.Render(fmt.Sprintf("  tool: %s", msg.ToolName))
-		input := msg.ToolInput
-		if len(input) > 200 {
-			input = input[:200] + "..."
+		if msg.ToolInput == "" {
+			return label
 		}
-		if input != "" {
-			inputRendered := dimStyle.Render(fmt.Sprintf("  %s", input))
-			return label + "\n" + inputRendered
+		input, collapsed := formatToolInput(msg.ToolInput, expandTools)
+		lines := []string{label}
+		for _, line := range strings.Split(input, "\n") {
+			lines = append(lines, dimStyle.Render("  "+line))
 		}
-		return label
+		if collapsed {
+			lines = append(lines, timestampStyle.Render("  (ctrl+o to expand)"))
+		}
+		return strings.Join(lines, "\n")
 	}
 
 	// Tool result message
 	if msg.IsToolResult {
 		output := msg.ToolOutput
 		if len(output) > 500 {
-			output = output[:500] + "\n... (truncated)"
+			// The cut may split a color sequence kept in the output.
+			output = tools.CleanTerminalOutput(output[:500], true) + "\n... (truncated)"
+		}
+		if strings.Contains(output, "\x1b[") {
+			output += "\x1b[0m" // keep the output's colors from running on
 		}
 		style := toolResultStyle
 		if msg.ToolIsError {
@@ -270,9 +390,11 @@ func renderDisplayMessage(msg DisplayMessage, width int) string {
 		roleLabel = userMsgStyle.Render("> you")
 	case message.Assistant:
 		if msg.IsStreaming {
-			roleLabel = assistantMsgStyle.Render("> assistant") + " " + streamingIndicator.Render("...")
+			roleLabel = assistantMsgStyle.Render("> "+assistant) + " " + streamingIndicator.Render("...")
+		} else if msg.Interrupted {
+			roleLabel = assistantMsgStyle.Render("> "+assistant) + " " + dimStyle.Render("(interrupted)")
 		} else {
-			roleLabel = assistantMsgStyle.Render("> assistant")
+			roleLabel = assistantMsgStyle.Render("> " + assistant)
 		}
 	case message.System:
 		roleLabel = dimStyle.Render("> system")
@@ -287,11 +409,16 @@ func renderDisplayMessage(msg DisplayMessage, width int) string {
 	if contentWidth < 20 {
 		contentWidth = 20
 	}
+	// Markdown is wrapped to the width left inside the padding, so that
+	// the style has nothing left to wrap.
+	style := msgContentStyle.Width(contentWidth)
+	textWidth := contentWidth - style.GetHorizontalPadding()
+	if msg.IsStreaming {
+		return header + "\n" + msg.stream.render(msg.Content, style, textWidth)
+	}
 	body := msg.Content
 	if msg.Role == message.Assistant {
-		body = renderMarkdown(body)
+		body = renderMarkdown(body, textWidth)
 	}
-	body = msgContentStyle.Width(contentWidth).Render(body)
-
-	return header + "\n" + body
+	return header + "\n" + style.Render(body)
 }
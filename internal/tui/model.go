@@ -2,9 +2,14 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +17,7 @@ import (
 	"github.com/webgovernor/goder/internal/config"
 	"github.com/webgovernor/goder/internal/db"
 	"github.com/webgovernor/goder/internal/llm/agent"
+	"github.com/webgovernor/goder/internal/llm/prompt"
 	"github.com/webgovernor/goder/internal/llm/provider"
 	"github.com/webgovernor/goder/internal/message"
 	"github.com/webgovernor/goder/internal/permission"
@@ -43,6 +49,14 @@ const (
 	BuildMode
 )
 
+// ParseMode returns the Mode named by s, falling back to PlanMode.
+func ParseMode(s string) Mode {
+	if s == BuildMode.String() {
+		return BuildMode
+	}
+	return PlanMode
+}
+
 func (m Mode) String() string {
 	switch m {
 	case PlanMode:
@@ -54,6 +68,16 @@ func (m Mode) String() string {
 	}
 }
 
+// agentPhase describes what the agent is doing while a turn is in progress.
+type agentPhase int
+
+const (
+	phaseWaiting          agentPhase = iota // waiting on the model
+	phaseResponding                         // model is streaming output
+	phaseRunningTool                        // a tool is executing
+	phaseAwaitingApproval                   // a tool is waiting for permission
+)
+
 // Model is the top-level bubbletea model for the application.
 type Model struct {
 	// Core state
@@ -73,22 +97,89 @@ type Model struct {
 	prov     provider.Provider
 	permSvc  *permission.Service
 
-	// Session usage state
-	tokenTotal int
+	toolMetrics *agent.ToolMetrics // tool calls made this session, for /stats
+
+	attachments []message.Attachment // queued by /attach for the next prompt
+	pinned      []string             // absolute paths pinned by /pin, sent with every request
+
+	// exportOverwrite is the existing file /export last declined to
+	// overwrite; exporting to it again confirms.
+	exportOverwrite string
+
+	// newProvider rebuilds the provider after the provider setting changes.
+	newProvider ProviderFactory
+
+	// newRegistry rebuilds the tool registry after /cwd.
+	newRegistry RegistryFactory
+
+	// version describes the running build, shown in the settings overlay.
+	version string
+
+	// Session state
+	history      inputHistory // submitted prompts, recalled with up/down
+	tokenTotal   int
+	sessionTitle string
+
+	// tokenEstimate approximates the tokens the prompt being typed would
+	// send with its history; estimateSeq debounces its refresh.
+	tokenEstimate int
+	estimateSeq   int
+
+	titlePending bool // true while a title generation request is in flight
 
 	// Agent state
 	agentCancel context.CancelFunc
 	thinking    bool                // true while agent is processing
+	phase       agentPhase          // what the agent is doing while thinking
+	phaseTool   string              // tool name for phaseRunningTool/phaseAwaitingApproval
 	streamBuf   string              // accumulates streaming text (plain string to avoid strings.Builder copy panic)
+	agentRun    int                 // incremented for each agent run; see agentEventMsg
 	permReq     *permission.Request // pending permission request
 
+	// reviewEditing is set while the content of a pending review request is
+	// being edited in the input area.
+	reviewEditing bool
+
 	// Settings overlay
 	settings     Settings
 	settingsOpen bool
 
+	// modelsCancel cancels the model list fetch in flight, if any.
+	// modelsFetch numbers the fetches, so the result of one that was
+	// cancelled or replaced is dropped; see modelsLoadedMsg.
+	modelsCancel context.CancelFunc
+	modelsFetch  int
+
+	// modelCache keeps fetched model lists; see newModelCache.
+	modelCache *provider.ModelCache
+
+	// Diff viewer overlay, shown in place of the messages
+	diffOpen    bool
+	diffView    diffViewer
+	lastChanges []agent.FileChange // files changed by the latest turn
+
 	// Quit confirmation
 	confirmQuit bool
 
+	// contextWarned is set once the session has been warned that it is
+	// nearing the model's context window; see checkContextUsage.
+	contextWarned bool
+
+	// rateLimit is what the provider last reported about its rate limits,
+	// shown in the status bar when they are nearly used up.
+	rateLimit *provider.RateLimitStatus
+
+	// quitting is set while waiting for a cancelled agent to stop before
+	// quitting; see shutdown.
+	quitting bool
+
+	// suggestedModel, when set, is offered in place of a configured model
+	// the API key cannot use.
+	suggestedModel string
+
+	// Terminal focus, used to decide whether to notify
+	focus focusState
+
 	// Program reference for sending commands from goroutines.
 	// This is a pointer to a shared struct so that all copies of Model
 	// (including the one inside tea.Program) share the same reference.
@@ -97,11 +188,15 @@ type Model struct {
 
 // New creates and returns a new Model.
 func New(cfg config.Config, database *db.DB, sessions *session.Service, registry *tools.Registry, prov provider.Provider, permSvc *permission.Service) Model {
+	msgs := NewMessageList()
+	msgs.SetAssistantName(cfg.DisplayName())
+	msgs.SetQuiet(cfg.Verbosity == "quiet")
+
 	return Model{
-		mode:     PlanMode,
+		mode:     ParseMode(cfg.DefaultMode),
 		keys:     DefaultKeyMap(),
-		input:    NewInput(),
-		msgs:     NewMessageList(),
+		input:    newPromptInput(cfg),
+		msgs:     msgs,
 		settings: NewSettings(),
 		cfg:      cfg,
 		database: database,
@@ -110,9 +205,50 @@ func New(cfg config.Config, database *db.DB, sessions *session.Service, registry
 		prov:     prov,
 		permSvc:  permSvc,
 		progRef:  &programRef{}, // shared across Bubble Tea value copies
+
+		toolMetrics: agent.NewToolMetrics(),
+		modelCache:  newModelCache(cfg),
 	}
 }
 
+// newPromptInput creates the prompt input with slash command and workspace
+// file suggestions.
+func newPromptInput(cfg config.Config) Input {
+	input := NewInput()
+
+	var commands []string
+	for _, c := range slashCommands() {
+		commands = append(commands, c.name)
+	}
+	input.SetCompletions(commands, workspaceFileLister(cfg))
+	return input
+}
+
+// ProviderFactory builds the LLM provider described by a configuration.
+type ProviderFactory func(cfg config.Config) (provider.Provider, error)
+
+// SetProviderFactory sets the function used to rebuild the provider when it
+// is switched from the settings overlay. It must be called before the model
+// is handed to tea.NewProgram.
+func (m *Model) SetProviderFactory(f ProviderFactory) {
+	m.newProvider = f
+}
+
+// RegistryFactory builds the tool registry for a working directory.
+type RegistryFactory func(workDir string) (*tools.Registry, error)
+
+// SetRegistryFactory sets the function used to rebuild the tool registry
+// when the working directory is changed with /cwd. It must be called before
+// the model is handed to tea.NewProgram.
+func (m *Model) SetRegistryFactory(f RegistryFactory) {
+	m.newRegistry = f
+}
+
+// SetVersion sets the build description shown in the settings "About" view.
+func (m *Model) SetVersion(info string) {
+	m.version = info
+}
+
 // SetProgram stores a reference to the tea.Program for async command sending.
 // Safe to call after tea.NewProgram because progRef is shared across copies.
 func (m *Model) SetProgram(p *tea.Program) {
@@ -122,7 +258,7 @@ func (m *Model) SetProgram(p *tea.Program) {
 // Init implements tea.Model.
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
-		tea.SetWindowTitle("goder"),
+		tea.SetWindowTitle(m.cfg.DisplayName()),
 		m.input.Focus(),
 		m.initSession(),
 		m.listenForPermissions(),
@@ -132,19 +268,28 @@ func (m Model) Init() tea.Cmd {
 	if m.cfg.APIKey == "" {
 		m.msgs.Add(message.System,
 			"No API key configured. Press ctrl+k to open settings and enter your OpenAI API key.")
+	} else if m.prov != nil {
+		cmds = append(cmds, checkModelCmd(m.prov))
 	}
 
 	return tea.Batch(cmds...)
 }
 
-// initSession creates or loads the initial session.
+// initSession creates or loads the initial session. With showStartupSummary
+// set, a session with no messages yet is shown with a summary of the project.
 func (m Model) initSession() tea.Cmd {
 	return func() tea.Msg {
 		sess, err := m.sessions.Current()
 		if err != nil {
 			return errMsg(fmt.Errorf("initializing session: %w", err))
 		}
-		return sessionLoadedMsg{session: sess}
+		var notice string
+		if m.cfg.ShowStartupSummary {
+			if messages, err := m.sessions.GetMessages(); err == nil && len(messages) == 0 {
+				notice = startupSummary(context.Background(), m.cfg)
+			}
+		}
+		return sessionLoadedMsg{session: sess, notice: notice}
 	}
 }
 
@@ -162,11 +307,21 @@ func (m Model) listenForPermissions() tea.Cmd {
 
 // --- Message types for async operations ---
 
-type sessionLoadedMsg struct{ session *db.Session }
+// sessionLoadedMsg reports the session to show. notice, if set, is shown
+// after its messages.
+type sessionLoadedMsg struct {
+	session *db.Session
+	notice  string
+}
 type errMsg error
 
-// agentEventMsg wraps an agent event for the TUI.
-type agentEventMsg struct{ event agent.Event }
+// agentEventMsg wraps an agent event for the TUI. run identifies the agent
+// run that produced it, so events still in flight from a cancelled run can
+// be told apart from those of the next one.
+type agentEventMsg struct {
+	event agent.Event
+	run   int
+}
 
 // permissionRequestMsg wraps a permission request for the TUI.
 type permissionRequestMsg struct{ request permission.Request }
@@ -182,6 +337,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.input.SetWidth(msg.Width)
 		return m, nil
 
+	case tea.FocusMsg:
+		m.focus = focusState{known: true, focused: true}
+		return m, nil
+
+	case tea.BlurMsg:
+		m.focus = focusState{known: true, focused: false}
+		return m, nil
+
 	case sessionLoadedMsg:
 		// Load messages from the session
 		messages, err := m.sessions.GetMessages()
@@ -190,43 +353,146 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.msgs.LoadFromMessages(messages)
+		m.history = inputHistory{}
+		for _, msg := range messages {
+			if msg.Role == message.User && msg.Content != "" {
+				m.history.Add(msg.Content)
+			}
+		}
 		total, err := m.sessions.GetTokenTotal()
 		if err != nil {
 			m.err = err
 			return m, nil
 		}
 		m.tokenTotal = total
+		m.contextWarned = false
+		m.sessionTitle = msg.session.Title
+		if msg.notice != "" {
+			m.msgs.Add(message.System, msg.notice)
+		}
+		return m, nil
+
+	case copyResultMsg:
+		return m, m.handleCopyResult(msg)
+
+	case sessionTitleMsg:
+		m.titlePending = false
+		// Title generation is best-effort; failures keep the default title.
+		if msg.err != nil || msg.title == "" || msg.sessionID != m.sessions.CurrentID() {
+			return m, nil
+		}
+		if err := m.sessions.UpdateTitle(msg.title); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.sessionTitle = msg.title
+		return m, nil
+
+	case sessionSummaryMsg:
+		if msg.err != nil {
+			m.msgs.Add(message.System, fmt.Sprintf("Summarize failed: %s", msg.err.Error()))
+			return m, nil
+		}
+		if msg.sessionID != m.sessions.CurrentID() || msg.summary == "" {
+			return m, nil
+		}
+		if err := m.sessions.SetSummary(msg.summary); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.msgs.Add(message.System, "Session summary updated:\n\n"+msg.summary)
 		return m, nil
 
 	case permissionRequestMsg:
 		m.permReq = &msg.request
-		return m, nil
+		m.phase = phaseAwaitingApproval
+		m.phaseTool = msg.request.ToolName
+		return m, m.notifyCmd()
 
 	case agentEventMsg:
+		if msg.run != m.agentRun {
+			return m.handleStaleAgentEvent(msg.event)
+		}
+		if m.quitting {
+			return m.handleShutdownEvent(msg.event)
+		}
 		return m.handleAgentEvent(msg.event)
 
+	case shutdownTimeoutMsg:
+		if m.quitting {
+			m.persistPartialResponse()
+			return m, tea.Quit
+		}
+		return m, nil
+
 	case modelsLoadedMsg:
+		if msg.fetch != m.modelsFetch {
+			return m, nil
+		}
+		m.modelsCancel()
+		m.modelsCancel = nil
 		m.settings.HandleModelsLoaded(msg.models, msg.err)
+		if msg.err == nil && m.prov != nil {
+			if err := m.modelCache.Put(provider.ModelCacheKey(m.prov), msg.models); err != nil {
+				m.settings.SetFeedback(err.Error(), true)
+			}
+		}
 		return m, nil
 
+	case modelCheckMsg:
+		return m.handleModelCheck(msg)
+
 	case tea.KeyMsg:
+		if m.quitting {
+			// Quit again to stop waiting for the agent.
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
 		if m.confirmQuit {
 			return m.handleQuitConfirmKey(msg)
 		}
-
 		// Handle settings overlay if open
 		if m.settingsOpen {
 			return m.handleSettingsKey(msg)
 		}
+		if m.diffOpen {
+			return m.handleDiffKey(msg)
+		}
 
 		// Handle permission dialog keys first
+		if m.reviewEditing {
+			return m.handleReviewEditKey(msg)
+		}
 		if m.permReq != nil {
 			return m.handlePermissionKey(msg)
 		}
+		if m.suggestedModel != "" {
+			if handled, cmd := m.handleModelSuggestionKey(msg); handled {
+				return m, cmd
+			}
+		}
+
+		// Suggestion navigation takes precedence over history and scrolling.
+		if !m.thinking && m.input.HandleCompletionKey(msg) {
+			return m, m.scheduleEstimate()
+		}
 
 		scrollAmount := m.messageScrollAmount()
 
 		switch {
+		case msg.Type == tea.KeyUp && m.canRecallHistory():
+			if prompt, ok := m.history.Prev(); ok {
+				m.input.SetValue(prompt)
+			}
+			return m, m.scheduleEstimate()
+
+		case msg.Type == tea.KeyDown && m.canRecallHistory() && m.history.Browsing():
+			prompt, _ := m.history.Next()
+			m.input.SetValue(prompt)
+			return m, m.scheduleEstimate()
+
 		case key.Matches(msg, m.keys.ScrollUp):
 			if !m.thinking {
 				m.msgs.ScrollUp(scrollAmount)
@@ -243,11 +509,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.confirmQuit = true
 			return m, nil
 
+		case key.Matches(msg, m.keys.ExpandTool):
+			m.msgs.ToggleToolInputs()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Diff):
+			m.openChangesDiff()
+			return m, nil
+
+		case key.Matches(msg, m.keys.CopyCmd):
+			return m, m.copyLastCommand()
+
 		case key.Matches(msg, m.keys.Cancel):
 			if m.thinking && m.agentCancel != nil {
 				m.agentCancel()
 				m.agentCancel = nil
+				m.agentRun++ // drop events still in flight from the cancelled run
 				m.thinking = false
+				m.msgs.InterruptStreaming()
+				m.streamBuf = ""
 				m.msgs.Add(message.System, "Agent cancelled.")
 				return m, m.listenForPermissions()
 			}
@@ -265,13 +545,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil // don't toggle while agent is running
 			}
 			if m.mode == PlanMode {
-				m.mode = BuildMode
-				m.msgs.Add(message.System,
-					"Switched to BUILD mode. The assistant can now create and modify files.")
+				m.setMode(BuildMode)
 			} else {
-				m.mode = PlanMode
-				m.msgs.Add(message.System,
-					"Switched to PLAN mode. The assistant will only analyze, not modify files.")
+				m.setMode(PlanMode)
 			}
 			return m, nil
 
@@ -285,23 +561,56 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.input.Reset()
+			m.history.Add(val)
+			if cmd, ok := m.runSlashCommand(val); ok {
+				return m, cmd
+			}
 			return m, m.submitPrompt(val)
 		}
 
 	case errMsg:
 		m.err = msg
 		return m, nil
+
+	case estimateMsg:
+		if msg.seq == m.estimateSeq {
+			m.refreshEstimate()
+		}
+		return m, nil
 	}
 
 	// Forward remaining messages to the text input (only if not thinking)
 	if !m.thinking {
-		cmd := m.input.Update(msg)
-		cmds = append(cmds, cmd)
+		before := m.input.Value()
+		cmds = append(cmds, m.input.Update(msg))
+		if m.input.Value() != before {
+			cmds = append(cmds, m.scheduleEstimate())
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// modePrompts returns the configured per-mode system prompt instructions.
+func (m Model) modePrompts() prompt.ModePrompts {
+	return prompt.ModePrompts{
+		Plan:    m.cfg.PlanPrompt,
+		Build:   m.cfg.BuildPrompt,
+		Replace: m.cfg.ReplaceModePrompts,
+	}
+}
+
+// canRecallHistory reports whether up/down should step through prompt
+// history instead of scrolling: the input must be empty or still showing an
+// unedited recalled prompt.
+func (m Model) canRecallHistory() bool {
+	if m.thinking || len(m.history.entries) == 0 {
+		return false
+	}
+	val := m.input.Value()
+	return val == "" || (m.history.Browsing() && val == m.history.Current())
+}
+
 // submitPrompt sends a user message and starts the agent loop.
 func (m *Model) submitPrompt(prompt string) tea.Cmd {
 	// Check if API key is configured
@@ -314,9 +623,14 @@ func (m *Model) submitPrompt(prompt string) tea.Cmd {
 	// Add user message
 	sessionID := m.sessions.CurrentID()
 	userMsg := message.NewUserMessage(sessionID, prompt)
+	userMsg.Attachments = m.attachments
+	m.attachments = nil
 	m.msgs.AddMessage(userMsg)
 	m.thinking = true
+	m.phase = phaseWaiting
 	m.streamBuf = ""
+	m.agentRun++
+	run := m.agentRun
 
 	// Persist user message
 	if err := m.sessions.AddMessage(userMsg); err != nil {
@@ -335,19 +649,55 @@ func (m *Model) submitPrompt(prompt string) tea.Cmd {
 		}
 	}
 
+	summary, err := m.sessions.GetSummary()
+	if err != nil {
+		return func() tea.Msg {
+			return errMsg(fmt.Errorf("loading session summary: %w", err))
+		}
+	}
+	instructions, err := m.sessions.GetInstructions()
+	if err != nil {
+		return func() tea.Msg {
+			return errMsg(fmt.Errorf("loading session instructions: %w", err))
+		}
+	}
+
 	// Create agent
 	ctx, cancel := context.WithCancel(context.Background())
 	m.agentCancel = cancel
 
 	ag := agent.New(agent.Config{
-		Provider:      m.prov,
-		Registry:      m.registry,
-		PermSvc:       m.permSvc,
-		WorkDir:       m.cfg.WorkDir,
-		Mode:          m.mode.String(),
-		Model:         m.cfg.Model,
-		MaxTokens:     m.cfg.MaxTokens,
-		MaxIterations: m.cfg.MaxIterations,
+		Provider:       m.prov,
+		Registry:       m.registry,
+		PermSvc:        m.permSvc,
+		WorkDir:        m.cfg.WorkDir,
+		Mode:           m.mode.String(),
+		Model:          m.cfg.ModelID(),
+		AssistantName:  m.cfg.DisplayName(),
+		Summary:        summary,
+		Instructions:   instructions,
+		Pinned:         slices.Clone(m.pinned),
+		ModePrompts:    m.modePrompts(),
+		MaxTokens:      m.cfg.MaxTokens,
+		MaxIterations:  m.cfg.MaxIterations,
+		MaxToolCalls:   m.cfg.MaxToolCallsPerTurn,
+		HistoryLimit:   m.cfg.HistoryLimit,
+		Delay:          time.Duration(m.cfg.IterationDelay) * time.Millisecond,
+		ReviewEdits:    m.cfg.ReviewEdits,
+		AllowDangerous: m.cfg.AllowDangerous,
+		Store:          m.cfg.Store,
+		Throttle:       m.cfg.ThrottleRateLimits,
+		ToolColors:     m.cfg.ToolOutputColors,
+		Metrics:        m.toolMetrics,
+		DisabledTools:  m.cfg.DisabledTools,
+		ToolRetries:    m.cfg.ToolRetries,
+
+		ReasoningEffort:  m.cfg.ReasoningEffort,
+		ReasoningReserve: m.cfg.ReasoningReserve,
+		ToolResultFormat: m.cfg.ToolResultFormat,
+
+		CompressToolResults: m.cfg.CompressToolResults,
+		CompressModel:       m.cfg.ResolveModel(m.cfg.CompressModel),
 	})
 
 	program := m.progRef.Load()
@@ -357,40 +707,69 @@ func (m *Model) submitPrompt(prompt string) tea.Cmd {
 		eventCh := ag.Run(ctx, history, sessionID)
 		event, ok := <-eventCh
 		if !ok {
-			return agentEventMsg{event: agent.Event{Type: agent.EventAgentDone}}
+			return agentEventMsg{event: agent.Event{Type: agent.EventAgentDone}, run: run}
 		}
 
 		// Start a goroutine to forward remaining events
 		go func() {
 			for ev := range eventCh {
 				if program != nil {
-					program.Send(agentEventMsg{event: ev})
+					program.Send(agentEventMsg{event: ev, run: run})
 				}
 			}
 		}()
 
-		return agentEventMsg{event: event}
+		return agentEventMsg{event: event, run: run}
 	}
 }
 
+// handleStaleAgentEvent processes an event from an agent run that has been
+// cancelled. Its messages are still persisted so the stored session stays
+// complete, but nothing else reaches the UI.
+func (m Model) handleStaleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
+	if event.Type == agent.EventPersistMessage && event.FinalMessage != nil {
+		if err := m.sessions.AddMessage(*event.FinalMessage); err != nil {
+			m.err = err
+		}
+	}
+	return m, nil
+}
+
 // handleAgentEvent processes events from the agent loop.
 func (m Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 	switch event.Type {
 	case agent.EventStreamText:
+		m.phase = phaseResponding
 		m.streamBuf += event.Text
 		// Update the streaming message in the list
 		m.msgs.UpdateStreaming(m.streamBuf)
 		return m, nil
 
 	case agent.EventToolCallStart:
+		m.phase = phaseResponding
 		m.msgs.AddToolCall(event.ToolCallName, event.ToolInput)
 		return m, nil
 
+	case agent.EventToolExecStart:
+		m.phase = phaseRunningTool
+		m.phaseTool = event.ToolCallName
+		return m, nil
+
+	case agent.EventNotice:
+		m.msgs.Add(message.System, event.Text)
+		return m, nil
+
+	case agent.EventRateLimit:
+		m.rateLimit = event.RateLimit
+		return m, nil
+
 	case agent.EventToolCallEnd:
 		m.msgs.UpdateLastToolCall(event.ToolCallName, event.ToolInput)
 		return m, nil
 
 	case agent.EventToolResult:
+		// Back to waiting until the next tool starts or the model responds.
+		m.phase = phaseWaiting
 		m.msgs.AddToolResult(event.ToolCallName, event.ToolOutput, event.ToolIsError)
 		return m, nil
 
@@ -401,6 +780,11 @@ func (m Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 				m.err = err
 			}
 			m.tokenTotal += event.FinalMessage.TotalTokens
+			// Tool results carry the usage of condensing them, which says
+			// nothing about the size of the conversation.
+			if event.FinalMessage.Role == message.Assistant {
+				m.checkContextUsage(event.FinalMessage.InputTokens)
+			}
 			// Also reset the stream buffer since the assistant turn is complete
 			// and a new LLM call will start after tool results.
 			m.msgs.FinalizeStreaming(event.FinalMessage.Content)
@@ -416,62 +800,287 @@ func (m Model) handleAgentEvent(event agent.Event) (tea.Model, tea.Cmd) {
 				m.err = err
 			}
 			m.tokenTotal += event.FinalMessage.TotalTokens
+			m.checkContextUsage(event.FinalMessage.InputTokens)
 			// Finalize the streaming message
 			m.msgs.FinalizeStreaming(event.FinalMessage.Content)
 		}
+		if len(event.Changes) > 0 {
+			m.lastChanges = event.Changes
+			m.msgs.Add(message.System, formatChanges(event.Changes))
+		}
 		m.streamBuf = ""
-		return m, m.listenForPermissions()
+		return m, tea.Batch(m.listenForPermissions(), m.maybeGenerateTitle(), m.notifyCmd())
 
 	case agent.EventAgentError:
 		m.thinking = false
+		m.msgs.InterruptStreaming()
 		m.streamBuf = ""
+
+		if provider.IsKeyRejected(event.Error) {
+			m.msgs.Add(message.System, fmt.Sprintf("Error: %s\nThe provider rejected the API key; enter a valid one in settings.", event.Error))
+			return m, tea.Batch(m.listenForPermissions(), m.notifyCmd(), m.openAPIKeyEntry())
+		}
+
+		var modelErr *provider.ModelError
+		if errors.As(event.Error, &modelErr) {
+			m.msgs.Add(message.System, fmt.Sprintf("Model %s is not available; choose another in settings (ctrl+k).", modelErr.Model))
+			return m, tea.Batch(m.listenForPermissions(), m.notifyCmd(), m.openModelPicker())
+		}
+
 		errText := "Agent error"
 		if event.Error != nil {
 			errText = fmt.Sprintf("Error: %s", event.Error.Error())
+			if hint := errorHint(event.Error); hint != "" {
+				errText += "\n" + hint
+			}
 		}
 		m.msgs.Add(message.System, errText)
-		return m, m.listenForPermissions()
+		return m, tea.Batch(m.listenForPermissions(), m.notifyCmd())
 	}
 
 	return m, nil
 }
 
+// errorHint suggests how to recover from a provider error of a known kind.
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, provider.ErrRateLimited):
+		var apiErr *provider.APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			return fmt.Sprintf("The provider is rate limiting requests. Wait %s and send the message again, or set requestsPerMinute in the config to slow down.", retryWait(apiErr.RetryAfter))
+		}
+		return "The provider is rate limiting requests. Wait a moment and send the message again, or set requestsPerMinute in the config to slow down."
+	case errors.Is(err, provider.ErrContextLength):
+		return "The conversation is too long for the model. Set historyLimit in the config to send only the most recent messages."
+	case errors.Is(err, provider.ErrNetwork):
+		return "Could not reach the provider. Check the network connection and send the message again."
+	case errors.Is(err, provider.ErrAuth):
+		// A rejected key opens the settings instead; this is a valid key
+		// refused access.
+		return "The provider refused access with this API key. Check that its project or organization may use this model."
+	}
+	return ""
+}
+
+// retryWait rounds a wait before retrying up to the second, for display.
+func retryWait(d time.Duration) time.Duration {
+	return (d + time.Second - 1).Truncate(time.Second)
+}
+
+// setMode switches to mode, tells the user and the model, and saves it as
+// the default mode if rememberMode is set.
+func (m *Model) setMode(mode Mode) {
+	m.mode = mode
+	if mode == BuildMode {
+		m.msgs.Add(message.System,
+			"Switched to BUILD mode. The assistant can now create and modify files.")
+	} else {
+		m.msgs.Add(message.System,
+			"Switched to PLAN mode. The assistant will only analyze, not modify files.")
+	}
+	m.recordModeChange()
+	if m.cfg.RememberMode {
+		m.cfg.DefaultMode = m.mode.String()
+		if err := config.Save(m.cfg); err != nil {
+			m.msgs.Add(message.System, fmt.Sprintf("Failed to remember mode: %s", err.Error()))
+		}
+	}
+}
+
+// recordModeChange adds a note about the new mode to the session history, so
+// the model learns on its next turn that its tools changed.
+func (m *Model) recordModeChange() {
+	note := "The user switched to PLAN mode. Tools that modify files or run commands are no longer available; analyze and plan only until told otherwise."
+	if m.mode == BuildMode {
+		note = "The user switched to BUILD mode. Tools that modify files and run commands are now available."
+	}
+	m.recordContextChange(note)
+}
+
+// recordContextChange adds note to the session history as a system message,
+// for changes the model should hear about mid-conversation. Before the
+// conversation starts the system prompt already describes the current state.
+// The note is hidden from the transcript, where the user was already told
+// about the change in their own terms.
+func (m *Model) recordContextChange(note string) {
+	if m.sessions == nil {
+		return
+	}
+	history, err := m.sessions.GetMessages()
+	if err != nil || len(history) == 0 {
+		return
+	}
+	msg := message.NewSystemMessage(m.sessions.CurrentID(), note)
+	msg.Hidden = true
+	if err := m.sessions.AddMessage(msg); err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Failed to record the change in the session: %s", err))
+	}
+}
+
+// openAPIKeyEntry opens the settings overlay on the API key input, explaining
+// that the current key was rejected.
+func (m *Model) openAPIKeyEntry() tea.Cmd {
+	m.cancelModelsFetch()
+	m.settingsOpen = true
+	m.settings = NewSettings()
+	cmd := m.settings.OpenAPIKey()
+	m.settings.SetFeedback("The current API key was rejected by the provider.", true)
+	m.input.Blur()
+	return cmd
+}
+
+// openModelPicker opens the settings overlay on the model selection list and
+// starts fetching the available models.
+func (m *Model) openModelPicker() tea.Cmd {
+	m.settingsOpen = true
+	m.settings = NewSettings()
+	m.settings.OpenModels()
+	m.input.Blur()
+	if m.prov == nil {
+		m.settings.HandleModelsLoaded(nil, fmt.Errorf("no provider configured (set API key first)"))
+		return nil
+	}
+	return m.startModelsFetch(false)
+}
+
+// modelCacheFile is the name of the model list cache in the data directory.
+const modelCacheFile = "models.json"
+
+// newModelCache creates the cache of model lists described by cfg: on disk
+// with modelCacheHours set, otherwise in memory.
+func newModelCache(cfg config.Config) *provider.ModelCache {
+	if cfg.ModelCacheHours <= 0 || cfg.DataDir == "" {
+		return provider.NewModelCache("", 0)
+	}
+	return provider.NewModelCache(filepath.Join(cfg.DataDir, modelCacheFile), time.Duration(cfg.ModelCacheHours)*time.Hour)
+}
+
+// startModelsFetch shows the provider's models in the settings model list,
+// from the cache if they are in it and refresh is not set, and otherwise
+// by fetching them, cancelling any fetch already in flight.
+func (m *Model) startModelsFetch(refresh bool) tea.Cmd {
+	m.cancelModelsFetch()
+	if !refresh {
+		if models, ok := m.modelCache.Get(provider.ModelCacheKey(m.prov)); ok {
+			m.settings.HandleModelsLoaded(models, nil)
+			return nil
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.modelsCancel = cancel
+	return fetchModelsCmd(ctx, m.modelsFetch, m.prov.ListModels)
+}
+
+// cancelModelsFetch cancels the model list fetch in flight, if any, once
+// the model list is left or the settings overlay closes.
+func (m *Model) cancelModelsFetch() {
+	if m.modelsCancel != nil {
+		m.modelsCancel()
+		m.modelsCancel = nil
+		m.modelsFetch++ // drop the result of the cancelled fetch
+	}
+}
+
+// maybeGenerateTitle starts background title generation if the current
+// session still has the default title.
+func (m *Model) maybeGenerateTitle() tea.Cmd {
+	if m.prov == nil || m.titlePending || m.sessionTitle != session.DefaultTitle {
+		return nil
+	}
+	history, err := m.sessions.GetMessages()
+	if err != nil || len(history) == 0 {
+		return nil
+	}
+	m.titlePending = true
+	return generateTitleCmd(m.prov, m.sessions.CurrentID(), history)
+}
+
+// formatChanges renders the files changed during a turn as a short changelog.
+func formatChanges(changes []agent.FileChange) string {
+	var b strings.Builder
+	b.WriteString("Changes this turn:")
+	for _, c := range changes {
+		action := "modified"
+		switch {
+		case c.Created:
+			action = "created"
+		case c.Deleted:
+			action = "deleted"
+		}
+		b.WriteString(fmt.Sprintf("\n  %-8s %s (%+d lines)", action, c.Path, c.Lines))
+	}
+	return b.String()
+}
+
+// phaseLabel describes the current agent phase for the status displays.
+func (m Model) phaseLabel() string {
+	switch m.phase {
+	case phaseResponding:
+		return "responding..."
+	case phaseRunningTool:
+		return fmt.Sprintf("running %s...", m.phaseTool)
+	case phaseAwaitingApproval:
+		return "awaiting approval"
+	default:
+		return "thinking..."
+	}
+}
+
 // handlePermissionKey handles key presses in the permission dialog.
 func (m Model) handlePermissionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.permReq.IsReview() {
+		return m.handleReviewKey(msg)
+	}
+	if m.permReq.IsBatch() {
+		return m.handleBatchKey(msg)
+	}
+
+	var resp permission.Response
 	switch msg.String() {
 	case "y", "Y":
-		m.permReq.ResponseCh <- permission.Allow
-		m.permReq = nil
-		return m, m.listenForPermissions()
+		resp = permission.Allow
 	case "n", "N":
-		m.permReq.ResponseCh <- permission.Deny
-		m.permReq = nil
-		return m, m.listenForPermissions()
+		resp = permission.Deny
 	case "a", "A":
-		m.permReq.ResponseCh <- permission.AllowForSession
-		m.permReq = nil
-		return m, m.listenForPermissions()
+		if m.permReq.Warning != "" {
+			return m, nil // dangerous calls are allowed one at a time
+		}
+		resp = permission.AllowForSession
+	case "d", "D":
+		return m.openPreviewDiff()
+	default:
+		return m, nil
 	}
-	return m, nil
+
+	m.permReq.ResponseCh <- resp
+	m.permReq = nil
+	// Once answered, the tool either runs or returns a denial immediately.
+	m.phase = phaseRunningTool
+	return m, m.listenForPermissions()
 }
 
 // handleSettingsKey routes key events to the settings overlay and handles
 // the resulting actions (save API key, select model, close overlay).
 func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	msg = m.settings.FocusedKey(msg)
 	prevView := m.settings.view
 
 	settings, shouldClose, cmd := m.settings.Update(msg)
 	m.settings = settings
+	if shouldClose || m.settings.view != settingsViewModels {
+		m.cancelModelsFetch()
+	}
 
 	if shouldClose {
 		m.settingsOpen = false
 		return m, m.input.Focus()
 	}
 
-	// Handle transition to model selection (trigger fetch)
-	if prevView != settingsViewModels && m.settings.view == settingsViewModels {
+	// Handle transition to model selection (trigger fetch), and refreshing it
+	refresh := m.settings.view == settingsViewModels && msg.String() == "ctrl+r"
+	if prevView != settingsViewModels && m.settings.view == settingsViewModels || refresh {
 		if m.prov != nil {
-			return m, fetchModelsCmd(context.Background(), m.prov.ListModels)
+			return m, m.startModelsFetch(refresh)
 		}
 		m.settings.HandleModelsLoaded(nil, fmt.Errorf("no provider configured (set API key first)"))
 		return m, nil
@@ -483,6 +1092,10 @@ func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if apiKey == "" {
 			return m, cmd
 		}
+		// Catch a mistyped or mispasted key now rather than as a 401 later.
+		if err := provider.CheckAPIKey(m.cfg.Provider, apiKey); err != nil && apiKey != m.settings.apiKeyWarned {
+			return m, tea.Batch(cmd, m.settings.WarnAPIKey(apiKey, err))
+		}
 
 		// Update config and provider
 		m.cfg.APIKey = apiKey
@@ -494,9 +1107,16 @@ func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
-		m.settings.SetFeedback("API key saved successfully", false)
+		// Another account may offer other models.
+		if err := m.modelCache.Forget(provider.ModelCacheKey(m.prov)); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("API key saved, but the cached model list was kept: %s", err), true)
+		} else {
+			m.settings.SetFeedback("API key saved; checking it with the provider...", false)
+		}
 		m.settings.view = settingsViewMenu
-		return m, cmd
+		// Listing the models tests the key, and a new key may not have
+		// access to the configured model.
+		return m, tea.Batch(cmd, verifyAPIKeyCmd(m.prov))
 	}
 
 	// Handle model selection on enter in model view
@@ -508,7 +1128,8 @@ func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		// Update config and provider
 		m.cfg.Model = selected
-		m.prov.SetModel(selected)
+		m.prov.SetModel(m.cfg.ModelID())
+		m.suggestedModel = ""
 
 		// Persist to config file
 		if err := config.Save(m.cfg); err != nil {
@@ -542,6 +1163,167 @@ func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Handle provider selection on enter in provider view
+	if m.settings.view == settingsViewProviders && msg.String() == "enter" {
+		selected := m.settings.SelectedProvider()
+		if selected == "" {
+			return m, cmd
+		}
+		m.settings.view = settingsViewMenu
+		if selected == m.cfg.Provider {
+			m.settings.SetFeedback(fmt.Sprintf("Already using %s", selected), false)
+			return m, cmd
+		}
+		if m.newProvider == nil {
+			m.settings.SetFeedback("Provider switching is not available", true)
+			return m, cmd
+		}
+
+		next := m.cfg
+		next.Provider = selected
+		next.APIKey = m.cfg.APIKeyFor(selected)
+		prov, err := m.newProvider(next)
+		if err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Switch failed: %s", err.Error()), true)
+			return m, cmd
+		}
+		m.cfg = next
+		m.prov = prov
+
+		// Persist to config file
+		if err := config.Save(m.cfg); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Save failed: %s", err.Error()), true)
+			return m, cmd
+		}
+
+		if m.cfg.APIKey == "" {
+			m.settings.SetFeedback(fmt.Sprintf("Provider set to %s; no API key found, set one with [1]", selected), true)
+			return m, cmd
+		}
+		m.settings.SetFeedback(fmt.Sprintf("Provider set to %s", selected), false)
+		return m, cmd
+	}
+
+	// Toggle auto-approve from the menu
+	if prevView == settingsViewMenu && msg.String() == "7" {
+		m.cfg.AutoApprove = !m.cfg.AutoApprove
+		m.permSvc.SetAutoApprove(m.cfg.AutoApprove)
+
+		state := "off"
+		if m.cfg.AutoApprove {
+			state = "on: tools run without asking in BUILD mode"
+		}
+		if err := config.Save(m.cfg); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Auto-approve %s, but saving the config failed: %s", state, err), true)
+			return m, cmd
+		}
+		m.settings.SetFeedback("Auto-approve "+state, m.cfg.AutoApprove)
+		return m, cmd
+	}
+
+	// Switch where settings are saved between the project and user config
+	if prevView == settingsViewMenu && msg.String() == "8" {
+		if m.saveTarget() == "project" {
+			path, err := config.UserConfigPath()
+			if err != nil {
+				m.settings.SetFeedback(err.Error(), true)
+				return m, cmd
+			}
+			m.cfg.SavePath = path
+		} else {
+			// A project config that was not loaded, such as one in a directory
+			// opened with /cwd, would lose its settings.
+			path := config.ProjectConfigPath(m.cfg.WorkDir)
+			if _, err := os.Stat(path); err == nil && path != m.cfg.Path {
+				m.settings.SetFeedback(path+" exists but was not loaded; start goder there to save to it", true)
+				return m, cmd
+			}
+			m.cfg.SavePath = path
+		}
+		m.settings.SetFeedback("Settings will be saved to "+m.cfg.SavePath, false)
+		return m, cmd
+	}
+
+	// Show the resolved paths in the chat
+	if prevView == settingsViewMenu && msg.String() == "9" {
+		m.settingsOpen = false
+		m.msgs.Add(message.System, m.configPathsReport())
+		return m, cmd
+	}
+
+	// Open the tool list
+	if prevView == settingsViewMenu && msg.String() == "0" {
+		m.settings.OpenTools(m.registry.Names())
+		return m, cmd
+	}
+
+	// Toggle the highlighted tool on enter or space in the tool list
+	if m.settings.view == settingsViewTools && (msg.String() == "enter" || msg.String() == " ") {
+		name := m.settings.SelectedTool()
+		if name == "" {
+			return m, cmd
+		}
+		state := "disabled"
+		if slices.Contains(m.cfg.DisabledTools, name) {
+			m.cfg.DisabledTools = slices.DeleteFunc(slices.Clone(m.cfg.DisabledTools), func(n string) bool { return n == name })
+			state = "enabled"
+		} else {
+			m.cfg.DisabledTools = append(slices.Clone(m.cfg.DisabledTools), name)
+		}
+		if err := config.Save(m.cfg); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("%s %s, but saving the config failed: %s", name, state, err), true)
+			return m, cmd
+		}
+		m.settings.SetFeedback(fmt.Sprintf("%s %s from the next prompt", name, state), false)
+		return m, cmd
+	}
+
+	// Handle max tokens save on enter in max tokens view
+	if m.settings.view == settingsViewMaxTokens && msg.String() == "enter" {
+		val := m.settings.MaxTokensValue()
+		if val == 0 {
+			return m, cmd
+		}
+		if info, ok := provider.LookupModel(m.cfg.ModelID()); ok && info.MaxOutput > 0 && val > info.MaxOutput {
+			return m, m.settings.RejectMaxTokens(fmt.Sprintf("%s produces at most %d tokens per response", m.cfg.ModelID(), info.MaxOutput))
+		}
+
+		// Update config; new agents pick it up on the next prompt
+		m.cfg.MaxTokens = val
+
+		// Persist to config file
+		if err := config.Save(m.cfg); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Save failed: %s", err.Error()), true)
+			return m, cmd
+		}
+
+		m.settings.SetFeedback(fmt.Sprintf("Max tokens set to %d", val), false)
+		m.settings.view = settingsViewMenu
+		return m, cmd
+	}
+
+	// Move the database on enter in the data directory view
+	if m.settings.view == settingsViewDataDir && msg.String() == "enter" {
+		if m.settings.DataDirValue() == "" {
+			return m, cmd
+		}
+		oldPath, err := m.moveDataDir(m.settings.DataDirValue())
+		if err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Move failed: %s", err), true)
+			return m, cmd
+		}
+		m.settings.dataDirInput.Blur()
+		m.settings.view = settingsViewMenu
+
+		// Persist to config file
+		if err := config.Save(m.cfg); err != nil {
+			m.settings.SetFeedback(fmt.Sprintf("Data moved to %s, but saving the config failed: %s", m.cfg.DataDir, err), true)
+			return m, cmd
+		}
+		m.settings.SetFeedback(fmt.Sprintf("Data moved to %s; the old copy at %s can be deleted", m.cfg.DataDir, oldPath), false)
+		return m, cmd
+	}
+
 	return m, cmd
 }
 
@@ -551,16 +1333,7 @@ func (m Model) messageScrollAmount() int {
 		return 1
 	}
 
-	headerHeight := 1
-	inputHeight := m.input.Height()
-	statusHeight := 1
-	separatorLines := 2
-	msgHeight := m.height - headerHeight - inputHeight - statusHeight - separatorLines
-	if msgHeight < 3 {
-		msgHeight = 3
-	}
-
-	scroll := msgHeight / 2
+	scroll := m.messageHeight() / 2
 	if scroll < 1 {
 		scroll = 1
 	}
@@ -568,12 +1341,17 @@ func (m Model) messageScrollAmount() int {
 	return scroll
 }
 
-// View implements tea.Model.
-func (m Model) View() string {
-	if m.width == 0 {
-		return "loading..."
+// saveTarget describes where settings are saved: "project" for the
+// project-local config file, otherwise "user".
+func (m Model) saveTarget() string {
+	if config.IsProjectConfig(m.cfg.SavePath) {
+		return "project"
 	}
+	return "user"
+}
 
+// messageHeight returns the height of the message area.
+func (m Model) messageHeight() int {
 	// Layout: header (1 line) + messages (flexible) + input (dynamic) + status (1 line)
 	headerHeight := 1
 	inputHeight := m.input.Height()
@@ -583,25 +1361,64 @@ func (m Model) View() string {
 	if msgHeight < 3 {
 		msgHeight = 3
 	}
+	return msgHeight
+}
+
+// View implements tea.Model.
+func (m Model) View() string {
+	if m.width == 0 {
+		return "loading..."
+	}
 
-	header := HeaderView(m.mode, m.cfg.Model, m.tokenTotal, m.width)
-	msgs := m.msgs.View(m.width, msgHeight)
+	msgHeight := m.messageHeight()
+
+	header := HeaderView(m.cfg.DisplayName(), m.mode, m.sessionTitle, m.cfg.WorkDir, m.cfg.ModelLabel(), m.tokenTotal, m.cfg.AutoApprove, m.width)
+	var msgs string
+	if m.diffOpen {
+		msgs = m.diffView.View(m.width, msgHeight)
+	} else {
+		msgs = m.msgs.View(m.width, msgHeight)
+	}
 
 	// Show confirmation dialog if quitting
 	var inputView string
-	if m.confirmQuit {
+	if m.quitting {
+		inputView = thinkingStyle.Width(m.width - 4).Render("  Saving the conversation and quitting...")
+	} else if m.confirmQuit {
 		inputView = m.renderQuitConfirmDialog()
 	} else if m.settingsOpen {
-		inputView = m.settings.View(m.width, m.cfg.APIKey, m.cfg.Model, m.cfg.MaxIterations)
+		inputView = m.settings.View(m.width, SettingsValues{
+			Provider:      m.cfg.Provider,
+			APIKey:        m.cfg.APIKey,
+			Model:         m.cfg.ModelID(),
+			MaxIterations: m.cfg.MaxIterations,
+			MaxTokens:     m.cfg.MaxTokens,
+			AutoApprove:   m.cfg.AutoApprove,
+			SaveTarget:    m.saveTarget() + " (" + m.cfg.SavePath + ")",
+			ConfigPath:    m.cfg.Path,
+			DataDir:       m.cfg.DataDir,
+			Version:       m.version,
+			DisabledTools: m.cfg.DisabledTools,
+		})
+	} else if m.reviewEditing {
+		inputView = m.input.View(m.width, m.mode)
 	} else if m.permReq != nil {
 		inputView = m.renderPermissionDialog()
+	} else if m.suggestedModel != "" {
+		inputView = m.renderModelSuggestionDialog()
 	} else if m.thinking {
-		inputView = thinkingStyle.Width(m.width - 4).Render("  thinking...")
+		inputView = thinkingStyle.Width(m.width - 4).Render("  " + m.phaseLabel())
 	} else {
 		inputView = m.input.View(m.width, m.mode)
 	}
 
-	status := StatusBarView(m.width, m.thinking)
+	var activity, estimate string
+	if m.thinking {
+		activity = m.phaseLabel()
+	} else if m.tokenEstimate > 0 && m.input.Value() != "" {
+		estimate = estimateLabel(m.tokenEstimate, m.cfg.ModelID())
+	}
+	status := StatusBarView(m.width, activity, estimate, rateLimitLabel(m.rateLimit))
 
 	return fmt.Sprintf("%s\n%s\n%s\n%s", header, msgs, inputView, status)
 }
@@ -610,10 +1427,7 @@ func (m Model) View() string {
 func (m Model) handleQuitConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y", "Y":
-		if m.agentCancel != nil {
-			m.agentCancel()
-		}
-		return m, tea.Quit
+		return m.shutdown()
 	case "n", "N", "esc":
 		m.confirmQuit = false
 		return m, nil
@@ -624,7 +1438,7 @@ func (m Model) handleQuitConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // renderQuitConfirmDialog renders the quit confirmation dialog.
 func (m Model) renderQuitConfirmDialog() string {
-	dialog := "  Quit goder?\n\n  [y] Yes  [n] No"
+	dialog := fmt.Sprintf("  Quit %s?\n\n  [y] Yes  [n] No", m.cfg.DisplayName())
 	return permissionStyle.Width(m.width - 4).Render(dialog)
 }
 
@@ -633,17 +1447,65 @@ func (m Model) renderPermissionDialog() string {
 	if m.permReq == nil {
 		return ""
 	}
+	if m.permReq.IsReview() {
+		return m.renderReviewDialog()
+	}
+	if m.permReq.IsBatch() {
+		return m.renderBatchDialog()
+	}
 
 	toolName := m.permReq.ToolName
-	input := m.permReq.Input
-	if len(input) > 200 {
-		input = input[:200] + "..."
+
+	var details string
+	if m.permReq.Preview != "" {
+		details = renderPreview(m.permReq.Preview, permissionPreviewMaxLines)
+	} else {
+		input := m.permReq.Input
+		if len(input) > 200 {
+			input = input[:200] + "..."
+		}
+		details = fmt.Sprintf("  Input: %s", input)
+	}
+
+	if warning := m.permReq.Warning; warning != "" {
+		dialog := fmt.Sprintf(
+			"  %s\n  Tool: %s\n%s\n\n  [y] Allow once  [n] Deny%s",
+			errorStyle.Render("DANGEROUS: "+warning), toolName, details, m.fullPreviewHint(),
+		)
+		return dangerStyle.Width(m.width - 4).Render(dialog)
 	}
 
 	dialog := fmt.Sprintf(
-		"  Tool: %s\n  Input: %s\n\n  [y] Allow  [n] Deny  [a] Allow for session",
-		toolName, input,
+		"  Tool: %s\n%s\n\n  [y] Allow  [n] Deny  [a] Allow for session%s",
+		toolName, details, m.fullPreviewHint(),
 	)
 
 	return permissionStyle.Width(m.width - 4).Render(dialog)
 }
+
+// permissionPreviewMaxLines caps how much of a preview the dialog shows.
+const permissionPreviewMaxLines = 20
+
+// renderPreview renders a permission preview, colorizing diff lines and
+// truncating to maxLines.
+func renderPreview(preview string, maxLines int) string {
+	lines := strings.Split(preview, "\n")
+	extra := 0
+	if len(lines) > maxLines {
+		extra = len(lines) - maxLines
+		lines = lines[:maxLines]
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("  ")
+		b.WriteString(renderDiffLine(line))
+	}
+	if extra > 0 {
+		b.WriteString("\n  " + dimStyle.Render(fmt.Sprintf("... %d more lines", extra)))
+	}
+	return b.String()
+}
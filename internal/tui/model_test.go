@@ -0,0 +1,1030 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/db"
+	"github.com/webgovernor/goder/internal/llm/agent"
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/permission"
+	"github.com/webgovernor/goder/internal/session"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+func TestCancelMidStream(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+
+	// Simulate a running agent that has streamed part of a response.
+	m.agentRun = 1
+	m.thinking = true
+	m.agentCancel = func() {}
+	stream := func(m Model, run int, text string) Model {
+		next, _ := m.Update(agentEventMsg{event: agent.Event{Type: agent.EventStreamText, Text: text}, run: run})
+		return next.(Model)
+	}
+	m = stream(m, 1, "partial ")
+	m = stream(m, 1, "answer")
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+
+	if m.thinking || m.streamBuf != "" || m.msgs.streaming != -1 {
+		t.Fatalf("state after cancel: thinking=%v streamBuf=%q streaming=%d", m.thinking, m.streamBuf, m.msgs.streaming)
+	}
+	partial := m.msgs.messages[0]
+	if partial.Content != "partial answer" || partial.IsStreaming || !partial.Interrupted {
+		t.Fatalf("interrupted message = %+v", partial)
+	}
+
+	// Text still in flight from the cancelled run is dropped.
+	m = stream(m, 1, " more")
+	if got := m.msgs.messages[0].Content; got != "partial answer" {
+		t.Errorf("cancelled run updated its message to %q", got)
+	}
+
+	// The next run streams into a new message rather than the old one.
+	m.agentRun++
+	m.thinking = true
+	m = stream(m, m.agentRun, "fresh")
+	if n := m.msgs.Count(); n != 3 {
+		t.Fatalf("got %d messages, want partial, cancel notice, and new response", n)
+	}
+	last := m.msgs.messages[2]
+	if last.Role != message.Assistant || last.Content != "fresh" || !last.IsStreaming {
+		t.Errorf("new streaming message = %+v", last)
+	}
+	if m.msgs.messages[0].Content != "partial answer" {
+		t.Error("new run clobbered the interrupted message")
+	}
+}
+
+func TestSettingsFocusNavigation(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	m := New(cfg, nil, nil, nil, nil, permission.NewService())
+	m.settingsOpen = true
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+
+	// Focus wraps backwards from the first menu item to the last.
+	press(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if key := settingsMenuKeys[m.settings.menuFocus]; key != "0" {
+		t.Fatalf("focus after shift+tab = %q, want the last item", key)
+	}
+
+	// Tab wraps around to [d] and on to [1]; down moves on to [3].
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.settings.view != settingsViewMaxIter || !m.settings.maxIterInput.Focused() {
+		t.Fatalf("view %v after enter, want the focused max iterations input", m.settings.view)
+	}
+
+	// Typing goes to the input only while it has focus.
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("7")})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("9")})
+	if got := m.settings.maxIterInput.Value(); got != "7" || m.settings.maxIterInput.Focused() {
+		t.Fatalf("input %q (focused %v), want 7 and blurred on the save button", got, m.settings.maxIterInput.Focused())
+	}
+
+	// Enter on the back button returns to the menu without saving, with
+	// the item that was opened still focused.
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.settings.view != settingsViewMenu || m.cfg.MaxIterations == 7 {
+		t.Fatalf("view %v, max iterations %d after back", m.settings.view, m.cfg.MaxIterations)
+	}
+	if key := settingsMenuKeys[m.settings.menuFocus]; key != "3" {
+		t.Errorf("focus after back = %q, want 3", key)
+	}
+
+	// Enter on the save button saves.
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("7")})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.cfg.MaxIterations != 7 {
+		t.Errorf("max iterations %d after save, want 7", m.cfg.MaxIterations)
+	}
+}
+
+func TestAutoApproveToggle(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	permSvc := permission.NewService()
+	m := New(cfg, nil, nil, nil, nil, permSvc)
+	m.width = 120
+	m.settingsOpen = true
+	toggle := func() {
+		t.Helper()
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("7")})
+		m = next.(Model)
+	}
+	header := func() string {
+		return HeaderView(m.cfg.DisplayName(), m.mode, "", m.cfg.WorkDir, m.cfg.ModelLabel(), 0, m.cfg.AutoApprove, m.width)
+	}
+
+	if strings.Contains(header(), "AUTO-APPROVE") {
+		t.Fatal("header shows the auto-approve badge while it is off")
+	}
+
+	toggle()
+	if !m.cfg.AutoApprove || !permSvc.AutoApprove() {
+		t.Fatalf("after toggling on: config %v, service %v", m.cfg.AutoApprove, permSvc.AutoApprove())
+	}
+	if !strings.Contains(header(), "AUTO-APPROVE") {
+		t.Error("header is missing the auto-approve badge")
+	}
+	if menu := m.settings.viewMenu(SettingsValues{Provider: "openai", Model: "gpt-4o", AutoApprove: true}); !strings.Contains(menu, "Auto-Approve on") {
+		t.Errorf("settings menu does not show auto-approve on:\n%s", menu)
+	}
+	if saved, err := os.ReadFile(cfg.SavePath); err != nil || !strings.Contains(string(saved), `"autoApprove": true`) {
+		t.Errorf("saved config = %s, %v", saved, err)
+	}
+
+	toggle()
+	if m.cfg.AutoApprove || permSvc.AutoApprove() {
+		t.Errorf("after toggling off: config %v, service %v", m.cfg.AutoApprove, permSvc.AutoApprove())
+	}
+	if strings.Contains(header(), "AUTO-APPROVE") {
+		t.Error("header still shows the auto-approve badge")
+	}
+}
+
+func TestMaxTokensBoundedByModel(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	cfg.Model = "gpt-4o" // at most 16384 output tokens
+	m := New(cfg, nil, nil, nil, nil, permission.NewService())
+	m.settingsOpen = true
+	press := func(keys ...string) {
+		t.Helper()
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			next, _ := m.Update(msg)
+			m = next.(Model)
+		}
+	}
+
+	press("4", "3", "2", "0", "0", "0", "enter")
+	if m.cfg.MaxTokens != 0 || m.settings.view != settingsViewMaxTokens || !strings.Contains(m.settings.feedback, "at most 16384") {
+		t.Fatalf("max tokens %d, view %v, feedback %q after entering 32000", m.cfg.MaxTokens, m.settings.view, m.settings.feedback)
+	}
+	if !m.settings.maxTokensInput.Focused() {
+		t.Error("input lost focus after the rejected value")
+	}
+
+	m.settings.maxTokensInput.SetValue("")
+	press("1", "6", "0", "0", "0", "enter")
+	if m.cfg.MaxTokens != 16000 {
+		t.Errorf("max tokens %d, want 16000 saved", m.cfg.MaxTokens)
+	}
+
+	// Models with larger limits accept more.
+	m.cfg.Model = "gpt-4.1"
+	press("4", "3", "2", "0", "0", "0", "enter")
+	if m.cfg.MaxTokens != 32000 {
+		t.Errorf("max tokens %d for gpt-4.1, want 32000 saved", m.cfg.MaxTokens)
+	}
+}
+
+func TestAuthErrorOpensAPIKeySettings(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	m.agentRun = 1
+	m.thinking = true
+
+	authErr := fmt.Errorf("LLM request failed: %w", &provider.APIError{Provider: "OpenAI", StatusCode: http.StatusUnauthorized})
+	next, _ := m.Update(agentEventMsg{event: agent.Event{Type: agent.EventAgentError, Error: authErr}, run: 1})
+	m = next.(Model)
+
+	if !m.settingsOpen || m.settings.view != settingsViewAPIKey {
+		t.Fatalf("settings open=%v view=%v, want the API key input", m.settingsOpen, m.settings.view)
+	}
+	if !m.settings.apiInput.Focused() {
+		t.Error("API key input is not focused")
+	}
+	last := m.msgs.messages[m.msgs.Count()-1]
+	if last.Role != message.System || !strings.Contains(last.Content, "rejected the API key") {
+		t.Errorf("last message = %+v, want the rejected key explained", last)
+	}
+
+	// A 403 refuses a valid key access, so a new key would not help.
+	m = New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	m.agentRun = 1
+	m.thinking = true
+	forbidden := fmt.Errorf("LLM request failed: %w", &provider.APIError{Provider: "OpenAI", StatusCode: http.StatusForbidden})
+	next, _ = m.Update(agentEventMsg{event: agent.Event{Type: agent.EventAgentError, Error: forbidden}, run: 1})
+	if m = next.(Model); m.settingsOpen {
+		t.Error("a 403 opened the API key settings")
+	}
+}
+
+func TestAPIKeySaveChecksKey(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	m := New(cfg, nil, nil, nil, &provider.Mock{}, permission.NewService())
+	m.settingsOpen = true
+	m.settings.OpenAPIKey()
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+
+	// A key without the provider's prefix is held back with a warning.
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("proj-abc")})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.cfg.APIKey != "" || m.settings.view != settingsViewAPIKey || !m.settings.apiInput.Focused() {
+		t.Fatalf("after a malformed key: saved %q, view %v, focused %v", m.cfg.APIKey, m.settings.view, m.settings.apiInput.Focused())
+	}
+	if !m.settings.feedbackErr || !strings.Contains(m.settings.feedback, `start with "sk-"`) {
+		t.Errorf("feedback = %q, want the expected prefix", m.settings.feedback)
+	}
+
+	// Entering it again saves it anyway, and the provider's verdict is shown.
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.cfg.APIKey != "proj-abc" || m.settings.view != settingsViewMenu {
+		t.Fatalf("after confirming: saved %q, view %v", m.cfg.APIKey, m.settings.view)
+	}
+	authErr := &provider.APIError{Provider: "OpenAI", StatusCode: http.StatusUnauthorized}
+	next, _ := m.Update(modelCheckMsg{err: authErr, verify: true})
+	m = next.(Model)
+	if !m.settings.feedbackErr || !strings.Contains(m.settings.feedback, "rejected") {
+		t.Errorf("feedback = %q, want the key rejected", m.settings.feedback)
+	}
+
+	// Once the overlay is closed, the verdict goes to the transcript.
+	m.settingsOpen = false
+	next, _ = m.Update(modelCheckMsg{models: []string{"gpt-4o"}, verify: true})
+	m = next.(Model)
+	if last := m.msgs.messages[m.msgs.Count()-1]; !strings.Contains(last.Content, "accepted") {
+		t.Errorf("last message = %+v, want the key accepted", last)
+	}
+}
+
+func TestCopyLastCommand(t *testing.T) {
+	var copied []string
+	writeClipboard = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+	t.Cleanup(func() { writeClipboard = clipboard.WriteAll })
+
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	press := func() tea.Cmd {
+		t.Helper()
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+		m = next.(Model)
+		if cmd == nil {
+			return nil
+		}
+		next, cmd = m.Update(cmd())
+		m = next.(Model)
+		return cmd
+	}
+
+	press()
+	if len(copied) != 0 {
+		t.Fatalf("copied %q with no bash calls", copied)
+	}
+
+	m.msgs.AddToolCall("bash", `{"command":"go test ./...","timeout":60}`)
+	m.msgs.AddToolResult("bash", "ok", false)
+	m.msgs.AddToolCall("view", `{"path":"main.go"}`)
+	if cmd := press(); cmd != nil {
+		t.Errorf("copy with a clipboard tool returned a command")
+	}
+	if !slices.Equal(copied, []string{"go test ./..."}) {
+		t.Errorf("copied %q, want the last bash command", copied)
+	}
+
+	// Without a clipboard tool the terminal is asked through the program.
+	writeClipboard = func(string) error { return errors.New("no xclip") }
+	if cmd := press(); cmd == nil {
+		t.Error("copy without a clipboard tool did not print the OSC 52 sequence")
+	}
+	if last := m.msgs.messages[m.msgs.Count()-1]; !strings.Contains(last.Content, "no clipboard tool: no xclip") {
+		t.Errorf("last message = %q, want the clipboard failure", last.Content)
+	}
+}
+
+func TestModelCheckOffersAvailableModel(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	m := New(cfg, nil, nil, nil, &provider.Mock{}, permission.NewService())
+
+	// The configured model is available: nothing to offer.
+	next, _ := m.Update(modelCheckMsg{models: []string{"gpt-4o", "gpt-4o-mini"}})
+	if got := next.(Model).suggestedModel; got != "" {
+		t.Fatalf("suggested %q for an available model", got)
+	}
+
+	next, _ = m.Update(modelCheckMsg{models: []string{"gpt-4o-audio-preview", "gpt-4o-mini", "o3"}})
+	m = next.(Model)
+	if m.suggestedModel != "gpt-4o-mini" {
+		t.Fatalf("suggested %q, want gpt-4o-mini", m.suggestedModel)
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = next.(Model)
+	if m.suggestedModel != "" || m.cfg.Model != "gpt-4o-mini" {
+		t.Errorf("after accepting: suggested %q, model %q", m.suggestedModel, m.cfg.Model)
+	}
+	saved, err := os.ReadFile(cfg.SavePath)
+	if err != nil || !strings.Contains(string(saved), `"model": "gpt-4o-mini"`) {
+		t.Errorf("saved config = %s (%v)", saved, err)
+	}
+}
+
+func TestModelCommandAliases(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	cfg.ModelAliases = map[string]string{"fast": "gpt-4o-mini", "smart": "o3"}
+	prov := &provider.Mock{}
+	m := New(cfg, nil, nil, nil, prov, permission.NewService())
+	lastMessage := func() string { return m.msgs.messages[m.msgs.Count()-1].Content }
+
+	m.runSlashCommand("/model fast")
+	if m.cfg.Model != "fast" || prov.Model() != "gpt-4o-mini" {
+		t.Fatalf("model %q, provider model %q after /model fast", m.cfg.Model, prov.Model())
+	}
+	if got := lastMessage(); got != "Model set to fast (gpt-4o-mini)" {
+		t.Errorf("message = %q", got)
+	}
+	saved, err := os.ReadFile(cfg.SavePath)
+	if err != nil || !strings.Contains(string(saved), `"model": "fast"`) {
+		t.Errorf("saved config = %s (%v), want the alias kept", saved, err)
+	}
+
+	m.runSlashCommand("/model")
+	if got := lastMessage(); got != "Model: fast (gpt-4o-mini)\nAliases:\n  fast -> gpt-4o-mini\n  smart -> o3" {
+		t.Errorf("listing = %q", got)
+	}
+
+	// Names that are not aliases are used as given.
+	m.runSlashCommand("/model gpt-4.1")
+	if prov.Model() != "gpt-4.1" || m.cfg.ModelLabel() != "gpt-4.1" {
+		t.Errorf("provider model %q, label %q", prov.Model(), m.cfg.ModelLabel())
+	}
+}
+
+func TestModelSuggestionLeavesOtherKeys(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, &provider.Mock{}, permission.NewService())
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+
+	// A pending permission request is answered before the suggestion.
+	respCh := make(chan permission.Response, 1)
+	m.suggestedModel = "gpt-4o-mini"
+	m.permReq = &permission.Request{ToolName: "write", ResponseCh: respCh}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if resp := <-respCh; resp != permission.Allow || m.cfg.Model == "gpt-4o-mini" {
+		t.Fatalf("response %v, model %q: the y went to the suggestion", resp, m.cfg.Model)
+	}
+
+	// Typing declines the suggestion and goes to the input.
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if m.suggestedModel != "" || m.input.Value() != "h" {
+		t.Fatalf("suggested %q, input %q after typing", m.suggestedModel, m.input.Value())
+	}
+
+	// ctrl+c still asks to quit.
+	m.suggestedModel = "gpt-4o-mini"
+	press(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !m.confirmQuit || m.suggestedModel != "" {
+		t.Errorf("confirmQuit %v, suggested %q after ctrl+c", m.confirmQuit, m.suggestedModel)
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	tests := []struct {
+		available []string
+		want      string
+	}{
+		{[]string{"gpt-4.1", "gpt-4o"}, "gpt-4o"},
+		{[]string{"gpt-4o-realtime", "gpt-5", "o1"}, "gpt-5"},
+		{[]string{"o1", "o3"}, "o1"},
+		{[]string{"gpt-4o-search-preview"}, ""},
+	}
+	for _, tt := range tests {
+		if got := defaultModel(tt.available); got != tt.want {
+			t.Errorf("defaultModel(%v) = %q, want %q", tt.available, got, tt.want)
+		}
+	}
+}
+
+func TestAssistantName(t *testing.T) {
+	for _, tc := range []struct {
+		name, logo, label string
+	}{
+		{"", "goder", "> goder"},
+		{"Ada", "Ada", "> Ada"},
+	} {
+		cfg := config.DefaultConfig()
+		cfg.AssistantName = tc.name
+		m := New(cfg, nil, nil, nil, nil, permission.NewService())
+		next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+		m = next.(Model)
+		m.msgs.Add(message.Assistant, "hello")
+
+		view := m.View()
+		if header := strings.Fields(view); len(header) == 0 || header[0] != tc.logo {
+			t.Errorf("assistantName %q: header does not show %q:\n%s", tc.name, tc.logo, view)
+		}
+		if !strings.Contains(view, tc.label) {
+			t.Errorf("assistantName %q: message not labelled %q:\n%s", tc.name, tc.label, view)
+		}
+	}
+}
+
+func TestBatchPermissionDialog(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = next.(Model)
+
+	respCh := make(chan permission.Response, 1)
+	next, _ = m.Update(permissionRequestMsg{request: permission.Request{
+		Batch: []permission.BatchItem{
+			{ToolName: "edit", Input: `{}`, Preview: "--- a/main.go\n+++ b/main.go\n-old\n+new"},
+			{ToolName: "bash", Input: `{"command":"go test ./..."}`},
+		},
+		ResponseCh: respCh,
+	}})
+	m = next.(Model)
+
+	view := m.View()
+	for _, want := range []string{"2 tool calls need permission", "1. Tool: edit", "+new", `Input: {"command":"go test ./..."}`, "[s] Step through"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("dialog missing %q:\n%s", want, view)
+		}
+	}
+
+	// "a" (allow for session) does not apply to a batch.
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m = next.(Model)
+	if m.permReq == nil {
+		t.Fatal("a answered the batch request")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = next.(Model)
+	if m.permReq != nil {
+		t.Fatal("dialog still open after s")
+	}
+	if resp := <-respCh; resp != permission.AskEach {
+		t.Errorf("response = %v, want AskEach", resp)
+	}
+}
+
+func TestQuietVerbosity(t *testing.T) {
+	ml := NewMessageList()
+	ml.SetQuiet(true)
+	ml.Add(message.User, "fix the build")
+	ml.AddToolCall("grep", `{"pattern":"foo"}`)
+	ml.AddToolResult("grep", "main.go:3: foo", false)
+	ml.Add(message.Assistant, "Found it, fixing.")
+	ml.AddToolCall("edit", `{"file_path":"main.go"}`)
+	ml.AddToolResult("edit", "no match", true)
+	ml.AddToolCall("edit", `{"file_path":"main.go"}`)
+	ml.AddToolResult("edit", "edited", false)
+	ml.Add(message.Assistant, "Fixed the build.")
+	ml.Add(message.User, "thanks")
+	ml.Add(message.Assistant, "You're welcome.")
+
+	view := ml.View(100, 60)
+	if n := strings.Count(view, "ran 3 tools: grep, edit (1 failed)"); n != 1 {
+		t.Errorf("got %d turn summaries, want 1:\n%s", n, view)
+	}
+	for _, hidden := range []string{"result: grep", "main.go:3: foo"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("quiet view shows %q:\n%s", hidden, view)
+		}
+	}
+	for _, shown := range []string{"Found it, fixing.", "Fixed the build.", "You're welcome."} {
+		if !strings.Contains(view, shown) {
+			t.Errorf("quiet view is missing %q:\n%s", shown, view)
+		}
+	}
+
+	// Expanding shows every step again.
+	ml.ToggleToolInputs()
+	if view := ml.View(100, 60); strings.Contains(view, "ran 3 tools") || !strings.Contains(view, "main.go:3: foo") {
+		t.Errorf("expanded view:\n%s", view)
+	}
+}
+
+// newSessionModel returns a model backed by a session store in a temporary
+// database, with a current session.
+func newSessionModel(t *testing.T, cfg config.Config, prov provider.Provider) (Model, *session.Service) {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "goder.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	sessions := session.NewService(database)
+	if _, err := sessions.Current(); err != nil {
+		t.Fatal(err)
+	}
+	return New(cfg, database, sessions, nil, prov, permission.NewService()), sessions
+}
+
+func TestFormatChanges(t *testing.T) {
+	got := formatChanges([]agent.FileChange{
+		{Path: "main.go", Lines: 3},
+		{Path: "docs/new.md", Created: true, Lines: 12},
+		{Path: "old.txt", Deleted: true, Lines: -4},
+	})
+	want := "Changes this turn:\n  modified main.go (+3 lines)\n  created  docs/new.md (+12 lines)\n  deleted  old.txt (-4 lines)"
+	if got != want {
+		t.Errorf("formatChanges =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestModeChangeRecordedInHistory(t *testing.T) {
+	m, sessions := newSessionModel(t, config.DefaultConfig(), nil)
+	toggle := func() {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+		m = next.(Model)
+	}
+
+	// Nothing is recorded before the conversation starts.
+	toggle()
+	if history, _ := sessions.GetMessages(); len(history) != 0 {
+		t.Fatalf("history before the first prompt = %+v", history)
+	}
+
+	if err := sessions.AddMessage(message.NewUserMessage(sessions.CurrentID(), "hello")); err != nil {
+		t.Fatal(err)
+	}
+	toggle()
+	history, _ := sessions.GetMessages()
+	if len(history) != 2 {
+		t.Fatalf("got %d messages, want the prompt and a mode note", len(history))
+	}
+	if note := history[1]; note.Role != message.System || !strings.Contains(note.Content, "PLAN mode") {
+		t.Errorf("mode note = %+v", note)
+	}
+}
+
+func TestExecuteCommandHandsPlanToBuildMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.APIKey = "sk-test"
+	m, sessions := newSessionModel(t, cfg, provider.NewMock())
+
+	if _, ok := m.runSlashCommand("/execute"); !ok || m.mode != PlanMode {
+		t.Fatalf("/execute without a plan: handled=%v mode=%v", ok, m.mode)
+	}
+
+	id := sessions.CurrentID()
+	for _, msg := range []message.Message{
+		message.NewUserMessage(id, "plan the refactor"),
+		message.NewAssistantMessage(id, "1. Extract the parser.\n2. Add tests.", nil),
+		message.NewAssistantMessage(id, "", []message.ToolCall{{ID: "c1", Name: "ls"}}),
+	} {
+		if err := sessions.AddMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.runSlashCommand("/execute skip the docs")
+	if m.mode != BuildMode || !m.thinking {
+		t.Fatalf("after /execute: mode=%v thinking=%v", m.mode, m.thinking)
+	}
+	history, _ := sessions.GetMessages()
+	prompt := history[len(history)-1]
+	if prompt.Role != message.User || !strings.Contains(prompt.Content, "<plan>\n1. Extract the parser.\n2. Add tests.\n</plan>") ||
+		!strings.HasSuffix(prompt.Content, "skip the docs") {
+		t.Errorf("execute prompt = %q", prompt.Content)
+	}
+}
+
+func TestContinueCommand(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.APIKey = "sk-test"
+	m, sessions := newSessionModel(t, cfg, provider.NewMock())
+
+	m.runSlashCommand("/continue")
+	if m.thinking {
+		t.Fatal("/continue on an empty session started the agent")
+	}
+	if last := m.msgs.messages[m.msgs.Count()-1]; last.Content != "Nothing to continue yet." {
+		t.Errorf("last message = %q", last.Content)
+	}
+
+	id := sessions.CurrentID()
+	for _, msg := range []message.Message{
+		message.NewUserMessage(id, "fix the tests"),
+		message.NewAssistantMessage(id, "I fixed the first one.", nil),
+	} {
+		if err := sessions.AddMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.runSlashCommand("/continue then run go vet")
+	if !m.thinking {
+		t.Fatal("/continue did not start the agent")
+	}
+	history, _ := sessions.GetMessages()
+	if len(history) != 3 {
+		t.Fatalf("got %d messages, want the continue prompt appended", len(history))
+	}
+	if prompt := history[2]; prompt.Role != message.User || prompt.Content != continuePrompt+"\n\nthen run go vet" {
+		t.Errorf("continue prompt = %q", prompt.Content)
+	}
+
+	// While the agent is running, /continue does nothing.
+	m.runSlashCommand("/continue")
+	if history, _ := sessions.GetMessages(); len(history) != 3 {
+		t.Errorf("/continue while thinking added messages: %d", len(history))
+	}
+}
+
+func TestCwdCommand(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "other"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "file.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.WorkDir = root
+	cfg.SavePath = config.ProjectConfigPath(root)
+	m := New(cfg, nil, nil, tools.NewRegistry(), nil, permission.NewService())
+	var built []string
+	m.SetRegistryFactory(func(workDir string) (*tools.Registry, error) {
+		built = append(built, workDir)
+		return tools.NewRegistry(), nil
+	})
+
+	for _, bad := range []string{"missing", "file.txt"} {
+		m.runSlashCommand("/cwd " + bad)
+		if m.cfg.WorkDir != root {
+			t.Fatalf("/cwd %s changed the directory to %s", bad, m.cfg.WorkDir)
+		}
+	}
+	if len(built) != 0 {
+		t.Fatalf("rebuilt the registry for an invalid path: %v", built)
+	}
+
+	m.runSlashCommand("/cwd other")
+	want := filepath.Join(root, "other")
+	if m.cfg.WorkDir != want || len(built) != 1 || built[0] != want {
+		t.Errorf("after /cwd other: workDir=%s, registries built for %v", m.cfg.WorkDir, built)
+	}
+	if m.cfg.SavePath != config.ProjectConfigPath(root) {
+		t.Errorf("after /cwd other: settings are saved to %s, want the loaded project config", m.cfg.SavePath)
+	}
+	if m.saveTarget() != "project" {
+		t.Errorf("after /cwd other: save target %q, want project", m.saveTarget())
+	}
+}
+
+func TestExpandUserPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct{ in, want string }{
+		{"~", home},
+		{" ~/notes.md ", filepath.Join(home, "notes.md")},
+		{`"~/my dir"`, filepath.Join(home, "my dir")},
+		{"'rel/path'", "rel/path"},
+		{"~user/file", "~user/file"},
+		{"/abs/~/x", "/abs/~/x"},
+	}
+	for _, tt := range tests {
+		if got, err := expandUserPath(tt.in); err != nil || got != tt.want {
+			t.Errorf("expandUserPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestExportAsksBeforeOverwriting(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.WorkDir = t.TempDir()
+	m, _ := newSessionModel(t, cfg, nil)
+	path := filepath.Join(cfg.WorkDir, "session.json")
+	if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m.runSlashCommand("/export session.json")
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Fatalf("first /export overwrote the file: %q", data)
+	}
+	m.runSlashCommand("/export session.json")
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "{") {
+		t.Fatalf("repeated /export did not overwrite the file: %q", data)
+	}
+	if m.exportOverwrite != "" {
+		t.Errorf("exportOverwrite = %q after the export, want it cleared", m.exportOverwrite)
+	}
+}
+
+func TestMoveDataDir(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DataDir = t.TempDir()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	database, err := db.New(cfg.DBPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	sessions := session.NewService(database)
+	if _, err := sessions.Current(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.AddMessage(message.NewUserMessage(sessions.CurrentID(), "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(cfg, database, sessions, nil, nil, permission.NewService())
+	m.settingsOpen = true
+	press := func(msg tea.KeyMsg) {
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	newDir := filepath.Join(t.TempDir(), "synced", "goder")
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(newDir)})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.settings.feedbackErr || m.settings.view != settingsViewMenu {
+		t.Fatalf("feedback %q, view %v", m.settings.feedback, m.settings.view)
+	}
+	if m.cfg.DataDir != newDir || database.Path() != filepath.Join(newDir, "goder.db") {
+		t.Errorf("data dir %q, database %q", m.cfg.DataDir, database.Path())
+	}
+	if history, err := sessions.GetMessages(); err != nil || len(history) != 1 {
+		t.Errorf("history after the move = %+v (%v)", history, err)
+	}
+	saved, err := os.ReadFile(cfg.SavePath)
+	if err != nil || !strings.Contains(string(saved), `"dataDir": "`+newDir+`"`) {
+		t.Errorf("saved config = %s (%v)", saved, err)
+	}
+
+	// Moving onto an existing database is refused.
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(cfg.DataDir)})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.settings.feedbackErr || !strings.Contains(m.settings.feedback, "already exists") {
+		t.Errorf("feedback %q, want the existing database refused", m.settings.feedback)
+	}
+	if database.Path() != filepath.Join(newDir, "goder.db") {
+		t.Errorf("database moved to %q", database.Path())
+	}
+}
+
+func TestQuitMidStreamPersistsResponse(t *testing.T) {
+	m, sessions := newSessionModel(t, config.DefaultConfig(), nil)
+	cancelled := false
+	m.thinking = true
+	m.agentCancel = func() { cancelled = true }
+	m.confirmQuit = true
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = next.(Model)
+	if !cancelled || !m.quitting {
+		t.Fatalf("cancelled %v, quitting %v after confirming", cancelled, m.quitting)
+	}
+	if cmd == nil {
+		t.Fatal("no shutdown timeout scheduled")
+	}
+
+	// Text still streaming in before the cancellation lands is kept.
+	next, _ = m.Update(agentEventMsg{event: agent.Event{Type: agent.EventStreamText, Text: "Partial answer"}, run: m.agentRun})
+	m = next.(Model)
+	next, cmd = m.Update(agentEventMsg{event: agent.Event{Type: agent.EventAgentError, Error: context.Canceled}, run: m.agentRun})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("no quit after the agent stopped")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("command after the agent stopped is not tea.Quit")
+	}
+
+	history, err := sessions.GetMessages()
+	if err != nil || len(history) != 1 || history[0].Role != message.Assistant || history[0].Content != "Partial answer" {
+		t.Errorf("history = %+v (%v), want the partial response", history, err)
+	}
+}
+
+func TestRateLimitWarning(t *testing.T) {
+	m := New(config.DefaultConfig(), nil, nil, nil, nil, permission.NewService())
+	m.width = 200
+	m.agentRun = 1
+
+	status := &provider.RateLimitStatus{RemainingRequests: 2, LimitRequests: 100, ResetRequests: 30 * time.Second, RemainingTokens: -1, LimitTokens: -1}
+	next, _ := m.Update(agentEventMsg{event: agent.Event{Type: agent.EventRateLimit, RateLimit: status}, run: 1})
+	m = next.(Model)
+	if !strings.Contains(m.View(), "rate limit: 2 requests left, resets in 30s") {
+		t.Error("status bar does not warn about the rate limit")
+	}
+	if got := rateLimitLabel(&provider.RateLimitStatus{RemainingRequests: 80, LimitRequests: 100, RemainingTokens: -1}); got != "" {
+		t.Errorf("rateLimitLabel with plenty left = %q, want none", got)
+	}
+
+	err := fmt.Errorf("LLM request failed: %w", &provider.APIError{
+		Provider:   "OpenAI",
+		StatusCode: http.StatusTooManyRequests,
+		RetryAfter: 6500 * time.Millisecond,
+	})
+	if hint := errorHint(err); !strings.Contains(hint, "Wait 7s") {
+		t.Errorf("errorHint = %q, want the wait from Retry-After", hint)
+	}
+}
+
+func TestContextNotesHiddenOnReload(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SavePath = filepath.Join(t.TempDir(), "config.json")
+	database, err := db.New(filepath.Join(t.TempDir(), "goder.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	sessions := session.NewService(database)
+	sess, err := sessions.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.AddMessage(message.NewUserMessage(sess.ID, "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(cfg, database, sessions, nil, nil, permission.NewService())
+	m.setMode(BuildMode)
+
+	history, err := sessions.GetMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Role != message.System || !history[1].Hidden {
+		t.Fatalf("history = %+v, want the mode note stored as a hidden system message", history)
+	}
+
+	next, _ := m.Update(sessionLoadedMsg{session: sess})
+	m = next.(Model)
+	if m.msgs.Count() != 1 || m.msgs.messages[0].Content != "hello" {
+		t.Errorf("reloaded transcript has %d messages, want only the user's", m.msgs.Count())
+	}
+}
+
+// blockingModels is a provider whose model list never loads; ListModels
+// waits for its context to end and reports that on cancelled.
+type blockingModels struct {
+	*provider.Mock
+	cancelled chan struct{}
+}
+
+func (p *blockingModels) ListModels(ctx context.Context) ([]string, error) {
+	<-ctx.Done()
+	close(p.cancelled)
+	return nil, ctx.Err()
+}
+
+func TestLeavingModelListCancelsFetch(t *testing.T) {
+	prov := &blockingModels{Mock: provider.NewMock(), cancelled: make(chan struct{})}
+	m := New(config.DefaultConfig(), nil, nil, nil, prov, permission.NewService())
+	m.settingsOpen = true
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = next.(Model)
+	if cmd == nil || m.settings.view != settingsViewModels {
+		t.Fatalf("view %v, want the model list loading", m.settings.view)
+	}
+	result := make(chan tea.Msg, 1)
+	go func() { result <- cmd() }()
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	select {
+	case <-prov.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("leaving the model list did not cancel the fetch")
+	}
+
+	// The cancelled fetch's result does not reach a model list opened since.
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	m = next.(Model)
+	next, _ = m.Update(<-result)
+	m = next.(Model)
+	if !m.settings.loadingModel || m.settings.modelsErr != nil {
+		t.Errorf("model list loading=%v err=%v after the cancelled result", m.settings.loadingModel, m.settings.modelsErr)
+	}
+}
+
+func TestModelListFilter(t *testing.T) {
+	s := NewSettings()
+	s.OpenModels()
+	s.HandleModelsLoaded([]string{"gpt-4.1", "gpt-4.1-mini", "gpt-5", "o3-mini", "gpt-5-mini"}, nil)
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		s, _, _ = s.Update(msg)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("MINI")})
+	if got := s.filteredModels(); strings.Join(got, ",") != "gpt-4.1-mini,o3-mini,gpt-5-mini" {
+		t.Fatalf("filtered models = %v", got)
+	}
+	if got := s.SelectedModel(); got != "gpt-4.1-mini" {
+		t.Errorf("selected %q after filtering, want the first match", got)
+	}
+
+	// Arrow keys move over the matches only.
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	if got := s.SelectedModel(); got != "gpt-5-mini" {
+		t.Errorf("selected %q, want the last match", got)
+	}
+	if view := s.viewModels(""); !strings.Contains(view, "3 of 5 models match") {
+		t.Errorf("view does not show the match count:\n%s", view)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got := s.SelectedModel(); got != "" || !strings.Contains(s.viewModels(""), "No models match") {
+		t.Errorf("selected %q with no matches", got)
+	}
+	press(tea.KeyMsg{Type: tea.KeyBackspace})
+	press(tea.KeyMsg{Type: tea.KeyBackspace})
+	press(tea.KeyMsg{Type: tea.KeyBackspace})
+	press(tea.KeyMsg{Type: tea.KeyBackspace})
+	press(tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := s.filteredModels(); len(got) != 5 {
+		t.Errorf("models after clearing the filter = %v", got)
+	}
+}
+
+// countingModels is a provider that counts its ListModels calls.
+type countingModels struct {
+	*provider.Mock
+	calls int
+}
+
+func (p *countingModels) ListModels(ctx context.Context) ([]string, error) {
+	p.calls++
+	return []string{"gpt-5", fmt.Sprintf("fetch-%d", p.calls)}, nil
+}
+
+func TestModelListCache(t *testing.T) {
+	prov := &countingModels{Mock: provider.NewMock()}
+	m := New(config.DefaultConfig(), nil, nil, nil, prov, permission.NewService())
+	m.settingsOpen = true
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, cmd := m.Update(msg)
+		m = next.(Model)
+		if cmd != nil {
+			if loaded, ok := cmd().(modelsLoadedMsg); ok {
+				next, _ = m.Update(loaded)
+				m = next.(Model)
+			}
+		}
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if prov.calls != 1 || m.settings.loadingModel || !slices.Contains(m.settings.models, "fetch-1") {
+		t.Fatalf("after reopening: %d fetches, models %v", prov.calls, m.settings.models)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if prov.calls != 2 || !slices.Contains(m.settings.models, "fetch-2") {
+		t.Fatalf("after ctrl+r: %d fetches, models %v", prov.calls, m.settings.models)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if prov.calls != 2 || !slices.Contains(m.settings.models, "fetch-2") {
+		t.Errorf("refreshed list not cached: %d fetches, models %v", prov.calls, m.settings.models)
+	}
+}
@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// modelCheckTimeout bounds the startup request for the available models.
+const modelCheckTimeout = 15 * time.Second
+
+// preferredModels are known-good default models, most preferred first.
+var preferredModels = []string{"gpt-4o", "gpt-4.1", "gpt-4o-mini", "gpt-4.1-mini", "o4-mini"}
+
+// specialModelMarkers identify models that are listed but not suited to
+// general chat, such as audio or search variants.
+var specialModelMarkers = []string{"audio", "realtime", "search", "transcribe", "tts", "image"}
+
+// modelCheckMsg carries the models available to the API key, fetched to
+// check that the configured model is among them. With verify set, the key
+// was just entered and the outcome is reported, testing the key.
+type modelCheckMsg struct {
+	models []string
+	err    error
+	verify bool
+}
+
+// checkModelCmd fetches the available models in the background.
+func checkModelCmd(prov provider.Provider) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), modelCheckTimeout)
+		defer cancel()
+		models, err := prov.ListModels(ctx)
+		return modelCheckMsg{models: models, err: err}
+	}
+}
+
+// verifyAPIKeyCmd fetches the available models in the background to test a
+// newly entered API key.
+func verifyAPIKeyCmd(prov provider.Provider) tea.Cmd {
+	check := checkModelCmd(prov)
+	return func() tea.Msg {
+		msg := check().(modelCheckMsg)
+		msg.verify = true
+		return msg
+	}
+}
+
+// handleModelCheck offers to switch models when the configured one is not
+// available. Errors are ignored unless a new key is being verified: a bad
+// key or network problem surfaces on the first request with a better
+// message.
+func (m Model) handleModelCheck(msg modelCheckMsg) (tea.Model, tea.Cmd) {
+	if msg.verify {
+		m.reportKeyCheck(msg.err)
+	}
+	if msg.err != nil || len(msg.models) == 0 || slices.Contains(msg.models, m.cfg.ModelID()) {
+		return m, nil
+	}
+	pick := defaultModel(msg.models)
+	if pick == "" {
+		return m, nil
+	}
+	m.suggestedModel = pick
+	m.msgs.Add(message.System, fmt.Sprintf("The configured model %s is not available to this API key.", m.cfg.ModelLabel()))
+	return m, nil
+}
+
+// reportKeyCheck tells the user whether the provider accepted a newly
+// entered API key, on the settings overlay if it is still open.
+func (m *Model) reportKeyCheck(err error) {
+	var text string
+	switch {
+	case err == nil:
+		text = "API key saved and accepted by the provider"
+	case provider.IsAuthError(err):
+		text = fmt.Sprintf("API key saved, but the provider rejected it: %s", err)
+	default:
+		text = fmt.Sprintf("API key saved, but it could not be checked: %s", err)
+	}
+	if m.settingsOpen {
+		m.settings.SetFeedback(text, err != nil)
+		return
+	}
+	m.msgs.Add(message.System, text+".")
+}
+
+// handleModelSuggestionKey handles key presses in the model switch dialog,
+// reporting whether it used the key. Any other key declines the switch and
+// is left to the usual handling, so that ctrl+c still quits and typing goes
+// to the input.
+func (m *Model) handleModelSuggestionKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		pick := m.suggestedModel
+		m.suggestedModel = ""
+		m.cfg.Model = pick
+		if m.prov != nil {
+			m.prov.SetModel(m.cfg.ModelID())
+		}
+		if err := config.Save(m.cfg); err != nil {
+			m.msgs.Add(message.System, fmt.Sprintf("Switched to %s for this session, but saving the config failed: %s", pick, err))
+			return true, nil
+		}
+		m.msgs.Add(message.System, fmt.Sprintf("Model set to %s.", pick))
+		return true, nil
+	case "n", "N", "esc":
+		m.declineModelSuggestion()
+		return true, nil
+	}
+	m.declineModelSuggestion()
+	return false, nil
+}
+
+// declineModelSuggestion closes the model switch dialog, keeping the
+// configured model.
+func (m *Model) declineModelSuggestion() {
+	m.suggestedModel = ""
+	m.msgs.Add(message.System, fmt.Sprintf("Keeping %s. Press ctrl+k to choose another model in settings.", m.cfg.ModelLabel()))
+}
+
+// renderModelSuggestionDialog renders the model switch dialog.
+func (m Model) renderModelSuggestionDialog() string {
+	dialog := fmt.Sprintf("  %s is not available to this API key. Switch to %s?\n\n  [y] Yes  [n] No",
+		m.cfg.ModelLabel(), m.suggestedModel)
+	return permissionStyle.Width(m.width - 4).Render(dialog)
+}
+
+// defaultModel picks a model from those available: the first known-good
+// default, or else the first general-purpose GPT model, or else the first
+// general-purpose model of any kind.
+func defaultModel(available []string) string {
+	for _, id := range preferredModels {
+		if slices.Contains(available, id) {
+			return id
+		}
+	}
+	var general []string
+	for _, id := range available {
+		if !slices.ContainsFunc(specialModelMarkers, func(s string) bool { return strings.Contains(id, s) }) {
+			general = append(general, id)
+		}
+	}
+	for _, id := range general {
+		if strings.HasPrefix(id, "gpt-") {
+			return id
+		}
+	}
+	if len(general) > 0 {
+		return general[0]
+	}
+	return ""
+}
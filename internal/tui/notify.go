@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// focusState tracks whether the terminal window has focus. Terminals that
+// don't support focus reporting never send focus events, in which case focus
+// is unknown and notifications always fire.
+type focusState struct {
+	known   bool
+	focused bool
+}
+
+// notifyCmd rings the terminal bell if notifications are enabled and the
+// window is not known to be focused.
+func (m Model) notifyCmd() tea.Cmd {
+	if !m.cfg.Notify || (m.focus.known && m.focus.focused) {
+		return nil
+	}
+	return func() tea.Msg {
+		// Write to stderr so the bell doesn't interleave with the renderer's
+		// stdout frames; both are normally the same terminal.
+		_, _ = os.Stderr.WriteString("\a")
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/agent"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// cmdPin adds a file to those sent with every request. Without arguments it
+// lists the pinned files.
+func (m *Model) cmdPin(args string) tea.Cmd {
+	if args == "" {
+		if len(m.pinned) == 0 {
+			m.msgs.Add(message.System, "Usage: /pin <path>. The file's current contents are sent with every request until /unpin.")
+			return nil
+		}
+		m.msgs.Add(message.System, "Pinned: "+m.pinnedNames())
+		return nil
+	}
+
+	path, err := m.checkPinnable(args)
+	if err != nil {
+		m.msgs.Add(message.System, fmt.Sprintf("Cannot pin: %s", err))
+		return nil
+	}
+	if slices.Contains(m.pinned, path) {
+		m.msgs.Add(message.System, fmt.Sprintf("%s is already pinned.", m.displayPath(path)))
+		return nil
+	}
+	m.pinned = append(m.pinned, path)
+	m.msgs.Add(message.System, fmt.Sprintf("Pinned %s; its current contents are sent with every request.", m.displayPath(path)))
+	return nil
+}
+
+// cmdUnpin removes a pinned file, or all of them with no arguments or "all".
+func (m *Model) cmdUnpin(args string) tea.Cmd {
+	if args == "" || args == "all" {
+		if len(m.pinned) == 0 {
+			m.msgs.Add(message.System, "No files are pinned.")
+			return nil
+		}
+		m.pinned = nil
+		m.msgs.Add(message.System, "Unpinned all files.")
+		return nil
+	}
+
+	path := m.resolvePinPath(args)
+	i := slices.Index(m.pinned, path)
+	if i < 0 {
+		m.msgs.Add(message.System, fmt.Sprintf("%s is not pinned.", args))
+		return nil
+	}
+	m.pinned = slices.Delete(m.pinned, i, i+1)
+	m.msgs.Add(message.System, fmt.Sprintf("Unpinned %s.", m.displayPath(path)))
+	return nil
+}
+
+// checkPinnable resolves path and checks that it is a text file small enough
+// to send whole.
+func (m *Model) checkPinnable(path string) (string, error) {
+	path = m.resolvePinPath(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", m.displayPath(path))
+	}
+	if info.Size() > agent.MaxPinnedBytes {
+		return "", fmt.Errorf("%s is larger than %d KB; reference the parts you need instead", m.displayPath(path), agent.MaxPinnedBytes>>10)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%s is a binary file", m.displayPath(path))
+	}
+	return path, nil
+}
+
+// resolvePinPath returns path made absolute against the working directory.
+func (m *Model) resolvePinPath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), `"'`)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.cfg.WorkDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// displayPath returns path relative to the working directory if it is
+// inside it.
+func (m *Model) displayPath(path string) string {
+	if rel, err := filepath.Rel(m.cfg.WorkDir, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
+
+// pinnedNames lists the pinned files for display.
+func (m *Model) pinnedNames() string {
+	names := make([]string, len(m.pinned))
+	for i, path := range m.pinned {
+		names[i] = m.displayPath(path)
+	}
+	return strings.Join(names, ", ")
+}
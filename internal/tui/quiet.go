@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// isToolStep reports whether dm is a tool call or result, which quiet mode
+// collapses.
+func isToolStep(dm *DisplayMessage) bool {
+	return dm.IsToolCall || dm.IsToolResult
+}
+
+// toolSummaries describes the tool activity of each turn in msgs for quiet
+// mode. A turn runs from one user message to the next. The summary of a turn
+// is keyed by the index of its first tool call or result, where it takes the
+// place of them all; turns without tools have none.
+func toolSummaries(msgs []DisplayMessage) map[int]string {
+	summaries := make(map[int]string)
+	first, calls, failed := -1, 0, 0
+	var names []string
+	flush := func() {
+		if first >= 0 {
+			summaries[first] = toolSummary(calls, failed, names)
+		}
+		first, calls, failed, names = -1, 0, 0, nil
+	}
+
+	for i := range msgs {
+		dm := &msgs[i]
+		switch {
+		case dm.Role == message.User && !isToolStep(dm):
+			flush()
+		case dm.IsToolCall:
+			if first < 0 {
+				first = i
+			}
+			calls++
+			if !slices.Contains(names, dm.ToolName) {
+				names = append(names, dm.ToolName)
+			}
+		case dm.IsToolResult:
+			if first < 0 {
+				first = i
+			}
+			if dm.ToolIsError {
+				failed++
+			}
+		}
+	}
+	flush()
+	return summaries
+}
+
+// toolSummary renders the line standing in for a turn's tool activity.
+func toolSummary(calls, failed int, names []string) string {
+	text := fmt.Sprintf("ran %d tools", calls)
+	if calls == 1 {
+		text = "ran 1 tool"
+	}
+	if len(names) > 0 {
+		text += ": " + strings.Join(names, ", ")
+	}
+	if failed > 0 {
+		text += fmt.Sprintf(" (%d failed)", failed)
+	}
+	return toolCallStyle.Render("  "+text) + " " + dimStyle.Render("(ctrl+o to expand)")
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/message"
+	"github.com/webgovernor/goder/internal/permission"
+)
+
+// handleReviewKey handles keys in the review dialog for a proposed file
+// change: apply it, skip it, or edit it in the input area first.
+func (m Model) handleReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		return m.finishReview(permission.ReviewResult{Response: permission.Allow, Content: m.permReq.Content})
+	case "n", "N":
+		return m.finishReview(permission.ReviewResult{Response: permission.Deny})
+	case "d", "D":
+		return m.openPreviewDiff()
+	case "e", "E":
+		if len(m.permReq.Content) > maxInputChars {
+			m.msgs.Add(message.System, "This change is too large to edit in the input area; apply or skip it instead.")
+			return m, nil
+		}
+		m.reviewEditing = true
+		m.input.SetValue(m.permReq.Content)
+		m.msgs.Add(message.System, "Editing the proposed content: ctrl+s applies it, esc returns to the review.")
+		return m, m.input.Focus()
+	}
+	return m, nil
+}
+
+// handleReviewEditKey handles keys while the proposed content is being edited
+// in the input area: submit applies the edited content and esc returns to
+// the review dialog.
+func (m Model) handleReviewEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Submit):
+		content := restoreTabs(m.permReq.Content, m.input.Value())
+		m.reviewEditing = false
+		m.input.Reset()
+		return m.finishReview(permission.ReviewResult{Response: permission.Allow, Content: content})
+
+	case key.Matches(msg, m.keys.Cancel):
+		m.reviewEditing = false
+		m.input.Reset()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Quit):
+		m.confirmQuit = true
+		return m, nil
+	}
+
+	return m, m.input.Update(msg)
+}
+
+// finishReview answers the pending review request.
+func (m Model) finishReview(result permission.ReviewResult) (tea.Model, tea.Cmd) {
+	m.permReq.ReviewCh <- result
+	m.permReq = nil
+	m.phase = phaseRunningTool
+	return m, m.listenForPermissions()
+}
+
+// renderReviewDialog renders the review dialog for a proposed file change.
+func (m Model) renderReviewDialog() string {
+	details := renderPreview(m.permReq.Preview, permissionPreviewMaxLines)
+	if m.permReq.Preview == "" {
+		details = "  (no changes)"
+	}
+
+	dialog := fmt.Sprintf(
+		"  Review: %s\n%s\n\n  [y] Apply  [n] Skip  [e] Edit%s",
+		m.permReq.ToolName, details, m.fullPreviewHint(),
+	)
+	return permissionStyle.Width(m.width - 4).Render(dialog)
+}
+
+// restoreTabs undoes the input area's expansion of tabs to spaces in edited,
+// which started out as original. Lines left unchanged are restored verbatim,
+// and if original is indented with tabs, so is every other line.
+func restoreTabs(original, edited string) string {
+	if !strings.Contains(original, "\t") {
+		return edited
+	}
+
+	expanded := make(map[string]string)
+	tabIndented := false
+	for _, line := range strings.Split(original, "\n") {
+		expanded[strings.ReplaceAll(line, "\t", tabSpaces)] = line
+		tabIndented = tabIndented || strings.HasPrefix(line, "\t")
+	}
+
+	lines := strings.Split(edited, "\n")
+	for i, line := range lines {
+		if orig, ok := expanded[line]; ok {
+			lines[i] = orig
+			continue
+		}
+		if tabIndented {
+			trimmed := strings.TrimLeft(line, " ")
+			indent := len(line) - len(trimmed)
+			lines[i] = strings.Repeat("\t", indent/len(tabSpaces)) +
+				strings.Repeat(" ", indent%len(tabSpaces)) + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tabSpaces is what the input area replaces each tab with.
+const tabSpaces = "    "
@@ -0,0 +1,28 @@
+package tui
+
+import "testing"
+
+func TestRestoreTabs(t *testing.T) {
+	original := "func main() {\n\tx := \"a\tb\"\n\tfmt.Println(x)\n}\n"
+
+	input := NewInput()
+	input.SetWidth(80)
+	input.SetValue(original)
+
+	// Round-tripping through the input area restores the original exactly.
+	if got := restoreTabs(original, input.Value()); got != original {
+		t.Errorf("unchanged content = %q, want %q", got, original)
+	}
+
+	// Edited lines are re-indented with tabs.
+	edited := "func main() {\n    x := 1\n        y := 2\n     z := 3\n}\n"
+	want := "func main() {\n\tx := 1\n\t\ty := 2\n\t z := 3\n}\n"
+	if got := restoreTabs(original, edited); got != want {
+		t.Errorf("edited content = %q, want %q", got, want)
+	}
+
+	// Space-indented files are left alone.
+	if got := restoreTabs("a\n    b\n", "a\n    c\n"); got != "a\n    c\n" {
+		t.Errorf("space-indented content = %q", got)
+	}
+}
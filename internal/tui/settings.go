@@ -3,36 +3,71 @@ package tui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
 )
 
 // settingsView represents which sub-view of the settings overlay is active.
 type settingsView int
 
 const (
-	settingsViewMenu    settingsView = iota // main menu
-	settingsViewAPIKey                      // API key input
-	settingsViewModels                      // model selection list
-	settingsViewMaxIter                     // max iterations input
+	settingsViewMenu      settingsView = iota // main menu
+	settingsViewAPIKey                        // API key input
+	settingsViewModels                        // model selection list
+	settingsViewMaxIter                       // max iterations input
+	settingsViewMaxTokens                     // max tokens input
+	settingsViewProviders                     // provider selection list
+	settingsViewAbout                         // build information
+	settingsViewTools                         // tool enable/disable list
+	settingsViewDataDir                       // data directory input
 )
 
+// minMaxTokens is the smallest output token limit the API accepts. The
+// upper bound depends on the model and is checked when the value is saved.
+const minMaxTokens = 16
+
 // Settings holds the state for the settings overlay.
 type Settings struct {
 	view     settingsView
 	apiInput textinput.Model
 
+	// apiKeyWarned is the API key last entered with a warning about its
+	// form; entering it again saves it anyway.
+	apiKeyWarned string
+
+	// Focus within the main menu, as an index into settingsMenuKeys, and
+	// within the input sub-views (inputFocusField and so on)
+	menuFocus  int
+	inputFocus int
+
 	// Max iterations input
 	maxIterInput textinput.Model
 
+	// Max tokens input
+	maxTokensInput textinput.Model
+
+	// Data directory input
+	dataDirInput textinput.Model
+
+	// Provider selection state
+	providerCursor int
+
+	// Tool list state
+	tools      []string // registered tool names
+	toolCursor int
+
 	// Model selection state
-	models       []string // available models from API
-	modelCursor  int      // currently highlighted index
-	modelsErr    error    // error from fetching models
-	loadingModel bool     // true while fetching models
+	models       []string        // available models from API
+	modelFilter  textinput.Model // narrows the list to models containing its text
+	modelCursor  int             // currently highlighted index in filteredModels
+	modelsErr    error           // error from fetching models
+	loadingModel bool            // true while fetching models
 
 	// Feedback messages
 	feedback    string // success/error message to show
@@ -53,17 +88,45 @@ func NewSettings() Settings {
 	mi.CharLimit = 5
 	mi.Width = 10
 
+	mt := textinput.New()
+	mt.Placeholder = "4096"
+	mt.CharLimit = 6
+	mt.Width = 10
+
+	di := textinput.New()
+	di.Placeholder = "~/Sync/goder"
+	di.CharLimit = 1024
+	di.Width = 60
+
+	mf := textinput.New()
+	mf.Prompt = "Filter: "
+	mf.Placeholder = "type to filter"
+	mf.CharLimit = 100
+	mf.Width = 30
+
 	return Settings{
-		view:         settingsViewMenu,
-		apiInput:     ti,
-		maxIterInput: mi,
+		view:           settingsViewMenu,
+		apiInput:       ti,
+		maxIterInput:   mi,
+		maxTokensInput: mt,
+		dataDirInput:   di,
+		modelFilter:    mf,
 	}
 }
 
+// settingsMenuKeys are the shortcut keys of the main menu's items, in the
+// order they are shown and focused.
+var settingsMenuKeys = []string{"d", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0"}
+
+// settingsMenuAliases maps the menu's letter shortcuts to its number keys.
+var settingsMenuAliases = map[string]string{"a": "1", "m": "2", "i": "3", "t": "4", "p": "5", "v": "6"}
+
 // --- Async message types for settings ---
 
-// modelsLoadedMsg carries the result of fetching models from the API.
+// modelsLoadedMsg carries the result of fetching models from the API. fetch
+// identifies the fetch; only the latest one's result is shown.
 type modelsLoadedMsg struct {
+	fetch  int
 	models []string
 	err    error
 }
@@ -78,6 +141,10 @@ type settingsModelSavedMsg struct{ model string }
 // Returns the updated settings, whether the overlay should close,
 // and any tea.Cmd to execute.
 func (s Settings) Update(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	if step := focusStep(msg); step != 0 {
+		cmd := s.moveFocus(step)
+		return s, false, cmd
+	}
 	switch s.view {
 	case settingsViewMenu:
 		return s.updateMenu(msg)
@@ -87,35 +154,132 @@ func (s Settings) Update(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
 		return s.updateModels(msg)
 	case settingsViewMaxIter:
 		return s.updateMaxIter(msg)
+	case settingsViewMaxTokens:
+		return s.updateMaxTokens(msg)
+	case settingsViewProviders:
+		return s.updateProviders(msg)
+	case settingsViewTools:
+		return s.updateTools(msg)
+	case settingsViewDataDir:
+		return s.updateDataDir(msg)
+	case settingsViewAbout:
+		if msg.String() == "esc" {
+			s.view = settingsViewMenu
+		}
 	}
 	return s, false, nil
 }
 
+// moveFocus moves focus by step within the current sub-view: through the
+// menu items, the entries of a list, or an input and its buttons.
+func (s *Settings) moveFocus(step int) tea.Cmd {
+	switch s.view {
+	case settingsViewMenu:
+		s.menuFocus = cycleFocus(s.menuFocus, step, len(settingsMenuKeys))
+	case settingsViewModels:
+		if !s.loadingModel && s.modelsErr == nil {
+			s.modelCursor = cycleFocus(s.modelCursor, step, len(s.filteredModels()))
+		}
+	case settingsViewProviders:
+		s.providerCursor = cycleFocus(s.providerCursor, step, len(provider.Names()))
+	case settingsViewTools:
+		s.toolCursor = cycleFocus(s.toolCursor, step, len(s.tools))
+	case settingsViewAPIKey, settingsViewMaxIter, settingsViewMaxTokens, settingsViewDataDir:
+		s.inputFocus = cycleFocus(s.inputFocus, step, inputFocusCount)
+		input := s.activeInput()
+		if s.inputFocus != inputFocusField {
+			input.Blur()
+			return nil
+		}
+		input.Focus()
+		return input.Cursor.BlinkCmd()
+	}
+	return nil
+}
+
+// FocusedKey returns the key that msg stands for given the focus: enter on
+// a menu item is that item's shortcut, and enter on an input's back button
+// is esc. Other keys are returned unchanged.
+func (s Settings) FocusedKey(msg tea.KeyMsg) tea.KeyMsg {
+	if msg.Type != tea.KeyEnter {
+		return msg
+	}
+	switch s.view {
+	case settingsViewMenu:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(settingsMenuKeys[s.menuFocus])}
+	case settingsViewAPIKey, settingsViewMaxIter, settingsViewMaxTokens, settingsViewDataDir:
+		if s.inputFocus == inputFocusBack {
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+	}
+	return msg
+}
+
+// activeInput returns the text input of the current input sub-view, or nil
+// if the sub-view has none.
+func (s *Settings) activeInput() *textinput.Model {
+	switch s.view {
+	case settingsViewAPIKey:
+		return &s.apiInput
+	case settingsViewMaxIter:
+		return &s.maxIterInput
+	case settingsViewMaxTokens:
+		return &s.maxTokensInput
+	case settingsViewDataDir:
+		return &s.dataDirInput
+	}
+	return nil
+}
+
+// openInput switches to an input sub-view with its input emptied and
+// focused.
+func (s *Settings) openInput(view settingsView) tea.Cmd {
+	s.view = view
+	s.feedback = ""
+	s.inputFocus = inputFocusField
+	input := s.activeInput()
+	input.SetValue("")
+	input.Focus()
+	return input.Cursor.BlinkCmd()
+}
+
 // updateMenu handles keys in the main settings menu.
 func (s Settings) updateMenu(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	key := strings.ToLower(msg.String())
+	if alias, ok := settingsMenuAliases[key]; ok {
+		key = alias
+	}
+	if i := slices.Index(settingsMenuKeys, key); i >= 0 {
+		s.menuFocus = i
+	}
+
 	switch msg.String() {
 	case "esc", "ctrl+k":
 		return s, true, nil // close settings
+	case "up":
+		s.menuFocus = cycleFocus(s.menuFocus, -1, len(settingsMenuKeys))
+	case "down":
+		s.menuFocus = cycleFocus(s.menuFocus, 1, len(settingsMenuKeys))
 	case "1", "a", "A":
-		s.view = settingsViewAPIKey
-		s.feedback = ""
-		s.apiInput.SetValue("")
-		s.apiInput.Focus()
-		return s, false, s.apiInput.Cursor.BlinkCmd()
+		return s, false, s.OpenAPIKey()
 	case "2", "m", "M":
-		s.view = settingsViewModels
-		s.feedback = ""
-		s.modelCursor = 0
-		s.models = nil
-		s.modelsErr = nil
-		s.loadingModel = true
+		s.OpenModels()
 		return s, false, nil // model fetch is triggered from model.go
 	case "3", "i", "I":
-		s.view = settingsViewMaxIter
+		return s, false, s.openInput(settingsViewMaxIter)
+	case "4", "t", "T":
+		return s, false, s.openInput(settingsViewMaxTokens)
+	case "5", "p", "P":
+		s.view = settingsViewProviders
+		s.feedback = ""
+		s.providerCursor = 0
+		return s, false, nil
+	case "6", "v", "V":
+		s.view = settingsViewAbout
 		s.feedback = ""
-		s.maxIterInput.SetValue("")
-		s.maxIterInput.Focus()
-		return s, false, s.maxIterInput.Cursor.BlinkCmd()
+		return s, false, nil
+	case "d", "D":
+		return s, false, s.openInput(settingsViewDataDir)
 	}
 	return s, false, nil
 }
@@ -157,6 +321,11 @@ func (s Settings) updateModels(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
 		return s, false, nil
 	}
 
+	if msg.String() == "ctrl+r" {
+		s.refreshModels()
+		return s, false, nil // the fetch is started by model.go
+	}
+
 	if s.modelsErr != nil {
 		// Only allow esc on error
 		if msg.String() == "esc" {
@@ -170,25 +339,51 @@ func (s Settings) updateModels(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
 		s.view = settingsViewMenu
+		s.modelFilter.Blur()
 		return s, false, nil
-	case "up", "k":
+	case "up":
 		if s.modelCursor > 0 {
 			s.modelCursor--
 		}
 		return s, false, nil
-	case "down", "j":
-		if s.modelCursor < len(s.models)-1 {
+	case "down":
+		if s.modelCursor < len(s.filteredModels())-1 {
 			s.modelCursor++
 		}
 		return s, false, nil
 	case "enter":
-		if len(s.models) > 0 && s.modelCursor < len(s.models) {
-			// Signal to model.go to save the selected model
-			return s, false, nil // actual save handled by model.go
+		return s, false, nil // saving the selected model is handled by model.go
+	}
+
+	// Anything else edits the filter, which starts over at the first match.
+	filter := s.modelFilter.Value()
+	var cmd tea.Cmd
+	s.modelFilter, cmd = s.modelFilter.Update(msg)
+	if s.modelFilter.Value() != filter {
+		s.modelCursor = 0
+	}
+	return s, false, cmd
+}
+
+// filterModels returns the models whose IDs contain filter, ignoring case,
+// or all of them if filter is empty.
+func filterModels(models []string, filter string) []string {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return models
+	}
+	var matches []string
+	for _, model := range models {
+		if strings.Contains(strings.ToLower(model), filter) {
+			matches = append(matches, model)
 		}
-		return s, false, nil
 	}
-	return s, false, nil
+	return matches
+}
+
+// filteredModels returns the models shown in the model list.
+func (s Settings) filteredModels() []string {
+	return filterModels(s.models, s.modelFilter.Value())
 }
 
 // updateMaxIter handles keys in the max iterations input sub-view.
@@ -244,6 +439,193 @@ func (s Settings) MaxIterValue() int {
 	return n
 }
 
+// updateMaxTokens handles keys in the max tokens input sub-view.
+func (s Settings) updateMaxTokens(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		s.view = settingsViewMenu
+		s.maxTokensInput.Blur()
+		return s, false, nil
+	case "enter":
+		val := strings.TrimSpace(s.maxTokensInput.Value())
+		if val == "" {
+			s.feedback = "Value cannot be empty"
+			s.feedbackErr = true
+			return s, false, nil
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < minMaxTokens {
+			s.feedback = fmt.Sprintf("Enter an integer of at least %d", minMaxTokens)
+			s.feedbackErr = true
+			return s, false, nil
+		}
+		// Signal to model.go to save the value
+		s.maxTokensInput.Blur()
+		return s, false, nil // actual save handled by model.go checking for enter
+	}
+
+	// Only allow digit keys in the text input
+	if len(msg.String()) == 1 && msg.String()[0] >= '0' && msg.String()[0] <= '9' {
+		var cmd tea.Cmd
+		s.maxTokensInput, cmd = s.maxTokensInput.Update(msg)
+		return s, false, cmd
+	}
+
+	// Allow backspace/delete
+	switch msg.Type {
+	case tea.KeyBackspace, tea.KeyDelete:
+		var cmd tea.Cmd
+		s.maxTokensInput, cmd = s.maxTokensInput.Update(msg)
+		return s, false, cmd
+	}
+
+	return s, false, nil
+}
+
+// updateDataDir handles keys in the data directory input sub-view.
+func (s Settings) updateDataDir(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		s.view = settingsViewMenu
+		s.dataDirInput.Blur()
+		return s, false, nil
+	case "enter":
+		if s.DataDirValue() == "" {
+			s.feedback = "Directory cannot be empty"
+			s.feedbackErr = true
+		}
+		return s, false, nil // the move is handled by model.go
+	}
+
+	var cmd tea.Cmd
+	s.dataDirInput, cmd = s.dataDirInput.Update(msg)
+	return s, false, cmd
+}
+
+// DataDirValue returns the current value in the data directory input.
+func (s Settings) DataDirValue() string {
+	return strings.TrimSpace(s.dataDirInput.Value())
+}
+
+// updateProviders handles keys in the provider selection sub-view.
+func (s Settings) updateProviders(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		s.view = settingsViewMenu
+	case "up", "k":
+		if s.providerCursor > 0 {
+			s.providerCursor--
+		}
+	case "down", "j":
+		if s.providerCursor < len(provider.Names())-1 {
+			s.providerCursor++
+		}
+	}
+	// enter is handled by model.go, which rebuilds the provider
+	return s, false, nil
+}
+
+// updateTools handles keys in the tool list sub-view.
+func (s Settings) updateTools(msg tea.KeyMsg) (Settings, bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		s.view = settingsViewMenu
+	case "up", "k":
+		if s.toolCursor > 0 {
+			s.toolCursor--
+		}
+	case "down", "j":
+		if s.toolCursor < len(s.tools)-1 {
+			s.toolCursor++
+		}
+	}
+	// enter and space are handled by model.go, which saves the change
+	return s, false, nil
+}
+
+// OpenTools switches to the tool list, showing names.
+func (s *Settings) OpenTools(names []string) {
+	s.view = settingsViewTools
+	s.feedback = ""
+	s.tools = names
+	s.toolCursor = 0
+}
+
+// SelectedTool returns the currently highlighted tool name, or empty if none.
+func (s Settings) SelectedTool() string {
+	if s.toolCursor < len(s.tools) {
+		return s.tools[s.toolCursor]
+	}
+	return ""
+}
+
+// SelectedProvider returns the currently highlighted provider name.
+func (s Settings) SelectedProvider() string {
+	names := provider.Names()
+	if s.providerCursor < len(names) {
+		return names[s.providerCursor]
+	}
+	return ""
+}
+
+// MaxTokensValue returns the current value in the max tokens input as an int,
+// or 0 if it is invalid or below minMaxTokens.
+func (s Settings) MaxTokensValue() int {
+	val := strings.TrimSpace(s.maxTokensInput.Value())
+	n, err := strconv.Atoi(val)
+	if err != nil || n < minMaxTokens {
+		return 0
+	}
+	return n
+}
+
+// RejectMaxTokens keeps the max tokens input open and focused, explaining why
+// the value entered cannot be saved.
+func (s *Settings) RejectMaxTokens(problem string) tea.Cmd {
+	s.SetFeedback(problem, true)
+	s.inputFocus = inputFocusField
+	s.maxTokensInput.Focus()
+	return s.maxTokensInput.Cursor.BlinkCmd()
+}
+
+// OpenAPIKey switches to an empty API key input and focuses it.
+func (s *Settings) OpenAPIKey() tea.Cmd {
+	s.apiKeyWarned = ""
+	return s.openInput(settingsViewAPIKey)
+}
+
+// WarnAPIKey keeps the API key input open, explaining what looks wrong with
+// the key entered, so that it can be fixed or entered again to save anyway.
+func (s *Settings) WarnAPIKey(key string, problem error) tea.Cmd {
+	s.apiKeyWarned = key
+	s.SetFeedback(fmt.Sprintf("This key looks wrong: %s. Press enter again to save it anyway.", problem), true)
+	s.inputFocus = inputFocusField
+	s.apiInput.Focus()
+	return s.apiInput.Cursor.BlinkCmd()
+}
+
+// OpenModels switches to the model selection list in its loading state. The
+// caller is responsible for fetching the models.
+func (s *Settings) OpenModels() {
+	s.view = settingsViewModels
+	s.feedback = ""
+	s.modelCursor = 0
+	s.models = nil
+	s.modelsErr = nil
+	s.loadingModel = true
+	s.modelFilter.SetValue("")
+	s.modelFilter.Focus()
+}
+
+// refreshModels puts the model list back in its loading state, keeping the
+// filter, for model.go to fetch the models again.
+func (s *Settings) refreshModels() {
+	s.feedback = ""
+	s.models = nil
+	s.modelsErr = nil
+	s.loadingModel = true
+}
+
 // HandleModelsLoaded processes the modelsLoadedMsg.
 func (s *Settings) HandleModelsLoaded(models []string, err error) {
 	s.loadingModel = false
@@ -263,8 +645,9 @@ func (s *Settings) SetFeedback(msg string, isErr bool) {
 
 // SelectedModel returns the currently highlighted model ID, or empty if none.
 func (s Settings) SelectedModel() string {
-	if len(s.models) > 0 && s.modelCursor < len(s.models) {
-		return s.models[s.modelCursor]
+	models := s.filteredModels()
+	if s.modelCursor < len(models) {
+		return models[s.modelCursor]
 	}
 	return ""
 }
@@ -274,33 +657,63 @@ func (s Settings) APIKeyValue() string {
 	return strings.TrimSpace(s.apiInput.Value())
 }
 
-// View renders the settings overlay.
-func (s Settings) View(width int, currentKey, currentModel string, currentMaxIter int) string {
+// SettingsValues are the current settings the overlay displays.
+type SettingsValues struct {
+	Provider      string
+	APIKey        string
+	Model         string
+	MaxIterations int
+	MaxTokens     int
+	AutoApprove   bool
+	SaveTarget    string // where changes are saved, as shown in the menu
+	ConfigPath    string
+	DataDir       string
+	Version       string
+	DisabledTools []string
+}
+
+// View renders the settings overlay showing the current values v.
+func (s Settings) View(width int, v SettingsValues) string {
 	innerWidth := width - 6 // account for border + padding
 
 	var content string
 	switch s.view {
 	case settingsViewMenu:
-		content = s.viewMenu(currentKey, currentModel, currentMaxIter)
+		content = s.viewMenu(v)
 	case settingsViewAPIKey:
 		content = s.viewAPIKey(innerWidth)
 	case settingsViewModels:
-		content = s.viewModels(currentModel)
+		content = s.viewModels(v.Model)
 	case settingsViewMaxIter:
-		content = s.viewMaxIter(innerWidth, currentMaxIter)
+		content = s.viewMaxIter(innerWidth, v.MaxIterations)
+	case settingsViewMaxTokens:
+		content = s.viewMaxTokens(v.Model, v.MaxTokens)
+	case settingsViewProviders:
+		content = s.viewProviders(v.Provider)
+	case settingsViewAbout:
+		content = s.viewAbout(v.Version)
+	case settingsViewTools:
+		content = s.viewTools(v.DisabledTools)
+	case settingsViewDataDir:
+		content = s.viewDataDir(innerWidth, v.DataDir)
 	}
 
 	return settingsStyle.Width(innerWidth).Render(content)
 }
 
 // viewMenu renders the main settings menu.
-func (s Settings) viewMenu(currentKey, currentModel string, currentMaxIter int) string {
+func (s Settings) viewMenu(v SettingsValues) string {
 	title := settingsTitleStyle.Render("Settings")
 
+	configPath := v.ConfigPath
+	if configPath == "" {
+		configPath = "none found, using defaults"
+	}
+
 	maskedKey := "(not set)"
-	if currentKey != "" {
-		if len(currentKey) > 8 {
-			maskedKey = currentKey[:3] + "..." + currentKey[len(currentKey)-4:]
+	if v.APIKey != "" {
+		if len(v.APIKey) > 8 {
+			maskedKey = v.APIKey[:3] + "..." + v.APIKey[len(v.APIKey)-4:]
 		} else {
 			maskedKey = "****"
 		}
@@ -308,9 +721,27 @@ func (s Settings) viewMenu(currentKey, currentModel string, currentMaxIter int)
 
 	var b strings.Builder
 	b.WriteString("  " + title + "\n\n")
-	b.WriteString(fmt.Sprintf("  [1] API Key     %s\n", dimStyle.Render(maskedKey)))
-	b.WriteString(fmt.Sprintf("  [2] Model       %s\n", dimStyle.Render(currentModel)))
-	b.WriteString(fmt.Sprintf("  [3] Max Iters   %s\n", dimStyle.Render(strconv.Itoa(currentMaxIter))))
+	b.WriteString(fmt.Sprintf("  Config file      %s\n", dimStyle.Render(configPath)))
+	b.WriteString(fmt.Sprintf("%s[d] Data Dir     %s\n\n", s.menuCursor("d"), dimStyle.Render(v.DataDir)))
+	b.WriteString(fmt.Sprintf("%s[1] API Key      %s\n", s.menuCursor("1"), dimStyle.Render(maskedKey)))
+	b.WriteString(fmt.Sprintf("%s[2] Model        %s\n", s.menuCursor("2"), dimStyle.Render(v.Model)))
+	b.WriteString(fmt.Sprintf("%s[3] Max Iters    %s\n", s.menuCursor("3"), dimStyle.Render(strconv.Itoa(v.MaxIterations))))
+	b.WriteString(fmt.Sprintf("%s[4] Max Tokens   %s\n", s.menuCursor("4"), dimStyle.Render(maxTokensLabel(v.Model, v.MaxTokens))))
+	b.WriteString(fmt.Sprintf("%s[5] Provider     %s\n", s.menuCursor("5"), dimStyle.Render(v.Provider)))
+	about, _, _ := strings.Cut(v.Version, "\n")
+	b.WriteString(fmt.Sprintf("%s[6] About        %s\n", s.menuCursor("6"), dimStyle.Render(about)))
+	if v.AutoApprove {
+		b.WriteString(fmt.Sprintf("%s[7] Auto-Approve %s\n", s.menuCursor("7"), settingsErrorStyle.Render("on (tools run without asking)")))
+	} else {
+		b.WriteString(fmt.Sprintf("%s[7] Auto-Approve %s\n", s.menuCursor("7"), dimStyle.Render("off")))
+	}
+	b.WriteString(fmt.Sprintf("%s[8] Save To      %s\n", s.menuCursor("8"), dimStyle.Render(v.SaveTarget)))
+	toolsLabel := "all enabled"
+	if len(v.DisabledTools) > 0 {
+		toolsLabel = "disabled: " + strings.Join(v.DisabledTools, ", ")
+	}
+	b.WriteString(fmt.Sprintf("%s[9] Paths        %s\n", s.menuCursor("9"), dimStyle.Render("show all config and data paths in the chat")))
+	b.WriteString(fmt.Sprintf("%s[0] Tools        %s\n", s.menuCursor("0"), dimStyle.Render(toolsLabel)))
 
 	if s.feedback != "" {
 		b.WriteString("\n")
@@ -322,22 +753,31 @@ func (s Settings) viewMenu(currentKey, currentModel string, currentMaxIter int)
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString("  " + settingsKeyHintStyle.Render("esc: close"))
+	b.WriteString("  " + settingsKeyHintStyle.Render("tab/up/down: move  enter: open  esc: close"))
 
 	return b.String()
 }
 
+// menuCursor returns the cursor shown before the menu item with the given
+// shortcut key: an arrow if it has focus, otherwise blank space.
+func (s Settings) menuCursor(key string) string {
+	if settingsMenuKeys[s.menuFocus] == key {
+		return settingsCursorStyle.Render("> ")
+	}
+	return "  "
+}
+
 // viewAPIKey renders the API key input sub-view.
 func (s Settings) viewAPIKey(width int) string {
 	title := settingsTitleStyle.Render("Enter OpenAI API Key")
-	s.apiInput.Width = width - 4
+	s.apiInput.Width = width - 12
 	if s.apiInput.Width < 20 {
 		s.apiInput.Width = 20
 	}
 
 	var b strings.Builder
 	b.WriteString("  " + title + "\n\n")
-	b.WriteString("  " + s.apiInput.View() + "\n")
+	b.WriteString(viewInputControls(s.apiInput, s.inputFocus, "Save"))
 
 	if s.feedback != "" {
 		b.WriteString("\n")
@@ -349,7 +789,7 @@ func (s Settings) viewAPIKey(width int) string {
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString("  " + settingsKeyHintStyle.Render("enter: save  esc: back"))
+	b.WriteString("  " + settingsKeyHintStyle.Render("tab: next  enter: save  esc: back"))
 
 	return b.String()
 }
@@ -371,23 +811,28 @@ func (s Settings) viewModels(currentModel string) string {
 	if s.modelsErr != nil {
 		b.WriteString("  " + settingsErrorStyle.Render(fmt.Sprintf("Error: %s", s.modelsErr.Error())))
 		b.WriteString("\n\n")
-		b.WriteString("  " + settingsKeyHintStyle.Render("esc: back"))
+		b.WriteString("  " + settingsKeyHintStyle.Render("ctrl+r: retry  esc: back"))
 		return b.String()
 	}
 
 	if len(s.models) == 0 {
-		
-  b.WriteString("  OpenAI\n\n")
+		b.WriteString("  OpenAI\n\n")
 		b.WriteString("\n\n")
-		b.WriteString("  " + settingsKeyHintStyle.Render("esc: back"))
+		b.WriteString("  " + settingsKeyHintStyle.Render("ctrl+r: refresh  esc: back"))
 		return b.String()
 	}
 
-	  b.WriteString("  OpenAI\n\n")
+	b.WriteString("  OpenAI\n\n")
+	b.WriteString("  " + s.modelFilter.View() + "\n\n")
+
+	models := s.filteredModels()
+	if len(models) == 0 {
+		b.WriteString("  " + dimStyle.Render("No models match") + "\n")
+	}
 
 	maxVisible := 10
-	if maxVisible > len(s.models) {
-		maxVisible = len(s.models)
+	if maxVisible > len(models) {
+		maxVisible = len(models)
 	}
 
 	// Calculate scroll window
@@ -396,8 +841,8 @@ func (s Settings) viewModels(currentModel string) string {
 		start = s.modelCursor - maxVisible + 1
 	}
 	end := start + maxVisible
-	if end > len(s.models) {
-		end = len(s.models)
+	if end > len(models) {
+		end = len(models)
 		start = end - maxVisible
 		if start < 0 {
 			start = 0
@@ -405,7 +850,7 @@ func (s Settings) viewModels(currentModel string) string {
 	}
 
 	for i := start; i < end; i++ {
-		model := s.models[i]
+		model := models[i]
 		cursor := "  "
 		style := settingsItemStyle
 
@@ -422,9 +867,16 @@ func (s Settings) viewModels(currentModel string) string {
 		b.WriteString("  " + cursor + style.Render(model) + suffix + "\n")
 	}
 
-	if len(s.models) > maxVisible {
+	switch {
+	case len(models) < len(s.models) && len(models) > maxVisible:
+		b.WriteString(fmt.Sprintf("\n  %s",
+			dimStyle.Render(fmt.Sprintf("showing %d-%d of %d matches (%d models)", start+1, end, len(models), len(s.models)))))
+	case len(models) < len(s.models):
 		b.WriteString(fmt.Sprintf("\n  %s",
-			dimStyle.Render(fmt.Sprintf("showing %d-%d of %d", start+1, end, len(s.models)))))
+			dimStyle.Render(fmt.Sprintf("%d of %d models match", len(models), len(s.models)))))
+	case len(models) > maxVisible:
+		b.WriteString(fmt.Sprintf("\n  %s",
+			dimStyle.Render(fmt.Sprintf("showing %d-%d of %d", start+1, end, len(models)))))
 	}
 
 	if s.feedback != "" {
@@ -437,7 +889,7 @@ func (s Settings) viewModels(currentModel string) string {
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString("  " + settingsKeyHintStyle.Render("up/down: navigate  enter: select  esc: back"))
+	b.WriteString("  " + settingsKeyHintStyle.Render("type: filter  up/down/tab: navigate  enter: select  ctrl+r: refresh  esc: back"))
 
 	return b.String()
 }
@@ -450,7 +902,7 @@ func (s Settings) viewMaxIter(width int, currentMaxIter int) string {
 	var b strings.Builder
 	b.WriteString("  " + title + "\n\n")
 	b.WriteString(fmt.Sprintf("  Current: %s\n\n", dimStyle.Render(strconv.Itoa(currentMaxIter))))
-	b.WriteString("  " + s.maxIterInput.View() + "\n")
+	b.WriteString(viewInputControls(s.maxIterInput, s.inputFocus, "Save"))
 
 	if s.feedback != "" {
 		b.WriteString("\n")
@@ -462,15 +914,164 @@ func (s Settings) viewMaxIter(width int, currentMaxIter int) string {
 	}
 
 	b.WriteString("\n\n")
-	b.WriteString("  " + settingsKeyHintStyle.Render("enter: save  esc: back"))
+	b.WriteString("  " + settingsKeyHintStyle.Render("tab: next  enter: save  esc: back"))
+
+	return b.String()
+}
+
+// viewMaxTokens renders the max tokens input sub-view.
+func (s Settings) viewMaxTokens(currentModel string, currentMaxTokens int) string {
+	title := settingsTitleStyle.Render("Max Response Tokens")
+	s.maxTokensInput.Width = 10
+
+	var b strings.Builder
+	b.WriteString("  " + title + "\n\n")
+	b.WriteString(fmt.Sprintf("  Current: %s\n\n", dimStyle.Render(maxTokensLabel(currentModel, currentMaxTokens))))
+	b.WriteString(viewInputControls(s.maxTokensInput, s.inputFocus, "Save"))
+
+	if s.feedback != "" {
+		b.WriteString("\n")
+		if s.feedbackErr {
+			b.WriteString("  " + settingsErrorStyle.Render(s.feedback))
+		} else {
+			b.WriteString("  " + settingsSuccessStyle.Render(s.feedback))
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString("  " + settingsKeyHintStyle.Render("tab: next  enter: save  esc: back"))
+
+	return b.String()
+}
+
+// viewDataDir renders the data directory input sub-view.
+func (s Settings) viewDataDir(width int, currentDataDir string) string {
+	title := settingsTitleStyle.Render("Data Directory")
+	s.dataDirInput.Width = max(width-12, 20)
+
+	var b strings.Builder
+	b.WriteString("  " + title + "\n\n")
+	b.WriteString(fmt.Sprintf("  Current: %s\n\n", dimStyle.Render(currentDataDir)))
+	b.WriteString(viewInputControls(s.dataDirInput, s.inputFocus, "Move") + "\n")
+	b.WriteString("  " + dimStyle.Render("The database is copied to the new directory and used from there;") + "\n")
+	b.WriteString("  " + dimStyle.Render("the old copy is left in place.") + "\n")
+
+	if s.feedback != "" {
+		b.WriteString("\n")
+		if s.feedbackErr {
+			b.WriteString("  " + settingsErrorStyle.Render(s.feedback))
+		} else {
+			b.WriteString("  " + settingsSuccessStyle.Render(s.feedback))
+		}
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString("  " + settingsKeyHintStyle.Render("tab: next  enter: move existing data  esc: back"))
+
+	return b.String()
+}
+
+// maxTokensLabel describes the output token limit used for model.
+func maxTokensLabel(model string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return fmt.Sprintf("model default (%d)", provider.OutputTokens(model, 0))
+	}
+	return strconv.Itoa(maxTokens)
+}
+
+// viewProviders renders the provider selection list sub-view.
+func (s Settings) viewProviders(currentProvider string) string {
+	title := settingsTitleStyle.Render("Select Provider")
+
+	var b strings.Builder
+	b.WriteString("  " + title + "\n\n")
+
+	for i, name := range provider.Names() {
+		cursor := "  "
+		style := settingsItemStyle
+		if i == s.providerCursor {
+			cursor = settingsCursorStyle.Render("> ")
+			style = settingsSelectedStyle
+		}
+
+		suffix := ""
+		if name == currentProvider {
+			suffix = dimStyle.Render(" (current)")
+		}
+
+		b.WriteString("  " + cursor + style.Render(name) + suffix + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("  " + settingsKeyHintStyle.Render("up/down/tab: navigate  enter: select  esc: back"))
+
+	return b.String()
+}
+
+// viewTools renders the tool enable/disable list sub-view.
+func (s Settings) viewTools(disabledTools []string) string {
+	title := settingsTitleStyle.Render("Tools")
+
+	var b strings.Builder
+	b.WriteString("  " + title + "\n\n")
+
+	for i, name := range s.tools {
+		cursor := "  "
+		style := settingsItemStyle
+		if i == s.toolCursor {
+			cursor = settingsCursorStyle.Render("> ")
+			style = settingsSelectedStyle
+		}
+
+		state := settingsSuccessStyle.Render("on ")
+		if slices.Contains(disabledTools, name) {
+			state = settingsErrorStyle.Render("off")
+		}
+
+		b.WriteString("  " + cursor + state + " " + style.Render(name) + "\n")
+	}
+
+	if s.feedback != "" {
+		b.WriteString("\n")
+		if s.feedbackErr {
+			b.WriteString("  " + settingsErrorStyle.Render(s.feedback))
+		} else {
+			b.WriteString("  " + settingsSuccessStyle.Render(s.feedback))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("  " + settingsKeyHintStyle.Render("up/down/tab: navigate  enter/space: toggle  esc: back"))
+
+	return b.String()
+}
+
+// viewAbout renders the build information sub-view.
+func (s Settings) viewAbout(version string) string {
+	title := settingsTitleStyle.Render("About")
+
+	if version == "" {
+		version = "goder (version unknown)"
+	}
+
+	var b strings.Builder
+	b.WriteString("  " + title + "\n\n")
+	for _, line := range strings.Split(version, "\n") {
+		b.WriteString("  " + settingsItemStyle.Render(line) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("  " + settingsKeyHintStyle.Render("esc: back"))
 
 	return b.String()
 }
 
 // fetchModelsCmd creates a tea.Cmd that fetches models from the provider.
-func fetchModelsCmd(ctx context.Context, listFn func(ctx context.Context) ([]string, error)) tea.Cmd {
+// Cancelling ctx abandons the request.
+func fetchModelsCmd(ctx context.Context, fetch int, listFn func(ctx context.Context) ([]string, error)) tea.Cmd {
 	return func() tea.Msg {
 		models, err := listFn(ctx)
-		return modelsLoadedMsg{models: models, err: err}
+		return modelsLoadedMsg{fetch: fetch, models: models, err: err}
 	}
 }
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/agent"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// shutdownTimeout is how long quitting waits for a cancelled agent to stop.
+const shutdownTimeout = 3 * time.Second
+
+// shutdownTimeoutMsg ends the wait for the agent when quitting.
+type shutdownTimeoutMsg struct{}
+
+// shutdown quits, first cancelling the agent if it is running. Messages the
+// agent finishes while it stops are persisted as usual, and the response it
+// was streaming is saved as far as it got, so quitting mid-turn loses
+// nothing already shown. Every database write happens here in Update, so
+// they are all done before the program exits and main closes the database.
+func (m Model) shutdown() (tea.Model, tea.Cmd) {
+	m.confirmQuit = false
+	if !m.thinking || m.agentCancel == nil {
+		return m, tea.Quit
+	}
+	m.agentCancel()
+	m.agentCancel = nil
+	m.quitting = true
+	return m, tea.Tick(shutdownTimeout, func(time.Time) tea.Msg { return shutdownTimeoutMsg{} })
+}
+
+// handleShutdownEvent handles an event from the agent being stopped by
+// shutdown, quitting once it is done.
+func (m Model) handleShutdownEvent(event agent.Event) (tea.Model, tea.Cmd) {
+	switch event.Type {
+	case agent.EventStreamText:
+		m.streamBuf += event.Text
+	case agent.EventPersistMessage:
+		if event.FinalMessage != nil {
+			if err := m.sessions.AddMessage(*event.FinalMessage); err != nil {
+				m.err = err
+			}
+			m.streamBuf = ""
+		}
+	case agent.EventAgentDone:
+		if event.FinalMessage != nil {
+			if err := m.sessions.AddMessage(*event.FinalMessage); err != nil {
+				m.err = err
+			}
+			m.streamBuf = ""
+		}
+		return m, tea.Quit
+	case agent.EventAgentError:
+		m.persistPartialResponse()
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// persistPartialResponse saves the text streamed so far of a response that
+// will not be finished.
+func (m *Model) persistPartialResponse() {
+	if strings.TrimSpace(m.streamBuf) == "" || m.sessions == nil {
+		return
+	}
+	msg := message.NewAssistantMessage(m.sessions.CurrentID(), m.streamBuf, nil)
+	if err := m.sessions.AddMessage(msg); err != nil {
+		m.err = err
+	}
+	m.streamBuf = ""
+}
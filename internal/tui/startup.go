@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/webgovernor/goder/internal/config"
+	"github.com/webgovernor/goder/internal/tools"
+)
+
+const (
+	// startupWalkTimeout bounds the file count in the startup summary; a
+	// count cut short is shown as a lower bound.
+	startupWalkTimeout = 2 * time.Second
+
+	// startupFileLimit caps the files counted for the startup summary.
+	startupFileLimit = 10000
+
+	// startupGitTimeout bounds each git command run for the startup summary.
+	startupGitTimeout = 3 * time.Second
+)
+
+// projectKind identifies a kind of project by its manifest file.
+type projectKind struct {
+	manifest string // file in the working directory that marks the project
+	language string
+	testCmd  string // command that usually runs the tests, if any
+}
+
+// projectKinds are checked in order; the first manifest found describes the
+// project.
+var projectKinds = []projectKind{
+	{"go.mod", "Go module", "go test ./..."},
+	{"Cargo.toml", "Rust crate", "cargo test"},
+	{"package.json", "Node package", "npm test"},
+	{"pyproject.toml", "Python project", "pytest"},
+	{"requirements.txt", "Python project", "pytest"},
+	{"pom.xml", "Maven project", "mvn test"},
+	{"build.gradle", "Gradle project", "gradle test"},
+	{"build.gradle.kts", "Gradle project", "gradle test"},
+	{"Gemfile", "Ruby project", "bundle exec rake test"},
+}
+
+// startupSummary describes the project in cfg.WorkDir from a quick look at
+// the filesystem and git, without asking the model: the kind of project and
+// how to test it, how many files it has, and the state of the repository.
+func startupSummary(ctx context.Context, cfg config.Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Working in %s\n", cfg.WorkDir)
+	if project := describeProject(cfg.WorkDir); project != "" {
+		b.WriteString("- " + project + "\n")
+	}
+	if files := countFiles(ctx, cfg); files != "" {
+		b.WriteString("- " + files + "\n")
+	}
+	b.WriteString("- " + describeGit(ctx, cfg.WorkDir))
+	return b.String()
+}
+
+// describeProject names the project in dir and its test command, or returns
+// "" if no known manifest is found.
+func describeProject(dir string) string {
+	for _, kind := range projectKinds {
+		data, err := os.ReadFile(filepath.Join(dir, kind.manifest))
+		if err != nil {
+			continue
+		}
+		desc := kind.language
+		if name := manifestName(kind.manifest, data); name != "" {
+			desc += " " + name
+		}
+		testCmd := kind.testCmd
+		if kind.manifest == "package.json" && !hasNPMTestScript(data) {
+			testCmd = ""
+		}
+		if testCmd != "" {
+			desc += fmt.Sprintf(" (test with `%s`)", testCmd)
+		}
+		return desc
+	}
+	return ""
+}
+
+// manifestName returns the module or package name declared in a manifest,
+// or "" if it cannot be found.
+func manifestName(manifest string, data []byte) string {
+	switch manifest {
+	case "go.mod":
+		return manifestLineValue(data, "module ")
+	case "package.json":
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			return pkg.Name
+		}
+	case "Cargo.toml", "pyproject.toml":
+		// The first name key is the package's in any conventional layout.
+		value := manifestLineValue(data, "name =")
+		return strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return ""
+}
+
+// manifestLineValue returns the rest of the first line of data that starts
+// with prefix, trimmed.
+func manifestLineValue(data []byte, prefix string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// hasNPMTestScript reports whether a package.json defines a test script.
+func hasNPMTestScript(data []byte) bool {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	return json.Unmarshal(data, &pkg) == nil && pkg.Scripts["test"] != ""
+}
+
+// countFiles counts the files the filesystem tools would see, skipping the
+// same ignored paths, and returns it as text, or "" if the directory cannot
+// be read.
+func countFiles(ctx context.Context, cfg config.Config) string {
+	ignore, err := tools.LoadIgnoreList(cfg.WorkDir, cfg.Ignore)
+	if err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, startupWalkTimeout)
+	defer cancel()
+
+	files, err := tools.WorkspaceFiles(ctx, cfg.WorkDir, ignore, startupFileLimit)
+	switch {
+	case err != nil && len(files) == 0:
+		return ""
+	case err != nil || len(files) >= startupFileLimit:
+		return fmt.Sprintf("%d+ files", len(files))
+	case len(files) == 1:
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", len(files))
+}
+
+// describeGit summarizes the git repository containing dir: its branch and
+// how many paths have uncommitted changes.
+func describeGit(ctx context.Context, dir string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "git not found"
+	}
+	branch, err := startupGit(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		// A repository with no commits yet has no HEAD to name.
+		if _, err := startupGit(ctx, dir, "rev-parse", "--git-dir"); err != nil {
+			return "not a git repository"
+		}
+		branch = "(no commits yet)"
+	}
+	if branch == "HEAD" {
+		branch = "(detached HEAD)"
+	}
+
+	status, err := startupGit(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return "git branch " + branch
+	}
+	switch changed := len(strings.FieldsFunc(status, func(r rune) bool { return r == '\n' })); changed {
+	case 0:
+		return fmt.Sprintf("git branch %s, no uncommitted changes", branch)
+	case 1:
+		return fmt.Sprintf("git branch %s, 1 uncommitted change", branch)
+	default:
+		return fmt.Sprintf("git branch %s, %d uncommitted changes", branch, changed)
+	}
+}
+
+// startupGit runs a git command in dir and returns its trimmed output.
+func startupGit(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, startupGitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/webgovernor/goder/internal/config"
+)
+
+func TestStartupSummary(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "// comment\nmodule example.com/demo\n\ngo 1.22\n")
+	write("main.go", "package main\n")
+	write("node_modules/dep/index.js", "ignored\n")
+
+	cfg := config.DefaultConfig()
+	cfg.WorkDir = dir
+
+	got := startupSummary(context.Background(), cfg)
+	for _, want := range []string{
+		"Working in " + dir,
+		"- Go module example.com/demo (test with `go test ./...`)",
+		"- 2 files",
+		"- not a git repository",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "trunk"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if out, err := startupGit(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	if got := describeGit(context.Background(), dir); got != "git branch trunk, 3 uncommitted changes" {
+		t.Errorf("describeGit = %q", got)
+	}
+}
+
+func TestDescribeProject(t *testing.T) {
+	for _, tc := range []struct {
+		manifest, content, want string
+	}{
+		{"package.json", `{"name": "web", "scripts": {"test": "vitest"}}`, "Node package web (test with `npm test`)"},
+		{"package.json", `{"name": "web"}`, "Node package web"},
+		{"Cargo.toml", "[package]\nname = \"tool\"\n", "Rust crate tool (test with `cargo test`)"},
+		{"README.md", "# Notes\n", ""},
+	} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, tc.manifest), []byte(tc.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := describeProject(dir); got != tc.want {
+			t.Errorf("%s %s: describeProject = %q, want %q", tc.manifest, tc.content, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/webgovernor/goder/internal/llm/agent"
+)
+
+// statsReport formats per-tool call counts and execution times, slowest
+// tools first.
+func statsReport(stats []agent.ToolStat) string {
+	if len(stats) == 0 {
+		return "No tools have run yet."
+	}
+
+	var b strings.Builder
+	b.WriteString("Tool usage (calls, errors, total / average / slowest time):")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "\n  %s: %d calls, %d errors, %s / %s / %s",
+			s.Name, s.Calls, s.Errors, roundDuration(s.Total), roundDuration(s.Average()), roundDuration(s.Max))
+	}
+	return b.String()
+}
+
+// roundDuration rounds d for display: to the millisecond below a second and
+// to the tenth of a second above.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
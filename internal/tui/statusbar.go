@@ -2,17 +2,31 @@ package tui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/text/language"
+	textmessage "golang.org/x/text/message"
+
+	"github.com/webgovernor/goder/internal/llm/provider"
 )
 
-// StatusBarView renders the bottom status bar.
-func StatusBarView(width int, thinking bool) string {
+// StatusBarView renders the bottom status bar. activity describes what the
+// agent is currently doing, estimate the approximate size of the prompt
+// being typed, and warning anything the user should know about before
+// sending more; each is omitted when empty.
+func StatusBarView(width int, activity, estimate, warning string) string {
 	sep := statusSepStyle.Render(" | ")
 
 	items := []string{}
-	if thinking {
-		items = append(items, thinkingStatusStyle.Render("thinking..."))
+	if activity != "" {
+		items = append(items, thinkingStatusStyle.Render(activity))
+	}
+	if estimate != "" {
+		items = append(items, statusDescStyle.Render(estimate))
+	}
+	if warning != "" {
+		items = append(items, statusWarnStyle.Render(warning))
 	}
 
 	items = append(items,
@@ -35,3 +49,23 @@ func StatusBarView(width int, thinking bool) string {
 		Align(lipgloss.Center).
 		Render(bar)
 }
+
+// rateLimitLabel describes the provider's rate limits for the status bar
+// when they are nearly used up, or returns "" when they are not.
+func rateLimitLabel(s *provider.RateLimitStatus) string {
+	if !s.Low() {
+		return ""
+	}
+	p := textmessage.NewPrinter(language.English)
+	var left string
+	var reset time.Duration
+	if s.RequestsLow() {
+		left, reset = p.Sprintf("%d requests", s.RemainingRequests), s.ResetRequests
+	} else {
+		left, reset = p.Sprintf("%d tokens", s.RemainingTokens), s.ResetTokens
+	}
+	if reset > 0 {
+		return fmt.Sprintf("rate limit: %s left, resets in %s", left, reset.Round(time.Second))
+	}
+	return fmt.Sprintf("rate limit: %s left", left)
+}
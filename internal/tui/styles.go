@@ -37,6 +37,12 @@ var (
 			Background(colorPlan).
 			Padding(0, 1)
 
+	autoApproveStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#000000")).
+				Background(colorWarning).
+				Padding(0, 1)
+
 	modeBuildStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#000000")).
@@ -80,6 +86,22 @@ var (
 			Foreground(colorError)
 )
 
+// Diff styles
+var (
+	diffAddStyle = lipgloss.NewStyle().
+			Foreground(colorSuccess)
+
+	diffRemoveStyle = lipgloss.NewStyle().
+			Foreground(colorError)
+
+	diffHunkStyle = lipgloss.NewStyle().
+			Foreground(colorSecondary)
+
+	diffTitleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorPrimary)
+)
+
 // Input area styles
 var (
 	inputBorderStyle = lipgloss.NewStyle().
@@ -97,6 +119,19 @@ var (
 				Bold(true)
 )
 
+// Completion popup styles
+var (
+	completionStyle = lipgloss.NewStyle().
+			Padding(0, 1)
+
+	completionItemStyle = lipgloss.NewStyle().
+				Foreground(colorDim)
+
+	completionSelectedStyle = lipgloss.NewStyle().
+				Foreground(colorPrimary).
+				Bold(true)
+)
+
 // Status bar styles
 var (
 	statusBarStyle = lipgloss.NewStyle().
@@ -116,6 +151,9 @@ var (
 	thinkingStatusStyle = lipgloss.NewStyle().
 				Foreground(colorWarning).
 				Bold(true)
+
+	statusWarnStyle = lipgloss.NewStyle().
+			Foreground(colorWarning)
 )
 
 // General styles
@@ -142,6 +180,11 @@ var (
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(colorWarning).
 			Padding(0, 1)
+
+	// dangerStyle frames permission requests for calls flagged as
+	// dangerous.
+	dangerStyle = permissionStyle.
+			BorderForeground(colorError)
 )
 
 // Settings overlay styles
@@ -178,4 +221,12 @@ var (
 	settingsErrorStyle = lipgloss.NewStyle().
 				Foreground(colorError).
 				Bold(true)
+
+	settingsButtonStyle = lipgloss.NewStyle().
+				Foreground(colorDim)
+
+	settingsFocusedButtonStyle = lipgloss.NewStyle().
+					Foreground(colorPrimary).
+					Bold(true).
+					Reverse(true)
 )
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/prompt"
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+const (
+	// summaryMaxTokens bounds the output of the summary request.
+	summaryMaxTokens = 1024
+
+	// summaryTimeout bounds how long the summary request may take.
+	summaryTimeout = 2 * time.Minute
+)
+
+// sessionSummaryMsg carries the result of a summary generation.
+type sessionSummaryMsg struct {
+	sessionID string
+	summary   string
+	err       error
+}
+
+// generateSummaryCmd asks the provider for a running summary of the session,
+// merging in the previously stored summary so nothing is lost. The whole
+// history is sent, so the conversation may overlap the previous summary.
+func generateSummaryCmd(prov provider.Provider, sessionID string, history []message.Message, previous string) tea.Cmd {
+	var input strings.Builder
+	if previous != "" {
+		input.WriteString("Previous summary:\n\n")
+		input.WriteString(previous)
+		input.WriteString("\n\nFull conversation, which may repeat what the previous summary covers:\n\n")
+	}
+	input.WriteString(conversationExcerpt(history))
+
+	req := provider.Request{
+		SystemPrompt: prompt.SummaryPrompt(),
+		Messages:     []message.Message{message.NewUserMessage(sessionID, input.String())},
+		MaxTokens:    summaryMaxTokens,
+	}
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+		defer cancel()
+
+		text, err := prov.Complete(ctx, req)
+		if err != nil {
+			return sessionSummaryMsg{sessionID: sessionID, err: err}
+		}
+		return sessionSummaryMsg{sessionID: sessionID, summary: strings.TrimSpace(text)}
+	}
+}
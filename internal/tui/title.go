@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/webgovernor/goder/internal/llm/prompt"
+	"github.com/webgovernor/goder/internal/llm/provider"
+	"github.com/webgovernor/goder/internal/message"
+)
+
+const (
+	// titleMaxTokens bounds the output of the title request.
+	titleMaxTokens = 64
+
+	// titleTimeout bounds how long the background title request may take.
+	titleTimeout = 30 * time.Second
+
+	// titleMaxLen is the maximum length of a generated title, in runes.
+	titleMaxLen = 60
+)
+
+// sessionTitleMsg carries the result of a background title generation.
+type sessionTitleMsg struct {
+	sessionID string
+	title     string
+	err       error
+}
+
+// generateTitleCmd asks the provider for a short title summarizing the given
+// conversation. It runs as a single non-streaming request off the UI loop.
+func generateTitleCmd(prov provider.Provider, sessionID string, history []message.Message) tea.Cmd {
+	req := provider.Request{
+		SystemPrompt: prompt.TitlePrompt(),
+		Messages:     []message.Message{message.NewUserMessage(sessionID, conversationExcerpt(history))},
+		MaxTokens:    titleMaxTokens,
+	}
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), titleTimeout)
+		defer cancel()
+
+		text, err := prov.Complete(ctx, req)
+		if err != nil {
+			return sessionTitleMsg{sessionID: sessionID, err: err}
+		}
+		return sessionTitleMsg{sessionID: sessionID, title: cleanTitle(text)}
+	}
+}
+
+// conversationExcerpt flattens the plain conversational text of history into
+// a single transcript. Tool calls and results are skipped: they are noise for
+// auxiliary requests and would otherwise need matching call/result pairs.
+func conversationExcerpt(history []message.Message) string {
+	var excerpt strings.Builder
+	for _, msg := range history {
+		if msg.Content == "" || (msg.Role != message.User && msg.Role != message.Assistant) {
+			continue
+		}
+		excerpt.WriteString(string(msg.Role))
+		excerpt.WriteString(": ")
+		excerpt.WriteString(msg.Content)
+		excerpt.WriteString("\n\n")
+	}
+	return excerpt.String()
+}
+
+// cleanTitle normalizes a model-generated title: first line only, without
+// surrounding quotes or trailing punctuation, and bounded in length.
+func cleanTitle(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.Trim(s, "\"'` ")
+	s = strings.TrimRight(s, ".!?:; ")
+
+	if r := []rune(s); len(r) > titleMaxLen {
+		s = strings.TrimSpace(string(r[:titleMaxLen]))
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// toolValueMaxLen is the length, in runes, beyond which a collapsed tool
+	// input value is shortened.
+	toolValueMaxLen = 80
+
+	// toolRawMaxLen bounds tool input that is shown as raw text because it is
+	// not a JSON object.
+	toolRawMaxLen = 200
+)
+
+// toolField is a top-level key of a tool call's JSON input.
+type toolField struct {
+	key   string
+	value json.RawMessage
+}
+
+// parseToolInput splits a JSON object into its fields, keeping the order in
+// which the model wrote them. It reports false if input is not an object.
+func parseToolInput(input string) ([]toolField, bool) {
+	dec := json.NewDecoder(strings.NewReader(input))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	var fields []toolField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, toolField{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// formatToolInput renders a tool call's input as one "key: value" line per
+// field. Unless expanded, long or multi-line values are shortened; the second
+// result reports whether any were. Input that is not a JSON object is shown
+// as-is, truncated.
+func formatToolInput(input string, expanded bool) (string, bool) {
+	fields, ok := parseToolInput(input)
+	if !ok {
+		if !expanded && len(input) > toolRawMaxLen {
+			return input[:toolRawMaxLen] + "...", true
+		}
+		return input, false
+	}
+
+	var lines []string
+	collapsed := false
+	for _, f := range fields {
+		value, short := formatToolValue(f.value, expanded)
+		collapsed = collapsed || short
+		lines = append(lines, f.key+": "+value)
+	}
+	return strings.Join(lines, "\n"), collapsed
+}
+
+// formatToolValue renders a single field value. Strings are shown unquoted;
+// other JSON values are compacted. Expanded multi-line strings continue on
+// indented lines below their key.
+func formatToolValue(raw json.RawMessage, expanded bool) (string, bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var compact bytes.Buffer
+		if json.Compact(&compact, raw) == nil {
+			raw = compact.Bytes()
+		}
+		text = string(raw)
+	}
+
+	if expanded {
+		return strings.ReplaceAll(text, "\n", "\n    "), false
+	}
+
+	first, rest, multiline := strings.Cut(text, "\n")
+	runes := []rune(first)
+	if !multiline && len(runes) <= toolValueMaxLen {
+		return first, false
+	}
+	if len(runes) > toolValueMaxLen {
+		first = string(runes[:toolValueMaxLen]) + "..."
+	}
+	if multiline {
+		first += fmt.Sprintf(" (+%d lines)", strings.Count(rest, "\n")+1)
+	}
+	return first, true
+}
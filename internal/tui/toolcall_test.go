@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatToolInput(t *testing.T) {
+	input := `{"file_path":"main.go","old_string":"func a() {\n\treturn\n}","replace_all":false,"n":[1, 2]}`
+
+	got, collapsed := formatToolInput(input, false)
+	want := "file_path: main.go\n" +
+		"old_string: func a() { (+2 lines)\n" +
+		"replace_all: false\n" +
+		"n: [1,2]"
+	if got != want {
+		t.Errorf("collapsed:\n%s\nwant:\n%s", got, want)
+	}
+	if !collapsed {
+		t.Error("multi-line value should be reported as collapsed")
+	}
+
+	got, collapsed = formatToolInput(input, true)
+	if !strings.Contains(got, "old_string: func a() {\n    \treturn\n    }") || collapsed {
+		t.Errorf("expanded:\n%s", got)
+	}
+
+	long := `{"command":"` + strings.Repeat("x", toolValueMaxLen+10) + `"}`
+	got, collapsed = formatToolInput(long, false)
+	if got != "command: "+strings.Repeat("x", toolValueMaxLen)+"..." || !collapsed {
+		t.Errorf("long value = %q", got)
+	}
+
+	// Input that is not a JSON object is shown raw.
+	for _, raw := range []string{`{"path": "unterminated`, `["a"]`, "plain"} {
+		if got, _ := formatToolInput(raw, false); got != raw {
+			t.Errorf("formatToolInput(%q) = %q, want it unchanged", raw, got)
+		}
+	}
+}
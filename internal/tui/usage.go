@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	textmessage "golang.org/x/text/message"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+// usagePromptMaxLen bounds the prompt excerpt labelling each turn, in runes.
+const usagePromptMaxLen = 40
+
+// turnUsage is the token usage of one turn: a user prompt and every model
+// response until the next prompt.
+type turnUsage struct {
+	prompt               string
+	input, output, total int
+}
+
+// usageReport summarizes the token usage recorded on history per turn, with
+// the session total.
+func usageReport(history []message.Message) string {
+	var turns []turnUsage
+	for _, msg := range history {
+		switch {
+		case msg.Role == message.User:
+			turns = append(turns, turnUsage{prompt: msg.Content})
+		case msg.TotalTokens > 0:
+			if len(turns) == 0 {
+				turns = append(turns, turnUsage{})
+			}
+			t := &turns[len(turns)-1]
+			t.input += msg.InputTokens
+			t.output += msg.OutputTokens
+			t.total += msg.TotalTokens
+		}
+	}
+
+	printer := textmessage.NewPrinter(language.English)
+	var b strings.Builder
+	var sum turnUsage
+	b.WriteString("Token usage (input / output / total):")
+	for i, t := range turns {
+		prompt := strings.Join(strings.Fields(t.prompt), " ")
+		if r := []rune(prompt); len(r) > usagePromptMaxLen {
+			prompt = string(r[:usagePromptMaxLen]) + "..."
+		}
+		b.WriteString(printer.Sprintf("\n  #%d %q: %d / %d / %d", i+1, prompt, t.input, t.output, t.total))
+		sum.input += t.input
+		sum.output += t.output
+		sum.total += t.total
+	}
+	b.WriteString(printer.Sprintf("\nSession total: %d / %d / %d", sum.input, sum.output, sum.total))
+	return b.String()
+}
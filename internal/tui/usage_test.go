@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/webgovernor/goder/internal/message"
+)
+
+func TestUsageReport(t *testing.T) {
+	withUsage := func(msg message.Message, in, out int) message.Message {
+		msg.InputTokens, msg.OutputTokens, msg.TotalTokens = in, out, in+out
+		return msg
+	}
+	history := []message.Message{
+		message.NewUserMessage("s", "fix the\nbuild"),
+		withUsage(message.NewAssistantMessage("s", "", []message.ToolCall{{ID: "c1", Name: "bash"}}), 1000, 50),
+		message.NewToolResultMessage("s", []message.ToolResult{{ToolCallID: "c1", Name: "bash"}}),
+		withUsage(message.NewAssistantMessage("s", "done", nil), 1200, 30),
+		message.NewUserMessage("s", "thanks"),
+		withUsage(message.NewAssistantMessage("s", "welcome", nil), 1300, 5),
+	}
+
+	got := usageReport(history)
+	for _, want := range []string{
+		`#1 "fix the build": 2,200 / 80 / 2,280`,
+		`#2 "thanks": 1,300 / 5 / 1,305`,
+		"Session total: 3,500 / 85 / 3,585",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+}